@@ -0,0 +1,33 @@
+package main
+
+import (
+	"flag"
+	"github.com/dontpanicw/EventBooker/config"
+	"github.com/dontpanicw/EventBooker/internal/app"
+	"log"
+)
+
+// Сверка available_tickets с вместимостью и активными бронями.
+// Без -repair только печатает расхождения. -verify снимает пометку непроверенной
+// вместимости с события, вместимость которого сверена вручную.
+func main() {
+	repair := flag.Bool("repair", false, "fix discrepancies and record adjustments")
+	verify := flag.String("verify", "", "mark the capacity of the event with this id as verified")
+	flag.Parse()
+
+	cfg, err := config.NewConfig()
+	if err != nil {
+		log.Fatalf("error creating config: %v", err)
+	}
+
+	if *verify != "" {
+		if err := app.VerifyCapacity(cfg, *verify); err != nil {
+			log.Fatalf("failed to verify capacity: %v", err)
+		}
+		return
+	}
+
+	if err := app.Reconcile(cfg, *repair); err != nil {
+		log.Fatalf("failed to reconcile inventory: %v", err)
+	}
+}
@@ -0,0 +1,318 @@
+package broker
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/dontpanicw/EventBooker/internal/domain"
+	"github.com/google/uuid"
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// deadLetterScanLimit - сколько сообщений максимум просматриваем в DLQ за одну операцию
+const deadLetterScanLimit = 1000
+
+// RetryQueueName возвращает имя очереди для попытки attempt (начиная с 1)
+func RetryQueueName(attempt int) string {
+	return fmt.Sprintf("%s%d", RetryQueuePrefix, attempt)
+}
+
+// RetryDelay - экспоненциальная задержка перед попыткой attempt: 1s, 2s, 4s, ...
+func RetryDelay(attempt int) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+	return RetryBaseDelay * time.Duration(1<<(attempt-1))
+}
+
+// RetryCount возвращает количество уже сделанных повторных попыток из заголовков сообщения
+func RetryCount(msg amqp.Delivery) int {
+	switch v := msg.Headers[RetryCountHeader].(type) {
+	case int:
+		return v
+	case int32:
+		return int(v)
+	case int64:
+		return int(v)
+	default:
+		return 0
+	}
+}
+
+// setupRetryQueues объявляет очереди повторных попыток и финальную dead-letter очередь.
+// Каждая retry-очередь держит сообщение свою задержку и через DLX возвращает его в delayed_cancellations.
+func (b *RabbitMQBroker) setupRetryQueues() error {
+	err := b.channel.ExchangeDeclare(
+		RetryExchange,
+		"direct",
+		true,
+		false,
+		false,
+		false,
+		nil,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to declare retry exchange: %w", err)
+	}
+
+	err = b.channel.ExchangeDeclare(
+		DeadLetterExchange,
+		"direct",
+		true,
+		false,
+		false,
+		false,
+		nil,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to declare dead letter exchange: %w", err)
+	}
+
+	for attempt := 1; attempt <= MaxRetryAttempts; attempt++ {
+		queue := RetryQueueName(attempt)
+		retryArgs := amqp.Table{
+			"x-message-ttl":             RetryDelay(attempt).Milliseconds(),
+			"x-dead-letter-exchange":    DelayedExchange,
+			"x-dead-letter-routing-key": DelayedCancellationsQueue,
+		}
+		_, err = b.channel.QueueDeclare(
+			queue,
+			true,
+			false,
+			false,
+			false,
+			retryArgs,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to declare retry queue %s: %w", queue, err)
+		}
+
+		err = b.channel.QueueBind(
+			queue,
+			queue,
+			RetryExchange,
+			false,
+			nil,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to bind retry queue %s: %w", queue, err)
+		}
+	}
+
+	_, err = b.channel.QueueDeclare(
+		DeadLetterQueue,
+		true,
+		false,
+		false,
+		false,
+		nil,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to declare dead letter queue: %w", err)
+	}
+
+	err = b.channel.QueueBind(
+		DeadLetterQueue,
+		DeadLetterQueue,
+		DeadLetterExchange,
+		false,
+		nil,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to bind dead letter queue: %w", err)
+	}
+
+	return nil
+}
+
+// PublishRetry отправляет сообщение в retry-очередь попытки attempt
+func (b *RabbitMQBroker) PublishRetry(ctx context.Context, msg amqp.Delivery, attempt int, reason string) error {
+	id := messageID(msg)
+	err := b.channel.PublishWithContext(
+		ctx,
+		RetryExchange,
+		RetryQueueName(attempt),
+		false,
+		false,
+		amqp.Publishing{
+			ContentType: msg.ContentType,
+			MessageId:   id,
+			Headers: amqp.Table{
+				RetryCountHeader:    int32(attempt),
+				FailureReasonHeader: reason,
+			},
+			Body: msg.Body,
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to publish retry: %w", err)
+	}
+
+	log.Printf("Scheduled retry %d/%d for message %s in %s", attempt, MaxRetryAttempts, id, RetryDelay(attempt))
+	return nil
+}
+
+// PublishDeadLetter отправляет сообщение в финальную dead-letter очередь
+func (b *RabbitMQBroker) PublishDeadLetter(ctx context.Context, msg amqp.Delivery, reason string) error {
+	id := messageID(msg)
+	err := b.channel.PublishWithContext(
+		ctx,
+		DeadLetterExchange,
+		DeadLetterQueue,
+		false,
+		false,
+		amqp.Publishing{
+			ContentType: msg.ContentType,
+			MessageId:   id,
+			Headers: amqp.Table{
+				RetryCountHeader:    int32(RetryCount(msg)),
+				FailureReasonHeader: reason,
+				FailedAtHeader:      time.Now().UTC().Format(time.RFC3339),
+			},
+			Body: msg.Body,
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to publish dead letter: %w", err)
+	}
+
+	log.Printf("Message %s moved to dead letter queue: %s", id, reason)
+	return nil
+}
+
+// ListDeadLetters возвращает до limit сообщений из DLQ, не удаляя их.
+// Сообщения забираются без ack на отдельном канале и возвращаются в очередь при его закрытии.
+func (b *RabbitMQBroker) ListDeadLetters(ctx context.Context, limit int) ([]*domain.DeadLetter, error) {
+	if limit <= 0 || limit > deadLetterScanLimit {
+		limit = deadLetterScanLimit
+	}
+
+	ch, err := b.conn.Channel()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open channel: %w", err)
+	}
+	defer ch.Close()
+
+	deadLetters := make([]*domain.DeadLetter, 0)
+	for len(deadLetters) < limit {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		msg, ok, err := ch.Get(DeadLetterQueue, false)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get dead letter: %w", err)
+		}
+		if !ok {
+			break
+		}
+		deadLetters = append(deadLetters, toDeadLetter(msg))
+	}
+
+	return deadLetters, nil
+}
+
+// GetDeadLetter возвращает сообщение из DLQ по его id
+func (b *RabbitMQBroker) GetDeadLetter(ctx context.Context, id string) (*domain.DeadLetter, error) {
+	var deadLetter *domain.DeadLetter
+	err := b.withDeadLetter(ctx, id, func(ch *amqp.Channel, msg amqp.Delivery) error {
+		deadLetter = toDeadLetter(msg)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return deadLetter, nil
+}
+
+// ReplayDeadLetter возвращает сообщение в delayed_cancellations со сброшенным счетчиком попыток
+func (b *RabbitMQBroker) ReplayDeadLetter(ctx context.Context, id string) error {
+	return b.withDeadLetter(ctx, id, func(ch *amqp.Channel, msg amqp.Delivery) error {
+		err := ch.PublishWithContext(
+			ctx,
+			DelayedExchange,
+			DelayedCancellationsQueue,
+			false,
+			false,
+			amqp.Publishing{
+				ContentType: msg.ContentType,
+				MessageId:   messageID(msg),
+				Body:        msg.Body,
+			},
+		)
+		if err != nil {
+			return fmt.Errorf("failed to replay dead letter: %w", err)
+		}
+		if err := msg.Ack(false); err != nil {
+			return fmt.Errorf("failed to ack dead letter: %w", err)
+		}
+		log.Printf("Dead letter %s replayed", id)
+		return nil
+	})
+}
+
+// DiscardDeadLetter удаляет сообщение из DLQ
+func (b *RabbitMQBroker) DiscardDeadLetter(ctx context.Context, id string) error {
+	return b.withDeadLetter(ctx, id, func(ch *amqp.Channel, msg amqp.Delivery) error {
+		if err := msg.Ack(false); err != nil {
+			return fmt.Errorf("failed to ack dead letter: %w", err)
+		}
+		log.Printf("Dead letter %s discarded", id)
+		return nil
+	})
+}
+
+// withDeadLetter ищет сообщение в DLQ и вызывает fn для него.
+// Все просмотренные, но не подтвержденные сообщения возвращаются в очередь при закрытии канала.
+func (b *RabbitMQBroker) withDeadLetter(ctx context.Context, id string, fn func(ch *amqp.Channel, msg amqp.Delivery) error) error {
+	ch, err := b.conn.Channel()
+	if err != nil {
+		return fmt.Errorf("failed to open channel: %w", err)
+	}
+	defer ch.Close()
+
+	for i := 0; i < deadLetterScanLimit; i++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		msg, ok, err := ch.Get(DeadLetterQueue, false)
+		if err != nil {
+			return fmt.Errorf("failed to get dead letter: %w", err)
+		}
+		if !ok {
+			break
+		}
+		if msg.MessageId == id {
+			return fn(ch, msg)
+		}
+	}
+
+	return domain.ErrDeadLetterNotFound
+}
+
+func toDeadLetter(msg amqp.Delivery) *domain.DeadLetter {
+	deadLetter := &domain.DeadLetter{
+		Id:       msg.MessageId,
+		Queue:    DeadLetterQueue,
+		Body:     string(msg.Body),
+		Attempts: RetryCount(msg),
+	}
+	if reason, ok := msg.Headers[FailureReasonHeader].(string); ok {
+		deadLetter.Reason = reason
+	}
+	if failedAt, ok := msg.Headers[FailedAtHeader].(string); ok {
+		if t, err := time.Parse(time.RFC3339, failedAt); err == nil {
+			deadLetter.FailedAt = t
+		}
+	}
+	return deadLetter
+}
+
+// messageID возвращает id сообщения; старые сообщения без id получают новый
+func messageID(msg amqp.Delivery) string {
+	if msg.MessageId != "" {
+		return msg.MessageId
+	}
+	return uuid.New().String()
+}
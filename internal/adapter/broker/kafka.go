@@ -0,0 +1,507 @@
+package broker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/dontpanicw/EventBooker/internal/domain"
+	"github.com/google/uuid"
+	kafkago "github.com/segmentio/kafka-go"
+	"github.com/wb-go/wbf/kafka"
+)
+
+const (
+	KafkaRetryTopicPrefix  = "cancellation-retries-"
+	KafkaDeadLetterTopic   = "cancellation-dead-letters"
+	KafkaDomainEventsTopic = "domain-events"
+	KafkaConsumerGroup     = "cancellation_consumer"
+
+	DeliverAtHeader = "x-deliver-at" // время, раньше которого сообщение нельзя обрабатывать
+	MessageIDHeader = "x-message-id"
+)
+
+// BookingHold - сколько бронь ждет оплаты до отмены
+const BookingHold = domain.BookingHold
+
+// KafkaBroker - реализация брокера на Kafka.
+// В Kafka нет TTL сообщений, поэтому отложенная отмена пишется в delay topic с заголовком
+// x-deliver-at, а consumer ждет наступления этого времени перед обработкой.
+type KafkaBroker struct {
+	brokers     []string
+	delayTopic  string
+	delays      *kafka.Producer
+	retries     []*kafka.Producer // по topic'у на попытку, retries[attempt-1]
+	deadLetters *kafka.Producer
+	events      *kafka.Producer
+
+	deadLetterTopicReady atomic.Bool // dead-letter topic создан или переведен на compaction
+}
+
+func NewKafkaBroker(brokers []string, delayTopic string) (*KafkaBroker, error) {
+	if len(brokers) == 0 {
+		return nil, errors.New("no kafka brokers configured")
+	}
+	if delayTopic == "" {
+		return nil, errors.New("kafka delay topic is empty")
+	}
+
+	retries := make([]*kafka.Producer, 0, MaxRetryAttempts)
+	for attempt := 1; attempt <= MaxRetryAttempts; attempt++ {
+		retries = append(retries, newKafkaProducer(brokers, KafkaRetryTopic(attempt)))
+	}
+
+	return &KafkaBroker{
+		brokers:     brokers,
+		delayTopic:  delayTopic,
+		delays:      newKafkaProducer(brokers, delayTopic),
+		retries:     retries,
+		deadLetters: newKafkaProducer(brokers, KafkaDeadLetterTopic),
+		events:      newKafkaProducer(brokers, KafkaDomainEventsTopic),
+	}, nil
+}
+
+func newKafkaProducer(brokers []string, topic string) *kafka.Producer {
+	producer := kafka.NewProducer(brokers, topic)
+	producer.Writer.RequiredAcks = kafkago.RequireAll
+	producer.Writer.AllowAutoTopicCreation = true
+	// wbf выбирает партицию по LeastBytes; Hash отправляет сообщения с одним ключом в одну партицию
+	producer.Writer.Balancer = &kafkago.Hash{}
+	// По умолчанию writer копит батч до секунды, а бронирование ждет записи синхронно
+	producer.Writer.BatchTimeout = 10 * time.Millisecond
+	return producer
+}
+
+// KafkaRetryTopic возвращает topic для попытки attempt (начиная с 1). У каждого topic'а
+// своя задержка RetryDelay(attempt), поэтому x-deliver-at в нем растет вместе с offset.
+func KafkaRetryTopic(attempt int) string {
+	return fmt.Sprintf("%s%d", KafkaRetryTopicPrefix, attempt)
+}
+
+// DelayTopic - topic, в который публикуются отложенные отмены
+func (b *KafkaBroker) DelayTopic() string {
+	return b.delayTopic
+}
+
+// Brokers - адреса Kafka для consumer'ов
+func (b *KafkaBroker) Brokers() []string {
+	return b.brokers
+}
+
+func (b *KafkaBroker) PublishDelayedCancellation(ctx context.Context, booking *domain.Booking) error {
+	msg := BookingMessage{
+		BookingID: booking.Id,
+		EventID:   booking.EventId,
+		UserID:    booking.UserId,
+		Timestamp: booking.Date,
+	}
+
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("failed to marshal message: %w", err)
+	}
+
+	deliverAt := booking.Date.Add(BookingHold)
+	err = b.delays.Writer.WriteMessages(ctx, kafkago.Message{
+		Key:   []byte(booking.Id),
+		Value: body,
+		Headers: []kafkago.Header{
+			{Key: MessageIDHeader, Value: []byte(uuid.New().String())},
+			{Key: DeliverAtHeader, Value: []byte(deliverAt.UTC().Format(time.RFC3339Nano))},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to publish delayed cancellation: %w", err)
+	}
+
+	log.Printf("Published delayed cancellation for booking %s (will be processed at %s)", booking.Id, deliverAt.Format(time.RFC3339))
+	return nil
+}
+
+// PublishRetry отправляет сообщение в retry topic попытки attempt
+func (b *KafkaBroker) PublishRetry(ctx context.Context, msg kafkago.Message, attempt int, reason string) error {
+	if attempt < 1 || attempt > len(b.retries) {
+		return fmt.Errorf("retry attempt %d out of range 1..%d", attempt, len(b.retries))
+	}
+
+	id := kafkaMessageID(msg)
+	err := b.retries[attempt-1].Writer.WriteMessages(ctx, kafkago.Message{
+		Key:   msg.Key,
+		Value: msg.Value,
+		Headers: []kafkago.Header{
+			{Key: MessageIDHeader, Value: []byte(id)},
+			{Key: DeliverAtHeader, Value: []byte(time.Now().Add(RetryDelay(attempt)).UTC().Format(time.RFC3339Nano))},
+			{Key: RetryCountHeader, Value: []byte(strconv.Itoa(attempt))},
+			{Key: FailureReasonHeader, Value: []byte(reason)},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to publish retry: %w", err)
+	}
+
+	log.Printf("Scheduled retry %d/%d for message %s in %s", attempt, MaxRetryAttempts, id, RetryDelay(attempt))
+	return nil
+}
+
+// PublishDeadLetter отправляет сообщение в dead-letter topic. Ключ - id сообщения,
+// чтобы replay и discard могли "удалить" его tombstone-записью.
+func (b *KafkaBroker) PublishDeadLetter(ctx context.Context, msg kafkago.Message, reason string) error {
+	b.ensureDeadLetterTopic(ctx)
+
+	id := kafkaMessageID(msg)
+	err := b.deadLetters.Writer.WriteMessages(ctx, kafkago.Message{
+		Key:   []byte(id),
+		Value: msg.Value,
+		Time:  time.Now(),
+		Headers: []kafkago.Header{
+			{Key: MessageIDHeader, Value: []byte(id)},
+			{Key: RetryCountHeader, Value: []byte(strconv.Itoa(KafkaRetryCount(msg)))},
+			{Key: FailureReasonHeader, Value: []byte(reason)},
+			{Key: FailedAtHeader, Value: []byte(time.Now().UTC().Format(time.RFC3339))},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to publish dead letter: %w", err)
+	}
+
+	log.Printf("Message %s moved to dead letter topic: %s", id, reason)
+	return nil
+}
+
+// PublishLifecycleEvent публикует доменное событие; тип события передается в заголовке,
+// ключ - id брони или мероприятия, чтобы события одного агрегата попадали в одну партицию
+func (b *KafkaBroker) PublishLifecycleEvent(ctx context.Context, event *domain.LifecycleEvent) error {
+	if event.Id == "" {
+		event.Id = uuid.New().String()
+	}
+
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal lifecycle event: %w", err)
+	}
+
+	err = b.events.Writer.WriteMessages(ctx, kafkago.Message{
+		Key:   []byte(event.AggregateId()),
+		Value: body,
+		Time:  event.OccurredAt,
+		Headers: []kafkago.Header{
+			{Key: MessageIDHeader, Value: []byte(event.Id)},
+			{Key: "type", Value: []byte(event.Type)},
+			{Key: EventVersionHeader, Value: []byte(strconv.Itoa(event.Version))},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to publish lifecycle event: %w", err)
+	}
+
+	log.Printf("Published %s event %s", event.Type, event.Id)
+	return nil
+}
+
+func (b *KafkaBroker) ListDeadLetters(ctx context.Context, limit int) ([]*domain.DeadLetter, error) {
+	msgs, err := b.scanDeadLetters(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if limit > 0 && len(msgs) > limit {
+		msgs = msgs[:limit]
+	}
+
+	deadLetters := make([]*domain.DeadLetter, 0, len(msgs))
+	for _, msg := range msgs {
+		deadLetters = append(deadLetters, kafkaToDeadLetter(msg))
+	}
+	return deadLetters, nil
+}
+
+func (b *KafkaBroker) GetDeadLetter(ctx context.Context, id string) (*domain.DeadLetter, error) {
+	msg, err := b.findDeadLetter(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return kafkaToDeadLetter(msg), nil
+}
+
+// ReplayDeadLetter публикует сообщение в delay topic для немедленной обработки и удаляет его из DLQ
+func (b *KafkaBroker) ReplayDeadLetter(ctx context.Context, id string) error {
+	msg, err := b.findDeadLetter(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	err = b.delays.Writer.WriteMessages(ctx, kafkago.Message{
+		Value: msg.Value,
+		Headers: []kafkago.Header{
+			{Key: MessageIDHeader, Value: []byte(id)},
+			{Key: DeliverAtHeader, Value: []byte(time.Now().UTC().Format(time.RFC3339Nano))},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to replay dead letter: %w", err)
+	}
+
+	if err := b.tombstoneDeadLetter(ctx, id); err != nil {
+		return err
+	}
+	log.Printf("Dead letter %s replayed", id)
+	return nil
+}
+
+func (b *KafkaBroker) DiscardDeadLetter(ctx context.Context, id string) error {
+	if _, err := b.findDeadLetter(ctx, id); err != nil {
+		return err
+	}
+	if err := b.tombstoneDeadLetter(ctx, id); err != nil {
+		return err
+	}
+	log.Printf("Dead letter %s discarded", id)
+	return nil
+}
+
+func (b *KafkaBroker) findDeadLetter(ctx context.Context, id string) (kafkago.Message, error) {
+	msgs, err := b.scanDeadLetters(ctx)
+	if err != nil {
+		return kafkago.Message{}, err
+	}
+	for _, msg := range msgs {
+		if string(msg.Key) == id {
+			return msg, nil
+		}
+	}
+	return kafkago.Message{}, domain.ErrDeadLetterNotFound
+}
+
+// tombstoneDeadLetter пишет запись с пустым значением: при compaction Kafka удалит сообщение,
+// а scanDeadLetters перестанет его возвращать сразу
+func (b *KafkaBroker) tombstoneDeadLetter(ctx context.Context, id string) error {
+	b.ensureDeadLetterTopic(ctx)
+
+	err := b.deadLetters.Writer.WriteMessages(ctx, kafkago.Message{Key: []byte(id), Value: nil, Time: time.Now()})
+	if err != nil {
+		return fmt.Errorf("failed to remove dead letter: %w", err)
+	}
+	return nil
+}
+
+// ensureDeadLetterTopic создает dead-letter topic с cleanup.policy=compact, а уже
+// существующему (например, созданному автоматически) включает compaction: без нее
+// tombstone не удаляет сообщения из topic'а. Ошибка только логируется - dead letter
+// важнее политики хранения, повторная попытка будет при следующей записи.
+func (b *KafkaBroker) ensureDeadLetterTopic(ctx context.Context) {
+	if b.deadLetterTopicReady.Load() {
+		return
+	}
+	if err := b.createDeadLetterTopic(ctx); err != nil {
+		log.Printf("Failed to make %s compacted: %v", KafkaDeadLetterTopic, err)
+		return
+	}
+	b.deadLetterTopicReady.Store(true)
+}
+
+func (b *KafkaBroker) createDeadLetterTopic(ctx context.Context) error {
+	client := &kafkago.Client{Addr: kafkago.TCP(b.brokers...)}
+	res, err := client.CreateTopics(ctx, &kafkago.CreateTopicsRequest{
+		Topics: []kafkago.TopicConfig{{
+			Topic:             KafkaDeadLetterTopic,
+			NumPartitions:     -1, // значения брокера по умолчанию
+			ReplicationFactor: -1,
+			ConfigEntries:     []kafkago.ConfigEntry{{ConfigName: "cleanup.policy", ConfigValue: "compact"}},
+		}},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create dead letter topic: %w", err)
+	}
+	err = res.Errors[KafkaDeadLetterTopic]
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, kafkago.TopicAlreadyExists) {
+		return fmt.Errorf("failed to create dead letter topic: %w", err)
+	}
+
+	alter, err := client.IncrementalAlterConfigs(ctx, &kafkago.IncrementalAlterConfigsRequest{
+		Resources: []kafkago.IncrementalAlterConfigsRequestResource{{
+			ResourceType: kafkago.ResourceTypeTopic,
+			ResourceName: KafkaDeadLetterTopic,
+			Configs: []kafkago.IncrementalAlterConfigsRequestConfig{{
+				Name:            "cleanup.policy",
+				Value:           "compact",
+				ConfigOperation: kafkago.ConfigOperationSet,
+			}},
+		}},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to alter dead letter topic: %w", err)
+	}
+	for _, resource := range alter.Resources {
+		if resource.Error != nil {
+			return fmt.Errorf("failed to alter dead letter topic: %w", resource.Error)
+		}
+	}
+	return nil
+}
+
+// scanDeadLetters читает dead-letter topic целиком и возвращает последнюю живую запись по каждому ключу.
+// Записи одного ключа могут лежать в разных партициях (до Hash balancer'а партиция выбиралась
+// по LeastBytes), поэтому последняя определяется по времени записи, а не по порядку партиций.
+func (b *KafkaBroker) scanDeadLetters(ctx context.Context) ([]kafkago.Message, error) {
+	conn, err := kafkago.DialContext(ctx, "tcp", b.brokers[0])
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to kafka: %w", err)
+	}
+	partitions, err := conn.ReadPartitions(KafkaDeadLetterTopic)
+	conn.Close()
+	if err != nil {
+		if errors.Is(err, kafkago.UnknownTopicOrPartition) {
+			return []kafkago.Message{}, nil
+		}
+		return nil, fmt.Errorf("failed to read dead letter partitions: %w", err)
+	}
+
+	latest := make(map[string]kafkago.Message)
+	for _, partition := range partitions {
+		if err := b.scanPartition(ctx, partition.ID, latest); err != nil {
+			return nil, err
+		}
+	}
+
+	msgs := make([]kafkago.Message, 0, len(latest))
+	for _, msg := range latest {
+		if msg.Value != nil {
+			msgs = append(msgs, msg)
+		}
+	}
+	sort.Slice(msgs, func(i, j int) bool {
+		return msgs[i].Time.Before(msgs[j].Time)
+	})
+	return msgs, nil
+}
+
+func (b *KafkaBroker) scanPartition(ctx context.Context, partition int, latest map[string]kafkago.Message) error {
+	leader, err := kafkago.DialLeader(ctx, "tcp", b.brokers[0], KafkaDeadLetterTopic, partition)
+	if err != nil {
+		return fmt.Errorf("failed to connect to partition leader: %w", err)
+	}
+	first, last, err := leader.ReadOffsets()
+	leader.Close()
+	if err != nil {
+		return fmt.Errorf("failed to read dead letter offsets: %w", err)
+	}
+	if first >= last {
+		return nil
+	}
+
+	reader := kafkago.NewReader(kafkago.ReaderConfig{
+		Brokers:   b.brokers,
+		Topic:     KafkaDeadLetterTopic,
+		Partition: partition,
+	})
+	defer reader.Close()
+
+	if err := reader.SetOffset(first); err != nil {
+		return fmt.Errorf("failed to seek dead letter partition: %w", err)
+	}
+	for {
+		msg, err := reader.ReadMessage(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to read dead letter: %w", err)
+		}
+		if current, ok := latest[string(msg.Key)]; !ok || newerDeadLetter(msg, current) {
+			latest[string(msg.Key)] = msg
+		}
+		if msg.Offset >= last-1 {
+			return nil
+		}
+	}
+}
+
+// newerDeadLetter сообщает, записан ли msg позже current. В одной партиции порядок задает
+// offset; между партициями - время записи, при равном времени выигрывает tombstone,
+// чтобы удаленное сообщение не вернулось.
+func newerDeadLetter(msg, current kafkago.Message) bool {
+	if msg.Partition == current.Partition {
+		return msg.Offset > current.Offset
+	}
+	if !msg.Time.Equal(current.Time) {
+		return msg.Time.After(current.Time)
+	}
+	return msg.Value == nil
+}
+
+func (b *KafkaBroker) Close() error {
+	var errs []error
+	producers := append([]*kafka.Producer{b.delays, b.deadLetters, b.events}, b.retries...)
+	for _, producer := range producers {
+		if err := producer.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// KafkaRetryCount возвращает количество уже сделанных повторных попыток из заголовков сообщения
+func KafkaRetryCount(msg kafkago.Message) int {
+	value, ok := kafkaHeader(msg, RetryCountHeader)
+	if !ok {
+		return 0
+	}
+	count, err := strconv.Atoi(value)
+	if err != nil {
+		return 0
+	}
+	return count
+}
+
+// KafkaDeliverAt возвращает время, раньше которого сообщение нельзя обрабатывать
+func KafkaDeliverAt(msg kafkago.Message) time.Time {
+	value, ok := kafkaHeader(msg, DeliverAtHeader)
+	if !ok {
+		return time.Time{}
+	}
+	deliverAt, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return time.Time{}
+	}
+	return deliverAt
+}
+
+func kafkaHeader(msg kafkago.Message, key string) (string, bool) {
+	for _, header := range msg.Headers {
+		if header.Key == key {
+			return string(header.Value), true
+		}
+	}
+	return "", false
+}
+
+// kafkaMessageID возвращает id сообщения; старые сообщения без id получают новый
+func kafkaMessageID(msg kafkago.Message) string {
+	if id, ok := kafkaHeader(msg, MessageIDHeader); ok && id != "" {
+		return id
+	}
+	return uuid.New().String()
+}
+
+func kafkaToDeadLetter(msg kafkago.Message) *domain.DeadLetter {
+	deadLetter := &domain.DeadLetter{
+		Id:       string(msg.Key),
+		Queue:    KafkaDeadLetterTopic,
+		Body:     string(msg.Value),
+		Attempts: KafkaRetryCount(msg),
+	}
+	if reason, ok := kafkaHeader(msg, FailureReasonHeader); ok {
+		deadLetter.Reason = reason
+	}
+	if failedAt, ok := kafkaHeader(msg, FailedAtHeader); ok {
+		if t, err := time.Parse(time.RFC3339, failedAt); err == nil {
+			deadLetter.FailedAt = t
+		}
+	}
+	return deadLetter
+}
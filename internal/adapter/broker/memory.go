@@ -0,0 +1,236 @@
+package broker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/dontpanicw/EventBooker/internal/domain"
+	"github.com/google/uuid"
+)
+
+// memoryDeliveriesBuffer - сколько созревших сообщений может ждать свободного воркера
+const memoryDeliveriesBuffer = 1024
+
+var ErrBrokerClosed = errors.New("broker closed")
+
+// MemoryMessage - сообщение in-process брокера
+type MemoryMessage struct {
+	Id         string
+	Body       []byte
+	RetryCount int
+}
+
+// MemoryBroker - брокер в памяти процесса для локального запуска и тестов.
+// Задержка отмены реализована таймерами: по истечении срока сообщение попадает
+// в канал Deliveries, откуда его читает consumer. Сообщения не переживают рестарт.
+type MemoryBroker struct {
+	hold       time.Duration
+	deliveries chan MemoryMessage
+	done       chan struct{}
+
+	mu          sync.Mutex
+	closed      bool
+	timers      map[*time.Timer]struct{}
+	deadLetters []*domain.DeadLetter
+	subscribers []func(*domain.LifecycleEvent)
+}
+
+// NewMemoryBroker создает брокер; hold - через сколько неоплаченная бронь уходит на отмену
+func NewMemoryBroker(hold time.Duration) *MemoryBroker {
+	return &MemoryBroker{
+		hold:       hold,
+		deliveries: make(chan MemoryMessage, memoryDeliveriesBuffer),
+		done:       make(chan struct{}),
+		timers:     make(map[*time.Timer]struct{}),
+	}
+}
+
+// Deliveries - канал сообщений, у которых наступил срок обработки; не закрывается
+func (b *MemoryBroker) Deliveries() <-chan MemoryMessage {
+	return b.deliveries
+}
+
+func (b *MemoryBroker) PublishDelayedCancellation(ctx context.Context, booking *domain.Booking) error {
+	msg := BookingMessage{
+		BookingID: booking.Id,
+		EventID:   booking.EventId,
+		UserID:    booking.UserId,
+		Timestamp: booking.Date,
+	}
+
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("failed to marshal message: %w", err)
+	}
+
+	if err := b.schedule(MemoryMessage{Id: uuid.New().String(), Body: body}, b.hold); err != nil {
+		return fmt.Errorf("failed to publish delayed cancellation: %w", err)
+	}
+
+	log.Printf("Published delayed cancellation for booking %s (will be processed in %s)", booking.Id, b.hold)
+	return nil
+}
+
+// PublishRetry планирует повторную доставку сообщения с задержкой попытки attempt
+func (b *MemoryBroker) PublishRetry(ctx context.Context, msg MemoryMessage, attempt int, reason string) error {
+	msg.RetryCount = attempt
+	if err := b.schedule(msg, RetryDelay(attempt)); err != nil {
+		return fmt.Errorf("failed to publish retry: %w", err)
+	}
+
+	log.Printf("Scheduled retry %d/%d for message %s in %s", attempt, MaxRetryAttempts, msg.Id, RetryDelay(attempt))
+	return nil
+}
+
+func (b *MemoryBroker) PublishDeadLetter(ctx context.Context, msg MemoryMessage, reason string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.deadLetters = append(b.deadLetters, &domain.DeadLetter{
+		Id:       msg.Id,
+		Queue:    DeadLetterQueue,
+		Body:     string(msg.Body),
+		Attempts: msg.RetryCount,
+		Reason:   reason,
+		FailedAt: time.Now().UTC(),
+	})
+
+	log.Printf("Message %s moved to dead letter queue: %s", msg.Id, reason)
+	return nil
+}
+
+// SubscribeLifecycleEvents регистрирует обработчик доменных событий.
+// Обработчик вызывается синхронно в горутине публикации и не должен блокироваться.
+func (b *MemoryBroker) SubscribeLifecycleEvents(handler func(*domain.LifecycleEvent)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.subscribers = append(b.subscribers, handler)
+}
+
+func (b *MemoryBroker) PublishLifecycleEvent(ctx context.Context, event *domain.LifecycleEvent) error {
+	if event.Id == "" {
+		event.Id = uuid.New().String()
+	}
+
+	b.mu.Lock()
+	subscribers := append([]func(*domain.LifecycleEvent){}, b.subscribers...)
+	b.mu.Unlock()
+
+	for _, subscriber := range subscribers {
+		subscriber(event)
+	}
+
+	log.Printf("Published %s event %s", event.Type, event.Id)
+	return nil
+}
+
+func (b *MemoryBroker) ListDeadLetters(ctx context.Context, limit int) ([]*domain.DeadLetter, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	count := len(b.deadLetters)
+	if limit > 0 && count > limit {
+		count = limit
+	}
+
+	deadLetters := make([]*domain.DeadLetter, 0, count)
+	for _, deadLetter := range b.deadLetters[:count] {
+		copied := *deadLetter
+		deadLetters = append(deadLetters, &copied)
+	}
+	return deadLetters, nil
+}
+
+func (b *MemoryBroker) GetDeadLetter(ctx context.Context, id string) (*domain.DeadLetter, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, deadLetter := range b.deadLetters {
+		if deadLetter.Id == id {
+			copied := *deadLetter
+			return &copied, nil
+		}
+	}
+	return nil, domain.ErrDeadLetterNotFound
+}
+
+// ReplayDeadLetter сразу возвращает сообщение consumer'у со сброшенным счетчиком попыток
+func (b *MemoryBroker) ReplayDeadLetter(ctx context.Context, id string) error {
+	deadLetter, err := b.removeDeadLetter(id)
+	if err != nil {
+		return err
+	}
+
+	if err := b.schedule(MemoryMessage{Id: deadLetter.Id, Body: []byte(deadLetter.Body)}, 0); err != nil {
+		return fmt.Errorf("failed to replay dead letter: %w", err)
+	}
+	log.Printf("Dead letter %s replayed", id)
+	return nil
+}
+
+func (b *MemoryBroker) DiscardDeadLetter(ctx context.Context, id string) error {
+	if _, err := b.removeDeadLetter(id); err != nil {
+		return err
+	}
+	log.Printf("Dead letter %s discarded", id)
+	return nil
+}
+
+func (b *MemoryBroker) removeDeadLetter(id string) (*domain.DeadLetter, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for i, deadLetter := range b.deadLetters {
+		if deadLetter.Id == id {
+			b.deadLetters = append(b.deadLetters[:i], b.deadLetters[i+1:]...)
+			return deadLetter, nil
+		}
+	}
+	return nil, domain.ErrDeadLetterNotFound
+}
+
+// schedule доставляет сообщение в Deliveries через delay
+func (b *MemoryBroker) schedule(msg MemoryMessage, delay time.Duration) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return ErrBrokerClosed
+	}
+
+	var timer *time.Timer
+	timer = time.AfterFunc(delay, func() {
+		b.mu.Lock()
+		delete(b.timers, timer)
+		b.mu.Unlock()
+
+		select {
+		case b.deliveries <- msg:
+		case <-b.done:
+		}
+	})
+	b.timers[timer] = struct{}{}
+	return nil
+}
+
+// Close останавливает таймеры; отложенные и недоставленные сообщения теряются
+func (b *MemoryBroker) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return nil
+	}
+	b.closed = true
+	for timer := range b.timers {
+		timer.Stop()
+	}
+	close(b.done)
+	return nil
+}
@@ -8,17 +8,28 @@ import (
 	"time"
 
 	"github.com/dontpanicw/EventBooker/internal/domain"
+	"github.com/google/uuid"
 	amqp "github.com/rabbitmq/amqp091-go"
 )
 
 const (
 	DelayedCancellationsQueue = "delayed_cancellations"
 	WaitingQueue              = "waiting_cancellations"
-	ConfirmationsQueue        = "confirmations"
 	DelayedExchange           = "delayed_exchange"
 	WaitingExchange           = "waiting_exchange"
-	ConfirmationsExchange     = "confirmations_exchange"
-	BookingTTL                = 1 * 60 * 1000 // 15 минут в миллисекундах
+	DomainEventsExchange      = "domain_events"
+	BookingTTL                = int64(domain.BookingHold / time.Millisecond)
+
+	RetryExchange       = "retry_exchange"
+	RetryQueuePrefix    = "retry_cancellations_"
+	DeadLetterExchange  = "dead_letter_exchange"
+	DeadLetterQueue     = "dead_letter_cancellations"
+	MaxRetryAttempts    = 5
+	RetryBaseDelay      = 1 * time.Second // задержка первой повторной попытки, далее удваивается
+	RetryCountHeader    = "x-retry-count"
+	FailureReasonHeader = "x-failure-reason"
+	FailedAtHeader      = "x-failed-at"
+	EventVersionHeader  = "x-event-version"
 )
 
 type RabbitMQBroker struct {
@@ -87,10 +98,10 @@ func (b *RabbitMQBroker) setupQueues() error {
 		return fmt.Errorf("failed to declare waiting exchange: %w", err)
 	}
 
-	// Declare confirmations exchange
+	// Declare domain events exchange (topic: routing key = тип события, например booking.confirmed)
 	err = b.channel.ExchangeDeclare(
-		ConfirmationsExchange,
-		"direct",
+		DomainEventsExchange,
+		"topic",
 		true,
 		false,
 		false,
@@ -98,7 +109,7 @@ func (b *RabbitMQBroker) setupQueues() error {
 		nil,
 	)
 	if err != nil {
-		return fmt.Errorf("failed to declare confirmations exchange: %w", err)
+		return fmt.Errorf("failed to declare domain events exchange: %w", err)
 	}
 
 	// Declare waiting queue с TTL и DLX (сообщения "спят" здесь 15 минут)
@@ -156,6 +167,10 @@ func (b *RabbitMQBroker) setupQueues() error {
 		return fmt.Errorf("failed to bind delayed queue: %w", err)
 	}
 
+	if err := b.setupRetryQueues(); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -182,6 +197,7 @@ func (b *RabbitMQBroker) PublishDelayedCancellation(ctx context.Context, booking
 		false,
 		amqp.Publishing{
 			ContentType: "application/json",
+			MessageId:   uuid.New().String(),
 			Body:        body,
 		},
 	)
@@ -193,6 +209,43 @@ func (b *RabbitMQBroker) PublishDelayedCancellation(ctx context.Context, booking
 	return nil
 }
 
+// PublishLifecycleEvent публикует доменное событие в topic exchange с routing key = тип события
+func (b *RabbitMQBroker) PublishLifecycleEvent(ctx context.Context, event *domain.LifecycleEvent) error {
+	if event.Id == "" {
+		event.Id = uuid.New().String()
+	}
+
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal lifecycle event: %w", err)
+	}
+
+	err = b.channel.PublishWithContext(
+		ctx,
+		DomainEventsExchange,
+		event.Type,
+		false,
+		false,
+		amqp.Publishing{
+			ContentType:  "application/json",
+			DeliveryMode: amqp.Persistent,
+			MessageId:    event.Id,
+			Type:         event.Type,
+			Timestamp:    event.OccurredAt,
+			Headers: amqp.Table{
+				EventVersionHeader: int32(event.Version),
+			},
+			Body: body,
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to publish lifecycle event: %w", err)
+	}
+
+	log.Printf("Published %s event %s", event.Type, event.Id)
+	return nil
+}
+
 func (b *RabbitMQBroker) Close() error {
 	if b.channel != nil {
 		b.channel.Close()
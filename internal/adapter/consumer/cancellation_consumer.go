@@ -2,31 +2,59 @@ package consumer
 
 import (
 	"context"
-	"encoding/json"
+	"fmt"
 	"log"
+	"sync"
 
 	"github.com/dontpanicw/EventBooker/internal/adapter/broker"
-	"github.com/dontpanicw/EventBooker/internal/domain"
-	"github.com/dontpanicw/EventBooker/internal/port"
 	amqp "github.com/rabbitmq/amqp091-go"
 )
 
+// RetryPublisher - отправка необработанных сообщений на повторную попытку или в dead-letter очередь
+type RetryPublisher interface {
+	PublishRetry(ctx context.Context, msg amqp.Delivery, attempt int, reason string) error
+	PublishDeadLetter(ctx context.Context, msg amqp.Delivery, reason string) error
+}
+
+// Options - настройки параллельной обработки сообщений
+type Options struct {
+	Workers  int // количество горутин-обработчиков
+	Prefetch int // сколько неподтвержденных сообщений RabbitMQ отдает consumer'у
+}
+
+const consumerTag = "cancellation_consumer"
+
 type CancellationConsumer struct {
 	channel *amqp.Channel
-	repo    port.Repository
+	handler *CancellationHandler
+	retries RetryPublisher
+	opts    Options
+	wg      sync.WaitGroup
 }
 
-func NewCancellationConsumer(channel *amqp.Channel, repo port.Repository) *CancellationConsumer {
+func NewCancellationConsumer(channel *amqp.Channel, handler *CancellationHandler, retries RetryPublisher, opts Options) *CancellationConsumer {
+	if opts.Workers <= 0 {
+		opts.Workers = 1
+	}
+	if opts.Prefetch < opts.Workers {
+		opts.Prefetch = opts.Workers
+	}
 	return &CancellationConsumer{
 		channel: channel,
-		repo:    repo,
+		handler: handler,
+		retries: retries,
+		opts:    opts,
 	}
 }
 
 func (c *CancellationConsumer) Start(ctx context.Context) error {
+	if err := c.channel.Qos(c.opts.Prefetch, 0, false); err != nil {
+		return fmt.Errorf("failed to set qos: %w", err)
+	}
+
 	msgs, err := c.channel.Consume(
 		broker.DelayedCancellationsQueue,
-		"cancellation_consumer",
+		consumerTag,
 		false, // auto-ack
 		false,
 		false,
@@ -37,66 +65,55 @@ func (c *CancellationConsumer) Start(ctx context.Context) error {
 		return err
 	}
 
-	log.Println("Cancellation consumer started")
-
+	// При остановке отменяем подписку: RabbitMQ перестает слать новые сообщения,
+	// а уже полученные дочитываются воркерами до закрытия канала deliveries
 	go func() {
-		for {
-			select {
-			case <-ctx.Done():
-				log.Println("Cancellation consumer stopped")
-				return
-			case msg, ok := <-msgs:
-				if !ok {
-					log.Println("Channel closed")
-					return
-				}
-				c.handleMessage(ctx, msg)
-			}
+		<-ctx.Done()
+		if err := c.channel.Cancel(consumerTag, false); err != nil {
+			log.Printf("Failed to cancel cancellation consumer: %v", err)
 		}
 	}()
 
+	c.consume(context.WithoutCancel(ctx), msgs)
+
+	log.Printf("Cancellation consumer started (workers: %d, prefetch: %d)", c.opts.Workers, c.opts.Prefetch)
 	return nil
 }
 
-func (c *CancellationConsumer) handleMessage(ctx context.Context, msg amqp.Delivery) {
-	var bookingMsg broker.BookingMessage
-	if err := json.Unmarshal(msg.Body, &bookingMsg); err != nil {
-		log.Printf("Failed to unmarshal message: %v", err)
-		msg.Nack(false, false)
-		return
+// consume запускает пул воркеров, которые обрабатывают сообщения до закрытия msgs
+func (c *CancellationConsumer) consume(ctx context.Context, msgs <-chan amqp.Delivery) {
+	for i := 0; i < c.opts.Workers; i++ {
+		c.wg.Add(1)
+		go func() {
+			defer c.wg.Done()
+			for msg := range msgs {
+				c.handleMessage(ctx, msg)
+			}
+		}()
 	}
+}
 
-	log.Printf("Processing cancellation check for booking %s", bookingMsg.BookingID)
-
-	// Получаем бронь из БД
-	booking, err := c.repo.GetBooking(ctx, bookingMsg.BookingID)
-	if err != nil {
-		log.Printf("Failed to get booking %s: %v", bookingMsg.BookingID, err)
-		msg.Nack(false, true) // Requeue
-		return
-	}
+// Wait дожидается, пока воркеры обработают уже полученные сообщения, или истечения ctx
+func (c *CancellationConsumer) Wait(ctx context.Context) error {
+	return waitDrained(ctx, &c.wg, "Cancellation consumer")
+}
 
-	// Проверяем статус
-	if booking.Status == domain.PendingStatus {
-		// Отменяем бронь и возвращаем место
-		log.Printf("Cancelling booking %s (not paid in time)", bookingMsg.BookingID)
+func (c *CancellationConsumer) handleMessage(ctx context.Context, msg amqp.Delivery) {
+	decision := c.handler.Handle(ctx, msg.Body, broker.RetryCount(msg))
 
-		if err := c.repo.CancelBooking(ctx, bookingMsg.BookingID); err != nil {
-			log.Printf("Failed to cancel booking %s: %v", bookingMsg.BookingID, err)
+	switch decision.Action {
+	case ActionRetry:
+		if err := c.retries.PublishRetry(ctx, msg, decision.Attempt, decision.Reason); err != nil {
+			log.Printf("Failed to schedule retry: %v", err)
 			msg.Nack(false, true)
 			return
 		}
-
-		// Возвращаем место
-		if err := c.repo.IncrementAvailableTickets(ctx, bookingMsg.EventID); err != nil {
-			log.Printf("Failed to increment tickets for event %s: %v", bookingMsg.EventID, err)
+	case ActionDeadLetter:
+		if err := c.retries.PublishDeadLetter(ctx, msg, decision.Reason); err != nil {
+			log.Printf("Failed to publish dead letter: %v", err)
 			msg.Nack(false, true)
 			return
 		}
-
-		log.Printf("Booking %s cancelled and ticket returned", bookingMsg.BookingID)
-	} else {
-		log.Printf("Booking %s already confirmed, skipping cancellation", bookingMsg.BookingID)
 	}
 
 	msg.Ack(false)
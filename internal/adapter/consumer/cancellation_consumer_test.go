@@ -3,11 +3,14 @@ package consumer
 import (
 	"context"
 	"encoding/json"
+	"fmt"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/dontpanicw/EventBooker/internal/adapter/broker"
 	"github.com/dontpanicw/EventBooker/internal/domain"
+	"github.com/dontpanicw/EventBooker/internal/port"
 	amqp "github.com/rabbitmq/amqp091-go"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -41,16 +44,27 @@ func (m *MockRepository) CreateEvent(ctx context.Context, event *domain.Event) (
 	return args.String(0), args.Error(1)
 }
 
-func (m *MockRepository) BookEvent(ctx context.Context, booking *domain.Booking) (string, error) {
+func (m *MockRepository) BookEvent(ctx context.Context, booking *domain.Booking) (*domain.Event, error) {
 	args := m.Called(ctx, booking)
-	return args.String(0), args.Error(1)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Event), args.Error(1)
 }
 
-func (m *MockRepository) ConfirmBooking(ctx context.Context, bookingID string) error {
-	args := m.Called(ctx, bookingID)
+func (m *MockRepository) ConfirmBooking(ctx context.Context, bookingID string, version int64) error {
+	args := m.Called(ctx, bookingID, version)
 	return args.Error(0)
 }
 
+func (m *MockRepository) UpdateEvent(ctx context.Context, eventID string, version int64, update domain.EventUpdate) (*domain.Event, error) {
+	args := m.Called(ctx, eventID, version, update)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Event), args.Error(1)
+}
+
 func (m *MockRepository) GetEvent(ctx context.Context, eventID string) (*domain.Event, error) {
 	args := m.Called(ctx, eventID)
 	if args.Get(0) == nil {
@@ -59,12 +73,28 @@ func (m *MockRepository) GetEvent(ctx context.Context, eventID string) (*domain.
 	return args.Get(0).(*domain.Event), args.Error(1)
 }
 
-func (m *MockRepository) GetAllEvents(ctx context.Context) ([]*domain.Event, error) {
-	args := m.Called(ctx)
+func (m *MockRepository) ListEvents(ctx context.Context, filter domain.EventFilter) (*domain.EventPage, error) {
+	args := m.Called(ctx, filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.EventPage), args.Error(1)
+}
+
+func (m *MockRepository) SearchEvents(ctx context.Context, query string, limit int) ([]*domain.EventSearchResult, error) {
+	args := m.Called(ctx, query, limit)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
-	return args.Get(0).([]*domain.Event), args.Error(1)
+	return args.Get(0).([]*domain.EventSearchResult), args.Error(1)
+}
+
+func (m *MockRepository) ListBookings(ctx context.Context, filter domain.BookingFilter) (*domain.BookingPage, error) {
+	args := m.Called(ctx, filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.BookingPage), args.Error(1)
 }
 
 func (m *MockRepository) AddAvailableTickets(ctx context.Context, eventID string) error {
@@ -72,6 +102,21 @@ func (m *MockRepository) AddAvailableTickets(ctx context.Context, eventID string
 	return args.Error(0)
 }
 
+// MockEventPublisher - мок публикации доменных событий
+type MockEventPublisher struct {
+	mock.Mock
+}
+
+func (m *MockEventPublisher) PublishLifecycleEvent(ctx context.Context, event *domain.LifecycleEvent) error {
+	args := m.Called(ctx, event)
+	return args.Error(0)
+}
+
+// consumerCtx проверяет, что отмена записывается в журнал от имени consumer'а
+var consumerCtx = mock.MatchedBy(func(ctx context.Context) bool {
+	return port.ActorFrom(ctx).Type == domain.ActorConsumer && port.AuditReason(ctx) == cancellationReason
+})
+
 // MockDelivery для тестирования обработки сообщений
 type MockDelivery struct {
 	body        []byte
@@ -93,8 +138,9 @@ func (m *MockDelivery) Nack(multiple, requeue bool) error {
 
 func TestCancellationConsumer_PendingBooking(t *testing.T) {
 	mockRepo := new(MockRepository)
+	mockEvents := new(MockEventPublisher)
 	consumer := &CancellationConsumer{
-		repo: mockRepo,
+		handler: NewCancellationHandler(mockRepo, mockEvents),
 	}
 
 	ctx := context.Background()
@@ -119,20 +165,27 @@ func TestCancellationConsumer_PendingBooking(t *testing.T) {
 	}
 
 	mockRepo.On("GetBooking", ctx, "booking-123").Return(booking, nil)
-	mockRepo.On("CancelBooking", ctx, "booking-123").Return(nil)
-	mockRepo.On("IncrementAvailableTickets", ctx, "event-123").Return(nil)
+	mockRepo.On("CancelBooking", consumerCtx, "booking-123").Return(nil)
+	mockEvents.On("PublishLifecycleEvent", ctx, mock.MatchedBy(func(event *domain.LifecycleEvent) bool {
+		return event.Type == domain.BookingExpiredEvent
+	})).Return(nil).Once()
+	mockEvents.On("PublishLifecycleEvent", ctx, mock.MatchedBy(func(event *domain.LifecycleEvent) bool {
+		return event.Type == domain.BookingCancelledEvent
+	})).Return(nil).Once()
 
 	consumer.handleMessage(ctx, delivery)
 
 	mockRepo.AssertExpectations(t)
-	mockRepo.AssertCalled(t, "CancelBooking", ctx, "booking-123")
-	mockRepo.AssertCalled(t, "IncrementAvailableTickets", ctx, "event-123")
+	mockEvents.AssertExpectations(t)
+	mockRepo.AssertCalled(t, "CancelBooking", consumerCtx, "booking-123")
+	// Место возвращает CancelBooking в своей транзакции
+	mockRepo.AssertNotCalled(t, "IncrementAvailableTickets", mock.Anything, mock.Anything)
 }
 
 func TestCancellationConsumer_ConfirmedBooking(t *testing.T) {
 	mockRepo := new(MockRepository)
 	consumer := &CancellationConsumer{
-		repo: mockRepo,
+		handler: NewCancellationHandler(mockRepo, nil),
 	}
 
 	ctx := context.Background()
@@ -164,6 +217,93 @@ func TestCancellationConsumer_ConfirmedBooking(t *testing.T) {
 	mockRepo.AssertNotCalled(t, "IncrementAvailableTickets", mock.Anything, mock.Anything)
 }
 
+func TestCancellationHandler_ConfirmedConcurrently(t *testing.T) {
+	mockRepo := new(MockRepository)
+	handler := NewCancellationHandler(mockRepo, nil)
+
+	ctx := context.Background()
+	body, _ := json.Marshal(broker.BookingMessage{BookingID: "booking-123", EventID: "event-123"})
+
+	booking := &domain.Booking{
+		Id:      "booking-123",
+		EventId: "event-123",
+		Status:  domain.PendingStatus,
+	}
+
+	// Бронь оплатили между GetBooking и CancelBooking
+	mockRepo.On("GetBooking", ctx, "booking-123").Return(booking, nil)
+	mockRepo.On("CancelBooking", consumerCtx, "booking-123").Return(fmt.Errorf("error cancel booking: %w", domain.ErrBookingNotPending))
+
+	decision := handler.Handle(ctx, body, 0)
+
+	assert.Equal(t, ActionAck, decision.Action)
+	mockRepo.AssertExpectations(t)
+	mockRepo.AssertNotCalled(t, "IncrementAvailableTickets", mock.Anything, mock.Anything)
+}
+
+func TestCancellationConsumer_WorkersProcessConcurrently(t *testing.T) {
+	mockRepo := new(MockRepository)
+	consumer := NewCancellationConsumer(nil, NewCancellationHandler(mockRepo, nil), nil, Options{Workers: 4, Prefetch: 8})
+
+	ctx := context.Background()
+	var inFlight, maxInFlight int32
+	mockRepo.On("GetBooking", ctx, mock.AnythingOfType("string")).
+		Run(func(args mock.Arguments) {
+			current := atomic.AddInt32(&inFlight, 1)
+			for {
+				prev := atomic.LoadInt32(&maxInFlight)
+				if current <= prev || atomic.CompareAndSwapInt32(&maxInFlight, prev, current) {
+					break
+				}
+			}
+			time.Sleep(20 * time.Millisecond)
+			atomic.AddInt32(&inFlight, -1)
+		}).
+		Return(&domain.Booking{Status: domain.ConfirmedStatus}, nil)
+
+	msgs := make(chan amqp.Delivery, 8)
+	for i := 0; i < 8; i++ {
+		body, _ := json.Marshal(broker.BookingMessage{BookingID: fmt.Sprintf("booking-%d", i)})
+		msgs <- amqp.Delivery{Body: body}
+	}
+	close(msgs)
+
+	consumer.consume(ctx, msgs)
+
+	waitCtx, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+	assert.NoError(t, consumer.Wait(waitCtx))
+
+	mockRepo.AssertNumberOfCalls(t, "GetBooking", 8)
+	assert.Greater(t, atomic.LoadInt32(&maxInFlight), int32(1))
+}
+
+func TestCancellationConsumer_WaitTimeout(t *testing.T) {
+	mockRepo := new(MockRepository)
+	consumer := NewCancellationConsumer(nil, NewCancellationHandler(mockRepo, nil), nil, Options{Workers: 1})
+
+	msgs := make(chan amqp.Delivery)
+	consumer.consume(context.Background(), msgs)
+	defer close(msgs)
+
+	waitCtx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.Error(t, consumer.Wait(waitCtx))
+}
+
+func TestNewCancellationConsumer_PrefetchAtLeastWorkers(t *testing.T) {
+	consumer := NewCancellationConsumer(nil, nil, nil, Options{Workers: 10, Prefetch: 2})
+
+	assert.Equal(t, 10, consumer.opts.Workers)
+	assert.Equal(t, 10, consumer.opts.Prefetch)
+}
+
+func TestRetryDelay_Exponential(t *testing.T) {
+	assert.Equal(t, broker.RetryBaseDelay, broker.RetryDelay(1))
+	assert.Equal(t, 2*broker.RetryBaseDelay, broker.RetryDelay(2))
+	assert.Equal(t, 8*broker.RetryBaseDelay, broker.RetryDelay(4))
+}
+
 func TestBookingMessage_Marshaling(t *testing.T) {
 	msg := broker.BookingMessage{
 		BookingID: "booking-123",
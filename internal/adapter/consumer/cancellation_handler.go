@@ -0,0 +1,130 @@
+package consumer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+
+	"github.com/dontpanicw/EventBooker/internal/adapter/broker"
+	"github.com/dontpanicw/EventBooker/internal/domain"
+	"github.com/dontpanicw/EventBooker/internal/port"
+)
+
+// Action - что consumer должен сделать с сообщением после обработки
+type Action int
+
+const (
+	ActionAck Action = iota
+	ActionRetry
+	ActionDeadLetter
+)
+
+// Decision - результат обработки сообщения об отмене
+type Decision struct {
+	Action  Action
+	Attempt int    // номер следующей попытки для ActionRetry
+	Reason  string // причина для ActionRetry и ActionDeadLetter
+}
+
+// cancellationReason - причина отмены в журнале аудита
+const cancellationReason = "payment timeout"
+
+// CancellationHandler - логика отмены неоплаченной брони, общая для всех брокеров.
+// Транспорт (RabbitMQ, Kafka) только доставляет сообщение и исполняет Decision.
+type CancellationHandler struct {
+	repo   port.Repository
+	events port.EventPublisher
+}
+
+func NewCancellationHandler(repo port.Repository, events port.EventPublisher) *CancellationHandler {
+	return &CancellationHandler{
+		repo:   repo,
+		events: events,
+	}
+}
+
+// Handle обрабатывает тело сообщения; retryCount - сколько повторов уже было
+func (h *CancellationHandler) Handle(ctx context.Context, body []byte, retryCount int) Decision {
+	var bookingMsg broker.BookingMessage
+	if err := json.Unmarshal(body, &bookingMsg); err != nil {
+		log.Printf("Failed to unmarshal message: %v", err)
+		return Decision{Action: ActionDeadLetter, Reason: fmt.Sprintf("malformed message: %v", err)}
+	}
+
+	if err := h.cancelIfPending(ctx, bookingMsg); err != nil {
+		log.Printf("Failed to process cancellation for booking %s: %v", bookingMsg.BookingID, err)
+		if errors.Is(err, domain.ErrBookingNotFound) {
+			// Повторы не помогут - бронь удалена или никогда не существовала
+			return Decision{Action: ActionDeadLetter, Reason: err.Error()}
+		}
+
+		attempt := retryCount + 1
+		if attempt > broker.MaxRetryAttempts {
+			return Decision{Action: ActionDeadLetter, Reason: fmt.Sprintf("retries exhausted: %v", err)}
+		}
+		return Decision{Action: ActionRetry, Attempt: attempt, Reason: err.Error()}
+	}
+
+	return Decision{Action: ActionAck}
+}
+
+func (h *CancellationHandler) cancelIfPending(ctx context.Context, bookingMsg broker.BookingMessage) error {
+	log.Printf("Processing cancellation check for booking %s", bookingMsg.BookingID)
+
+	// Получаем бронь из БД
+	booking, err := h.repo.GetBooking(ctx, bookingMsg.BookingID)
+	if err != nil {
+		return fmt.Errorf("failed to get booking: %w", err)
+	}
+
+	// Проверяем статус
+	if booking.Status != domain.PendingStatus {
+		log.Printf("Booking %s already %s, skipping cancellation", bookingMsg.BookingID, booking.Status)
+		return nil
+	}
+
+	// Отменяем бронь; место возвращается в той же транзакции
+	log.Printf("Cancelling booking %s (not paid in time)", bookingMsg.BookingID)
+
+	auditCtx := port.WithAuditReason(port.WithActor(ctx, domain.Actor{Type: domain.ActorConsumer}), cancellationReason)
+	if err := h.repo.CancelBooking(auditCtx, bookingMsg.BookingID); err != nil {
+		if errors.Is(err, domain.ErrBookingNotPending) {
+			// Бронь успели оплатить между проверкой и отменой - место не возвращаем
+			log.Printf("Booking %s changed status concurrently, skipping cancellation", bookingMsg.BookingID)
+			return nil
+		}
+		return fmt.Errorf("failed to cancel booking: %w", err)
+	}
+
+	log.Printf("Booking %s cancelled and ticket returned", bookingMsg.BookingID)
+
+	// booking.expired - первым: WebSocket брони закрывается на первом итоговом статусе,
+	// и клиент должен узнать, что бронь истекла, а не просто отменена
+	booking.Status = domain.CancelledStatus
+	for _, eventType := range []string{domain.BookingExpiredEvent, domain.BookingCancelledEvent} {
+		if err := h.events.PublishLifecycleEvent(ctx, domain.NewBookingLifecycleEvent(eventType, booking)); err != nil {
+			log.Printf("Failed to publish %s event: %v", eventType, err)
+		}
+	}
+	return nil
+}
+
+// waitDrained дожидается завершения воркеров consumer'а или истечения ctx
+func waitDrained(ctx context.Context, wg *sync.WaitGroup, name string) error {
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		log.Printf("%s stopped", name)
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("%s drain: %w", name, ctx.Err())
+	}
+}
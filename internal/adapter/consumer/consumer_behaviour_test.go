@@ -0,0 +1,253 @@
+package consumer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/dontpanicw/EventBooker/internal/adapter/broker"
+	"github.com/dontpanicw/EventBooker/internal/domain"
+	amqp "github.com/rabbitmq/amqp091-go"
+	kafkago "github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// outcome - что consumer сделал с сообщением, независимо от брокера
+type outcome struct {
+	acked        bool
+	retryAttempt int
+	deadLettered bool
+	reason       string
+}
+
+// adapter прогоняет одно сообщение через consumer конкретного брокера
+type adapter struct {
+	name    string
+	deliver func(t *testing.T, handler *CancellationHandler, body []byte, retryCount int) outcome
+}
+
+var adapters = []adapter{
+	{name: "rabbitmq", deliver: deliverRabbitMQ},
+	{name: "kafka", deliver: deliverKafka},
+	{name: "memory", deliver: deliverMemory},
+}
+
+type recordingAcknowledger struct {
+	out *outcome
+}
+
+func (a *recordingAcknowledger) Ack(tag uint64, multiple bool) error {
+	a.out.acked = true
+	return nil
+}
+
+func (a *recordingAcknowledger) Nack(tag uint64, multiple, requeue bool) error {
+	return nil
+}
+
+func (a *recordingAcknowledger) Reject(tag uint64, requeue bool) error {
+	return nil
+}
+
+type recordingRabbitPublisher struct {
+	out *outcome
+}
+
+func (p *recordingRabbitPublisher) PublishRetry(ctx context.Context, msg amqp.Delivery, attempt int, reason string) error {
+	p.out.retryAttempt = attempt
+	p.out.reason = reason
+	return nil
+}
+
+func (p *recordingRabbitPublisher) PublishDeadLetter(ctx context.Context, msg amqp.Delivery, reason string) error {
+	p.out.deadLettered = true
+	p.out.reason = reason
+	return nil
+}
+
+func deliverRabbitMQ(t *testing.T, handler *CancellationHandler, body []byte, retryCount int) outcome {
+	var out outcome
+	consumer := NewCancellationConsumer(nil, handler, &recordingRabbitPublisher{out: &out}, Options{})
+
+	delivery := amqp.Delivery{
+		Acknowledger: &recordingAcknowledger{out: &out},
+		Body:         body,
+	}
+	if retryCount > 0 {
+		delivery.Headers = amqp.Table{broker.RetryCountHeader: int32(retryCount)}
+	}
+
+	consumer.handleMessage(context.Background(), delivery)
+	return out
+}
+
+type recordingKafkaPublisher struct {
+	out *outcome
+}
+
+func (p *recordingKafkaPublisher) PublishRetry(ctx context.Context, msg kafkago.Message, attempt int, reason string) error {
+	p.out.retryAttempt = attempt
+	p.out.reason = reason
+	return nil
+}
+
+func (p *recordingKafkaPublisher) PublishDeadLetter(ctx context.Context, msg kafkago.Message, reason string) error {
+	p.out.deadLettered = true
+	p.out.reason = reason
+	return nil
+}
+
+func deliverKafka(t *testing.T, handler *CancellationHandler, body []byte, retryCount int) outcome {
+	var out outcome
+	consumer := NewKafkaCancellationConsumer(nil, "event-tasks", handler, &recordingKafkaPublisher{out: &out})
+
+	msg := kafkago.Message{Value: body}
+	if retryCount > 0 {
+		msg.Headers = []kafkago.Header{{Key: broker.RetryCountHeader, Value: []byte(strconv.Itoa(retryCount))}}
+	}
+
+	// Для Kafka "ack" - это разрешение закоммитить offset
+	out.acked = consumer.handleMessage(context.Background(), msg) == nil
+	return out
+}
+
+type recordingMemoryQueue struct {
+	out *outcome
+}
+
+func (q *recordingMemoryQueue) Deliveries() <-chan broker.MemoryMessage {
+	return nil
+}
+
+func (q *recordingMemoryQueue) PublishRetry(ctx context.Context, msg broker.MemoryMessage, attempt int, reason string) error {
+	q.out.retryAttempt = attempt
+	q.out.reason = reason
+	return nil
+}
+
+func (q *recordingMemoryQueue) PublishDeadLetter(ctx context.Context, msg broker.MemoryMessage, reason string) error {
+	q.out.deadLettered = true
+	q.out.reason = reason
+	return nil
+}
+
+func deliverMemory(t *testing.T, handler *CancellationHandler, body []byte, retryCount int) outcome {
+	var out outcome
+	consumer := NewMemoryCancellationConsumer(&recordingMemoryQueue{out: &out}, handler, 1)
+
+	// В памяти нет ack: сообщение считается обработанным, как только handleMessage вернулся
+	consumer.handleMessage(context.Background(), broker.MemoryMessage{Id: "msg-1", Body: body, RetryCount: retryCount})
+	out.acked = true
+	return out
+}
+
+func bookingMessageBody(t *testing.T) []byte {
+	body, err := json.Marshal(broker.BookingMessage{BookingID: "booking-123", EventID: "event-123", UserID: "user-123"})
+	assert.NoError(t, err)
+	return body
+}
+
+func TestConsumerBehaviour_PendingBookingIsCancelled(t *testing.T) {
+	for _, a := range adapters {
+		t.Run(a.name, func(t *testing.T) {
+			mockRepo := new(MockRepository)
+			mockEvents := new(MockEventPublisher)
+			mockRepo.On("GetBooking", mock.Anything, "booking-123").Return(&domain.Booking{Id: "booking-123", EventId: "event-123", Status: domain.PendingStatus}, nil)
+			mockRepo.On("CancelBooking", mock.Anything, "booking-123").Return(nil)
+			mockEvents.On("PublishLifecycleEvent", mock.Anything, mock.Anything).Return(nil)
+
+			out := a.deliver(t, NewCancellationHandler(mockRepo, mockEvents), bookingMessageBody(t), 0)
+
+			assert.Equal(t, outcome{acked: true}, out)
+			mockRepo.AssertExpectations(t)
+			mockEvents.AssertExpectations(t)
+		})
+	}
+}
+
+func TestConsumerBehaviour_ConfirmedBookingIsSkipped(t *testing.T) {
+	for _, a := range adapters {
+		t.Run(a.name, func(t *testing.T) {
+			mockRepo := new(MockRepository)
+			mockRepo.On("GetBooking", mock.Anything, "booking-123").Return(&domain.Booking{Id: "booking-123", Status: domain.ConfirmedStatus}, nil)
+
+			out := a.deliver(t, NewCancellationHandler(mockRepo, nil), bookingMessageBody(t), 0)
+
+			assert.Equal(t, outcome{acked: true}, out)
+			mockRepo.AssertNotCalled(t, "CancelBooking", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestConsumerBehaviour_TransientErrorSchedulesRetry(t *testing.T) {
+	for _, a := range adapters {
+		t.Run(a.name, func(t *testing.T) {
+			mockRepo := new(MockRepository)
+			mockRepo.On("GetBooking", mock.Anything, "booking-123").Return(nil, errors.New("connection refused"))
+
+			out := a.deliver(t, NewCancellationHandler(mockRepo, nil), bookingMessageBody(t), 2)
+
+			assert.True(t, out.acked)
+			assert.Equal(t, 3, out.retryAttempt)
+			assert.False(t, out.deadLettered)
+		})
+	}
+}
+
+func TestConsumerBehaviour_RetriesExhausted(t *testing.T) {
+	for _, a := range adapters {
+		t.Run(a.name, func(t *testing.T) {
+			mockRepo := new(MockRepository)
+			mockRepo.On("GetBooking", mock.Anything, "booking-123").Return(nil, errors.New("connection refused"))
+
+			out := a.deliver(t, NewCancellationHandler(mockRepo, nil), bookingMessageBody(t), broker.MaxRetryAttempts)
+
+			assert.True(t, out.acked)
+			assert.True(t, out.deadLettered)
+			assert.Zero(t, out.retryAttempt)
+			assert.True(t, strings.HasPrefix(out.reason, "retries exhausted"))
+		})
+	}
+}
+
+func TestConsumerBehaviour_MissingBookingGoesToDeadLetter(t *testing.T) {
+	for _, a := range adapters {
+		t.Run(a.name, func(t *testing.T) {
+			mockRepo := new(MockRepository)
+			mockRepo.On("GetBooking", mock.Anything, "booking-123").Return(nil, fmt.Errorf("error get booking: %w", domain.ErrBookingNotFound))
+
+			out := a.deliver(t, NewCancellationHandler(mockRepo, nil), bookingMessageBody(t), 0)
+
+			assert.True(t, out.acked)
+			assert.True(t, out.deadLettered)
+			assert.Zero(t, out.retryAttempt)
+		})
+	}
+}
+
+func TestConsumerBehaviour_MalformedMessageGoesToDeadLetter(t *testing.T) {
+	for _, a := range adapters {
+		t.Run(a.name, func(t *testing.T) {
+			mockRepo := new(MockRepository)
+
+			out := a.deliver(t, NewCancellationHandler(mockRepo, nil), []byte("not json"), 0)
+
+			assert.True(t, out.acked)
+			assert.True(t, out.deadLettered)
+			assert.True(t, strings.HasPrefix(out.reason, "malformed message"))
+			mockRepo.AssertNotCalled(t, "GetBooking", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestKafkaDeliverAt(t *testing.T) {
+	msg := kafkago.Message{Headers: []kafkago.Header{{Key: broker.DeliverAtHeader, Value: []byte("2026-03-01T19:15:00Z")}}}
+
+	assert.Equal(t, "2026-03-01T19:15:00Z", broker.KafkaDeliverAt(msg).Format("2006-01-02T15:04:05Z07:00"))
+	assert.True(t, broker.KafkaDeliverAt(kafkago.Message{}).IsZero())
+}
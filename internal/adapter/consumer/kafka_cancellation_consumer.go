@@ -0,0 +1,149 @@
+package consumer
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/dontpanicw/EventBooker/internal/adapter/broker"
+	kafkago "github.com/segmentio/kafka-go"
+	"github.com/wb-go/wbf/kafka"
+)
+
+// kafkaRetryInterval - пауза перед повторной публикацией retry/dead letter при недоступности Kafka
+const kafkaRetryInterval = time.Second
+
+// KafkaRetryPublisher - отправка необработанных сообщений Kafka на повтор или в dead-letter topic
+type KafkaRetryPublisher interface {
+	PublishRetry(ctx context.Context, msg kafkago.Message, attempt int, reason string) error
+	PublishDeadLetter(ctx context.Context, msg kafkago.Message, reason string) error
+}
+
+// kafkaReader - чтение topic'а в consumer group с ручным коммитом offset
+type kafkaReader interface {
+	Fetch(ctx context.Context) (kafkago.Message, error)
+	Commit(ctx context.Context, msg kafkago.Message) error
+	Close() error
+}
+
+// KafkaCancellationConsumer читает delay topic и retry topic'и попыток и обрабатывает
+// сообщение, только когда наступило время из заголовка x-deliver-at. Пока голова партиции
+// ждет своего срока, следующие за ней сообщения не читаются. Это не задерживает их дольше
+// собственного срока, потому что задержка внутри topic'а одна: BookingHold в delay topic,
+// RetryDelay(attempt) в retry topic'е попытки attempt. Исключение - replay из DLQ: он пишется
+// в delay topic с немедленной доставкой и может ждать до BookingHold за более ранними отменами.
+type KafkaCancellationConsumer struct {
+	topics    []string
+	handler   *CancellationHandler
+	retries   KafkaRetryPublisher
+	newReader func(topic string) kafkaReader
+	wg        sync.WaitGroup
+}
+
+func NewKafkaCancellationConsumer(brokers []string, delayTopic string, handler *CancellationHandler, retries KafkaRetryPublisher) *KafkaCancellationConsumer {
+	topics := []string{delayTopic}
+	for attempt := 1; attempt <= broker.MaxRetryAttempts; attempt++ {
+		topics = append(topics, broker.KafkaRetryTopic(attempt))
+	}
+
+	return &KafkaCancellationConsumer{
+		topics:  topics,
+		handler: handler,
+		retries: retries,
+		newReader: func(topic string) kafkaReader {
+			return kafka.NewConsumer(brokers, topic, broker.KafkaConsumerGroup)
+		},
+	}
+}
+
+func (c *KafkaCancellationConsumer) Start(ctx context.Context) error {
+	for _, topic := range c.topics {
+		reader := c.newReader(topic)
+		c.wg.Add(1)
+		go c.run(ctx, topic, reader)
+	}
+
+	log.Printf("Kafka cancellation consumer started (topics: %v)", c.topics)
+	return nil
+}
+
+func (c *KafkaCancellationConsumer) run(ctx context.Context, topic string, reader kafkaReader) {
+	defer c.wg.Done()
+	defer func() {
+		if err := reader.Close(); err != nil {
+			log.Printf("Failed to close kafka reader for %s: %v", topic, err)
+		}
+	}()
+
+	// Начатую обработку доводим до конца даже при остановке
+	processCtx := context.WithoutCancel(ctx)
+
+	for {
+		msg, err := reader.Fetch(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			log.Printf("Failed to fetch from %s: %v", topic, err)
+			if !sleep(ctx, kafkaRetryInterval) {
+				return
+			}
+			continue
+		}
+
+		// Не дождались срока - не коммитим, после рестарта сообщение прочитается снова
+		if !sleep(ctx, time.Until(broker.KafkaDeliverAt(msg))) {
+			return
+		}
+
+		for {
+			err := c.handleMessage(processCtx, msg)
+			if err == nil {
+				break
+			}
+			log.Printf("Failed to handle message from %s: %v", topic, err)
+			if !sleep(ctx, kafkaRetryInterval) {
+				return
+			}
+		}
+
+		if err := reader.Commit(processCtx, msg); err != nil {
+			log.Printf("Failed to commit offset %d in %s: %v", msg.Offset, topic, err)
+		}
+	}
+}
+
+// handleMessage возвращает nil, если offset сообщения можно коммитить
+func (c *KafkaCancellationConsumer) handleMessage(ctx context.Context, msg kafkago.Message) error {
+	decision := c.handler.Handle(ctx, msg.Value, broker.KafkaRetryCount(msg))
+
+	switch decision.Action {
+	case ActionRetry:
+		return c.retries.PublishRetry(ctx, msg, decision.Attempt, decision.Reason)
+	case ActionDeadLetter:
+		return c.retries.PublishDeadLetter(ctx, msg, decision.Reason)
+	}
+	return nil
+}
+
+// Wait дожидается завершения обработки текущих сообщений или истечения ctx
+func (c *KafkaCancellationConsumer) Wait(ctx context.Context) error {
+	return waitDrained(ctx, &c.wg, "Kafka cancellation consumer")
+}
+
+// sleep ждет d или отмены ctx; возвращает false, если ctx отменен
+func sleep(ctx context.Context, d time.Duration) bool {
+	if d <= 0 {
+		return ctx.Err() == nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
@@ -0,0 +1,115 @@
+package consumer
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dontpanicw/EventBooker/internal/adapter/broker"
+	"github.com/dontpanicw/EventBooker/internal/domain"
+	kafkago "github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeKafkaReader отдает одно сообщение, дальше блокируется до отмены ctx
+type fakeKafkaReader struct {
+	msgs chan kafkago.Message
+
+	mu        sync.Mutex
+	committed []time.Time
+}
+
+func newFakeKafkaReader(msgs ...kafkago.Message) *fakeKafkaReader {
+	r := &fakeKafkaReader{msgs: make(chan kafkago.Message, len(msgs))}
+	for _, msg := range msgs {
+		r.msgs <- msg
+	}
+	return r
+}
+
+func (r *fakeKafkaReader) Fetch(ctx context.Context) (kafkago.Message, error) {
+	select {
+	case msg := <-r.msgs:
+		return msg, nil
+	case <-ctx.Done():
+		return kafkago.Message{}, ctx.Err()
+	}
+}
+
+// ReadMessage - чтение без группы для KafkaUpdatesConsumer
+func (r *fakeKafkaReader) ReadMessage(ctx context.Context) (kafkago.Message, error) {
+	return r.Fetch(ctx)
+}
+
+func (r *fakeKafkaReader) Commit(ctx context.Context, msg kafkago.Message) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.committed = append(r.committed, time.Now())
+	return nil
+}
+
+func (r *fakeKafkaReader) Close() error {
+	return nil
+}
+
+func (r *fakeKafkaReader) commits() []time.Time {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]time.Time(nil), r.committed...)
+}
+
+func deliverAtMessage(t *testing.T, deliverAt time.Time) kafkago.Message {
+	return kafkago.Message{
+		Value:   bookingMessageBody(t),
+		Headers: []kafkago.Header{{Key: broker.DeliverAtHeader, Value: []byte(deliverAt.UTC().Format(time.RFC3339Nano))}},
+	}
+}
+
+func newTestKafkaConsumer(reader *fakeKafkaReader, handler *CancellationHandler) *KafkaCancellationConsumer {
+	c := NewKafkaCancellationConsumer(nil, "event-tasks", handler, &recordingKafkaPublisher{out: &outcome{}})
+	c.topics = []string{"event-tasks"}
+	c.newReader = func(topic string) kafkaReader { return reader }
+	return c
+}
+
+func TestKafkaCancellationConsumer_CommitsAfterDeadline(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockEvents := new(MockEventPublisher)
+	mockRepo.On("GetBooking", mock.Anything, "booking-123").Return(&domain.Booking{Id: "booking-123", EventId: "event-123", Status: domain.PendingStatus}, nil)
+	mockRepo.On("CancelBooking", mock.Anything, "booking-123").Return(nil)
+	mockEvents.On("PublishLifecycleEvent", mock.Anything, mock.Anything).Return(nil)
+
+	deliverAt := time.Now().Add(100 * time.Millisecond)
+	reader := newFakeKafkaReader(deliverAtMessage(t, deliverAt))
+	c := newTestKafkaConsumer(reader, NewCancellationHandler(mockRepo, mockEvents))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	require.NoError(t, c.Start(ctx))
+
+	assert.Eventually(t, func() bool { return len(reader.commits()) == 1 }, 2*time.Second, 10*time.Millisecond)
+	assert.False(t, reader.commits()[0].Before(deliverAt), "offset committed before x-deliver-at")
+	mockRepo.AssertExpectations(t)
+
+	cancel()
+	require.NoError(t, c.Wait(context.Background()))
+}
+
+func TestKafkaCancellationConsumer_StopBeforeDeadlineDoesNotCommit(t *testing.T) {
+	mockRepo := new(MockRepository)
+	reader := newFakeKafkaReader(deliverAtMessage(t, time.Now().Add(time.Hour)))
+	c := newTestKafkaConsumer(reader, NewCancellationHandler(mockRepo, nil))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	require.NoError(t, c.Start(ctx))
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+	require.NoError(t, c.Wait(context.Background()))
+
+	// Сообщение не обработано и не закоммичено: после рестарта оно прочитается снова
+	assert.Empty(t, reader.commits())
+	mockRepo.AssertNotCalled(t, "GetBooking", mock.Anything, mock.Anything)
+}
@@ -0,0 +1,117 @@
+package consumer
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"github.com/dontpanicw/EventBooker/internal/adapter/broker"
+	"github.com/dontpanicw/EventBooker/internal/port"
+	kafkago "github.com/segmentio/kafka-go"
+)
+
+// kafkaPartitionReader - чтение одной партиции без consumer group и коммита offset
+type kafkaPartitionReader interface {
+	ReadMessage(ctx context.Context) (kafkago.Message, error)
+	Close() error
+}
+
+// KafkaUpdatesConsumer передает потоку обновлений доменные события всех экземпляров.
+// Каждый экземпляр читает все партиции domain-events сам, без consumer group: хранить
+// offset'ы ему не нужно, а группа на каждый запуск оставалась бы в Kafka после остановки.
+// Чтение начинается с конца партиций: история клиентам не нужна, текущее состояние они
+// получают при подключении. Партиции, добавленные после запуска, читаются после рестарта.
+type KafkaUpdatesConsumer struct {
+	sink       port.EventPublisher
+	partitions func(ctx context.Context) ([]int, error)
+	newReader  func(partition int) kafkaPartitionReader
+}
+
+func NewKafkaUpdatesConsumer(brokers []string, sink port.EventPublisher) *KafkaUpdatesConsumer {
+	return &KafkaUpdatesConsumer{
+		sink: sink,
+		partitions: func(ctx context.Context) ([]int, error) {
+			return lookupKafkaPartitions(ctx, brokers, broker.KafkaDomainEventsTopic)
+		},
+		newReader: func(partition int) kafkaPartitionReader {
+			reader := kafkago.NewReader(kafkago.ReaderConfig{
+				Brokers:   brokers,
+				Topic:     broker.KafkaDomainEventsTopic,
+				Partition: partition,
+			})
+			// Ошибку SetOffset возвращает только закрытый reader или reader группы
+			_ = reader.SetOffset(kafkago.LastOffset)
+			return reader
+		},
+	}
+}
+
+// Start не ждет Kafka: партиции topic'а ищутся в фоне, пока он не появится
+func (c *KafkaUpdatesConsumer) Start(ctx context.Context) error {
+	go c.run(ctx)
+	return nil
+}
+
+func (c *KafkaUpdatesConsumer) run(ctx context.Context) {
+	var partitions []int
+	for {
+		var err error
+		partitions, err = c.partitions(ctx)
+		if err == nil && len(partitions) > 0 {
+			break
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		log.Printf("Failed to look up partitions of %s: %v", broker.KafkaDomainEventsTopic, err)
+		if !sleep(ctx, kafkaRetryInterval) {
+			return
+		}
+	}
+
+	for _, partition := range partitions {
+		go c.read(ctx, partition, c.newReader(partition))
+	}
+	log.Printf("Kafka updates consumer started (partitions: %v)", partitions)
+}
+
+func (c *KafkaUpdatesConsumer) read(ctx context.Context, partition int, reader kafkaPartitionReader) {
+	defer func() {
+		if err := reader.Close(); err != nil {
+			log.Printf("Failed to close kafka updates reader for partition %d: %v", partition, err)
+		}
+	}()
+
+	for {
+		msg, err := reader.ReadMessage(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			log.Printf("Failed to read domain event from partition %d: %v", partition, err)
+			if !sleep(ctx, kafkaRetryInterval) {
+				return
+			}
+			continue
+		}
+		deliverUpdate(ctx, c.sink, msg.Value)
+	}
+}
+
+// lookupKafkaPartitions возвращает номера партиций topic'а у первого доступного брокера
+func lookupKafkaPartitions(ctx context.Context, brokers []string, topic string) ([]int, error) {
+	err := errors.New("no kafka brokers")
+	for _, address := range brokers {
+		var partitions []kafkago.Partition
+		partitions, err = kafkago.LookupPartitions(ctx, "tcp", address, topic)
+		if err != nil {
+			continue
+		}
+		ids := make([]int, 0, len(partitions))
+		for _, partition := range partitions {
+			ids = append(ids, partition.ID)
+		}
+		return ids, nil
+	}
+	return nil, err
+}
@@ -0,0 +1,79 @@
+package consumer
+
+import (
+	"context"
+	"log"
+	"sync"
+
+	"github.com/dontpanicw/EventBooker/internal/adapter/broker"
+)
+
+// MemoryQueue - источник сообщений in-process брокера
+type MemoryQueue interface {
+	Deliveries() <-chan broker.MemoryMessage
+	PublishRetry(ctx context.Context, msg broker.MemoryMessage, attempt int, reason string) error
+	PublishDeadLetter(ctx context.Context, msg broker.MemoryMessage, reason string) error
+}
+
+// MemoryCancellationConsumer обрабатывает отмены из in-process брокера тем же CancellationHandler,
+// что и consumer'ы RabbitMQ и Kafka
+type MemoryCancellationConsumer struct {
+	queue   MemoryQueue
+	handler *CancellationHandler
+	workers int
+	wg      sync.WaitGroup
+}
+
+func NewMemoryCancellationConsumer(queue MemoryQueue, handler *CancellationHandler, workers int) *MemoryCancellationConsumer {
+	if workers <= 0 {
+		workers = 1
+	}
+	return &MemoryCancellationConsumer{
+		queue:   queue,
+		handler: handler,
+		workers: workers,
+	}
+}
+
+func (c *MemoryCancellationConsumer) Start(ctx context.Context) error {
+	processCtx := context.WithoutCancel(ctx)
+	deliveries := c.queue.Deliveries()
+
+	for i := 0; i < c.workers; i++ {
+		c.wg.Add(1)
+		go func() {
+			defer c.wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case msg := <-deliveries:
+					c.handleMessage(processCtx, msg)
+				}
+			}
+		}()
+	}
+
+	log.Printf("Memory cancellation consumer started (workers: %d)", c.workers)
+	return nil
+}
+
+func (c *MemoryCancellationConsumer) handleMessage(ctx context.Context, msg broker.MemoryMessage) {
+	decision := c.handler.Handle(ctx, msg.Body, msg.RetryCount)
+
+	var err error
+	switch decision.Action {
+	case ActionRetry:
+		err = c.queue.PublishRetry(ctx, msg, decision.Attempt, decision.Reason)
+	case ActionDeadLetter:
+		err = c.queue.PublishDeadLetter(ctx, msg, decision.Reason)
+	}
+	if err != nil {
+		log.Printf("Failed to reschedule message %s: %v", msg.Id, err)
+	}
+}
+
+// Wait дожидается завершения обработки текущих сообщений или истечения ctx
+func (c *MemoryCancellationConsumer) Wait(ctx context.Context) error {
+	return waitDrained(ctx, &c.wg, "Memory cancellation consumer")
+}
@@ -0,0 +1,84 @@
+package consumer
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dontpanicw/EventBooker/internal/adapter/broker"
+	"github.com/dontpanicw/EventBooker/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestMemoryCancellationConsumer_CancelsUnpaidBookingAfterHold(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockEvents := new(MockEventPublisher)
+	memoryBroker := broker.NewMemoryBroker(10 * time.Millisecond)
+	defer memoryBroker.Close()
+
+	cancelled := make(chan struct{})
+	mockRepo.On("GetBooking", mock.Anything, "booking-123").Return(&domain.Booking{Id: "booking-123", EventId: "event-123", Status: domain.PendingStatus}, nil)
+	mockRepo.On("CancelBooking", mock.Anything, "booking-123").
+		Run(func(args mock.Arguments) { close(cancelled) }).
+		Return(nil)
+	mockEvents.On("PublishLifecycleEvent", mock.Anything, mock.Anything).Return(nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	consumer := NewMemoryCancellationConsumer(memoryBroker, NewCancellationHandler(mockRepo, mockEvents), 2)
+	assert.NoError(t, consumer.Start(ctx))
+
+	err := memoryBroker.PublishDelayedCancellation(ctx, &domain.Booking{Id: "booking-123", EventId: "event-123", Date: time.Now()})
+	assert.NoError(t, err)
+
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Fatal("booking was not cancelled after hold")
+	}
+
+	cancel()
+	waitCtx, waitCancel := context.WithTimeout(context.Background(), time.Second)
+	defer waitCancel()
+	assert.NoError(t, consumer.Wait(waitCtx))
+	mockRepo.AssertExpectations(t)
+}
+
+func TestMemoryBroker_DeadLetterReplay(t *testing.T) {
+	mockRepo := new(MockRepository)
+	memoryBroker := broker.NewMemoryBroker(time.Millisecond)
+	defer memoryBroker.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	mockRepo.On("GetBooking", mock.Anything, "booking-123").Return(nil, domain.ErrBookingNotFound).Once()
+	consumer := NewMemoryCancellationConsumer(memoryBroker, NewCancellationHandler(mockRepo, nil), 1)
+	assert.NoError(t, consumer.Start(ctx))
+
+	err := memoryBroker.PublishDelayedCancellation(ctx, &domain.Booking{Id: "booking-123", EventId: "event-123"})
+	assert.NoError(t, err)
+
+	var deadLetters []*domain.DeadLetter
+	assert.Eventually(t, func() bool {
+		deadLetters, _ = memoryBroker.ListDeadLetters(ctx, 0)
+		return len(deadLetters) == 1
+	}, time.Second, 5*time.Millisecond)
+
+	replayed := make(chan struct{})
+	mockRepo.On("GetBooking", mock.Anything, "booking-123").
+		Run(func(args mock.Arguments) { close(replayed) }).
+		Return(&domain.Booking{Id: "booking-123", Status: domain.ConfirmedStatus}, nil).Once()
+
+	assert.NoError(t, memoryBroker.ReplayDeadLetter(ctx, deadLetters[0].Id))
+
+	select {
+	case <-replayed:
+	case <-time.After(time.Second):
+		t.Fatal("dead letter was not replayed")
+	}
+
+	_, err = memoryBroker.GetDeadLetter(ctx, deadLetters[0].Id)
+	assert.True(t, errors.Is(err, domain.ErrDeadLetterNotFound))
+}
@@ -0,0 +1,40 @@
+package consumer
+
+import (
+	"context"
+	"log"
+
+	"github.com/dontpanicw/EventBooker/internal/domain"
+	"github.com/dontpanicw/EventBooker/internal/port"
+)
+
+// MemoryEvents - подписка на доменные события in-process брокера
+type MemoryEvents interface {
+	SubscribeLifecycleEvents(handler func(*domain.LifecycleEvent))
+}
+
+// MemoryUpdatesConsumer передает потоку обновлений доменные события in-process брокера.
+// Экземпляр с таким брокером один, поэтому события приходят синхронно, без сериализации.
+type MemoryUpdatesConsumer struct {
+	events MemoryEvents
+	sink   port.EventPublisher
+}
+
+func NewMemoryUpdatesConsumer(events MemoryEvents, sink port.EventPublisher) *MemoryUpdatesConsumer {
+	return &MemoryUpdatesConsumer{
+		events: events,
+		sink:   sink,
+	}
+}
+
+func (c *MemoryUpdatesConsumer) Start(ctx context.Context) error {
+	c.events.SubscribeLifecycleEvents(func(event *domain.LifecycleEvent) {
+		if ctx.Err() != nil {
+			return
+		}
+		if err := c.sink.PublishLifecycleEvent(ctx, event); err != nil {
+			log.Printf("Failed to deliver %s event %s to updates: %v", event.Type, event.Id, err)
+		}
+	})
+	return nil
+}
@@ -0,0 +1,88 @@
+package consumer
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/dontpanicw/EventBooker/internal/adapter/broker"
+	"github.com/dontpanicw/EventBooker/internal/domain"
+	"github.com/dontpanicw/EventBooker/internal/port"
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+const updatesConsumerTag = "updates_consumer"
+
+// UpdatesConsumer передает потоку обновлений доменные события всех экземпляров приложения.
+// В отличие от отмен, сообщения здесь не делятся между consumer'ами: у каждого экземпляра
+// своя временная очередь, привязанная к domain_events, которая удаляется при отключении.
+type UpdatesConsumer struct {
+	channel *amqp.Channel
+	sink    port.EventPublisher
+}
+
+func NewUpdatesConsumer(channel *amqp.Channel, sink port.EventPublisher) *UpdatesConsumer {
+	return &UpdatesConsumer{
+		channel: channel,
+		sink:    sink,
+	}
+}
+
+func (c *UpdatesConsumer) Start(ctx context.Context) error {
+	queue, err := c.channel.QueueDeclare(
+		"",    // имя выдает RabbitMQ
+		false, // durable: пропущенные за время простоя обновления не нужны
+		true,  // auto-delete
+		true,  // exclusive
+		false,
+		nil,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to declare updates queue: %w", err)
+	}
+	if err := c.channel.QueueBind(queue.Name, "#", broker.DomainEventsExchange, false, nil); err != nil {
+		return fmt.Errorf("failed to bind updates queue: %w", err)
+	}
+
+	msgs, err := c.channel.Consume(
+		queue.Name,
+		updatesConsumerTag,
+		true, // auto-ack: потерянное обновление клиент восстановит перечитыванием
+		true,
+		false,
+		false,
+		nil,
+	)
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		<-ctx.Done()
+		if err := c.channel.Cancel(updatesConsumerTag, false); err != nil {
+			log.Printf("Failed to cancel updates consumer: %v", err)
+		}
+	}()
+
+	go func() {
+		for msg := range msgs {
+			deliverUpdate(ctx, c.sink, msg.Body)
+		}
+	}()
+
+	log.Printf("Updates consumer started (queue: %s)", queue.Name)
+	return nil
+}
+
+// deliverUpdate разбирает доменное событие и передает его потоку обновлений.
+// Нераспознанное сообщение только логируется: повторять его бессмысленно.
+func deliverUpdate(ctx context.Context, sink port.EventPublisher, body []byte) {
+	event, err := domain.DecodeLifecycleEvent(body)
+	if err != nil {
+		log.Printf("Skipping lifecycle event: %v", err)
+		return
+	}
+	if err := sink.PublishLifecycleEvent(ctx, event); err != nil {
+		log.Printf("Failed to deliver %s event %s to updates: %v", event.Type, event.Id, err)
+	}
+}
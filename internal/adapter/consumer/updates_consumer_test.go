@@ -0,0 +1,94 @@
+package consumer
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dontpanicw/EventBooker/internal/adapter/broker"
+	"github.com/dontpanicw/EventBooker/internal/domain"
+	kafkago "github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingSink запоминает доменные события, дошедшие до потока обновлений
+type recordingSink struct {
+	mu     sync.Mutex
+	events []*domain.LifecycleEvent
+}
+
+func (s *recordingSink) PublishLifecycleEvent(ctx context.Context, event *domain.LifecycleEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events = append(s.events, event)
+	return nil
+}
+
+func TestDeliverUpdate(t *testing.T) {
+	sink := &recordingSink{}
+	booking := &domain.Booking{Id: "booking-1", EventId: "event-1", UserId: "user-1", Status: domain.ConfirmedStatus}
+	body, err := json.Marshal(domain.NewBookingLifecycleEvent(domain.BookingConfirmedEvent, booking))
+	require.NoError(t, err)
+
+	deliverUpdate(context.Background(), sink, body)
+	deliverUpdate(context.Background(), sink, []byte("not json"))
+
+	require.Len(t, sink.events, 1)
+	assert.Equal(t, domain.BookingConfirmedEvent, sink.events[0].Type)
+	assert.Equal(t, domain.BookingPayload{BookingId: "booking-1", EventId: "event-1", UserId: "user-1", Status: domain.ConfirmedStatus}, sink.events[0].Data)
+}
+
+func TestMemoryUpdatesConsumer(t *testing.T) {
+	memoryBroker := broker.NewMemoryBroker(time.Minute)
+	defer memoryBroker.Close()
+
+	sink := &recordingSink{}
+	ctx, cancel := context.WithCancel(context.Background())
+	require.NoError(t, NewMemoryUpdatesConsumer(memoryBroker, sink).Start(ctx))
+
+	event := domain.NewEventLifecycleEvent(domain.EventSoldOutEvent, &domain.Event{Id: "event-1"})
+	require.NoError(t, memoryBroker.PublishLifecycleEvent(context.Background(), event))
+	require.Len(t, sink.events, 1)
+	assert.Same(t, event, sink.events[0])
+
+	// После остановки события в поток не попадают
+	cancel()
+	require.NoError(t, memoryBroker.PublishLifecycleEvent(context.Background(), event))
+	assert.Len(t, sink.events, 1)
+}
+
+func (s *recordingSink) received() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.events)
+}
+
+func TestKafkaUpdatesConsumer_ReadsEveryPartition(t *testing.T) {
+	event := domain.NewEventLifecycleEvent(domain.EventSoldOutEvent, &domain.Event{Id: "event-1"})
+	body, err := json.Marshal(event)
+	require.NoError(t, err)
+
+	sink := &recordingSink{}
+	c := NewKafkaUpdatesConsumer(nil, sink)
+	c.partitions = func(ctx context.Context) ([]int, error) { return []int{0, 1, 2}, nil }
+	var mu sync.Mutex
+	var read []int
+	c.newReader = func(partition int) kafkaPartitionReader {
+		mu.Lock()
+		defer mu.Unlock()
+		read = append(read, partition)
+		return newFakeKafkaReader(kafkago.Message{Partition: partition, Value: body})
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	require.NoError(t, c.Start(ctx))
+
+	assert.Eventually(t, func() bool { return sink.received() == 3 }, time.Second, 10*time.Millisecond)
+	mu.Lock()
+	assert.ElementsMatch(t, []int{0, 1, 2}, read)
+	mu.Unlock()
+}
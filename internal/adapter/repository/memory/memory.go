@@ -0,0 +1,1045 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/dontpanicw/EventBooker/internal/domain"
+	"github.com/dontpanicw/EventBooker/internal/port"
+	"github.com/google/uuid"
+)
+
+var (
+	// errTicketsOutOfRange - аналог CHECK (available_tickets BETWEEN 0 AND capacity) в БД
+	errTicketsOutOfRange = errors.New("available tickets out of range")
+	// errPriceForFree - аналог CHECK check_price_for_free в БД: у бесплатного события цены нет
+	errPriceForFree = errors.New("free event must not have a price")
+)
+
+// EventRepository - хранилище в памяти процесса с той же семантикой, что и Postgres:
+// атомарное списание билета, смена статуса только из pending и ошибки not found.
+// Данные не переживают рестарт - режим для локального запуска и тестов.
+type EventRepository struct {
+	mu          sync.RWMutex
+	events      map[string]*domain.Event
+	bookings    map[string]*domain.Booking
+	adjustments []*domain.InventoryAdjustment
+	auditLog    []*domain.AuditEntry
+	idempotency map[string]*domain.IdempotencyRecord
+	rooms       map[string]*domain.WaitingRoom
+	tickets     map[string]map[string]*domain.QueueTicket // мероприятие -> пользователь -> место в очереди
+	ballots     map[string]*domain.Ballot
+	entries     map[string]map[string]*domain.BallotEntry // мероприятие -> пользователь -> заявка
+	promos      map[string]*domain.PromoCode
+	redemptions []*domain.PromoRedemption
+	phases      map[string][]domain.PricePhase // расписания цен по мероприятиям
+	fees        map[string]*domain.FeeRule     // правила сбора по feeRuleKey
+}
+
+func NewEventRepository() port.Repository {
+	return &EventRepository{
+		events:      make(map[string]*domain.Event),
+		bookings:    make(map[string]*domain.Booking),
+		idempotency: make(map[string]*domain.IdempotencyRecord),
+		rooms:       make(map[string]*domain.WaitingRoom),
+		tickets:     make(map[string]map[string]*domain.QueueTicket),
+		ballots:     make(map[string]*domain.Ballot),
+		entries:     make(map[string]map[string]*domain.BallotEntry),
+		promos:      make(map[string]*domain.PromoCode),
+		phases:      make(map[string][]domain.PricePhase),
+		fees:        make(map[string]*domain.FeeRule),
+	}
+}
+
+func (r *EventRepository) CreateEvent(ctx context.Context, event *domain.Event) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.events[event.Id]; ok {
+		return "", fmt.Errorf("error create event: event %s already exists", event.Id)
+	}
+
+	if event.AvailableTickets > event.Capacity {
+		return "", fmt.Errorf("error create event: %w", errTicketsOutOfRange)
+	}
+	if event.IsFree && event.Price.Amount != 0 {
+		return "", fmt.Errorf("error create event: %w", errPriceForFree)
+	}
+
+	copied := *event
+	copied.Version = 1
+	r.events[event.Id] = &copied
+
+	r.auditLog = append(r.auditLog, port.NewEventCreatedAuditEntry(ctx, &copied))
+	return event.Id, nil
+}
+
+func (r *EventRepository) BookEvent(ctx context.Context, booking *domain.Booking) (*domain.Event, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	event, ok := r.events[booking.EventId]
+	if !ok {
+		return nil, fmt.Errorf("failed to book event: %w", domain.ErrEventNotFound)
+	}
+	if event.SaleMode == domain.SaleModeBallot {
+		return nil, fmt.Errorf("failed to book event: %w", domain.ErrBallotEvent)
+	}
+	if event.AvailableTickets == 0 {
+		return nil, domain.ErrNoTicketsAvailable
+	}
+	if _, ok := r.bookings[booking.Id]; ok {
+		return nil, fmt.Errorf("failed to book event: booking %s already exists", booking.Id)
+	}
+	var admission *domain.QueueTicket
+	if booking.Admission != nil {
+		admission = r.tickets[booking.EventId][booking.Admission.UserId]
+		if admission == nil || admission.Position != booking.Admission.Position || admission.BookingId != "" {
+			return nil, fmt.Errorf("failed to use admission: %w", domain.ErrAdmissionTokenUsed)
+		}
+	}
+	face := r.ticketPrice(event, booking.Date)
+	booking.Price = face
+	discount := domain.Money{Currency: face.Currency}
+	if booking.PromoCode != "" {
+		var err error
+		if discount, err = r.promoDiscount(booking); err != nil {
+			return nil, fmt.Errorf("failed to book event: %w", err)
+		}
+	}
+	if err := booking.Charge(face, discount, r.feeRule(event)); err != nil {
+		return nil, fmt.Errorf("failed to book event: %w", err)
+	}
+
+	event.AvailableTickets--
+	event.Version++
+	if admission != nil {
+		admission.BookingId = booking.Id
+	}
+	copied := *booking
+	copied.Version = 1
+	copied.LineItems = slices.Clone(booking.LineItems)
+	copied.Admission = nil
+	r.bookings[booking.Id] = &copied
+	if booking.PromoCode != "" {
+		r.redemptions = append(r.redemptions, &domain.PromoRedemption{
+			Code:       booking.PromoCode,
+			BookingId:  booking.Id,
+			UserId:     booking.UserId,
+			EventId:    booking.EventId,
+			Discount:   discount,
+			RedeemedAt: booking.Date,
+		})
+	}
+
+	r.auditLog = append(r.auditLog,
+		port.NewBookingAuditEntry(ctx, &copied, domain.AuditBookingCreated, ""),
+		port.NewTicketsAuditEntry(ctx, event.Id, int(event.AvailableTickets)+1, int(event.AvailableTickets)),
+	)
+	return r.withCounts(event), nil
+}
+
+func (r *EventRepository) ConfirmBooking(ctx context.Context, bookingID string, version int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	booking, err := r.transition(bookingID, domain.ConfirmedStatus, version)
+	if err != nil {
+		return fmt.Errorf("error confirm booking: %w", err)
+	}
+	r.auditLog = append(r.auditLog, port.NewBookingAuditEntry(ctx, booking, domain.AuditBookingConfirmed, domain.PendingStatus))
+	return nil
+}
+
+func (r *EventRepository) CancelBooking(ctx context.Context, bookingID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	booking, ok := r.bookings[bookingID]
+	if ok && booking.Status == domain.PendingStatus {
+		// Как CHECK в БД: при разошедшемся счетчике отмена не должна поднять его выше вместимости
+		if event := r.events[booking.EventId]; event.AvailableTickets >= event.Capacity {
+			return fmt.Errorf("failed to release ticket: %w", errTicketsOutOfRange)
+		}
+	}
+
+	booking, err := r.transition(bookingID, domain.CancelledStatus, 0)
+	if err != nil {
+		return fmt.Errorf("error cancel booking: %w", err)
+	}
+	event := r.events[booking.EventId]
+	event.AvailableTickets++
+
+	r.auditLog = append(r.auditLog,
+		port.NewBookingAuditEntry(ctx, booking, domain.AuditBookingCancelled, domain.PendingStatus),
+		port.NewTicketsAuditEntry(ctx, event.Id, int(event.AvailableTickets)-1, int(event.AvailableTickets)),
+	)
+	return nil
+}
+
+// transition переводит бронь из pending в status и поднимает версии брони и события;
+// ненулевой version сверяется с версией брони. Вызывается под r.mu.
+func (r *EventRepository) transition(bookingID, status string, version int64) (*domain.Booking, error) {
+	booking, ok := r.bookings[bookingID]
+	if !ok {
+		return nil, domain.ErrBookingNotFound
+	}
+	if version != 0 && booking.Version != version {
+		return nil, domain.ErrVersionMismatch
+	}
+	if booking.Status != domain.PendingStatus {
+		return nil, domain.ErrBookingNotPending
+	}
+	booking.Status = status
+	booking.Version++
+	r.events[booking.EventId].Version++
+	return booking, nil
+}
+
+func (r *EventRepository) UpdateEvent(ctx context.Context, eventID string, version int64, update domain.EventUpdate) (*domain.Event, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	event, ok := r.events[eventID]
+	if !ok {
+		return nil, fmt.Errorf("error update event: %w", domain.ErrEventNotFound)
+	}
+	if version != 0 && event.Version != version {
+		return nil, fmt.Errorf("error update event: %w", domain.ErrVersionMismatch)
+	}
+	if err := update.ValidateFor(*event); err != nil {
+		return nil, fmt.Errorf("error update event: %w", err)
+	}
+
+	old := *event
+	update.Apply(event)
+	event.Version++
+	r.auditLog = append(r.auditLog, port.NewEventUpdatedAuditEntry(ctx, &old, event))
+	return r.withCounts(event), nil
+}
+
+func (r *EventRepository) GetEvent(ctx context.Context, eventID string) (*domain.Event, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	event, ok := r.events[eventID]
+	if !ok {
+		return nil, fmt.Errorf("error get event: %w", domain.ErrEventNotFound)
+	}
+	copied := r.withCounts(event)
+	copied.PricePhases = slices.Clone(r.phases[eventID])
+	return copied, nil
+}
+
+func (r *EventRepository) ListEvents(ctx context.Context, filter domain.EventFilter) (*domain.EventPage, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var events []*domain.Event
+	for _, event := range r.events {
+		if filter.Matches(event) && (filter.After == nil || eventLess(filter.Sort, filter.After, event)) {
+			events = append(events, event)
+		}
+	}
+
+	sort.Slice(events, func(i, j int) bool {
+		return eventLess(filter.Sort, domain.NewEventCursor(filter.Sort, events[i]), events[j])
+	})
+	if len(events) > filter.Limit+1 {
+		events = events[:filter.Limit+1]
+	}
+	for i, event := range events {
+		events[i] = r.withCounts(event)
+	}
+	return domain.NewEventPage(events, filter), nil
+}
+
+// eventLess сообщает, идет ли позиция c раньше события в порядке sort
+func eventLess(sort string, c *domain.EventCursor, event *domain.Event) bool {
+	var order int
+	switch sort {
+	case domain.SortPriceAsc, domain.SortPriceDesc:
+		order = cmp.Compare(c.Price, event.SortPrice())
+	default:
+		order = c.Date.Compare(event.Date)
+	}
+	if order == 0 {
+		order = strings.Compare(c.Id, event.Id)
+	}
+	if sort == domain.SortDateDesc || sort == domain.SortPriceDesc {
+		return order > 0
+	}
+	return order < 0
+}
+
+func (r *EventRepository) SearchEvents(ctx context.Context, query string, limit int) ([]*domain.EventSearchResult, error) {
+	terms := domain.SearchTerms(query)
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var results []*domain.EventSearchResult
+	for _, event := range r.events {
+		name, nameHits := highlight(event.Name, terms)
+		description, descriptionHits := highlight(event.Description, terms)
+		if !containsAllTerms(event.Name+" "+event.Description, terms) {
+			continue
+		}
+		results = append(results, &domain.EventSearchResult{
+			Event:                r.withCounts(event),
+			Rank:                 float64(2*nameHits + descriptionHits), // совпадение в названии весит больше, как вес A в Postgres
+			NameHighlight:        name,
+			DescriptionHighlight: description,
+		})
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Rank != results[j].Rank {
+			return results[i].Rank > results[j].Rank
+		}
+		return results[i].Event.Id < results[j].Event.Id
+	})
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	return results, nil
+}
+
+// containsAllTerms сообщает, начинается ли с каждого из terms хотя бы одно слово текста
+func containsAllTerms(text string, terms []string) bool {
+	words := domain.SearchTerms(text)
+	for _, term := range terms {
+		if !slices.ContainsFunc(words, func(word string) bool { return strings.HasPrefix(word, term) }) {
+			return false
+		}
+	}
+	return true
+}
+
+// highlight экранирует текст для HTML, выделяет слова, начинающиеся с одного из terms,
+// и возвращает число выделенных слов
+func highlight(text string, terms []string) (string, int) {
+	var b strings.Builder
+	hits := 0
+	isWord := func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) }
+	for len(text) > 0 {
+		end := strings.IndexFunc(text, func(r rune) bool { return !isWord(r) })
+		if end == 0 {
+			end = strings.IndexFunc(text, isWord)
+			if end < 0 {
+				end = len(text)
+			}
+			b.WriteString(html.EscapeString(text[:end]))
+			text = text[end:]
+			continue
+		}
+		if end < 0 {
+			end = len(text)
+		}
+		word := text[:end]
+		lower := strings.ToLower(word)
+		if slices.ContainsFunc(terms, func(term string) bool { return strings.HasPrefix(lower, term) }) {
+			b.WriteString(domain.HighlightStart + html.EscapeString(word) + domain.HighlightStop)
+			hits++
+		} else {
+			b.WriteString(html.EscapeString(word))
+		}
+		text = text[end:]
+	}
+	return b.String(), hits
+}
+
+func (r *EventRepository) GetBooking(ctx context.Context, bookingID string) (*domain.Booking, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	booking, ok := r.bookings[bookingID]
+	if !ok {
+		return nil, fmt.Errorf("error get booking: %w", domain.ErrBookingNotFound)
+	}
+	copied := *booking
+	copied.LineItems = slices.Clone(booking.LineItems)
+	return &copied, nil
+}
+
+func (r *EventRepository) ListBookings(ctx context.Context, filter domain.BookingFilter) (*domain.BookingPage, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var bookings []*domain.Booking
+	for _, booking := range r.bookings {
+		if filter.Matches(booking) && (filter.After == nil || filter.After.Before(booking)) {
+			bookings = append(bookings, booking)
+		}
+	}
+
+	sort.Slice(bookings, func(i, j int) bool {
+		return (&domain.BookingCursor{Date: bookings[i].Date, Id: bookings[i].Id}).Before(bookings[j])
+	})
+	if len(bookings) > filter.Limit+1 {
+		bookings = bookings[:filter.Limit+1]
+	}
+
+	details := make([]*domain.BookingDetails, 0, len(bookings))
+	for _, booking := range bookings {
+		copied := *booking
+		// Как в БД, состав суммы загружается только по запросу
+		copied.LineItems = nil
+		if filter.LineItems {
+			copied.LineItems = slices.Clone(booking.LineItems)
+		}
+		details = append(details, &domain.BookingDetails{
+			Booking: &copied,
+			Event:   r.withCounts(r.events[booking.EventId]),
+		})
+	}
+	return domain.NewBookingPage(details, filter), nil
+}
+
+func (r *EventRepository) IncrementAvailableTickets(ctx context.Context, eventID string) error {
+	if err := r.addTicket(ctx, eventID); err != nil {
+		return fmt.Errorf("failed to increment tickets: %w", err)
+	}
+	return nil
+}
+
+func (r *EventRepository) AddAvailableTickets(ctx context.Context, eventID string) error {
+	if err := r.addTicket(ctx, eventID); err != nil {
+		return fmt.Errorf("failed to update tickets: %w", err)
+	}
+	return nil
+}
+
+func (r *EventRepository) addTicket(ctx context.Context, eventID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	event, ok := r.events[eventID]
+	if !ok {
+		return domain.ErrEventNotFound
+	}
+	if event.AvailableTickets >= event.Capacity {
+		return errTicketsOutOfRange
+	}
+	event.AvailableTickets++
+	event.Version++
+	r.auditLog = append(r.auditLog, port.NewTicketsAuditEntry(ctx, eventID, int(event.AvailableTickets)-1, int(event.AvailableTickets)))
+	return nil
+}
+
+// withCounts копирует событие и считает его оплаченные и ожидающие оплаты брони; вызывается под r.mu
+func (r *EventRepository) withCounts(event *domain.Event) *domain.Event {
+	copied := *event
+	for _, booking := range r.bookings {
+		if booking.EventId != event.Id {
+			continue
+		}
+		switch booking.Status {
+		case domain.ConfirmedStatus:
+			copied.Sold++
+		case domain.PendingStatus:
+			copied.Held++
+		}
+	}
+	return &copied
+}
+
+func (r *EventRepository) FindInventoryDiscrepancies(ctx context.Context) ([]*domain.InventoryDiscrepancy, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var discrepancies []*domain.InventoryDiscrepancy
+	for eventID := range r.events {
+		if d := r.inventory(eventID); d.Drift() != 0 {
+			discrepancies = append(discrepancies, d)
+		}
+	}
+
+	sort.Slice(discrepancies, func(i, j int) bool {
+		return discrepancies[i].EventId < discrepancies[j].EventId
+	})
+	return discrepancies, nil
+}
+
+func (r *EventRepository) RepairInventory(ctx context.Context, eventID string, reason string) (*domain.InventoryAdjustment, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	event, ok := r.events[eventID]
+	if !ok {
+		return nil, fmt.Errorf("error repair inventory: %w", domain.ErrEventNotFound)
+	}
+
+	d := r.inventory(eventID)
+	if d.Drift() == 0 {
+		return nil, nil
+	}
+
+	adjustment := &domain.InventoryAdjustment{
+		Id:             uuid.New().String(),
+		EventId:        eventID,
+		OldAvailable:   d.AvailableTickets,
+		NewAvailable:   d.ExpectedAvailable,
+		ActiveBookings: d.ActiveBookings,
+		Reason:         reason,
+		CreatedAt:      time.Now().UTC(),
+	}
+	event.AvailableTickets = uint32(d.ExpectedAvailable)
+	event.Version++
+	r.adjustments = append(r.adjustments, adjustment)
+	r.auditLog = append(r.auditLog, port.NewTicketsAuditEntry(port.WithAuditReason(ctx, reason), eventID, d.AvailableTickets, d.ExpectedAvailable))
+
+	copied := *adjustment
+	return &copied, nil
+}
+
+// ListUnverifiedCapacities ничего не возвращает: события в памяти создаются с вместимостью,
+// миграции 002 они не проходили
+func (r *EventRepository) ListUnverifiedCapacities(ctx context.Context) ([]*domain.UnverifiedCapacity, error) {
+	return nil, nil
+}
+
+func (r *EventRepository) VerifyCapacity(ctx context.Context, eventID string) error {
+	return fmt.Errorf("error verify capacity: %w", domain.ErrUnverifiedCapacityNotFound)
+}
+
+// inventory считает активные брони события; вызывается под r.mu
+func (r *EventRepository) inventory(eventID string) *domain.InventoryDiscrepancy {
+	d := &domain.InventoryDiscrepancy{
+		EventId:          eventID,
+		Capacity:         int(r.events[eventID].Capacity),
+		AvailableTickets: int(r.events[eventID].AvailableTickets),
+	}
+	for _, booking := range r.bookings {
+		if booking.EventId == eventID && (booking.Status == domain.PendingStatus || booking.Status == domain.ConfirmedStatus) {
+			d.ActiveBookings++
+		}
+	}
+	d.ExpectedAvailable = domain.ExpectedAvailable(d.Capacity, d.ActiveBookings)
+	return d
+}
+
+func (r *EventRepository) ListAuditLog(ctx context.Context, filter domain.AuditFilter) ([]*domain.AuditEntry, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var entries []*domain.AuditEntry
+	for _, entry := range r.auditLog {
+		if !matchesAuditFilter(entry, filter) {
+			continue
+		}
+		copied := *entry
+		entries = append(entries, &copied)
+		if filter.Limit > 0 && len(entries) == filter.Limit {
+			break
+		}
+	}
+	return entries, nil
+}
+
+func matchesAuditFilter(entry *domain.AuditEntry, filter domain.AuditFilter) bool {
+	switch {
+	case filter.BookingId != "":
+		return entry.EntityType == domain.AuditEntityBooking && entry.EntityId == filter.BookingId
+	case filter.EventId != "":
+		return entry.EventId == filter.EventId
+	case filter.UserId != "":
+		return entry.UserId == filter.UserId
+	}
+	return true
+}
+
+func (r *EventRepository) ReserveIdempotencyKey(ctx context.Context, record *domain.IdempotencyRecord) (*domain.IdempotencyRecord, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if existing, ok := r.idempotency[record.Key]; ok {
+		copied := *existing
+		return &copied, nil
+	}
+	copied := *record
+	r.idempotency[record.Key] = &copied
+	return nil, nil
+}
+
+func (r *EventRepository) CompleteIdempotencyKey(ctx context.Context, record *domain.IdempotencyRecord) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if existing, ok := r.idempotency[record.Key]; ok {
+		existing.StatusCode = record.StatusCode
+		existing.ContentType = record.ContentType
+		existing.Body = slices.Clone(record.Body)
+	}
+	return nil
+}
+
+func (r *EventRepository) ReleaseIdempotencyKey(ctx context.Context, key string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.idempotency, key)
+	return nil
+}
+
+func (r *EventRepository) ReleaseExpiredIdempotencyKey(ctx context.Context, key string, before time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if record, ok := r.idempotency[key]; ok && record.CreatedAt.Before(before) {
+		delete(r.idempotency, key)
+	}
+	return nil
+}
+
+func (r *EventRepository) PurgeIdempotencyKeys(ctx context.Context, before time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var purged int64
+	for key, record := range r.idempotency {
+		if record.CreatedAt.Before(before) {
+			delete(r.idempotency, key)
+			purged++
+		}
+	}
+	return purged, nil
+}
+
+func (r *EventRepository) SaveWaitingRoom(ctx context.Context, room *domain.WaitingRoom) (*domain.WaitingRoom, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	saved, ok := r.rooms[room.EventId]
+	if !ok {
+		saved = &domain.WaitingRoom{EventId: room.EventId, LastAdmitAt: room.OpenedAt, OpenedAt: room.OpenedAt}
+		r.rooms[room.EventId] = saved
+	}
+	saved.RatePerMinute = room.RatePerMinute
+
+	copied := *saved
+	return &copied, nil
+}
+
+func (r *EventRepository) GetWaitingRoom(ctx context.Context, eventID string) (*domain.WaitingRoom, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	room, ok := r.rooms[eventID]
+	if !ok {
+		return nil, fmt.Errorf("error get waiting room: %w", domain.ErrWaitingRoomNotFound)
+	}
+	copied := *room
+	return &copied, nil
+}
+
+func (r *EventRepository) JoinWaitingRoom(ctx context.Context, eventID, userID string, now time.Time) (*domain.QueueTicket, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	room, ok := r.rooms[eventID]
+	if !ok {
+		return nil, fmt.Errorf("error join waiting room: %w", domain.ErrWaitingRoomNotFound)
+	}
+	if ticket, ok := r.tickets[eventID][userID]; ok && ticket.Active(now) {
+		copied := *ticket
+		return &copied, nil
+	}
+
+	ticket := room.Enqueue(userID, now)
+	if r.tickets[eventID] == nil {
+		r.tickets[eventID] = make(map[string]*domain.QueueTicket)
+	}
+	r.tickets[eventID][userID] = &ticket
+	copied := ticket
+	return &copied, nil
+}
+
+func (r *EventRepository) DeleteWaitingRoom(ctx context.Context, eventID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.rooms[eventID]; !ok {
+		return fmt.Errorf("error delete waiting room: %w", domain.ErrWaitingRoomNotFound)
+	}
+	delete(r.rooms, eventID)
+	delete(r.tickets, eventID)
+	return nil
+}
+
+func (r *EventRepository) SaveBallot(ctx context.Context, ballot *domain.Ballot) (*domain.Ballot, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.events[ballot.EventId]; !ok {
+		return nil, fmt.Errorf("error save ballot: %w", domain.ErrEventNotFound)
+	}
+	saved, ok := r.ballots[ballot.EventId]
+	if ok && saved.Drawn() {
+		return nil, fmt.Errorf("error save ballot: %w", domain.ErrBallotAlreadyDrawn)
+	}
+	if !ok {
+		saved = &domain.Ballot{EventId: ballot.EventId}
+		r.ballots[ballot.EventId] = saved
+	}
+	saved.EntryOpensAt = ballot.EntryOpensAt
+	saved.EntryClosesAt = ballot.EntryClosesAt
+	saved.Waitlist = ballot.Waitlist
+	return r.ballotCopy(saved), nil
+}
+
+func (r *EventRepository) GetBallot(ctx context.Context, eventID string) (*domain.Ballot, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	ballot, ok := r.ballots[eventID]
+	if !ok {
+		return nil, fmt.Errorf("error get ballot: %w", domain.ErrBallotNotFound)
+	}
+	return r.ballotCopy(ballot), nil
+}
+
+func (r *EventRepository) ListActiveBallots(ctx context.Context, now time.Time) ([]*domain.Ballot, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var ballots []*domain.Ballot
+	for _, ballot := range r.ballots {
+		if ballot.Due(now) || (ballot.Drawn() && len(r.ballotEntries(ballot.EventId, domain.BallotWaitlisted)) > 0) {
+			ballots = append(ballots, r.ballotCopy(ballot))
+		}
+	}
+	slices.SortFunc(ballots, func(a, b *domain.Ballot) int {
+		return cmp.Or(a.EntryClosesAt.Compare(b.EntryClosesAt), strings.Compare(a.EventId, b.EventId))
+	})
+	return ballots, nil
+}
+
+func (r *EventRepository) EnterBallot(ctx context.Context, entry *domain.BallotEntry) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.entries[entry.EventId][entry.UserId]; ok {
+		return fmt.Errorf("error enter ballot: %w", domain.ErrAlreadyEntered)
+	}
+	ballot, ok := r.ballots[entry.EventId]
+	if !ok || ballot.Drawn() {
+		return fmt.Errorf("error enter ballot: %w", domain.ErrBallotEntryClosed)
+	}
+	if r.entries[entry.EventId] == nil {
+		r.entries[entry.EventId] = make(map[string]*domain.BallotEntry)
+	}
+	copied := *entry
+	r.entries[entry.EventId][entry.UserId] = &copied
+	return nil
+}
+
+func (r *EventRepository) GetBallotEntry(ctx context.Context, eventID, userID string) (*domain.BallotEntry, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	entry, ok := r.entries[eventID][userID]
+	if !ok {
+		return nil, fmt.Errorf("error get ballot entry: %w", domain.ErrBallotEntryNotFound)
+	}
+	copied := *entry
+	return &copied, nil
+}
+
+func (r *EventRepository) ListBallotEntries(ctx context.Context, eventID, status string) ([]*domain.BallotEntry, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.ballotEntries(eventID, status), nil
+}
+
+func (r *EventRepository) DrawBallot(ctx context.Context, eventID string, seed int64, drawnAt time.Time) (*domain.Ballot, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ballot, ok := r.ballots[eventID]
+	if !ok {
+		return nil, fmt.Errorf("error draw ballot: %w", domain.ErrBallotNotFound)
+	}
+	if ballot.Drawn() {
+		return nil, fmt.Errorf("error draw ballot: %w", domain.ErrBallotAlreadyDrawn)
+	}
+	ballot.Seed = seed
+	ballot.DrawnAt = &drawnAt
+
+	var userIDs []string
+	for userID, entry := range r.entries[eventID] {
+		if entry.Status == domain.BallotEntered {
+			userIDs = append(userIDs, userID)
+		}
+	}
+	for i, userID := range domain.DrawOrder(userIDs, seed) {
+		entry := r.entries[eventID][userID]
+		entry.Status = domain.BallotWaitlisted
+		entry.Rank = i + 1
+	}
+	return r.ballotCopy(ballot), nil
+}
+
+func (r *EventRepository) AllocateBallotTicket(ctx context.Context, booking *domain.Booking) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	waitlisted := r.ballotEntries(booking.EventId, domain.BallotWaitlisted)
+	if len(waitlisted) == 0 {
+		return fmt.Errorf("error allocate ballot ticket: %w", domain.ErrBallotWaitlistEmpty)
+	}
+	event := r.events[booking.EventId]
+	if event.SaleMode != domain.SaleModeBallot {
+		return fmt.Errorf("error allocate ballot ticket: %w", domain.ErrNotBallotEvent)
+	}
+	if event.AvailableTickets == 0 {
+		return fmt.Errorf("error allocate ballot ticket: %w", domain.ErrNoTicketsAvailable)
+	}
+
+	entry := r.entries[booking.EventId][waitlisted[0].UserId]
+	booking.UserId = entry.UserId
+	face := r.ticketPrice(event, booking.Date)
+	if err := booking.Charge(face, domain.Money{Currency: face.Currency}, r.feeRule(event)); err != nil {
+		return fmt.Errorf("error allocate ballot ticket: %w", err)
+	}
+	event.AvailableTickets--
+	event.Version++
+	copied := *booking
+	copied.Version = 1
+	copied.LineItems = slices.Clone(booking.LineItems)
+	r.bookings[booking.Id] = &copied
+	entry.Status = domain.BallotWon
+	entry.BookingId = booking.Id
+
+	r.auditLog = append(r.auditLog,
+		port.NewBookingAuditEntry(ctx, &copied, domain.AuditBookingCreated, ""),
+		port.NewTicketsAuditEntry(ctx, event.Id, int(event.AvailableTickets)+1, int(event.AvailableTickets)),
+	)
+	return nil
+}
+
+func (r *EventRepository) CloseBallotWaitlist(ctx context.Context, eventID string) ([]*domain.BallotEntry, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	closed := r.ballotEntries(eventID, domain.BallotWaitlisted)
+	for _, entry := range closed {
+		r.entries[eventID][entry.UserId].Status = domain.BallotLost
+		entry.Status = domain.BallotLost
+	}
+	return closed, nil
+}
+
+// ballotCopy возвращает копию розыгрыша с числом заявок. Вызывается под r.mu.
+func (r *EventRepository) ballotCopy(ballot *domain.Ballot) *domain.Ballot {
+	copied := *ballot
+	copied.Entries = len(r.entries[ballot.EventId])
+	return &copied
+}
+
+// ballotEntries возвращает копии заявок в порядке розыгрыша; пустой status - все заявки.
+// Вызывается под r.mu.
+func (r *EventRepository) ballotEntries(eventID, status string) []*domain.BallotEntry {
+	var entries []*domain.BallotEntry
+	for _, entry := range r.entries[eventID] {
+		if status == "" || entry.Status == status {
+			copied := *entry
+			entries = append(entries, &copied)
+		}
+	}
+	slices.SortFunc(entries, func(a, b *domain.BallotEntry) int {
+		return cmp.Or(cmp.Compare(a.Rank, b.Rank), a.EnteredAt.Compare(b.EnteredAt), strings.Compare(a.UserId, b.UserId))
+	})
+	return entries
+}
+
+func (r *EventRepository) CreatePromoCode(ctx context.Context, promo *domain.PromoCode) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.promos[promo.Code]; ok {
+		return fmt.Errorf("error create promo code: %w", domain.ErrPromoCodeExists)
+	}
+	if promo.EventId != "" {
+		if _, ok := r.events[promo.EventId]; !ok {
+			return fmt.Errorf("error create promo code: %w", domain.ErrEventNotFound)
+		}
+	}
+	copied := *promo
+	copied.Uses, copied.TotalDiscount = 0, nil
+	r.promos[promo.Code] = &copied
+	return nil
+}
+
+func (r *EventRepository) GetPromoCode(ctx context.Context, code string) (*domain.PromoCode, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	promo, ok := r.promos[code]
+	if !ok {
+		return nil, fmt.Errorf("error get promo code: %w", domain.ErrPromoCodeNotFound)
+	}
+	return r.promoCopy(promo), nil
+}
+
+func (r *EventRepository) ListPromoCodes(ctx context.Context) ([]*domain.PromoCode, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	promos := make([]*domain.PromoCode, 0, len(r.promos))
+	for _, promo := range r.promos {
+		promos = append(promos, r.promoCopy(promo))
+	}
+	slices.SortFunc(promos, func(a, b *domain.PromoCode) int {
+		return cmp.Or(a.CreatedAt.Compare(b.CreatedAt), cmp.Compare(a.Code, b.Code))
+	})
+	return promos, nil
+}
+
+func (r *EventRepository) ListPromoRedemptions(ctx context.Context, code string) ([]*domain.PromoRedemption, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var redemptions []*domain.PromoRedemption
+	for _, redemption := range r.redemptions {
+		if redemption.Code == code {
+			copied := *redemption
+			copied.BookingStatus = r.bookings[redemption.BookingId].Status
+			redemptions = append(redemptions, &copied)
+		}
+	}
+	slices.SortFunc(redemptions, func(a, b *domain.PromoRedemption) int {
+		return cmp.Or(a.RedeemedAt.Compare(b.RedeemedAt), cmp.Compare(a.BookingId, b.BookingId))
+	})
+	return redemptions, nil
+}
+
+// promoDiscount проверяет промокод брони и возвращает скидку с booking.Price. Вызывается под r.mu.
+func (r *EventRepository) promoDiscount(booking *domain.Booking) (domain.Money, error) {
+	promo, ok := r.promos[booking.PromoCode]
+	if !ok {
+		return domain.Money{}, domain.ErrPromoCodeNotFound
+	}
+	var used bool
+	for _, redemption := range r.redemptions {
+		if redemption.Code == promo.Code && redemption.UserId == booking.UserId &&
+			r.bookings[redemption.BookingId].Status != domain.CancelledStatus {
+			used = true
+			break
+		}
+	}
+	return r.promoCopy(promo).Redeem(booking.EventId, booking.Price, booking.Date, used)
+}
+
+// promoCopy возвращает копию промокода с использованиями неотмененными бронями. Вызывается под r.mu.
+func (r *EventRepository) promoCopy(promo *domain.PromoCode) *domain.PromoCode {
+	copied := *promo
+	copied.TotalDiscount = nil
+	for _, redemption := range r.redemptions {
+		if redemption.Code == promo.Code && r.bookings[redemption.BookingId].Status != domain.CancelledStatus {
+			copied.Uses++
+			i := slices.IndexFunc(copied.TotalDiscount, func(total domain.Money) bool {
+				return total.Currency == redemption.Discount.Currency
+			})
+			if i < 0 {
+				copied.TotalDiscount = append(copied.TotalDiscount, domain.Money{Currency: redemption.Discount.Currency})
+				i = len(copied.TotalDiscount) - 1
+			}
+			copied.TotalDiscount[i].Amount += redemption.Discount.Amount
+		}
+	}
+	slices.SortFunc(copied.TotalDiscount, func(a, b domain.Money) int {
+		return cmp.Compare(a.Currency, b.Currency)
+	})
+	return &copied
+}
+
+func (r *EventRepository) SetPricePhases(ctx context.Context, eventID string, phases []domain.PricePhase) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	event, ok := r.events[eventID]
+	if !ok {
+		return fmt.Errorf("error set price phases: %w", domain.ErrEventNotFound)
+	}
+	r.auditLog = append(r.auditLog, port.NewPricePhasesAuditEntry(ctx, eventID, r.phases[eventID], phases))
+	if len(phases) == 0 {
+		delete(r.phases, eventID)
+	} else {
+		r.phases[eventID] = slices.Clone(phases)
+	}
+	event.Version++
+	return nil
+}
+
+// ticketPrice - цена билета, который сейчас спишет бронь, по расписанию мероприятия.
+// Вызывается под r.mu до уменьшения AvailableTickets.
+func (r *EventRepository) ticketPrice(event *domain.Event, now time.Time) domain.Money {
+	priced := *event
+	priced.PricePhases = r.phases[event.Id]
+	return priced.TicketPrice(now)
+}
+
+func (r *EventRepository) SaveFeeRule(ctx context.Context, rule *domain.FeeRule) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	copied := *rule
+	if rule.VatPercent != nil {
+		vat := *rule.VatPercent
+		copied.VatPercent = &vat
+	}
+	r.fees[feeRuleKey(rule.Scope, rule.ScopeId)] = &copied
+	return nil
+}
+
+func (r *EventRepository) DeleteFeeRule(ctx context.Context, scope, scopeID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := feeRuleKey(scope, scopeID)
+	if _, ok := r.fees[key]; !ok {
+		return fmt.Errorf("error delete fee rule: %w", domain.ErrFeeRuleNotFound)
+	}
+	delete(r.fees, key)
+	return nil
+}
+
+// ListFeeRules возвращает правила в порядке области и ее id, как БД
+func (r *EventRepository) ListFeeRules(ctx context.Context) ([]*domain.FeeRule, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	rules := make([]*domain.FeeRule, 0, len(r.fees))
+	for _, rule := range r.fees {
+		copied := *rule
+		rules = append(rules, &copied)
+	}
+	slices.SortFunc(rules, func(a, b *domain.FeeRule) int {
+		return cmp.Or(cmp.Compare(a.Scope, b.Scope), cmp.Compare(a.ScopeId, b.ScopeId))
+	})
+	return rules, nil
+}
+
+// feeRule - правило сбора для брони мероприятия. Вызывается под r.mu.
+func (r *EventRepository) feeRule(event *domain.Event) *domain.FeeRule {
+	rules := make([]*domain.FeeRule, 0, len(r.fees))
+	for _, rule := range r.fees {
+		rules = append(rules, rule)
+	}
+	return domain.MatchFeeRule(event, rules)
+}
+
+func feeRuleKey(scope, scopeID string) string {
+	return scope + "/" + scopeID
+}
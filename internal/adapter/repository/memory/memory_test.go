@@ -0,0 +1,14 @@
+package memory
+
+import (
+	"testing"
+
+	"github.com/dontpanicw/EventBooker/internal/adapter/repository/repositorytest"
+	"github.com/dontpanicw/EventBooker/internal/port"
+)
+
+func TestEventRepository_Conformance(t *testing.T) {
+	repositorytest.Run(t, func(t *testing.T) port.Repository {
+		return NewEventRepository()
+	})
+}
@@ -0,0 +1,108 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"github.com/dontpanicw/EventBooker/internal/domain"
+)
+
+const (
+	insertAuditQuery = `INSERT INTO audit_log (id, entity_type, entity_id, event_id, user_id, action, actor_type, actor_id, old_value, new_value, reason, created_at)
+						VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12);`
+	selectAuditQuery = `SELECT id, entity_type, entity_id, event_id, COALESCE(user_id, ''), action, actor_type, COALESCE(actor_id, ''),
+							old_value, new_value, COALESCE(reason, ''), created_at
+						FROM audit_log`
+	auditByBookingQuery = selectAuditQuery + ` WHERE entity_type = 'booking' AND entity_id = $1 ORDER BY created_at, seq LIMIT $2;`
+	auditByEventQuery   = selectAuditQuery + ` WHERE event_id = $1 ORDER BY created_at, seq LIMIT $2;`
+	auditByUserQuery    = selectAuditQuery + ` WHERE user_id = $1 ORDER BY created_at, seq LIMIT $2;`
+)
+
+// insertAudit пишет записи журнала в транзакции изменения
+func insertAudit(ctx context.Context, tx *sql.Tx, entries ...*domain.AuditEntry) error {
+	for _, entry := range entries {
+		_, err := tx.ExecContext(ctx, insertAuditQuery,
+			entry.Id,
+			entry.EntityType,
+			entry.EntityId,
+			entry.EventId,
+			nullString(entry.UserId),
+			entry.Action,
+			entry.ActorType,
+			nullString(entry.ActorId),
+			nullJSON(entry.OldValue),
+			nullJSON(entry.NewValue),
+			nullString(entry.Reason),
+			entry.CreatedAt,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to write audit log: %w", err)
+		}
+	}
+	return nil
+}
+
+func (e *EventRepository) ListAuditLog(ctx context.Context, filter domain.AuditFilter) ([]*domain.AuditEntry, error) {
+	query, arg := auditByEventQuery, filter.EventId
+	switch {
+	case filter.BookingId != "":
+		query, arg = auditByBookingQuery, filter.BookingId
+	case filter.UserId != "":
+		query, arg = auditByUserQuery, filter.UserId
+	}
+	// LIMIT NULL - без ограничения
+	var limit sql.NullInt64
+	if filter.Limit > 0 {
+		limit = sql.NullInt64{Int64: int64(filter.Limit), Valid: true}
+	}
+
+	// Журнал читается из master: история сразу после изменения должна его содержать
+	rows, err := e.PostgresDB.Master.QueryContext(ctx, query, arg, limit)
+	if err != nil {
+		return nil, fmt.Errorf("error querying audit log: %w", err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			log.Printf("error closing rows: %v", err)
+		}
+	}()
+
+	var entries []*domain.AuditEntry
+	for rows.Next() {
+		var entry domain.AuditEntry
+		var oldValue, newValue []byte
+		if err := rows.Scan(&entry.Id, &entry.EntityType, &entry.EntityId, &entry.EventId, &entry.UserId,
+			&entry.Action, &entry.ActorType, &entry.ActorId, &oldValue, &newValue, &entry.Reason, &entry.CreatedAt); err != nil {
+			return nil, fmt.Errorf("error scanning audit log: %w", err)
+		}
+		entry.OldValue, entry.NewValue = rawJSON(oldValue), rawJSON(newValue)
+		entries = append(entries, &entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating audit log: %w", err)
+	}
+	return entries, nil
+}
+
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+// nullJSON передает JSON строкой: lib/pq отправляет []byte как bytea, а не jsonb
+func nullJSON(v json.RawMessage) any {
+	if v == nil {
+		return nil
+	}
+	return string(v)
+}
+
+func rawJSON(b []byte) json.RawMessage {
+	if b == nil {
+		return nil
+	}
+	return json.RawMessage(b)
+}
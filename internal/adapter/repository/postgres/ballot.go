@@ -0,0 +1,310 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/dontpanicw/EventBooker/internal/domain"
+	"github.com/dontpanicw/EventBooker/internal/port"
+)
+
+const (
+	// saveBallotQuery не меняет проведенный розыгрыш: строка не вернется
+	saveBallotQuery = `INSERT INTO ballots (event_id, entry_opens_at, entry_closes_at, waitlist)
+						VALUES ($1, $2, $3, $4)
+						ON CONFLICT (event_id) DO UPDATE SET entry_opens_at = EXCLUDED.entry_opens_at,
+							entry_closes_at = EXCLUDED.entry_closes_at, waitlist = EXCLUDED.waitlist
+						WHERE ballots.drawn_at IS NULL
+						RETURNING event_id;`
+	selectBallotsQuery = `SELECT b.event_id, b.entry_opens_at, b.entry_closes_at, b.waitlist, b.seed, b.drawn_at,
+							(SELECT COUNT(*) FROM ballot_entries e WHERE e.event_id = b.event_id)
+						FROM ballots b`
+	getBallotQuery = selectBallotsQuery + ` WHERE b.event_id = $1;`
+	// listActiveBallotsQuery - розыгрыши, которые пора провести, и проведенные с листом ожидания
+	listActiveBallotsQuery = selectBallotsQuery + `
+						WHERE (b.drawn_at IS NULL AND b.entry_closes_at <= $1)
+							OR (b.drawn_at IS NOT NULL AND EXISTS (
+								SELECT 1 FROM ballot_entries e WHERE e.event_id = b.event_id AND e.status = $2))
+						ORDER BY b.entry_closes_at, b.event_id;`
+	// enterBallotQuery блокирует строку розыгрыша на чтение: заявка, поданная во время
+	// розыгрыша, дождется его и не будет вставлена
+	enterBallotQuery = `INSERT INTO ballot_entries (event_id, user_id, status, entered_at)
+						SELECT event_id, $2::varchar, $3::varchar, $4::timestamp
+						FROM ballots WHERE event_id = $1 AND drawn_at IS NULL FOR SHARE
+						ON CONFLICT (event_id, user_id) DO NOTHING;`
+	selectBallotEntriesQuery = `SELECT event_id, user_id, status, rank, COALESCE(booking_id, ''), entered_at
+							FROM ballot_entries`
+	getBallotEntryQuery     = selectBallotEntriesQuery + ` WHERE event_id = $1 AND user_id = $2;`
+	ballotEntryExistsQuery  = `SELECT EXISTS (SELECT 1 FROM ballot_entries WHERE event_id = $1 AND user_id = $2);`
+	ballotExistsQuery       = `SELECT EXISTS (SELECT 1 FROM ballots WHERE event_id = $1);`
+	listBallotEntriesQuery  = selectBallotEntriesQuery + ` WHERE event_id = $1 AND ($2 = '' OR status = $2) ORDER BY rank, entered_at, user_id;`
+	markBallotDrawnQuery    = `UPDATE ballots SET seed = $2, drawn_at = $3 WHERE event_id = $1 AND drawn_at IS NULL;`
+	listBallotEntrantsQuery = `SELECT user_id FROM ballot_entries WHERE event_id = $1 AND status = $2;`
+	rankBallotEntryQuery    = `UPDATE ballot_entries SET status = $3, rank = $4 WHERE event_id = $1 AND user_id = $2;`
+	// nextWaitlistedQuery пропускает заявки, которые сейчас получают место в другой транзакции
+	nextWaitlistedQuery = `SELECT user_id FROM ballot_entries WHERE event_id = $1 AND status = $2
+							ORDER BY rank LIMIT 1 FOR UPDATE SKIP LOCKED;`
+	winBallotEntryQuery = `UPDATE ballot_entries SET status = $3, booking_id = $4 WHERE event_id = $1 AND user_id = $2;`
+	closeWaitlistQuery  = `UPDATE ballot_entries SET status = $2 WHERE event_id = $1 AND status = $3
+							RETURNING event_id, user_id, status, rank, COALESCE(booking_id, ''), entered_at;`
+)
+
+func (e *EventRepository) SaveBallot(ctx context.Context, ballot *domain.Ballot) (*domain.Ballot, error) {
+	var eventID string
+	err := e.PostgresDB.Master.QueryRowContext(ctx, saveBallotQuery,
+		ballot.EventId, ballot.EntryOpensAt.UTC(), ballot.EntryClosesAt.UTC(), ballot.Waitlist).Scan(&eventID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("error save ballot: %w", domain.ErrBallotAlreadyDrawn)
+		}
+		return nil, fmt.Errorf("error save ballot: %w", err)
+	}
+	return e.GetBallot(port.WithStrongConsistency(ctx), eventID)
+}
+
+func (e *EventRepository) GetBallot(ctx context.Context, eventID string) (*domain.Ballot, error) {
+	ballot, err := scanBallot(e.reader(ctx).QueryRowContext(ctx, getBallotQuery, eventID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("error get ballot: %w", domain.ErrBallotNotFound)
+		}
+		return nil, fmt.Errorf("error get ballot: %w", err)
+	}
+	return ballot, nil
+}
+
+// ListActiveBallots читает из master: по результату проводятся розыгрыши и раздаются места
+func (e *EventRepository) ListActiveBallots(ctx context.Context, now time.Time) ([]*domain.Ballot, error) {
+	rows, err := e.PostgresDB.Master.QueryContext(ctx, listActiveBallotsQuery, now.UTC(), domain.BallotWaitlisted)
+	if err != nil {
+		return nil, fmt.Errorf("error list active ballots: %w", err)
+	}
+	defer rows.Close()
+
+	var ballots []*domain.Ballot
+	for rows.Next() {
+		ballot, err := scanBallot(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error list active ballots: %w", err)
+		}
+		ballots = append(ballots, ballot)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error list active ballots: %w", err)
+	}
+	return ballots, nil
+}
+
+func (e *EventRepository) EnterBallot(ctx context.Context, entry *domain.BallotEntry) error {
+	result, err := e.PostgresDB.Master.ExecContext(ctx, enterBallotQuery,
+		entry.EventId, entry.UserId, entry.Status, entry.EnteredAt.UTC())
+	if err != nil {
+		return fmt.Errorf("error enter ballot: %w", err)
+	}
+	if inserted, err := result.RowsAffected(); err != nil {
+		return fmt.Errorf("error enter ballot: %w", err)
+	} else if inserted > 0 {
+		return nil
+	}
+
+	// Ничего не вставлено: повторная заявка или прием закрыт
+	var exists bool
+	if err := e.PostgresDB.Master.QueryRowContext(ctx, ballotEntryExistsQuery, entry.EventId, entry.UserId).Scan(&exists); err != nil {
+		return fmt.Errorf("error enter ballot: %w", err)
+	}
+	if exists {
+		return fmt.Errorf("error enter ballot: %w", domain.ErrAlreadyEntered)
+	}
+	return fmt.Errorf("error enter ballot: %w", domain.ErrBallotEntryClosed)
+}
+
+// GetBallotEntry читает из master: статус заявки меняется розыгрышем, и участник
+// должен сразу увидеть выделенное место
+func (e *EventRepository) GetBallotEntry(ctx context.Context, eventID, userID string) (*domain.BallotEntry, error) {
+	entry, err := scanBallotEntry(e.PostgresDB.Master.QueryRowContext(ctx, getBallotEntryQuery, eventID, userID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("error get ballot entry: %w", domain.ErrBallotEntryNotFound)
+		}
+		return nil, fmt.Errorf("error get ballot entry: %w", err)
+	}
+	return entry, nil
+}
+
+func (e *EventRepository) ListBallotEntries(ctx context.Context, eventID, status string) ([]*domain.BallotEntry, error) {
+	rows, err := e.reader(ctx).QueryContext(ctx, listBallotEntriesQuery, eventID, status)
+	if err != nil {
+		return nil, fmt.Errorf("error list ballot entries: %w", err)
+	}
+	entries, err := scanBallotEntries(rows)
+	if err != nil {
+		return nil, fmt.Errorf("error list ballot entries: %w", err)
+	}
+	return entries, nil
+}
+
+// DrawBallot сначала отмечает розыгрыш проведенным: строка блокируется, и новые заявки
+// ждут конца транзакции, после чего отклоняются
+func (e *EventRepository) DrawBallot(ctx context.Context, eventID string, seed int64, drawnAt time.Time) (*domain.Ballot, error) {
+	var ballot *domain.Ballot
+	err := e.PostgresDB.WithTx(ctx, func(tx *sql.Tx) error {
+		result, err := tx.ExecContext(ctx, markBallotDrawnQuery, eventID, seed, drawnAt.UTC())
+		if err != nil {
+			return err
+		}
+		if marked, err := result.RowsAffected(); err != nil {
+			return err
+		} else if marked == 0 {
+			var exists bool
+			if err := tx.QueryRowContext(ctx, ballotExistsQuery, eventID).Scan(&exists); err != nil {
+				return err
+			}
+			if !exists {
+				return domain.ErrBallotNotFound
+			}
+			return domain.ErrBallotAlreadyDrawn
+		}
+
+		rows, err := tx.QueryContext(ctx, listBallotEntrantsQuery, eventID, domain.BallotEntered)
+		if err != nil {
+			return err
+		}
+		var userIDs []string
+		for rows.Next() {
+			var userID string
+			if err := rows.Scan(&userID); err != nil {
+				rows.Close()
+				return err
+			}
+			userIDs = append(userIDs, userID)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		for i, userID := range domain.DrawOrder(userIDs, seed) {
+			if _, err := tx.ExecContext(ctx, rankBallotEntryQuery, eventID, userID, domain.BallotWaitlisted, i+1); err != nil {
+				return err
+			}
+		}
+
+		ballot, err = scanBallot(tx.QueryRowContext(ctx, getBallotQuery, eventID))
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error draw ballot: %w", err)
+	}
+	return ballot, nil
+}
+
+func (e *EventRepository) AllocateBallotTicket(ctx context.Context, booking *domain.Booking) error {
+	err := e.PostgresDB.WithTx(ctx, func(tx *sql.Tx) error {
+		err := tx.QueryRowContext(ctx, nextWaitlistedQuery, booking.EventId, domain.BallotWaitlisted).Scan(&booking.UserId)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return domain.ErrBallotWaitlistEmpty
+			}
+			return err
+		}
+
+		event, err := takeTicket(ctx, tx, booking.EventId, domain.SaleModeBallot)
+		if err != nil {
+			return err
+		}
+		newAvailableTickets := int(event.AvailableTickets)
+		face, err := ticketPrice(ctx, tx, event, booking.Date)
+		if err != nil {
+			return err
+		}
+		if err := chargeBooking(ctx, tx, event, booking, face, domain.Money{Currency: face.Currency}); err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, bookEventQuery,
+			booking.Id,
+			booking.UserId,
+			booking.EventId,
+			booking.Status,
+			booking.Date,
+			booking.Price.Amount,
+			booking.Price.Currency,
+			booking.PromoCode,
+		)
+		if err != nil {
+			return err
+		}
+		if err := insertLineItems(ctx, tx, booking); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, winBallotEntryQuery, booking.EventId, booking.UserId, domain.BallotWon, booking.Id); err != nil {
+			return err
+		}
+
+		return insertAudit(ctx, tx,
+			port.NewBookingAuditEntry(ctx, booking, domain.AuditBookingCreated, ""),
+			port.NewTicketsAuditEntry(ctx, booking.EventId, newAvailableTickets+1, newAvailableTickets),
+		)
+	})
+	if err != nil {
+		return fmt.Errorf("error allocate ballot ticket: %w", err)
+	}
+	return nil
+}
+
+func (e *EventRepository) CloseBallotWaitlist(ctx context.Context, eventID string) ([]*domain.BallotEntry, error) {
+	rows, err := e.PostgresDB.Master.QueryContext(ctx, closeWaitlistQuery, eventID, domain.BallotLost, domain.BallotWaitlisted)
+	if err != nil {
+		return nil, fmt.Errorf("error close ballot waitlist: %w", err)
+	}
+	entries, err := scanBallotEntries(rows)
+	if err != nil {
+		return nil, fmt.Errorf("error close ballot waitlist: %w", err)
+	}
+	// RETURNING не упорядочен
+	slices.SortFunc(entries, func(a, b *domain.BallotEntry) int { return a.Rank - b.Rank })
+	return entries, nil
+}
+
+// scanBallotEntries читает и закрывает rows
+func scanBallotEntries(rows *sql.Rows) ([]*domain.BallotEntry, error) {
+	defer rows.Close()
+
+	var entries []*domain.BallotEntry
+	for rows.Next() {
+		entry, err := scanBallotEntry(rows)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
+
+func scanBallot(row interface{ Scan(dest ...any) error }) (*domain.Ballot, error) {
+	var ballot domain.Ballot
+	var seed sql.NullInt64
+	var drawnAt sql.NullTime
+	err := row.Scan(&ballot.EventId, &ballot.EntryOpensAt, &ballot.EntryClosesAt, &ballot.Waitlist,
+		&seed, &drawnAt, &ballot.Entries)
+	if err != nil {
+		return nil, err
+	}
+	ballot.Seed = seed.Int64
+	if drawnAt.Valid {
+		ballot.DrawnAt = &drawnAt.Time
+	}
+	return &ballot, nil
+}
+
+func scanBallotEntry(row interface{ Scan(dest ...any) error }) (*domain.BallotEntry, error) {
+	var entry domain.BallotEntry
+	err := row.Scan(&entry.EventId, &entry.UserId, &entry.Status, &entry.Rank, &entry.BookingId, &entry.EnteredAt)
+	if err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
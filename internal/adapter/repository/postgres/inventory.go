@@ -0,0 +1,162 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/dontpanicw/EventBooker/internal/domain"
+	"github.com/dontpanicw/EventBooker/internal/port"
+	"github.com/google/uuid"
+)
+
+const (
+	inventoryCountsQuery = `SELECT e.id, e.capacity, COALESCE(e.available_tickets, 0), COUNT(b.id)
+							FROM events e
+							LEFT JOIN bookings b ON b.event_id = e.id AND b.status IN ($1, $2)
+							GROUP BY e.id, e.capacity, e.available_tickets
+							ORDER BY e.id;`
+	lockEventInventoryQuery = `SELECT capacity, COALESCE(available_tickets, 0) FROM events WHERE id = $1 FOR UPDATE;`
+	activeBookingsQuery     = `SELECT COUNT(*) FROM bookings WHERE event_id = $1 AND status IN ($2, $3);`
+	setAvailableQuery       = `UPDATE events SET available_tickets = $2, version = version + 1 WHERE id = $1;`
+	insertAdjustmentQuery   = `INSERT INTO inventory_adjustments (id, event_id, old_available, new_available, active_bookings, reason, created_at)
+							 VALUES ($1, $2, $3, $4, $5, $6, $7);`
+	unverifiedCapacitiesQuery = `SELECT u.event_id, e.capacity, COALESCE(e.available_tickets, 0), u.flagged_at
+								FROM unverified_capacities u
+								JOIN events e ON e.id = u.event_id
+								ORDER BY u.event_id;`
+	deleteUnverifiedCapacityQuery = `DELETE FROM unverified_capacities WHERE event_id = $1;`
+)
+
+// FindInventoryDiscrepancies читает из master: отчет по отстающей реплике показал бы ложные расхождения
+func (e *EventRepository) FindInventoryDiscrepancies(ctx context.Context) ([]*domain.InventoryDiscrepancy, error) {
+	rows, err := e.PostgresDB.Master.QueryContext(ctx, inventoryCountsQuery, domain.PendingStatus, domain.ConfirmedStatus)
+	if err != nil {
+		return nil, fmt.Errorf("error querying inventory: %w", err)
+	}
+	defer rows.Close()
+
+	var discrepancies []*domain.InventoryDiscrepancy
+	for rows.Next() {
+		var d domain.InventoryDiscrepancy
+		if err := rows.Scan(&d.EventId, &d.Capacity, &d.AvailableTickets, &d.ActiveBookings); err != nil {
+			return nil, fmt.Errorf("error scanning inventory: %w", err)
+		}
+		d.ExpectedAvailable = domain.ExpectedAvailable(d.Capacity, d.ActiveBookings)
+		if d.Drift() != 0 {
+			discrepancies = append(discrepancies, &d)
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating inventory: %w", err)
+	}
+	return discrepancies, nil
+}
+
+// RepairInventory блокирует строку события, поэтому конкурентные бронирования и отмены
+// не меняют счетчик между подсчетом броней и записью
+func (e *EventRepository) RepairInventory(ctx context.Context, eventID string, reason string) (*domain.InventoryAdjustment, error) {
+	tx, err := e.PostgresDB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		err = tx.Rollback()
+		if err != nil && !errors.Is(err, sql.ErrTxDone) {
+			log.Printf("failed to rollback transaction: %v", err)
+		}
+	}()
+
+	var capacity, available int
+	if err := tx.QueryRowContext(ctx, lockEventInventoryQuery, eventID).Scan(&capacity, &available); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("error repair inventory: %w", domain.ErrEventNotFound)
+		}
+		return nil, fmt.Errorf("error repair inventory: %w", err)
+	}
+
+	var active int
+	if err := tx.QueryRowContext(ctx, activeBookingsQuery, eventID, domain.PendingStatus, domain.ConfirmedStatus).Scan(&active); err != nil {
+		return nil, fmt.Errorf("error counting bookings: %w", err)
+	}
+
+	expected := domain.ExpectedAvailable(capacity, active)
+	if expected == available {
+		return nil, nil
+	}
+
+	adjustment := &domain.InventoryAdjustment{
+		Id:             uuid.New().String(),
+		EventId:        eventID,
+		OldAvailable:   available,
+		NewAvailable:   expected,
+		ActiveBookings: active,
+		Reason:         reason,
+		CreatedAt:      time.Now().UTC(),
+	}
+
+	if _, err := tx.ExecContext(ctx, setAvailableQuery, eventID, expected); err != nil {
+		return nil, fmt.Errorf("error update available tickets: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, insertAdjustmentQuery,
+		adjustment.Id,
+		adjustment.EventId,
+		adjustment.OldAvailable,
+		adjustment.NewAvailable,
+		adjustment.ActiveBookings,
+		adjustment.Reason,
+		adjustment.CreatedAt,
+	); err != nil {
+		return nil, fmt.Errorf("error insert inventory adjustment: %w", err)
+	}
+	audit := port.NewTicketsAuditEntry(port.WithAuditReason(ctx, reason), eventID, available, expected)
+	if err := insertAudit(ctx, tx, audit); err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	log.Printf("Inventory of event %s repaired: %d -> %d available", eventID, available, expected)
+	return adjustment, nil
+}
+
+// ListUnverifiedCapacities читает из master, как и отчет о расхождениях
+func (e *EventRepository) ListUnverifiedCapacities(ctx context.Context) ([]*domain.UnverifiedCapacity, error) {
+	rows, err := e.PostgresDB.Master.QueryContext(ctx, unverifiedCapacitiesQuery)
+	if err != nil {
+		return nil, fmt.Errorf("error querying unverified capacities: %w", err)
+	}
+	defer rows.Close()
+
+	var unverified []*domain.UnverifiedCapacity
+	for rows.Next() {
+		var u domain.UnverifiedCapacity
+		if err := rows.Scan(&u.EventId, &u.Capacity, &u.AvailableTickets, &u.FlaggedAt); err != nil {
+			return nil, fmt.Errorf("error scanning unverified capacity: %w", err)
+		}
+		unverified = append(unverified, &u)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating unverified capacities: %w", err)
+	}
+	return unverified, nil
+}
+
+func (e *EventRepository) VerifyCapacity(ctx context.Context, eventID string) error {
+	result, err := e.PostgresDB.Master.ExecContext(ctx, deleteUnverifiedCapacityQuery, eventID)
+	if err != nil {
+		return fmt.Errorf("error verify capacity: %w", err)
+	}
+	if deleted, err := result.RowsAffected(); err != nil {
+		return fmt.Errorf("error verify capacity: %w", err)
+	} else if deleted == 0 {
+		return fmt.Errorf("error verify capacity: %w", domain.ErrUnverifiedCapacityNotFound)
+	}
+	return nil
+}
@@ -11,53 +11,129 @@ import (
 	"github.com/wb-go/wbf/dbpg"
 	"github.com/wb-go/wbf/retry"
 	"log"
+	"strings"
 	"time"
 )
 
 const (
-	createEventQuery   = `INSERT INTO events (id, name, description, is_free, price, available_tickets, date) VALUES ($1, $2, $3, $4, $5, $6, $7);`
-	getEventQuery      = `SELECT * FROM events WHERE id = $1;`
-	getAllEventsQuery  = `SELECT id, name, description, is_free, price, available_tickets, date FROM events ORDER BY date ASC;`
-	bookEventQuery     = `INSERT INTO bookings (id, user_id, event_id, status, date) VALUES ($1, $2, $3, $4, $5);`
-	confirmBookQuery   = `UPDATE bookings SET status = $1 WHERE id = $2;`
-	getBookingQuery    = `SELECT id, user_id, event_id, status, date FROM bookings WHERE id = $1;`
-	cancelBookingQuery = `UPDATE bookings SET status = $1 WHERE id = $2;`
-	updateEventQuery   = `UPDATE events 
-						SET available_tickets = available_tickets - 1 
-						WHERE id = $1 AND available_tickets > 0
-						RETURNING available_tickets;`
+	createEventQuery = `INSERT INTO events (id, name, description, is_free, price, currency, organizer_id, available_tickets, capacity, date, sale_mode) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11);`
+	// selectEventsQuery считает оплаченные ($1) и ожидающие оплаты ($2) брони каждого события
+	selectEventsQuery = `SELECT e.id, e.name, e.description, e.is_free, COALESCE(e.price, 0), e.currency, e.organizer_id, e.available_tickets, e.capacity, e.date, e.version, e.sale_mode,
+							COUNT(b.id) FILTER (WHERE b.status = $1),
+							COUNT(b.id) FILTER (WHERE b.status = $2)
+						FROM events e
+						LEFT JOIN bookings b ON b.event_id = e.id`
+	getEventQuery  = selectEventsQuery + ` WHERE e.id = $3 GROUP BY e.id;`
+	bookEventQuery = `INSERT INTO bookings (id, user_id, event_id, status, date, price, currency, promo_code) VALUES ($1, $2, $3, $4, $5, $6, $7, NULLIF($8, ''));`
+	// confirmBookQuery сверяет версию брони, если $4 не 0
+	confirmBookQuery = `UPDATE bookings SET status = $1, version = version + 1
+						WHERE id = $2 AND status = $3 AND ($4::bigint = 0 OR version = $4)
+						RETURNING user_id, event_id, version;`
+	getBookingQuery    = `SELECT id, user_id, event_id, status, date, price, currency, COALESCE(promo_code, ''), version FROM bookings WHERE id = $1;`
+	cancelBookingQuery = `UPDATE bookings SET status = $1, version = version + 1 WHERE id = $2 AND status = $3 RETURNING user_id, event_id, version;`
+	eventSaleModeQuery = `SELECT sale_mode FROM events WHERE id = $1;`
+	// updateEventQuery списывает билет, только если мероприятие продается в режиме $2,
+	// и возвращает то, от чего зависит цена билета
+	updateEventQuery = `UPDATE events 
+						SET available_tickets = available_tickets - 1, version = version + 1 
+						WHERE id = $1 AND available_tickets > 0 AND sale_mode = $2
+						RETURNING available_tickets, capacity, is_free, COALESCE(price, 0), currency, organizer_id, name, date;`
 	addAvailableTicketQuery = `UPDATE events
-							   SET available_tickets = available_tickets + 1
+							   SET available_tickets = available_tickets + 1, version = version + 1
 							   WHERE id = $1
 							   RETURNING available_tickets;`
+	// bumpEventVersionQuery отмечает изменение Sold и Held события при оплате брони
+	bumpEventVersionQuery = `UPDATE events SET version = version + 1 WHERE id = $1;`
+	lockEventQuery        = `SELECT id FROM events WHERE id = $1 FOR UPDATE;`
+	editEventQuery        = `UPDATE events SET name = $2, description = $3, is_free = $4, price = $5, version = version + 1 WHERE id = $1;`
+	// selectBookingsQuery выбирает брони с мероприятиями; счетчики броней мероприятия
+	// ($1 оплаченные, $2 ожидающие оплаты) считаются подзапросами только для строк страницы
+	selectBookingsQuery = `SELECT b.id, b.user_id, b.event_id, b.status, b.date, b.price, b.currency, COALESCE(b.promo_code, ''), b.version,
+							e.id, e.name, e.description, e.is_free, COALESCE(e.price, 0), e.currency, e.organizer_id, e.available_tickets, e.capacity, e.date, e.version, e.sale_mode,
+							(SELECT COUNT(*) FROM bookings s WHERE s.event_id = e.id AND s.status = $1),
+							(SELECT COUNT(*) FROM bookings s WHERE s.event_id = e.id AND s.status = $2)
+						FROM bookings b
+						JOIN events e ON e.id = b.event_id`
+	// searchEventsQuery ищет по search_vector (миграция 006) запросом в синтаксисе websearch,
+	// разобранным русской и английской конфигурациями. Текст экранируется до ts_headline,
+	// чтобы в подсветке не было разметки из названия или описания.
+	searchEventsQuery = `WITH q AS (
+							SELECT websearch_to_tsquery('russian', $3) || websearch_to_tsquery('english', $3) AS query
+						)
+						SELECT e.id, e.name, e.description, e.is_free, COALESCE(e.price, 0), e.currency, e.organizer_id, e.available_tickets, e.capacity, e.date, e.version, e.sale_mode,
+							(SELECT COUNT(*) FROM bookings b WHERE b.event_id = e.id AND b.status = $1),
+							(SELECT COUNT(*) FROM bookings b WHERE b.event_id = e.id AND b.status = $2),
+							ts_rank(e.search_vector, q.query) AS rank,
+							ts_headline('russian',
+								replace(replace(replace(e.name, '&', '&amp;'), '<', '&lt;'), '>', '&gt;'),
+								q.query, 'HighlightAll=true, StartSel=<mark>, StopSel=</mark>'),
+							ts_headline('russian',
+								replace(replace(replace(COALESCE(e.description, ''), '&', '&amp;'), '<', '&lt;'), '>', '&gt;'),
+								q.query, 'StartSel=<mark>, StopSel=</mark>, MaxFragments=2, MaxWords=30, MinWords=10, FragmentDelimiter=" … "')
+						FROM events e, q
+						WHERE e.search_vector @@ q.query
+						ORDER BY rank DESC, e.id
+						LIMIT $4;`
 )
 
+// EventRepository пишет только в master. Чтения событий идут в здоровую реплику,
+// если ctx не требует строгой согласованности (port.WithStrongConsistency);
+// брони всегда читаются из master, чтобы подтверждение и отмена видели свежий статус.
 type EventRepository struct {
 	PostgresDB *dbpg.DB
+	replicas   *replicaPool
 }
 
-func NewEventRepository(cfg *config.Config) port.Repository {
+// NewEventRepository подключается к master и репликам из cfg.SlaveDSNs и
+// проверяет отставание реплик, пока ctx не отменен
+func NewEventRepository(ctx context.Context, cfg *config.Config) port.Repository {
 	opts := &dbpg.Options{MaxOpenConns: 10, MaxIdleConns: 5}
 	db, err := dbpg.New(cfg.MasterDSN, cfg.SlaveDSNs, opts)
 	if err != nil {
 		panic(err)
 	}
 
+	replicas := newReplicaPool(db.Slaves, cfg.ReplicaMaxLag)
+	replicas.start(ctx, cfg.ReplicaCheckInterval)
+
 	return &EventRepository{
 		PostgresDB: db,
+		replicas:   replicas,
+	}
+}
+
+// reader выбирает БД для чтения: реплику или master, если реплик нет, все отстают
+// или ctx требует строгой согласованности
+func (e *EventRepository) reader(ctx context.Context) *sql.DB {
+	if port.StrongConsistency(ctx) {
+		return e.PostgresDB.Master
+	}
+	if replica := e.replicas.pick(); replica != nil {
+		return replica
 	}
+	return e.PostgresDB.Master
 }
 
 func (e *EventRepository) CreateEvent(ctx context.Context, event *domain.Event) (string, error) {
-	_, err := e.PostgresDB.ExecWithRetry(ctx, createRetryStrategy(), createEventQuery,
-		event.Id,
-		event.Name,
-		event.Description,
-		event.IsFree,
-		event.Price,
-		event.AvailableTickets,
-		event.Date,
-	)
+	err := e.PostgresDB.WithTxWithRetry(ctx, createRetryStrategy(), func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, createEventQuery,
+			event.Id,
+			event.Name,
+			event.Description,
+			event.IsFree,
+			event.Price.Amount,
+			event.Price.Currency,
+			event.OrganizerId,
+			event.AvailableTickets,
+			event.Capacity,
+			event.Date,
+			event.SaleMode,
+		)
+		if err != nil {
+			return err
+		}
+		return insertAudit(ctx, tx, port.NewEventCreatedAuditEntry(ctx, event))
+	})
 	if err != nil {
 		return "", fmt.Errorf("error create event: %w", err)
 	}
@@ -65,30 +141,28 @@ func (e *EventRepository) CreateEvent(ctx context.Context, event *domain.Event)
 	return event.Id, nil
 }
 
-func (e *EventRepository) BookEvent(ctx context.Context, booking *domain.Booking) (string, error) {
+func (e *EventRepository) BookEvent(ctx context.Context, booking *domain.Booking) (*domain.Event, error) {
 	tx, err := e.PostgresDB.BeginTx(ctx, nil)
 	if err != nil {
-		return "", fmt.Errorf("failed to begin transaction: %w", err)
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
 	}
 	defer func() {
 		err = tx.Rollback()
-		if err != nil {
+		if err != nil && !errors.Is(err, sql.ErrTxDone) {
 			log.Printf("failed to rollback transaction: %v", err)
 		}
 	}() // Будет отменен, если не закоммитим
 
-	var newAvailableTickets int
-
-	err = e.PostgresDB.QueryRowContext(ctx, updateEventQuery, booking.EventId).Scan(&newAvailableTickets)
-
+	event, err := takeTicket(ctx, tx, booking.EventId, domain.SaleModeFirstCome)
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			// Билетов нет или событие не найдено
-			log.Printf("No tickets available or event not found for ID: %s", booking.EventId)
-			return "", fmt.Errorf("no tickets available")
+		return nil, err
+	}
+	if booking.Admission != nil {
+		if err := useAdmission(ctx, tx, booking); err != nil {
+			return nil, err
 		}
-		return "", fmt.Errorf("failed to update tickets: %w", err)
 	}
+	newAvailableTickets := int(event.AvailableTickets)
 	// Успешное обновление, newAvailableTickets содержит новое количество билетов
 	log.Printf("Tickets updated successfully. Remaining tickets: %d", newAvailableTickets)
 
@@ -96,50 +170,196 @@ func (e *EventRepository) BookEvent(ctx context.Context, booking *domain.Booking
 		log.Printf("Event %s is now sold out", booking.EventId)
 	}
 
-	_, err = e.PostgresDB.ExecWithRetry(ctx, createRetryStrategy(), bookEventQuery,
+	face, err := ticketPrice(ctx, tx, event, booking.Date)
+	if err != nil {
+		return nil, err
+	}
+	booking.Price = face
+	discount := domain.Money{Currency: face.Currency}
+	if booking.PromoCode != "" {
+		if discount, err = promoDiscount(ctx, tx, booking); err != nil {
+			return nil, fmt.Errorf("failed to book event: %w", err)
+		}
+	}
+	if err := chargeBooking(ctx, tx, event, booking, face, discount); err != nil {
+		return nil, err
+	}
+
+	_, err = tx.ExecContext(ctx, bookEventQuery,
 		booking.Id,
 		booking.UserId,
 		booking.EventId,
 		booking.Status,
 		booking.Date,
+		booking.Price.Amount,
+		booking.Price.Currency,
+		booking.PromoCode,
 	)
 	if err != nil {
-		return "", fmt.Errorf("failed to book event: %w", err)
+		return nil, fmt.Errorf("failed to book event: %w", err)
+	}
+	if err := insertLineItems(ctx, tx, booking); err != nil {
+		return nil, err
+	}
+	if booking.PromoCode != "" {
+		_, err = tx.ExecContext(ctx, insertRedemptionQuery,
+			booking.Id, booking.PromoCode, booking.UserId, booking.EventId, discount.Amount, booking.Date)
+		if err != nil {
+			return nil, fmt.Errorf("failed to redeem promo code: %w", err)
+		}
+	}
+
+	if err := insertAudit(ctx, tx,
+		port.NewBookingAuditEntry(ctx, booking, domain.AuditBookingCreated, ""),
+		port.NewTicketsAuditEntry(ctx, booking.EventId, newAvailableTickets+1, newAvailableTickets),
+	); err != nil {
+		return nil, err
 	}
 
 	if err = tx.Commit(); err != nil {
-		return "", fmt.Errorf("failed to commit transaction: %w", err)
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
 	}
 	log.Printf("Ticket booked successfully. Booking ID: %s, Remaining tickets: %d",
 		booking.Id, newAvailableTickets)
 
-	return booking.Id, nil
+	return event, nil
 }
 
-func (e *EventRepository) ConfirmBooking(ctx context.Context, bookingID string) error {
+// takeTicket списывает билет мероприятия, продающегося в режиме saleMode, и возвращает
+// мероприятие с оставшимся числом мест и полями, нужными для цены билета (ticketPrice)
+func takeTicket(ctx context.Context, tx *sql.Tx, eventID, saleMode string) (*domain.Event, error) {
+	event := &domain.Event{Id: eventID}
+	err := tx.QueryRowContext(ctx, updateEventQuery, eventID, saleMode).
+		Scan(&event.AvailableTickets, &event.Capacity, &event.IsFree, &event.Price.Amount, &event.Price.Currency, &event.OrganizerId,
+			&event.Name, &event.Date)
+	if err == nil {
+		return event, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("failed to update tickets: %w", err)
+	}
+
+	// Билетов нет, событие не найдено или продается в другом режиме
+	var mode string
+	if err := tx.QueryRowContext(ctx, eventSaleModeQuery, eventID).Scan(&mode); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("failed to book event: %w", domain.ErrEventNotFound)
+		}
+		return nil, fmt.Errorf("failed to check event: %w", err)
+	}
+	if mode != saleMode {
+		if mode == domain.SaleModeBallot {
+			return nil, fmt.Errorf("failed to book event: %w", domain.ErrBallotEvent)
+		}
+		return nil, fmt.Errorf("failed to book event: %w", domain.ErrNotBallotEvent)
+	}
+	log.Printf("No tickets available for event ID: %s", eventID)
+	return nil, domain.ErrNoTicketsAvailable
+}
+
+func (e *EventRepository) ConfirmBooking(ctx context.Context, bookingID string, version int64) error {
 	log.Printf("Confirming booking %s", bookingID)
-	_, err := e.PostgresDB.ExecWithRetry(ctx, createRetryStrategy(), confirmBookQuery,
-		domain.ConfirmedStatus,
-		bookingID,
-	)
+	booking := &domain.Booking{Id: bookingID, Status: domain.ConfirmedStatus}
+	var confirmed bool
+	err := e.PostgresDB.WithTxWithRetry(ctx, createRetryStrategy(), func(tx *sql.Tx) error {
+		err := tx.QueryRowContext(ctx, confirmBookQuery,
+			domain.ConfirmedStatus,
+			bookingID,
+			domain.PendingStatus,
+			version,
+		).Scan(&booking.UserId, &booking.EventId, &booking.Version)
+		if errors.Is(err, sql.ErrNoRows) {
+			// Повтор не поможет: брони нет, она уже не pending или версия устарела
+			confirmed = false
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		confirmed = true
+		if _, err := tx.ExecContext(ctx, bumpEventVersionQuery, booking.EventId); err != nil {
+			return err
+		}
+		return insertAudit(ctx, tx, port.NewBookingAuditEntry(ctx, booking, domain.AuditBookingConfirmed, domain.PendingStatus))
+	})
 	if err != nil {
 		return fmt.Errorf("error confirm booking: %w", err)
 	}
+	if !confirmed {
+		return fmt.Errorf("error confirm booking: %w", e.transitionError(ctx, bookingID, version))
+	}
 	log.Printf("Confirmed booking %s", bookingID)
 	return nil
 }
 
+// UpdateEvent блокирует строку события, сверяет версию и пишет правку вместе с записью журнала
+func (e *EventRepository) UpdateEvent(ctx context.Context, eventID string, version int64, update domain.EventUpdate) (*domain.Event, error) {
+	var old, event *domain.Event
+	err := e.PostgresDB.WithTx(ctx, func(tx *sql.Tx) error {
+		var id string
+		if err := tx.QueryRowContext(ctx, lockEventQuery, eventID).Scan(&id); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return domain.ErrEventNotFound
+			}
+			return err
+		}
+		var err error
+		old, err = scanEvent(tx.QueryRowContext(ctx, getEventQuery, domain.ConfirmedStatus, domain.PendingStatus, eventID))
+		if err != nil {
+			return err
+		}
+		if version != 0 && old.Version != version {
+			return domain.ErrVersionMismatch
+		}
+		if err := update.ValidateFor(*old); err != nil {
+			return err
+		}
+
+		updated := *old
+		update.Apply(&updated)
+		updated.Version++
+		if _, err := tx.ExecContext(ctx, editEventQuery, eventID, updated.Name, updated.Description, updated.IsFree, updated.Price.Amount); err != nil {
+			return err
+		}
+		event = &updated
+		return insertAudit(ctx, tx, port.NewEventUpdatedAuditEntry(ctx, old, event))
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error update event: %w", err)
+	}
+	return event, nil
+}
+
 func (e *EventRepository) GetEvent(ctx context.Context, eventID string) (*domain.Event, error) {
-	var event domain.Event
-	err := e.PostgresDB.QueryRowContext(ctx, getEventQuery, eventID).Scan(&event.Id, &event.Name, &event.Description, &event.IsFree, &event.Price, &event.AvailableTickets, &event.Date)
+	db := e.reader(ctx)
+	row := db.QueryRowContext(ctx, getEventQuery, domain.ConfirmedStatus, domain.PendingStatus, eventID)
+	event, err := scanEvent(row)
 	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("error get event: %w", domain.ErrEventNotFound)
+		}
 		return nil, fmt.Errorf("error get event: %w", err)
 	}
+	if event.PricePhases, err = listPricePhases(ctx, db, eventID); err != nil {
+		return nil, fmt.Errorf("error get event: %w", err)
+	}
+	return event, nil
+}
+
+// scanEvent читает строку selectEventsQuery
+func scanEvent(row interface{ Scan(dest ...any) error }) (*domain.Event, error) {
+	var event domain.Event
+	err := row.Scan(&event.Id, &event.Name, &event.Description, &event.IsFree, &event.Price.Amount, &event.Price.Currency, &event.OrganizerId,
+		&event.AvailableTickets, &event.Capacity, &event.Date, &event.Version, &event.SaleMode, &event.Sold, &event.Held)
+	if err != nil {
+		return nil, err
+	}
 	return &event, nil
 }
 
-func (e *EventRepository) GetAllEvents(ctx context.Context) ([]*domain.Event, error) {
-	rows, err := e.PostgresDB.QueryContext(ctx, getAllEventsQuery)
+func (e *EventRepository) ListEvents(ctx context.Context, filter domain.EventFilter) (*domain.EventPage, error) {
+	query, args := listEventsQuery(filter)
+	rows, err := e.reader(ctx).QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("error querying all events: %w", err)
 	}
@@ -152,27 +372,82 @@ func (e *EventRepository) GetAllEvents(ctx context.Context) ([]*domain.Event, er
 
 	var events []*domain.Event
 	for rows.Next() {
-		var event domain.Event
-		err := rows.Scan(&event.Id, &event.Name, &event.Description, &event.IsFree, &event.Price, &event.AvailableTickets, &event.Date)
+		event, err := scanEvent(rows)
 		if err != nil {
 			return nil, fmt.Errorf("error scanning event: %w", err)
 		}
-		events = append(events, &event)
+		events = append(events, event)
 	}
 
 	if err = rows.Err(); err != nil {
 		return nil, fmt.Errorf("error iterating events: %w", err)
 	}
 
-	return events, nil
+	return domain.NewEventPage(events, filter), nil
 }
 
-func (e *EventRepository) AddAvailableTickets(ctx context.Context, eventID string) error {
-	var newAvailableTickets int
+// listEventsQuery строит выборку страницы: фильтры и курсор уходят в WHERE,
+// сортировка по ключу и id совпадает с индексами миграции 005
+func listEventsQuery(filter domain.EventFilter) (string, []any) {
+	args := []any{domain.ConfirmedStatus, domain.PendingStatus}
+	arg := func(v any) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
 
-	err := e.PostgresDB.QueryRowContext(ctx, addAvailableTicketQuery, eventID).Scan(&newAvailableTickets)
+	var where []string
+	if !filter.DateFrom.IsZero() {
+		where = append(where, "e.date >= "+arg(filter.DateFrom))
+	}
+	if !filter.DateTo.IsZero() {
+		where = append(where, "e.date < "+arg(filter.DateTo))
+	}
+	if filter.IsFree != nil {
+		where = append(where, "e.is_free = "+arg(*filter.IsFree))
+	}
+	if filter.Currency != "" {
+		where = append(where, "e.currency = "+arg(filter.Currency))
+	}
+	if filter.MinPrice != nil {
+		where = append(where, "COALESCE(e.price, 0) >= "+arg(*filter.MinPrice))
+	}
+	if filter.MaxPrice != nil {
+		where = append(where, "COALESCE(e.price, 0) <= "+arg(*filter.MaxPrice))
+	}
+	if filter.OnlyAvailable {
+		where = append(where, "e.available_tickets > 0")
+	}
 
+	byPrice := filter.Sort == domain.SortPriceAsc || filter.Sort == domain.SortPriceDesc
+	key, direction, op := "e.date", "ASC", ">"
+	if byPrice {
+		key = "COALESCE(e.price, 0)"
+	}
+	if filter.Sort == domain.SortDateDesc || filter.Sort == domain.SortPriceDesc {
+		direction, op = "DESC", "<"
+	}
+	if c := filter.After; c != nil {
+		var value any = c.Date
+		if byPrice {
+			value = c.Price
+		}
+		where = append(where, fmt.Sprintf("(%s, e.id) %s (%s, %s)", key, op, arg(value), arg(c.Id)))
+	}
+
+	query := selectEventsQuery
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += fmt.Sprintf(" GROUP BY e.id ORDER BY %s %s, e.id %s LIMIT %s;", key, direction, direction, arg(filter.Limit+1))
+	return query, args
+}
+
+func (e *EventRepository) AddAvailableTickets(ctx context.Context, eventID string) error {
+	newAvailableTickets, err := e.addTicket(ctx, eventID)
 	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("failed to update tickets: %w", domain.ErrEventNotFound)
+		}
 		return fmt.Errorf("failed to update tickets: %w", err)
 	}
 	// Успешное обновление, newAvailableTickets содержит новое количество билетов
@@ -188,38 +463,209 @@ func createRetryStrategy() retry.Strategy {
 		Backoff:  2}
 }
 
+func (e *EventRepository) SearchEvents(ctx context.Context, query string, limit int) ([]*domain.EventSearchResult, error) {
+	rows, err := e.reader(ctx).QueryContext(ctx, searchEventsQuery,
+		domain.ConfirmedStatus, domain.PendingStatus, query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("error searching events: %w", err)
+	}
+	defer func() {
+		err = rows.Close()
+		if err != nil {
+			log.Printf("error closing rows: %v", err)
+		}
+	}()
+
+	var results []*domain.EventSearchResult
+	for rows.Next() {
+		var event domain.Event
+		var result domain.EventSearchResult
+		err := rows.Scan(&event.Id, &event.Name, &event.Description, &event.IsFree, &event.Price.Amount, &event.Price.Currency, &event.OrganizerId,
+			&event.AvailableTickets, &event.Capacity, &event.Date, &event.Version, &event.SaleMode, &event.Sold, &event.Held,
+			&result.Rank, &result.NameHighlight, &result.DescriptionHighlight)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning search result: %w", err)
+		}
+		result.Event = &event
+		results = append(results, &result)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating search results: %w", err)
+	}
+
+	return results, nil
+}
+
 func (e *EventRepository) GetBooking(ctx context.Context, bookingID string) (*domain.Booking, error) {
 	var booking domain.Booking
-	err := e.PostgresDB.QueryRowContext(ctx, getBookingQuery, bookingID).Scan(
+	err := e.PostgresDB.Master.QueryRowContext(ctx, getBookingQuery, bookingID).Scan(
 		&booking.Id,
 		&booking.UserId,
 		&booking.EventId,
 		&booking.Status,
 		&booking.Date,
+		&booking.Price.Amount,
+		&booking.Price.Currency,
+		&booking.PromoCode,
+		&booking.Version,
 	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("error get booking: %w", domain.ErrBookingNotFound)
+		}
+		return nil, fmt.Errorf("error get booking: %w", err)
+	}
+	booking.LineItems, err = listLineItems(ctx, e.PostgresDB.Master, booking.Id, booking.Price.Currency)
 	if err != nil {
 		return nil, fmt.Errorf("error get booking: %w", err)
 	}
 	return &booking, nil
 }
 
+// ListBookings читает из master, как и GetBooking: пользователь должен сразу видеть
+// только что созданную или оплаченную бронь
+func (e *EventRepository) ListBookings(ctx context.Context, filter domain.BookingFilter) (*domain.BookingPage, error) {
+	query, args := listBookingsQuery(filter)
+	rows, err := e.PostgresDB.Master.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error querying user bookings: %w", err)
+	}
+	defer func() {
+		err = rows.Close()
+		if err != nil {
+			log.Printf("error closing rows: %v", err)
+		}
+	}()
+
+	var bookings []*domain.BookingDetails
+	for rows.Next() {
+		var booking domain.Booking
+		var event domain.Event
+		err := rows.Scan(&booking.Id, &booking.UserId, &booking.EventId, &booking.Status, &booking.Date,
+			&booking.Price.Amount, &booking.Price.Currency, &booking.PromoCode, &booking.Version,
+			&event.Id, &event.Name, &event.Description, &event.IsFree, &event.Price.Amount, &event.Price.Currency, &event.OrganizerId,
+			&event.AvailableTickets, &event.Capacity, &event.Date, &event.Version, &event.SaleMode, &event.Sold, &event.Held)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning user booking: %w", err)
+		}
+		bookings = append(bookings, &domain.BookingDetails{Booking: &booking, Event: &event})
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating user bookings: %w", err)
+	}
+
+	page := domain.NewBookingPage(bookings, filter)
+	if filter.LineItems {
+		if err := loadLineItems(ctx, e.PostgresDB.Master, page.Bookings); err != nil {
+			return nil, fmt.Errorf("error get line items: %w", err)
+		}
+	}
+	return page, nil
+}
+
+// listBookingsQuery строит выборку страницы броней; порядок совпадает с индексами
+// миграций 007 (по пользователю) и 008 (по мероприятию)
+func listBookingsQuery(filter domain.BookingFilter) (string, []any) {
+	args := []any{domain.ConfirmedStatus, domain.PendingStatus}
+	var where []string
+	if filter.UserId != "" {
+		args = append(args, filter.UserId)
+		where = append(where, fmt.Sprintf("b.user_id = $%d", len(args)))
+	}
+	if filter.EventId != "" {
+		args = append(args, filter.EventId)
+		where = append(where, fmt.Sprintf("b.event_id = $%d", len(args)))
+	}
+	if filter.Status != "" {
+		args = append(args, filter.Status)
+		where = append(where, fmt.Sprintf("b.status = $%d", len(args)))
+	}
+	if c := filter.After; c != nil {
+		args = append(args, c.Date, c.Id)
+		where = append(where, fmt.Sprintf("(b.date, b.id) < ($%d, $%d)", len(args)-1, len(args)))
+	}
+	args = append(args, filter.Limit+1)
+	query := fmt.Sprintf("%s WHERE %s ORDER BY b.date DESC, b.id DESC LIMIT $%d;",
+		selectBookingsQuery, strings.Join(where, " AND "), len(args))
+	return query, args
+}
+
 func (e *EventRepository) CancelBooking(ctx context.Context, bookingID string) error {
-	_, err := e.PostgresDB.ExecWithRetry(ctx, createRetryStrategy(), cancelBookingQuery,
-		domain.CancelledStatus,
-		bookingID,
-	)
+	tx, err := e.PostgresDB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		err = tx.Rollback()
+		if err != nil && !errors.Is(err, sql.ErrTxDone) {
+			log.Printf("failed to rollback transaction: %v", err)
+		}
+	}()
+
+	booking := &domain.Booking{Id: bookingID, Status: domain.CancelledStatus}
+	err = tx.QueryRowContext(ctx, cancelBookingQuery, domain.CancelledStatus, bookingID, domain.PendingStatus).Scan(&booking.UserId, &booking.EventId, &booking.Version)
 	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("error cancel booking: %w", e.transitionError(ctx, bookingID, 0))
+		}
 		return fmt.Errorf("error cancel booking: %w", err)
 	}
+
+	// Место возвращаем в той же транзакции, иначе счетчик разойдется с бронями при сбое
+	var newAvailableTickets int
+	if err := tx.QueryRowContext(ctx, addAvailableTicketQuery, booking.EventId).Scan(&newAvailableTickets); err != nil {
+		return fmt.Errorf("failed to release ticket: %w", err)
+	}
+
+	if err := insertAudit(ctx, tx,
+		port.NewBookingAuditEntry(ctx, booking, domain.AuditBookingCancelled, domain.PendingStatus),
+		port.NewTicketsAuditEntry(ctx, booking.EventId, newAvailableTickets-1, newAvailableTickets),
+	); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	log.Printf("Booking %s cancelled, available tickets for event %s: %d", bookingID, booking.EventId, newAvailableTickets)
 	return nil
 }
 
+// transitionError объясняет, почему смена статуса из pending не затронула бронь:
+// бронь не существует, ее версия не равна ненулевому version или она уже не pending
+func (e *EventRepository) transitionError(ctx context.Context, bookingID string, version int64) error {
+	booking, err := e.GetBooking(ctx, bookingID)
+	if err != nil {
+		return err
+	}
+	if version != 0 && booking.Version != version {
+		return domain.ErrVersionMismatch
+	}
+	return domain.ErrBookingNotPending
+}
+
 func (e *EventRepository) IncrementAvailableTickets(ctx context.Context, eventID string) error {
-	var newAvailableTickets int
-	err := e.PostgresDB.QueryRowContext(ctx, addAvailableTicketQuery, eventID).Scan(&newAvailableTickets)
+	newAvailableTickets, err := e.addTicket(ctx, eventID)
 	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("failed to increment tickets: %w", domain.ErrEventNotFound)
+		}
 		return fmt.Errorf("failed to increment tickets: %w", err)
 	}
 	log.Printf("Tickets incremented successfully. Available tickets: %d", newAvailableTickets)
 	return nil
 }
+
+// addTicket возвращает место в продажу и пишет изменение в журнал в одной транзакции
+func (e *EventRepository) addTicket(ctx context.Context, eventID string) (int, error) {
+	var newAvailableTickets int
+	err := e.PostgresDB.WithTx(ctx, func(tx *sql.Tx) error {
+		if err := tx.QueryRowContext(ctx, addAvailableTicketQuery, eventID).Scan(&newAvailableTickets); err != nil {
+			return err
+		}
+		return insertAudit(ctx, tx, port.NewTicketsAuditEntry(ctx, eventID, newAvailableTickets-1, newAvailableTickets))
+	})
+	return newAvailableTickets, err
+}
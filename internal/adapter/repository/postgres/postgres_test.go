@@ -0,0 +1,83 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/dontpanicw/EventBooker/config"
+	"github.com/dontpanicw/EventBooker/internal/adapter/repository/repositorytest"
+	"github.com/dontpanicw/EventBooker/internal/domain"
+	"github.com/dontpanicw/EventBooker/internal/port"
+	"github.com/dontpanicw/EventBooker/pkg/migrations"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	_ "github.com/lib/pq"
+)
+
+// TEST_POSTGRES_DSN - БД для тестов; таблицы в ней очищаются перед каждым тестом
+const testDSNEnv = "TEST_POSTGRES_DSN"
+
+func TestEventRepository_Conformance(t *testing.T) {
+	dsn := os.Getenv(testDSNEnv)
+	if dsn == "" {
+		t.Skipf("%s is not set", testDSNEnv)
+	}
+
+	db, err := sql.Open("postgres", dsn)
+	require.NoError(t, err)
+	defer db.Close()
+	require.NoError(t, migrations.Migrate(db))
+
+	repositorytest.Run(t, func(t *testing.T) port.Repository {
+		_, err := db.Exec(`TRUNCATE unverified_capacities, idempotency_keys, audit_log, inventory_adjustments, fee_rules, booking_line_items, promo_redemptions, promo_codes, price_phases, ballot_entries, ballots, waiting_room_tickets, waiting_rooms, bookings, events;`)
+		require.NoError(t, err)
+
+		repo := NewEventRepository(context.Background(), &config.Config{MasterDSN: dsn})
+		t.Cleanup(func() {
+			repo.(*EventRepository).PostgresDB.Master.Close()
+		})
+		return repo
+	})
+}
+
+// Бесплатные события, созданные до миграции 015 или вручную, хранят цену NULL
+func TestEventRepository_NullPrice(t *testing.T) {
+	dsn := os.Getenv(testDSNEnv)
+	if dsn == "" {
+		t.Skipf("%s is not set", testDSNEnv)
+	}
+
+	db, err := sql.Open("postgres", dsn)
+	require.NoError(t, err)
+	defer db.Close()
+	require.NoError(t, migrations.Migrate(db))
+	_, err = db.Exec(`TRUNCATE unverified_capacities, idempotency_keys, audit_log, inventory_adjustments, fee_rules, booking_line_items, promo_redemptions, promo_codes, price_phases, ballot_entries, ballots, waiting_room_tickets, waiting_rooms, bookings, events;`)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	date := time.Date(2026, time.March, 1, 19, 0, 0, 0, time.UTC)
+	_, err = db.ExecContext(ctx, createEventQuery, "11111111-1111-1111-1111-111111111111", "Open lecture", "Free lecture", true, nil,
+		domain.DefaultCurrency, "", 10, 10, date, domain.SaleModeFirstCome)
+	require.NoError(t, err)
+
+	repo := NewEventRepository(ctx, &config.Config{MasterDSN: dsn})
+	defer repo.(*EventRepository).PostgresDB.Master.Close()
+
+	event, err := repo.GetEvent(ctx, "11111111-1111-1111-1111-111111111111")
+	require.NoError(t, err)
+	assert.Equal(t, domain.Money{Currency: domain.DefaultCurrency}, event.Price)
+
+	page, err := repo.ListEvents(ctx, domain.EventFilter{Limit: domain.DefaultEventsLimit})
+	require.NoError(t, err)
+	require.Len(t, page.Events, 1)
+	assert.Equal(t, int64(0), page.Events[0].Price.Amount)
+
+	results, err := repo.SearchEvents(ctx, "lecture", 10)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, int64(0), results[0].Event.Price.Amount)
+}
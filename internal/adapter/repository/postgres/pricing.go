@@ -0,0 +1,86 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/dontpanicw/EventBooker/internal/domain"
+	"github.com/dontpanicw/EventBooker/internal/port"
+)
+
+const (
+	listPricePhasesQuery   = `SELECT name, price, until, max_sold FROM price_phases WHERE event_id = $1 ORDER BY position;`
+	deletePricePhasesQuery = `DELETE FROM price_phases WHERE event_id = $1;`
+	insertPricePhaseQuery  = `INSERT INTO price_phases (event_id, position, name, price, until, max_sold) VALUES ($1, $2, $3, $4, $5, $6);`
+)
+
+// SetPricePhases сначала поднимает версию события: блокировка его строки упорядочивает
+// замену расписания с бронями, которые списывают билет той же строкой
+func (e *EventRepository) SetPricePhases(ctx context.Context, eventID string, phases []domain.PricePhase) error {
+	err := e.PostgresDB.WithTx(ctx, func(tx *sql.Tx) error {
+		result, err := tx.ExecContext(ctx, bumpEventVersionQuery, eventID)
+		if err != nil {
+			return err
+		}
+		if updated, err := result.RowsAffected(); err != nil {
+			return err
+		} else if updated == 0 {
+			return domain.ErrEventNotFound
+		}
+
+		old, err := listPricePhases(ctx, tx, eventID)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, deletePricePhasesQuery, eventID); err != nil {
+			return err
+		}
+		for i, phase := range phases {
+			_, err := tx.ExecContext(ctx, insertPricePhaseQuery,
+				eventID, i, phase.Name, phase.Price, nullTime(phase.Until), phase.MaxSold)
+			if err != nil {
+				return err
+			}
+		}
+		return insertAudit(ctx, tx, port.NewPricePhasesAuditEntry(ctx, eventID, old, phases))
+	})
+	if err != nil {
+		return fmt.Errorf("error set price phases: %w", err)
+	}
+	return nil
+}
+
+// ticketPrice - цена билета, только что списанного takeTicket, по расписанию мероприятия
+func ticketPrice(ctx context.Context, tx *sql.Tx, event *domain.Event, now time.Time) (domain.Money, error) {
+	phases, err := listPricePhases(ctx, tx, event.Id)
+	if err != nil {
+		return domain.Money{}, fmt.Errorf("failed to get price phases: %w", err)
+	}
+	// Цена считается по продажам до этого билета
+	before := *event
+	before.AvailableTickets++
+	before.PricePhases = phases
+	return before.TicketPrice(now), nil
+}
+
+func listPricePhases(ctx context.Context, q rowsQuerier, eventID string) ([]domain.PricePhase, error) {
+	rows, err := q.QueryContext(ctx, listPricePhasesQuery, eventID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var phases []domain.PricePhase
+	for rows.Next() {
+		var phase domain.PricePhase
+		var until sql.NullTime
+		if err := rows.Scan(&phase.Name, &phase.Price, &until, &phase.MaxSold); err != nil {
+			return nil, err
+		}
+		phase.Until = until.Time
+		phases = append(phases, phase)
+	}
+	return phases, rows.Err()
+}
@@ -0,0 +1,205 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/dontpanicw/EventBooker/internal/domain"
+)
+
+const (
+	createPromoCodeQuery = `INSERT INTO promo_codes (code, event_id, discount_type, value, currency, max_uses, valid_from, valid_until, created_at)
+						VALUES ($1, NULLIF($2, ''), $3, $4, $5, $6, $7, $8, $9)
+						ON CONFLICT (code) DO NOTHING;`
+	// selectPromoCodesQuery считает только использования неотмененными бронями ($1 - cancelled)
+	selectPromoCodesQuery = `SELECT p.code, COALESCE(p.event_id, ''), p.discount_type, p.value, p.currency, p.max_uses,
+							p.valid_from, p.valid_until, p.created_at,
+							(SELECT COUNT(*) FROM promo_redemptions r JOIN bookings b ON b.id = r.booking_id
+								WHERE r.code = p.code AND b.status <> $1)
+						FROM promo_codes p`
+	getPromoCodeQuery   = selectPromoCodesQuery + ` WHERE p.code = $2;`
+	listPromoCodesQuery = selectPromoCodesQuery + ` ORDER BY p.created_at, p.code;`
+	// lockPromoCodeQuery упорядочивает параллельные брони с одним кодом: счетчик
+	// использований читается только после блокировки
+	lockPromoCodeQuery = `SELECT code FROM promo_codes WHERE code = $1 FOR UPDATE;`
+	userUsedPromoQuery = `SELECT EXISTS (
+							SELECT 1 FROM promo_redemptions r JOIN bookings b ON b.id = r.booking_id
+							WHERE r.code = $1 AND r.user_id = $2 AND b.status <> $3);`
+	insertRedemptionQuery = `INSERT INTO promo_redemptions (booking_id, code, user_id, event_id, discount, redeemed_at)
+						VALUES ($1, $2, $3, $4, $5, $6);`
+	// promoTotalsQuery - суммы скидок неотмененных броней по кодам и валютам; пустой $2 - все коды
+	promoTotalsQuery = `SELECT r.code, b.currency, SUM(r.discount)
+						FROM promo_redemptions r JOIN bookings b ON b.id = r.booking_id
+						WHERE b.status <> $1 AND ($2 = '' OR r.code = $2)
+						GROUP BY r.code, b.currency
+						ORDER BY b.currency;`
+	listRedemptionsQuery = `SELECT r.code, r.booking_id, r.user_id, r.event_id, r.discount, b.currency, b.status, r.redeemed_at
+						FROM promo_redemptions r JOIN bookings b ON b.id = r.booking_id
+						WHERE r.code = $1
+						ORDER BY r.redeemed_at, r.booking_id;`
+)
+
+func (e *EventRepository) CreatePromoCode(ctx context.Context, promo *domain.PromoCode) error {
+	result, err := e.PostgresDB.Master.ExecContext(ctx, createPromoCodeQuery,
+		promo.Code, promo.EventId, promo.DiscountType, promoValue(promo), nullString(promo.Amount.Currency), promo.MaxUses,
+		nullTime(promo.ValidFrom), nullTime(promo.ValidUntil), promo.CreatedAt.UTC())
+	if err != nil {
+		return fmt.Errorf("error create promo code: %w", err)
+	}
+	if created, err := result.RowsAffected(); err != nil {
+		return fmt.Errorf("error create promo code: %w", err)
+	} else if created == 0 {
+		return fmt.Errorf("error create promo code: %w", domain.ErrPromoCodeExists)
+	}
+	return nil
+}
+
+func (e *EventRepository) GetPromoCode(ctx context.Context, code string) (*domain.PromoCode, error) {
+	db := e.reader(ctx)
+	promo, err := scanPromoCode(db.QueryRowContext(ctx, getPromoCodeQuery, domain.CancelledStatus, code))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("error get promo code: %w", domain.ErrPromoCodeNotFound)
+		}
+		return nil, fmt.Errorf("error get promo code: %w", err)
+	}
+	if err := addPromoTotals(ctx, db, code, promo); err != nil {
+		return nil, fmt.Errorf("error get promo code: %w", err)
+	}
+	return promo, nil
+}
+
+func (e *EventRepository) ListPromoCodes(ctx context.Context) ([]*domain.PromoCode, error) {
+	db := e.reader(ctx)
+	rows, err := db.QueryContext(ctx, listPromoCodesQuery, domain.CancelledStatus)
+	if err != nil {
+		return nil, fmt.Errorf("error list promo codes: %w", err)
+	}
+	defer rows.Close()
+
+	var promos []*domain.PromoCode
+	for rows.Next() {
+		promo, err := scanPromoCode(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error list promo codes: %w", err)
+		}
+		promos = append(promos, promo)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error list promo codes: %w", err)
+	}
+	if err := addPromoTotals(ctx, db, "", promos...); err != nil {
+		return nil, fmt.Errorf("error list promo codes: %w", err)
+	}
+	return promos, nil
+}
+
+// addPromoTotals заполняет TotalDiscount промокодов; code - единственный код promos или пустой
+func addPromoTotals(ctx context.Context, db *sql.DB, code string, promos ...*domain.PromoCode) error {
+	rows, err := db.QueryContext(ctx, promoTotalsQuery, domain.CancelledStatus, code)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	totals := make(map[string][]domain.Money)
+	for rows.Next() {
+		var promoCode string
+		var total domain.Money
+		if err := rows.Scan(&promoCode, &total.Currency, &total.Amount); err != nil {
+			return err
+		}
+		totals[promoCode] = append(totals[promoCode], total)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	for _, promo := range promos {
+		promo.TotalDiscount = totals[promo.Code]
+	}
+	return nil
+}
+
+func (e *EventRepository) ListPromoRedemptions(ctx context.Context, code string) ([]*domain.PromoRedemption, error) {
+	rows, err := e.reader(ctx).QueryContext(ctx, listRedemptionsQuery, code)
+	if err != nil {
+		return nil, fmt.Errorf("error list promo redemptions: %w", err)
+	}
+	defer rows.Close()
+
+	var redemptions []*domain.PromoRedemption
+	for rows.Next() {
+		var redemption domain.PromoRedemption
+		err := rows.Scan(&redemption.Code, &redemption.BookingId, &redemption.UserId, &redemption.EventId,
+			&redemption.Discount.Amount, &redemption.Discount.Currency, &redemption.BookingStatus, &redemption.RedeemedAt)
+		if err != nil {
+			return nil, fmt.Errorf("error list promo redemptions: %w", err)
+		}
+		redemptions = append(redemptions, &redemption)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error list promo redemptions: %w", err)
+	}
+	return redemptions, nil
+}
+
+// promoDiscount проверяет промокод брони и возвращает скидку с booking.Price.
+// Строка промокода блокируется до конца транзакции BookEvent, поэтому параллельные
+// брони с одним кодом не превысят лимит и не дадут пользователю второе использование.
+func promoDiscount(ctx context.Context, tx *sql.Tx, booking *domain.Booking) (domain.Money, error) {
+	var code string
+	if err := tx.QueryRowContext(ctx, lockPromoCodeQuery, booking.PromoCode).Scan(&code); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.Money{}, domain.ErrPromoCodeNotFound
+		}
+		return domain.Money{}, fmt.Errorf("failed to lock promo code: %w", err)
+	}
+	promo, err := scanPromoCode(tx.QueryRowContext(ctx, getPromoCodeQuery, domain.CancelledStatus, code))
+	if err != nil {
+		return domain.Money{}, fmt.Errorf("failed to get promo code: %w", err)
+	}
+	var used bool
+	err = tx.QueryRowContext(ctx, userUsedPromoQuery, code, booking.UserId, domain.CancelledStatus).Scan(&used)
+	if err != nil {
+		return domain.Money{}, fmt.Errorf("failed to check promo code usage: %w", err)
+	}
+	return promo.Redeem(booking.EventId, booking.Price, booking.Date, used)
+}
+
+func scanPromoCode(row interface{ Scan(dest ...any) error }) (*domain.PromoCode, error) {
+	var promo domain.PromoCode
+	var value float64
+	var currency sql.NullString
+	var validFrom, validUntil sql.NullTime
+	err := row.Scan(&promo.Code, &promo.EventId, &promo.DiscountType, &value, &currency, &promo.MaxUses,
+		&validFrom, &validUntil, &promo.CreatedAt, &promo.Uses)
+	if err != nil {
+		return nil, err
+	}
+	if promo.DiscountType == domain.DiscountFixed {
+		promo.Amount = domain.Money{Amount: int64(math.Round(value)), Currency: currency.String}
+	} else {
+		promo.Percent = value
+	}
+	promo.ValidFrom = validFrom.Time
+	promo.ValidUntil = validUntil.Time
+	return &promo, nil
+}
+
+// promoValue - значение колонки value: процент скидки или сумма фиксированной скидки
+// в минимальных единицах
+func promoValue(promo *domain.PromoCode) float64 {
+	if promo.DiscountType == domain.DiscountFixed {
+		return float64(promo.Amount.Amount)
+	}
+	return promo.Percent
+}
+
+// nullTime - NULL для нулевого времени
+func nullTime(t time.Time) sql.NullTime {
+	return sql.NullTime{Time: t.UTC(), Valid: !t.IsZero()}
+}
@@ -0,0 +1,111 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"sync/atomic"
+	"time"
+)
+
+// replicaLagQuery возвращает отставание реплики в секундах. Если реплика применила все
+// полученные WAL, отставания нет, даже когда master давно ничего не писал. Но совпадение
+// LSN ничего не значит, если реплика отключилась от master и больше ничего не получает:
+// без активного WAL receiver запрос возвращает NULL.
+const replicaLagQuery = `SELECT CASE
+	WHEN NOT pg_is_in_recovery() THEN 0
+	WHEN NOT EXISTS (SELECT 1 FROM pg_stat_wal_receiver WHERE status = 'streaming') THEN NULL
+	WHEN pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0
+	ELSE COALESCE(EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp()), 0)
+END;`
+
+var errReplicaNotStreaming = errors.New("replica is not streaming WAL from master")
+
+// replicaPool раздает реплики для чтения по кругу, пропуская отстающие и недоступные
+type replicaPool struct {
+	replicas []*sql.DB
+	healthy  []atomic.Bool
+	next     atomic.Uint32
+	maxLag   time.Duration
+	// lag измеряет отставание реплики; подменяется в тестах
+	lag func(ctx context.Context, replica *sql.DB) (time.Duration, error)
+}
+
+func newReplicaPool(replicas []*sql.DB, maxLag time.Duration) *replicaPool {
+	return &replicaPool{
+		replicas: replicas,
+		healthy:  make([]atomic.Bool, len(replicas)),
+		maxLag:   maxLag,
+		lag:      replicaLag,
+	}
+}
+
+func replicaLag(ctx context.Context, replica *sql.DB) (time.Duration, error) {
+	var lagSeconds sql.NullFloat64
+	if err := replica.QueryRowContext(ctx, replicaLagQuery).Scan(&lagSeconds); err != nil {
+		return 0, err
+	}
+	if !lagSeconds.Valid {
+		return 0, errReplicaNotStreaming
+	}
+	return time.Duration(lagSeconds.Float64 * float64(time.Second)), nil
+}
+
+// pick возвращает здоровую реплику или nil, если читать нужно из master
+func (p *replicaPool) pick() *sql.DB {
+	count := len(p.replicas)
+	if count == 0 {
+		return nil
+	}
+
+	start := int(p.next.Add(1))
+	for i := 0; i < count; i++ {
+		idx := (start + i) % count
+		if p.healthy[idx].Load() {
+			return p.replicas[idx]
+		}
+	}
+	return nil
+}
+
+// start проверяет отставание реплик сразу и затем каждые interval, пока ctx не отменен.
+// До первой проверки реплики считаются нездоровыми и чтения идут в master.
+func (p *replicaPool) start(ctx context.Context, interval time.Duration) {
+	if len(p.replicas) == 0 {
+		return
+	}
+
+	p.checkLag(ctx)
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				p.checkLag(ctx)
+			}
+		}
+	}()
+}
+
+func (p *replicaPool) checkLag(ctx context.Context) {
+	for i, replica := range p.replicas {
+		checkCtx, cancel := context.WithTimeout(ctx, p.maxLag)
+		lag, err := p.lag(checkCtx, replica)
+		cancel()
+
+		healthy := err == nil && lag <= p.maxLag
+		if was := p.healthy[i].Swap(healthy); was != healthy {
+			if healthy {
+				log.Printf("Replica %d is back in rotation (lag %s)", i, lag)
+			} else if err != nil {
+				log.Printf("Replica %d removed from rotation: %v", i, err)
+			} else {
+				log.Printf("Replica %d removed from rotation: lag %s exceeds %s", i, lag, p.maxLag)
+			}
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestReplicaPool - пул из count реплик, отставание которых задает lags
+func newTestReplicaPool(count int, lags map[int]time.Duration, errs map[int]error) (*replicaPool, []*sql.DB) {
+	replicas := make([]*sql.DB, count)
+	index := make(map[*sql.DB]int, count)
+	for i := range replicas {
+		replicas[i] = &sql.DB{}
+		index[replicas[i]] = i
+	}
+
+	pool := newReplicaPool(replicas, time.Second)
+	pool.lag = func(ctx context.Context, replica *sql.DB) (time.Duration, error) {
+		i := index[replica]
+		return lags[i], errs[i]
+	}
+	return pool, replicas
+}
+
+func TestReplicaPool_PickRoundRobin(t *testing.T) {
+	pool, replicas := newTestReplicaPool(3, nil, nil)
+	pool.checkLag(context.Background())
+
+	picked := map[*sql.DB]int{}
+	for i := 0; i < 6; i++ {
+		picked[pool.pick()]++
+	}
+	for _, replica := range replicas {
+		assert.Equal(t, 2, picked[replica])
+	}
+}
+
+func TestReplicaPool_FallbackToMaster(t *testing.T) {
+	// Без реплик и до первой проверки чтения идут в master
+	empty, _ := newTestReplicaPool(0, nil, nil)
+	assert.Nil(t, empty.pick())
+
+	pool, _ := newTestReplicaPool(2, nil, nil)
+	assert.Nil(t, pool.pick())
+
+	pool, _ = newTestReplicaPool(2, map[int]time.Duration{0: time.Minute},
+		map[int]error{1: errReplicaNotStreaming})
+	pool.checkLag(context.Background())
+	assert.Nil(t, pool.pick())
+}
+
+func TestReplicaPool_CheckLag(t *testing.T) {
+	lags := map[int]time.Duration{0: 100 * time.Millisecond, 1: 5 * time.Second}
+	errs := map[int]error{2: errors.New("connection refused")}
+	pool, replicas := newTestReplicaPool(4, lags, errs)
+
+	pool.checkLag(context.Background())
+	assert.True(t, pool.healthy[0].Load())
+	assert.False(t, pool.healthy[1].Load(), "lag above maxLag")
+	assert.False(t, pool.healthy[2].Load(), "unreachable replica")
+	assert.True(t, pool.healthy[3].Load())
+	for i := 0; i < 4; i++ {
+		assert.NotSame(t, replicas[1], pool.pick())
+		assert.NotSame(t, replicas[2], pool.pick())
+	}
+
+	// Реплика, отключившаяся от master, выходит из ротации, догнавшая - возвращается
+	errs[0] = errReplicaNotStreaming
+	lags[1] = 0
+	pool.checkLag(context.Background())
+	assert.False(t, pool.healthy[0].Load())
+	assert.True(t, pool.healthy[1].Load())
+}
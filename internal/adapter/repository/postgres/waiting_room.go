@@ -0,0 +1,127 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/dontpanicw/EventBooker/internal/domain"
+)
+
+const (
+	saveWaitingRoomQuery = `INSERT INTO waiting_rooms (event_id, rate_per_minute, joined, last_admit_at, opened_at)
+								VALUES ($1, $2, 0, $3, $3)
+								ON CONFLICT (event_id) DO UPDATE SET rate_per_minute = EXCLUDED.rate_per_minute
+								RETURNING event_id, rate_per_minute, joined, last_admit_at, opened_at;`
+	getWaitingRoomQuery = `SELECT event_id, rate_per_minute, joined, last_admit_at, opened_at
+							FROM waiting_rooms WHERE event_id = $1;`
+	lockWaitingRoomQuery = `SELECT event_id, rate_per_minute, joined, last_admit_at, opened_at
+							FROM waiting_rooms WHERE event_id = $1 FOR UPDATE;`
+	joinWaitingRoomQuery   = `UPDATE waiting_rooms SET joined = $2, last_admit_at = $3 WHERE event_id = $1;`
+	deleteWaitingRoomQuery = `DELETE FROM waiting_rooms WHERE event_id = $1;`
+	getQueueTicketQuery    = `SELECT position, admit_at, COALESCE(booking_id, '')
+								FROM waiting_room_tickets WHERE event_id = $1 AND user_id = $2;`
+	saveQueueTicketQuery = `INSERT INTO waiting_room_tickets (event_id, user_id, position, admit_at)
+								VALUES ($1, $2, $3, $4)
+								ON CONFLICT (event_id, user_id) DO UPDATE SET
+									position = EXCLUDED.position, admit_at = EXCLUDED.admit_at, booking_id = NULL;`
+	useQueueTicketQuery = `UPDATE waiting_room_tickets SET booking_id = $4
+							WHERE event_id = $1 AND user_id = $2 AND position = $3 AND booking_id IS NULL;`
+)
+
+func (e *EventRepository) SaveWaitingRoom(ctx context.Context, room *domain.WaitingRoom) (*domain.WaitingRoom, error) {
+	saved, err := scanWaitingRoom(e.PostgresDB.Master.QueryRowContext(ctx, saveWaitingRoomQuery,
+		room.EventId, room.RatePerMinute, room.OpenedAt.UTC()))
+	if err != nil {
+		return nil, fmt.Errorf("error save waiting room: %w", err)
+	}
+	return saved, nil
+}
+
+// GetWaitingRoom читает из реплики, если ctx не требует иного: проверка очереди идет
+// при каждом бронировании, и несколько броней в первые миллисекунды после открытия
+// очереди допустимы
+func (e *EventRepository) GetWaitingRoom(ctx context.Context, eventID string) (*domain.WaitingRoom, error) {
+	room, err := scanWaitingRoom(e.reader(ctx).QueryRowContext(ctx, getWaitingRoomQuery, eventID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("error get waiting room: %w", domain.ErrWaitingRoomNotFound)
+		}
+		return nil, fmt.Errorf("error get waiting room: %w", err)
+	}
+	return room, nil
+}
+
+// JoinWaitingRoom блокирует строку очереди, поэтому два пользователя не получат одну позицию,
+// а повторные входы одного пользователя - два места
+func (e *EventRepository) JoinWaitingRoom(ctx context.Context, eventID, userID string, now time.Time) (*domain.QueueTicket, error) {
+	var ticket domain.QueueTicket
+	err := e.PostgresDB.WithTx(ctx, func(tx *sql.Tx) error {
+		room, err := scanWaitingRoom(tx.QueryRowContext(ctx, lockWaitingRoomQuery, eventID))
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return domain.ErrWaitingRoomNotFound
+			}
+			return err
+		}
+
+		ticket = domain.QueueTicket{EventId: eventID, UserId: userID, OpenedAt: room.OpenedAt}
+		err = tx.QueryRowContext(ctx, getQueueTicketQuery, eventID, userID).Scan(&ticket.Position, &ticket.AdmitAt, &ticket.BookingId)
+		if err == nil && ticket.Active(now) {
+			return nil
+		}
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+
+		ticket = room.Enqueue(userID, now)
+		if _, err := tx.ExecContext(ctx, joinWaitingRoomQuery, eventID, room.Joined, room.LastAdmitAt.UTC()); err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, saveQueueTicketQuery, eventID, userID, ticket.Position, ticket.AdmitAt.UTC())
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error join waiting room: %w", err)
+	}
+	return &ticket, nil
+}
+
+// useAdmission расходует допуск брони booking из очереди; место должно быть тем же, что
+// подписано в токене, и еще не использованным
+func useAdmission(ctx context.Context, tx *sql.Tx, booking *domain.Booking) error {
+	ticket := booking.Admission
+	result, err := tx.ExecContext(ctx, useQueueTicketQuery, ticket.EventId, ticket.UserId, ticket.Position, booking.Id)
+	if err != nil {
+		return fmt.Errorf("failed to use admission: %w", err)
+	}
+	if used, err := result.RowsAffected(); err != nil {
+		return fmt.Errorf("failed to use admission: %w", err)
+	} else if used == 0 {
+		return fmt.Errorf("failed to use admission: %w", domain.ErrAdmissionTokenUsed)
+	}
+	return nil
+}
+
+func (e *EventRepository) DeleteWaitingRoom(ctx context.Context, eventID string) error {
+	result, err := e.PostgresDB.Master.ExecContext(ctx, deleteWaitingRoomQuery, eventID)
+	if err != nil {
+		return fmt.Errorf("error delete waiting room: %w", err)
+	}
+	if deleted, err := result.RowsAffected(); err != nil {
+		return fmt.Errorf("error delete waiting room: %w", err)
+	} else if deleted == 0 {
+		return fmt.Errorf("error delete waiting room: %w", domain.ErrWaitingRoomNotFound)
+	}
+	return nil
+}
+
+func scanWaitingRoom(row interface{ Scan(dest ...any) error }) (*domain.WaitingRoom, error) {
+	var room domain.WaitingRoom
+	if err := row.Scan(&room.EventId, &room.RatePerMinute, &room.Joined, &room.LastAdmitAt, &room.OpenedAt); err != nil {
+		return nil, err
+	}
+	return &room, nil
+}
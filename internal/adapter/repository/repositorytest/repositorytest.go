@@ -0,0 +1,1469 @@
+// Package repositorytest - общий набор тестов на соответствие port.Repository.
+// Каждая реализация хранилища прогоняет его в своих тестах, чтобы их семантика не расходилась.
+package repositorytest
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dontpanicw/EventBooker/internal/domain"
+	"github.com/dontpanicw/EventBooker/internal/port"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Factory возвращает пустое хранилище для одного теста
+type Factory func(t *testing.T) port.Repository
+
+// Run прогоняет набор тестов против хранилища из newRepository
+func Run(t *testing.T, newRepository Factory) {
+	tests := []struct {
+		name string
+		test func(t *testing.T, repo port.Repository)
+	}{
+		{"CreateAndGetEvent", testCreateAndGetEvent},
+		{"CreateEventDuplicate", testCreateEventDuplicate},
+		{"GetEventNotFound", testGetEventNotFound},
+		{"ListEventsOrderedByDate", testListEventsOrderedByDate},
+		{"ListEventsPagination", testListEventsPagination},
+		{"ListEventsSortByPrice", testListEventsSortByPrice},
+		{"ListEventsFilters", testListEventsFilters},
+		{"SearchEvents", testSearchEvents},
+		{"BookEventDecrementsTickets", testBookEventDecrementsTickets},
+		{"BookEventSoldOut", testBookEventSoldOut},
+		{"BookEventNotFound", testBookEventNotFound},
+		{"ConcurrentBooking", testConcurrentBooking},
+		{"ConfirmBooking", testConfirmBooking},
+		{"CancelBooking", testCancelBooking},
+		{"TransitionNotFound", testTransitionNotFound},
+		{"GetBookingNotFound", testGetBookingNotFound},
+		{"ListBookings", testListBookings},
+		{"IncrementAvailableTickets", testIncrementAvailableTickets},
+		{"AvailableTicketsWithinCapacity", testAvailableTicketsWithinCapacity},
+		{"SoldAndHeldCounts", testSoldAndHeldCounts},
+		{"InventoryReconciliation", testInventoryReconciliation},
+		{"Versions", testVersions},
+		{"UpdateEvent", testUpdateEvent},
+		{"AuditLog", testAuditLog},
+		{"IdempotencyKeys", testIdempotencyKeys},
+		{"WaitingRooms", testWaitingRooms},
+		{"Ballots", testBallots},
+		{"PromoCodes", testPromoCodes},
+		{"PricePhases", testPricePhases},
+		{"FeeRules", testFeeRules},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.test(t, newRepository(t))
+		})
+	}
+}
+
+// baseDate - время с точностью до микросекунд в UTC, которое сохраняют все хранилища
+var baseDate = time.Date(2026, time.March, 1, 19, 0, 0, 0, time.UTC)
+
+// rub - сумма в копейках
+func rub(amount int64) domain.Money {
+	return domain.Money{Amount: amount, Currency: "RUB"}
+}
+
+func percent(value float64) *float64 {
+	return &value
+}
+
+func createEvent(t *testing.T, repo port.Repository, tickets uint32, date time.Time) *domain.Event {
+	t.Helper()
+
+	event := &domain.Event{
+		Id:               uuid.New().String(),
+		Name:             "Concert",
+		Description:      "Evening concert",
+		Price:            rub(150000),
+		AvailableTickets: tickets,
+		Capacity:         tickets,
+		Date:             date,
+		SaleMode:         domain.SaleModeFirstCome,
+	}
+	_, err := repo.CreateEvent(context.Background(), event)
+	require.NoError(t, err)
+	return event
+}
+
+func newBooking(eventID string) *domain.Booking {
+	return &domain.Booking{
+		Id:      uuid.New().String(),
+		UserId:  uuid.New().String(),
+		EventId: eventID,
+		Status:  domain.PendingStatus,
+		Date:    baseDate,
+	}
+}
+
+func bookEvent(t *testing.T, repo port.Repository, eventID string) *domain.Booking {
+	t.Helper()
+
+	booking := newBooking(eventID)
+	_, err := repo.BookEvent(context.Background(), booking)
+	require.NoError(t, err)
+	return booking
+}
+
+func availableTickets(t *testing.T, repo port.Repository, eventID string) uint32 {
+	t.Helper()
+
+	event, err := repo.GetEvent(context.Background(), eventID)
+	require.NoError(t, err)
+	return event.AvailableTickets
+}
+
+func testCreateAndGetEvent(t *testing.T, repo port.Repository) {
+	event := createEvent(t, repo, 10, baseDate)
+
+	got, err := repo.GetEvent(context.Background(), event.Id)
+	require.NoError(t, err)
+	assert.Equal(t, event.Id, got.Id)
+	assert.Equal(t, event.Name, got.Name)
+	assert.Equal(t, event.Description, got.Description)
+	assert.Equal(t, event.IsFree, got.IsFree)
+	assert.Equal(t, event.Price, got.Price)
+	assert.Equal(t, event.AvailableTickets, got.AvailableTickets)
+	assert.Equal(t, event.Capacity, got.Capacity)
+	assert.True(t, event.Date.Equal(got.Date), "date %s != %s", got.Date, event.Date)
+}
+
+func testCreateEventDuplicate(t *testing.T, repo port.Repository) {
+	event := createEvent(t, repo, 10, baseDate)
+
+	_, err := repo.CreateEvent(context.Background(), event)
+	assert.Error(t, err)
+}
+
+func testGetEventNotFound(t *testing.T, repo port.Repository) {
+	_, err := repo.GetEvent(context.Background(), uuid.New().String())
+	assert.ErrorIs(t, err, domain.ErrEventNotFound)
+}
+
+// listEvents запрашивает страницу так же, как usecases: с разрешенным фильтром
+func listEvents(t *testing.T, repo port.Repository, filter domain.EventFilter) *domain.EventPage {
+	t.Helper()
+
+	filter, err := filter.Resolve(baseDate)
+	require.NoError(t, err)
+	page, err := repo.ListEvents(context.Background(), filter)
+	require.NoError(t, err)
+	return page
+}
+
+func eventIds(events []*domain.Event) []string {
+	ids := make([]string, 0, len(events))
+	for _, event := range events {
+		ids = append(ids, event.Id)
+	}
+	return ids
+}
+
+// allPages проходит все страницы фильтра по курсорам и возвращает id событий по порядку
+func allPages(t *testing.T, repo port.Repository, filter domain.EventFilter) []string {
+	t.Helper()
+
+	var ids []string
+	for pages := 0; ; pages++ {
+		require.Less(t, pages, 20, "pagination does not terminate")
+		page := listEvents(t, repo, filter)
+		require.LessOrEqual(t, len(page.Events), filter.Limit)
+		ids = append(ids, eventIds(page.Events)...)
+		if page.NextCursor == "" {
+			return ids
+		}
+		cursor, err := domain.DecodeEventCursor(page.NextCursor)
+		require.NoError(t, err)
+		filter.After = cursor
+	}
+}
+
+func testListEventsOrderedByDate(t *testing.T, repo port.Repository) {
+	later := createEvent(t, repo, 1, baseDate.Add(48*time.Hour))
+	earlier := createEvent(t, repo, 1, baseDate)
+	middle := createEvent(t, repo, 1, baseDate.Add(24*time.Hour))
+
+	page := listEvents(t, repo, domain.EventFilter{})
+	assert.Equal(t, []string{earlier.Id, middle.Id, later.Id}, eventIds(page.Events))
+	assert.Empty(t, page.NextCursor)
+
+	page = listEvents(t, repo, domain.EventFilter{Sort: domain.SortDateDesc})
+	assert.Equal(t, []string{later.Id, middle.Id, earlier.Id}, eventIds(page.Events))
+}
+
+func testListEventsPagination(t *testing.T, repo port.Repository) {
+	var expected []string
+	for i := 0; i < 5; i++ {
+		expected = append(expected, createEvent(t, repo, 1, baseDate.Add(time.Duration(i)*time.Hour)).Id)
+	}
+	// Два события в одно время: порядок между ними задает id, и курсор не теряет ни одно
+	sameTime := []string{
+		createEvent(t, repo, 1, baseDate.Add(10*time.Hour)).Id,
+		createEvent(t, repo, 1, baseDate.Add(10*time.Hour)).Id,
+	}
+	if sameTime[0] > sameTime[1] {
+		sameTime[0], sameTime[1] = sameTime[1], sameTime[0]
+	}
+	expected = append(expected, sameTime...)
+
+	assert.Equal(t, expected, allPages(t, repo, domain.EventFilter{Limit: 2}))
+
+	reversed := make([]string, len(expected))
+	for i, id := range expected {
+		reversed[len(expected)-1-i] = id
+	}
+	assert.Equal(t, reversed, allPages(t, repo, domain.EventFilter{Sort: domain.SortDateDesc, Limit: 3}))
+
+	// Последняя полная страница не оставляет пустую следующую
+	page := listEvents(t, repo, domain.EventFilter{Limit: len(expected)})
+	assert.Len(t, page.Events, len(expected))
+	assert.Empty(t, page.NextCursor)
+}
+
+func testListEventsSortByPrice(t *testing.T, repo port.Repository) {
+	ctx := context.Background()
+	create := func(price domain.Money, isFree bool) string {
+		event := &domain.Event{
+			Id:               uuid.New().String(),
+			Name:             "Concert",
+			IsFree:           isFree,
+			Price:            price,
+			AvailableTickets: 1,
+			Capacity:         1,
+			Date:             baseDate,
+			SaleMode:         domain.SaleModeFirstCome,
+		}
+		_, err := repo.CreateEvent(ctx, event)
+		require.NoError(t, err)
+		return event.Id
+	}
+	free := create(rub(0), true)
+	expensive := create(rub(300000), false)
+	cheap := create(rub(50000), false)
+
+	// У бесплатного события цены нет: иначе сортировка в хранилище и курсор (SortPrice) разошлись бы
+	_, err := repo.CreateEvent(ctx, &domain.Event{
+		Id: uuid.New().String(), Name: "Concert", IsFree: true, Price: rub(80000),
+		AvailableTickets: 1, Capacity: 1, Date: baseDate, SaleMode: domain.SaleModeFirstCome,
+	})
+	assert.Error(t, err)
+
+	assert.Equal(t, []string{free, cheap, expensive}, allPages(t, repo, domain.EventFilter{Sort: domain.SortPriceAsc, Limit: 1}))
+	assert.Equal(t, []string{expensive, cheap, free}, allPages(t, repo, domain.EventFilter{Sort: domain.SortPriceDesc, Limit: 2}))
+
+	minPrice, maxPrice := int64(10000), int64(100000)
+	page := listEvents(t, repo, domain.EventFilter{MinPrice: &minPrice, MaxPrice: &maxPrice})
+	assert.Equal(t, []string{cheap}, eventIds(page.Events))
+
+	isFree, isPaid := true, false
+	page = listEvents(t, repo, domain.EventFilter{IsFree: &isFree})
+	assert.Equal(t, []string{free}, eventIds(page.Events))
+	page = listEvents(t, repo, domain.EventFilter{IsFree: &isPaid, Sort: domain.SortPriceAsc})
+	assert.Equal(t, []string{cheap, expensive}, eventIds(page.Events))
+
+	// Цены в разных валютах не сравниваются: фильтр по цене указывают вместе с валютой
+	euro := create(domain.Money{Amount: 5000, Currency: "EUR"}, false)
+	page = listEvents(t, repo, domain.EventFilter{Currency: "EUR"})
+	assert.Equal(t, []string{euro}, eventIds(page.Events))
+	page = listEvents(t, repo, domain.EventFilter{Currency: "RUB", MinPrice: &minPrice, MaxPrice: &maxPrice})
+	assert.Equal(t, []string{cheap}, eventIds(page.Events))
+}
+
+func testListEventsFilters(t *testing.T, repo port.Repository) {
+	past := createEvent(t, repo, 1, baseDate.Add(-24*time.Hour))
+	soldOut := createEvent(t, repo, 1, baseDate.Add(24*time.Hour))
+	bookEvent(t, repo, soldOut.Id)
+	upcoming := createEvent(t, repo, 1, baseDate.Add(48*time.Hour))
+
+	// Resolve в listEvents считает текущим моментом baseDate
+	page := listEvents(t, repo, domain.EventFilter{Period: domain.PeriodUpcoming})
+	assert.Equal(t, []string{soldOut.Id, upcoming.Id}, eventIds(page.Events))
+
+	page = listEvents(t, repo, domain.EventFilter{Period: domain.PeriodPast})
+	assert.Equal(t, []string{past.Id}, eventIds(page.Events))
+
+	page = listEvents(t, repo, domain.EventFilter{OnlyAvailable: true})
+	assert.Equal(t, []string{past.Id, upcoming.Id}, eventIds(page.Events))
+
+	// DateFrom включается, DateTo - нет
+	page = listEvents(t, repo, domain.EventFilter{DateFrom: soldOut.Date, DateTo: upcoming.Date})
+	assert.Equal(t, []string{soldOut.Id}, eventIds(page.Events))
+
+	page = listEvents(t, repo, domain.EventFilter{DateFrom: baseDate.Add(72 * time.Hour)})
+	assert.Empty(t, page.Events)
+	assert.NotNil(t, page.Events)
+}
+
+func createNamedEvent(t *testing.T, repo port.Repository, name, description string) *domain.Event {
+	t.Helper()
+
+	event := &domain.Event{
+		Id:               uuid.New().String(),
+		Name:             name,
+		Description:      description,
+		IsFree:           true,
+		AvailableTickets: 1,
+		Capacity:         1,
+		Date:             baseDate,
+		SaleMode:         domain.SaleModeFirstCome,
+	}
+	_, err := repo.CreateEvent(context.Background(), event)
+	require.NoError(t, err)
+	return event
+}
+
+func searchResultIds(results []*domain.EventSearchResult) []string {
+	ids := make([]string, 0, len(results))
+	for _, result := range results {
+		ids = append(ids, result.Event.Id)
+	}
+	return ids
+}
+
+func testSearchEvents(t *testing.T, repo port.Repository) {
+	ctx := context.Background()
+	jazz := createNamedEvent(t, repo, "Джазовый концерт", "Вечер живой музыки <в клубе> & баре")
+	rock := createNamedEvent(t, repo, "Rock festival", "Открытый концерт под открытым небом")
+	createNamedEvent(t, repo, "Выставка", "Современное искусство")
+
+	// Совпадение в названии релевантнее совпадения в описании
+	results, err := repo.SearchEvents(ctx, "концерт", 10)
+	require.NoError(t, err)
+	assert.Equal(t, []string{jazz.Id, rock.Id}, searchResultIds(results))
+	assert.Greater(t, results[0].Rank, results[1].Rank)
+	assert.Contains(t, results[0].NameHighlight, domain.HighlightStart+"концерт"+domain.HighlightStop)
+	assert.Contains(t, results[1].DescriptionHighlight, domain.HighlightStart+"концерт"+domain.HighlightStop)
+
+	results, err = repo.SearchEvents(ctx, "концерт", 1)
+	require.NoError(t, err)
+	assert.Equal(t, []string{jazz.Id}, searchResultIds(results))
+
+	results, err = repo.SearchEvents(ctx, "festival", 10)
+	require.NoError(t, err)
+	require.Equal(t, []string{rock.Id}, searchResultIds(results))
+	assert.Equal(t, rock.Name, results[0].Event.Name)
+	assert.Contains(t, results[0].NameHighlight, domain.HighlightStart+"festival"+domain.HighlightStop)
+
+	// Все слова запроса обязательны
+	results, err = repo.SearchEvents(ctx, "rock концерт", 10)
+	require.NoError(t, err)
+	assert.Equal(t, []string{rock.Id}, searchResultIds(results))
+
+	// Разметка из описания экранируется, в подсветке остается только <mark>
+	results, err = repo.SearchEvents(ctx, "музыки", 10)
+	require.NoError(t, err)
+	require.Equal(t, []string{jazz.Id}, searchResultIds(results))
+	assert.Contains(t, results[0].DescriptionHighlight, "&lt;в клубе&gt;")
+	assert.NotContains(t, results[0].DescriptionHighlight, "<в")
+
+	results, err = repo.SearchEvents(ctx, "опера", 10)
+	require.NoError(t, err)
+	assert.Empty(t, results)
+}
+
+func testBookEventDecrementsTickets(t *testing.T, repo port.Repository) {
+	event := createEvent(t, repo, 2, baseDate)
+
+	booking := bookEvent(t, repo, event.Id)
+
+	assert.Equal(t, uint32(1), availableTickets(t, repo, event.Id))
+	got, err := repo.GetBooking(context.Background(), booking.Id)
+	require.NoError(t, err)
+	assert.Equal(t, booking.UserId, got.UserId)
+	assert.Equal(t, event.Id, got.EventId)
+	assert.Equal(t, domain.PendingStatus, got.Status)
+}
+
+func testBookEventSoldOut(t *testing.T, repo port.Repository) {
+	event := createEvent(t, repo, 1, baseDate)
+
+	// Остаток мест после брони - из ее транзакции, по нему публикуется event.sold_out
+	booked, err := repo.BookEvent(context.Background(), newBooking(event.Id))
+	require.NoError(t, err)
+	assert.Equal(t, event.Id, booked.Id)
+	assert.Equal(t, event.Name, booked.Name)
+	assert.True(t, booked.Date.Equal(event.Date), "date %s, want %s", booked.Date, event.Date)
+	assert.Equal(t, uint32(0), booked.AvailableTickets)
+
+	booking := newBooking(event.Id)
+	_, err = repo.BookEvent(context.Background(), booking)
+
+	assert.ErrorIs(t, err, domain.ErrNoTicketsAvailable)
+	assert.Equal(t, uint32(0), availableTickets(t, repo, event.Id))
+	_, err = repo.GetBooking(context.Background(), booking.Id)
+	assert.ErrorIs(t, err, domain.ErrBookingNotFound)
+}
+
+func testBookEventNotFound(t *testing.T, repo port.Repository) {
+	_, err := repo.BookEvent(context.Background(), newBooking(uuid.New().String()))
+	assert.ErrorIs(t, err, domain.ErrEventNotFound)
+}
+
+func testConcurrentBooking(t *testing.T, repo port.Repository) {
+	const tickets, buyers = 5, 20
+	event := createEvent(t, repo, tickets, baseDate)
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		booked   int
+		soldOut  int
+		failures []error
+	)
+	for i := 0; i < buyers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := repo.BookEvent(context.Background(), newBooking(event.Id))
+
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case err == nil:
+				booked++
+			case errors.Is(err, domain.ErrNoTicketsAvailable):
+				soldOut++
+			default:
+				failures = append(failures, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Empty(t, failures)
+	assert.Equal(t, tickets, booked)
+	assert.Equal(t, buyers-tickets, soldOut)
+	assert.Equal(t, uint32(0), availableTickets(t, repo, event.Id))
+}
+
+func testConfirmBooking(t *testing.T, repo port.Repository) {
+	ctx := context.Background()
+	event := createEvent(t, repo, 1, baseDate)
+	booking := bookEvent(t, repo, event.Id)
+
+	require.NoError(t, repo.ConfirmBooking(ctx, booking.Id, 0))
+
+	got, err := repo.GetBooking(ctx, booking.Id)
+	require.NoError(t, err)
+	assert.Equal(t, domain.ConfirmedStatus, got.Status)
+
+	// Оплаченную бронь нельзя ни оплатить повторно, ни отменить
+	assert.ErrorIs(t, repo.ConfirmBooking(ctx, booking.Id, 0), domain.ErrBookingNotPending)
+	assert.ErrorIs(t, repo.CancelBooking(ctx, booking.Id), domain.ErrBookingNotPending)
+}
+
+func testCancelBooking(t *testing.T, repo port.Repository) {
+	ctx := context.Background()
+	event := createEvent(t, repo, 1, baseDate)
+	booking := bookEvent(t, repo, event.Id)
+
+	require.NoError(t, repo.CancelBooking(ctx, booking.Id))
+
+	got, err := repo.GetBooking(ctx, booking.Id)
+	require.NoError(t, err)
+	assert.Equal(t, domain.CancelledStatus, got.Status)
+	// Отмена возвращает место в продажу
+	assert.Equal(t, uint32(1), availableTickets(t, repo, event.Id))
+
+	// Отмененную бронь нельзя оплатить
+	assert.ErrorIs(t, repo.ConfirmBooking(ctx, booking.Id, 0), domain.ErrBookingNotPending)
+}
+
+func testTransitionNotFound(t *testing.T, repo port.Repository) {
+	ctx := context.Background()
+
+	assert.ErrorIs(t, repo.ConfirmBooking(ctx, uuid.New().String(), 0), domain.ErrBookingNotFound)
+	assert.ErrorIs(t, repo.CancelBooking(ctx, uuid.New().String()), domain.ErrBookingNotFound)
+}
+
+func testGetBookingNotFound(t *testing.T, repo port.Repository) {
+	_, err := repo.GetBooking(context.Background(), uuid.New().String())
+	assert.ErrorIs(t, err, domain.ErrBookingNotFound)
+}
+
+func testListBookings(t *testing.T, repo port.Repository) {
+	ctx := context.Background()
+	event := createEvent(t, repo, 10, baseDate)
+	userID := uuid.New().String()
+
+	var ids []string
+	for i := 0; i < 4; i++ {
+		booking := newBooking(event.Id)
+		booking.UserId = userID
+		booking.Date = baseDate.Add(time.Duration(i) * time.Minute)
+		_, err := repo.BookEvent(ctx, booking)
+		require.NoError(t, err)
+		ids = append(ids, booking.Id)
+	}
+	require.NoError(t, repo.ConfirmBooking(ctx, ids[1], 0))
+	bookEvent(t, repo, event.Id) // бронь другого пользователя
+
+	list := func(filter domain.BookingFilter) *domain.BookingPage {
+		t.Helper()
+		filter, err := filter.Resolve()
+		require.NoError(t, err)
+		page, err := repo.ListBookings(ctx, filter)
+		require.NoError(t, err)
+		return page
+	}
+	bookingIds := func(page *domain.BookingPage) []string {
+		ids := make([]string, 0, len(page.Bookings))
+		for _, b := range page.Bookings {
+			ids = append(ids, b.Booking.Id)
+		}
+		return ids
+	}
+
+	// Новые брони первыми, вместе с мероприятием и его счетчиками
+	page := list(domain.BookingFilter{UserId: userID})
+	assert.Equal(t, []string{ids[3], ids[2], ids[1], ids[0]}, bookingIds(page))
+	assert.Empty(t, page.NextCursor)
+	first := page.Bookings[0]
+	assert.Equal(t, userID, first.Booking.UserId)
+	assert.Equal(t, domain.PendingStatus, first.Booking.Status)
+	assert.True(t, first.Booking.Date.Equal(baseDate.Add(3*time.Minute)))
+	assert.Equal(t, event.Name, first.Event.Name)
+	assert.Equal(t, uint32(1), first.Event.Sold)
+	assert.Equal(t, uint32(4), first.Event.Held)
+
+	page = list(domain.BookingFilter{UserId: userID, Status: domain.ConfirmedStatus})
+	assert.Equal(t, []string{ids[1]}, bookingIds(page))
+
+	page = list(domain.BookingFilter{UserId: userID, Limit: 3})
+	assert.Equal(t, []string{ids[3], ids[2], ids[1]}, bookingIds(page))
+	require.NotEmpty(t, page.NextCursor)
+	cursor, err := domain.DecodeBookingCursor(page.NextCursor)
+	require.NoError(t, err)
+	page = list(domain.BookingFilter{UserId: userID, Limit: 3, After: cursor})
+	assert.Equal(t, []string{ids[0]}, bookingIds(page))
+	assert.Empty(t, page.NextCursor)
+
+	page = list(domain.BookingFilter{UserId: uuid.New().String()})
+	assert.Empty(t, page.Bookings)
+	assert.NotNil(t, page.Bookings)
+
+	// Брони мероприятия: все пользователи, но только это мероприятие
+	other := createEvent(t, repo, 10, baseDate)
+	otherBooking := newBooking(other.Id)
+	otherBooking.UserId = userID
+	_, err = repo.BookEvent(ctx, otherBooking)
+	require.NoError(t, err)
+
+	page = list(domain.BookingFilter{EventId: event.Id})
+	assert.Len(t, page.Bookings, 5)
+	assert.NotContains(t, bookingIds(page), otherBooking.Id)
+
+	page = list(domain.BookingFilter{EventId: other.Id, UserId: userID})
+	assert.Equal(t, []string{otherBooking.Id}, bookingIds(page))
+	assert.Equal(t, other.Id, page.Bookings[0].Event.Id)
+}
+
+func testIncrementAvailableTickets(t *testing.T, repo port.Repository) {
+	ctx := context.Background()
+	event := createEvent(t, repo, 2, baseDate)
+	bookEvent(t, repo, event.Id)
+	bookEvent(t, repo, event.Id)
+
+	require.NoError(t, repo.IncrementAvailableTickets(ctx, event.Id))
+	assert.Equal(t, uint32(1), availableTickets(t, repo, event.Id))
+
+	require.NoError(t, repo.AddAvailableTickets(ctx, event.Id))
+	assert.Equal(t, uint32(2), availableTickets(t, repo, event.Id))
+
+	assert.ErrorIs(t, repo.IncrementAvailableTickets(ctx, uuid.New().String()), domain.ErrEventNotFound)
+	assert.ErrorIs(t, repo.AddAvailableTickets(ctx, uuid.New().String()), domain.ErrEventNotFound)
+}
+
+func testAvailableTicketsWithinCapacity(t *testing.T, repo port.Repository) {
+	ctx := context.Background()
+
+	// Свободных мест не может быть больше вместимости
+	event := &domain.Event{
+		Id:               uuid.New().String(),
+		Name:             "Concert",
+		Price:            rub(150000),
+		AvailableTickets: 3,
+		Capacity:         2,
+		Date:             baseDate,
+		SaleMode:         domain.SaleModeFirstCome,
+	}
+	_, err := repo.CreateEvent(ctx, event)
+	assert.Error(t, err)
+
+	full := createEvent(t, repo, 1, baseDate)
+	assert.Error(t, repo.IncrementAvailableTickets(ctx, full.Id))
+	assert.Error(t, repo.AddAvailableTickets(ctx, full.Id))
+	assert.Equal(t, uint32(1), availableTickets(t, repo, full.Id))
+}
+
+func testSoldAndHeldCounts(t *testing.T, repo port.Repository) {
+	ctx := context.Background()
+	event := createEvent(t, repo, 5, baseDate)
+	confirmed := bookEvent(t, repo, event.Id)
+	bookEvent(t, repo, event.Id)
+	cancelled := bookEvent(t, repo, event.Id)
+	require.NoError(t, repo.ConfirmBooking(ctx, confirmed.Id, 0))
+	require.NoError(t, repo.CancelBooking(ctx, cancelled.Id))
+	empty := createEvent(t, repo, 1, baseDate.Add(time.Hour))
+
+	got, err := repo.GetEvent(ctx, event.Id)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(5), got.Capacity)
+	assert.Equal(t, uint32(1), got.Sold)
+	assert.Equal(t, uint32(1), got.Held)
+	assert.Equal(t, uint32(3), got.AvailableTickets)
+
+	events := listEvents(t, repo, domain.EventFilter{}).Events
+	require.Len(t, events, 2)
+	assert.Equal(t, []uint32{1, 1}, []uint32{events[0].Sold, events[0].Held})
+	assert.Equal(t, empty.Id, events[1].Id)
+	assert.Equal(t, []uint32{0, 0}, []uint32{events[1].Sold, events[1].Held})
+}
+
+func testInventoryReconciliation(t *testing.T, repo port.Repository) {
+	ctx := context.Background()
+	inventory, ok := repo.(port.InventoryRepository)
+	require.True(t, ok, "%T does not implement port.InventoryRepository", repo)
+
+	consistent := createEvent(t, repo, 2, baseDate)
+	bookEvent(t, repo, consistent.Id)
+
+	drifted := createEvent(t, repo, 3, baseDate)
+	bookEvent(t, repo, drifted.Id)
+	// Лишний возврат места - счетчик больше, чем вместимость минус брони
+	require.NoError(t, repo.AddAvailableTickets(ctx, drifted.Id))
+
+	discrepancies, err := inventory.FindInventoryDiscrepancies(ctx)
+	require.NoError(t, err)
+	require.Len(t, discrepancies, 1)
+	assert.Equal(t, domain.InventoryDiscrepancy{
+		EventId:           drifted.Id,
+		Capacity:          3,
+		ActiveBookings:    1,
+		AvailableTickets:  3,
+		ExpectedAvailable: 2,
+	}, *discrepancies[0])
+
+	adjustment, err := inventory.RepairInventory(ctx, drifted.Id, "test")
+	require.NoError(t, err)
+	require.NotNil(t, adjustment)
+	assert.Equal(t, 3, adjustment.OldAvailable)
+	assert.Equal(t, 2, adjustment.NewAvailable)
+	assert.Equal(t, uint32(2), availableTickets(t, repo, drifted.Id))
+
+	// Исправление попадает в журнал аудита с причиной сверки
+	if auditLog, ok := repo.(port.AuditRepository); ok {
+		entries, err := auditLog.ListAuditLog(ctx, domain.AuditFilter{EventId: drifted.Id})
+		require.NoError(t, err)
+		require.NotEmpty(t, entries)
+		last := entries[len(entries)-1]
+		assert.Equal(t, domain.AuditTicketsChanged, last.Action)
+		assert.Equal(t, "test", last.Reason)
+		assert.JSONEq(t, `{"available_tickets":3}`, string(last.OldValue))
+		assert.JSONEq(t, `{"available_tickets":2}`, string(last.NewValue))
+	}
+
+	discrepancies, err = inventory.FindInventoryDiscrepancies(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, discrepancies)
+
+	// Повторный ремонт верного счетчика ничего не меняет
+	adjustment, err = inventory.RepairInventory(ctx, consistent.Id, "test")
+	require.NoError(t, err)
+	assert.Nil(t, adjustment)
+
+	_, err = inventory.RepairInventory(ctx, uuid.New().String(), "test")
+	assert.ErrorIs(t, err, domain.ErrEventNotFound)
+
+	// События, созданные через хранилище, получают вместимость при создании - проверять нечего
+	unverified, err := inventory.ListUnverifiedCapacities(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, unverified)
+	assert.ErrorIs(t, inventory.VerifyCapacity(ctx, consistent.Id), domain.ErrUnverifiedCapacityNotFound)
+}
+
+func testVersions(t *testing.T, repo port.Repository) {
+	ctx := context.Background()
+	event := createEvent(t, repo, 2, baseDate)
+	version := func() int64 {
+		got, err := repo.GetEvent(ctx, event.Id)
+		require.NoError(t, err)
+		return got.Version
+	}
+	assert.Equal(t, int64(1), version())
+
+	// Бронь, оплата и отмена меняют счетчики события, поэтому поднимают и его версию
+	booking := bookEvent(t, repo, event.Id)
+	assert.Equal(t, int64(2), version())
+	got, err := repo.GetBooking(ctx, booking.Id)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), got.Version)
+
+	assert.ErrorIs(t, repo.ConfirmBooking(ctx, booking.Id, 2), domain.ErrVersionMismatch)
+	got, err = repo.GetBooking(ctx, booking.Id)
+	require.NoError(t, err)
+	assert.Equal(t, domain.PendingStatus, got.Status)
+
+	require.NoError(t, repo.ConfirmBooking(ctx, booking.Id, 1))
+	assert.Equal(t, int64(3), version())
+	got, err = repo.GetBooking(ctx, booking.Id)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), got.Version)
+	// Повтор с новой версией упирается в статус, со старой - в версию
+	assert.ErrorIs(t, repo.ConfirmBooking(ctx, booking.Id, 2), domain.ErrBookingNotPending)
+	assert.ErrorIs(t, repo.ConfirmBooking(ctx, booking.Id, 1), domain.ErrVersionMismatch)
+
+	expired := bookEvent(t, repo, event.Id)
+	require.NoError(t, repo.CancelBooking(ctx, expired.Id))
+	assert.Equal(t, int64(5), version())
+	got, err = repo.GetBooking(ctx, expired.Id)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), got.Version)
+
+}
+
+func testUpdateEvent(t *testing.T, repo port.Repository) {
+	auditLog, ok := repo.(port.AuditRepository)
+	require.True(t, ok, "%T does not implement port.AuditRepository", repo)
+
+	ctx := port.WithActor(context.Background(), domain.Actor{Type: domain.ActorAdmin, Id: "admin-1"})
+	event := createEvent(t, repo, 2, baseDate)
+	bookEvent(t, repo, event.Id)
+
+	name, price := "Morning concert", int64(90000)
+	updated, err := repo.UpdateEvent(ctx, event.Id, 2, domain.EventUpdate{Name: &name, Price: &price})
+	require.NoError(t, err)
+	assert.Equal(t, name, updated.Name)
+	assert.Equal(t, event.Description, updated.Description)
+	assert.Equal(t, rub(price), updated.Price)
+	assert.Equal(t, int64(3), updated.Version)
+	assert.Equal(t, uint32(1), updated.Held)
+
+	got, err := repo.GetEvent(ctx, event.Id)
+	require.NoError(t, err)
+	assert.Equal(t, updated, got)
+
+	// Вторая правка по той же версии - чужое изменение не перезаписывается
+	other := "Night concert"
+	_, err = repo.UpdateEvent(ctx, event.Id, 2, domain.EventUpdate{Name: &other})
+	assert.ErrorIs(t, err, domain.ErrVersionMismatch)
+	got, err = repo.GetEvent(ctx, event.Id)
+	require.NoError(t, err)
+	assert.Equal(t, name, got.Name)
+
+	// Версия 0 - правка без проверки
+	updated, err = repo.UpdateEvent(ctx, event.Id, 0, domain.EventUpdate{Name: &other})
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), updated.Version)
+
+	_, err = repo.UpdateEvent(ctx, uuid.New().String(), 0, domain.EventUpdate{Name: &other})
+	assert.ErrorIs(t, err, domain.ErrEventNotFound)
+
+	// Платное событие не может остаться без цены; отклоненная правка ничего не меняет
+	zero, free, paid := int64(0), true, false
+	_, err = repo.UpdateEvent(ctx, event.Id, 0, domain.EventUpdate{Price: &zero})
+	assert.ErrorIs(t, err, domain.ErrInvalidEventUpdate)
+	_, err = repo.UpdateEvent(ctx, event.Id, 0, domain.EventUpdate{IsFree: &free})
+	require.NoError(t, err)
+	_, err = repo.UpdateEvent(ctx, event.Id, 0, domain.EventUpdate{IsFree: &paid})
+	assert.ErrorIs(t, err, domain.ErrInvalidEventUpdate)
+	got, err = repo.GetEvent(ctx, event.Id)
+	require.NoError(t, err)
+	assert.True(t, got.IsFree)
+	assert.Equal(t, int64(5), got.Version)
+
+	entries, err := auditLog.ListAuditLog(context.Background(), domain.AuditFilter{EventId: event.Id})
+	require.NoError(t, err)
+	var edits []*domain.AuditEntry
+	for _, entry := range entries {
+		if entry.Action == domain.AuditEventUpdated {
+			edits = append(edits, entry)
+		}
+	}
+	require.Len(t, edits, 3)
+	assert.Equal(t, "admin-1", edits[0].ActorId)
+	assert.JSONEq(t, `{"name":"Concert","description":"Evening concert","is_free":false,"price":{"amount":150000,"currency":"RUB"}}`, string(edits[0].OldValue))
+	assert.JSONEq(t, `{"name":"Morning concert","description":"Evening concert","is_free":false,"price":{"amount":90000,"currency":"RUB"}}`, string(edits[0].NewValue))
+}
+
+func testAuditLog(t *testing.T, repo port.Repository) {
+	auditLog, ok := repo.(port.AuditRepository)
+	require.True(t, ok, "%T does not implement port.AuditRepository", repo)
+
+	adminCtx := port.WithActor(context.Background(), domain.Actor{Type: domain.ActorAdmin, Id: "admin-1"})
+	event := &domain.Event{
+		Id:               uuid.New().String(),
+		Name:             "Concert",
+		Price:            rub(150000),
+		AvailableTickets: 2,
+		Capacity:         2,
+		Date:             baseDate,
+		SaleMode:         domain.SaleModeFirstCome,
+	}
+	_, err := repo.CreateEvent(adminCtx, event)
+	require.NoError(t, err)
+
+	paid := newBooking(event.Id)
+	userCtx := port.WithActor(context.Background(), domain.Actor{Type: domain.ActorUser, Id: paid.UserId})
+	_, err = repo.BookEvent(userCtx, paid)
+	require.NoError(t, err)
+	require.NoError(t, repo.ConfirmBooking(userCtx, paid.Id, 0))
+
+	expired := bookEvent(t, repo, event.Id)
+	consumerCtx := port.WithAuditReason(port.WithActor(context.Background(), domain.Actor{Type: domain.ActorConsumer}), "payment timeout")
+	require.NoError(t, repo.CancelBooking(consumerCtx, expired.Id))
+
+	// Неудачный переход статуса в журнал не попадает
+	assert.ErrorIs(t, repo.ConfirmBooking(userCtx, expired.Id, 0), domain.ErrBookingNotPending)
+
+	entries, err := auditLog.ListAuditLog(context.Background(), domain.AuditFilter{EventId: event.Id})
+	require.NoError(t, err)
+	actions := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		actions = append(actions, entry.Action)
+	}
+	assert.Equal(t, []string{
+		domain.AuditEventCreated,
+		domain.AuditBookingCreated, domain.AuditTicketsChanged,
+		domain.AuditBookingConfirmed,
+		domain.AuditBookingCreated, domain.AuditTicketsChanged,
+		domain.AuditBookingCancelled, domain.AuditTicketsChanged,
+	}, actions)
+	assert.Equal(t, domain.ActorAdmin, entries[0].ActorType)
+	assert.Equal(t, "admin-1", entries[0].ActorId)
+	assert.Equal(t, domain.ActorSystem, entries[4].ActorType)
+
+	cancelled, released := entries[6], entries[7]
+	assert.Equal(t, domain.AuditEntityBooking, cancelled.EntityType)
+	assert.Equal(t, expired.Id, cancelled.EntityId)
+	assert.Equal(t, expired.UserId, cancelled.UserId)
+	assert.Equal(t, domain.ActorConsumer, cancelled.ActorType)
+	assert.Equal(t, "payment timeout", cancelled.Reason)
+	assert.JSONEq(t, `{"status":"pending"}`, string(cancelled.OldValue))
+	assert.JSONEq(t, `{"status":"cancelled"}`, string(cancelled.NewValue))
+	assert.Equal(t, domain.AuditEntityEvent, released.EntityType)
+	assert.JSONEq(t, `{"available_tickets":0}`, string(released.OldValue))
+	assert.JSONEq(t, `{"available_tickets":1}`, string(released.NewValue))
+	assert.False(t, released.CreatedAt.IsZero())
+
+	entries, err = auditLog.ListAuditLog(context.Background(), domain.AuditFilter{BookingId: paid.Id})
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, domain.AuditBookingCreated, entries[0].Action)
+	assert.Nil(t, entries[0].OldValue)
+	assert.Equal(t, domain.AuditBookingConfirmed, entries[1].Action)
+	assert.Equal(t, domain.ActorUser, entries[1].ActorType)
+	assert.Equal(t, paid.UserId, entries[1].ActorId)
+
+	entries, err = auditLog.ListAuditLog(context.Background(), domain.AuditFilter{UserId: expired.UserId})
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, []string{domain.AuditBookingCreated, domain.AuditBookingCancelled}, []string{entries[0].Action, entries[1].Action})
+
+	entries, err = auditLog.ListAuditLog(context.Background(), domain.AuditFilter{EventId: event.Id, Limit: 3})
+	require.NoError(t, err)
+	assert.Len(t, entries, 3)
+}
+
+func testIdempotencyKeys(t *testing.T, repo port.Repository) {
+	ctx := context.Background()
+	keys, ok := repo.(port.IdempotencyRepository)
+	require.True(t, ok, "%T does not implement port.IdempotencyRepository", repo)
+
+	record := &domain.IdempotencyRecord{Key: uuid.New().String(), RequestHash: "hash-1", CreatedAt: baseDate}
+
+	existing, err := keys.ReserveIdempotencyKey(ctx, record)
+	require.NoError(t, err)
+	assert.Nil(t, existing)
+
+	// Повтор до завершения видит запись без ответа и не меняет ее
+	existing, err = keys.ReserveIdempotencyKey(ctx, &domain.IdempotencyRecord{Key: record.Key, RequestHash: "hash-2", CreatedAt: baseDate})
+	require.NoError(t, err)
+	require.NotNil(t, existing)
+	assert.Equal(t, "hash-1", existing.RequestHash)
+	assert.False(t, existing.Completed())
+
+	body := []byte(`{"booking_id":"booking-1"}`)
+	require.NoError(t, keys.CompleteIdempotencyKey(ctx, &domain.IdempotencyRecord{
+		Key: record.Key, StatusCode: 201, ContentType: "application/json", Body: body,
+	}))
+	existing, err = keys.ReserveIdempotencyKey(ctx, record)
+	require.NoError(t, err)
+	require.NotNil(t, existing)
+	assert.Equal(t, 201, existing.StatusCode)
+	assert.Equal(t, "application/json", existing.ContentType)
+	assert.Equal(t, body, existing.Body)
+	assert.True(t, existing.CreatedAt.Equal(baseDate))
+
+	// Освобожденный ключ можно занять снова
+	require.NoError(t, keys.ReleaseIdempotencyKey(ctx, record.Key))
+	existing, err = keys.ReserveIdempotencyKey(ctx, record)
+	require.NoError(t, err)
+	assert.Nil(t, existing)
+
+	fresh := &domain.IdempotencyRecord{Key: uuid.New().String(), RequestHash: "hash-3", CreatedAt: baseDate.Add(time.Hour)}
+	_, err = keys.ReserveIdempotencyKey(ctx, fresh)
+	require.NoError(t, err)
+
+	// Освобождение просроченного ключа не трогает запись, созданную после cutoff
+	require.NoError(t, keys.ReleaseExpiredIdempotencyKey(ctx, fresh.Key, baseDate.Add(time.Minute)))
+	existing, err = keys.ReserveIdempotencyKey(ctx, fresh)
+	require.NoError(t, err)
+	assert.NotNil(t, existing, "fresh key must survive expired release")
+	require.NoError(t, keys.ReleaseExpiredIdempotencyKey(ctx, record.Key, baseDate.Add(time.Minute)))
+	existing, err = keys.ReserveIdempotencyKey(ctx, record)
+	require.NoError(t, err)
+	assert.Nil(t, existing, "expired key must be released")
+
+	purged, err := keys.PurgeIdempotencyKeys(ctx, baseDate.Add(time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), purged)
+	existing, err = keys.ReserveIdempotencyKey(ctx, fresh)
+	require.NoError(t, err)
+	assert.NotNil(t, existing, "key created after the cutoff must survive purge")
+}
+
+func testWaitingRooms(t *testing.T, repo port.Repository) {
+	ctx := context.Background()
+	rooms, ok := repo.(port.WaitingRoomRepository)
+	require.True(t, ok, "%T does not implement port.WaitingRoomRepository", repo)
+
+	event := createEvent(t, repo, 10, baseDate)
+	_, err := rooms.GetWaitingRoom(ctx, event.Id)
+	assert.ErrorIs(t, err, domain.ErrWaitingRoomNotFound)
+	_, err = rooms.JoinWaitingRoom(ctx, event.Id, "user-1", baseDate)
+	assert.ErrorIs(t, err, domain.ErrWaitingRoomNotFound)
+
+	room, err := rooms.SaveWaitingRoom(ctx, &domain.WaitingRoom{EventId: event.Id, RatePerMinute: 60, OpenedAt: baseDate})
+	require.NoError(t, err)
+	assert.Equal(t, 60, room.RatePerMinute)
+	assert.Equal(t, int64(0), room.Joined)
+	assert.True(t, room.OpenedAt.Equal(baseDate))
+	assert.True(t, room.LastAdmitAt.Equal(baseDate))
+
+	// Толпа в момент открытия получает допуски через секунду друг за другом
+	ticket, err := rooms.JoinWaitingRoom(ctx, event.Id, "user-1", baseDate)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), ticket.Position)
+	assert.Equal(t, "user-1", ticket.UserId)
+	assert.True(t, ticket.AdmitAt.Equal(baseDate.Add(time.Second)), "admit at %v", ticket.AdmitAt)
+	assert.True(t, ticket.OpenedAt.Equal(baseDate))
+	ticket, err = rooms.JoinWaitingRoom(ctx, event.Id, "user-2", baseDate)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), ticket.Position)
+	assert.True(t, ticket.AdmitAt.Equal(baseDate.Add(2*time.Second)), "admit at %v", ticket.AdmitAt)
+
+	// Повторный вход возвращает прежнее место и не отодвигает следующих
+	again, err := rooms.JoinWaitingRoom(ctx, event.Id, "user-1", baseDate.Add(time.Second))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), again.Position)
+	assert.True(t, again.AdmitAt.Equal(baseDate.Add(time.Second)), "admit at %v", again.AdmitAt)
+
+	// Смена скорости не сбрасывает очередь
+	room, err = rooms.SaveWaitingRoom(ctx, &domain.WaitingRoom{EventId: event.Id, RatePerMinute: 120, OpenedAt: baseDate.Add(time.Hour)})
+	require.NoError(t, err)
+	assert.Equal(t, 120, room.RatePerMinute)
+	assert.Equal(t, int64(2), room.Joined)
+	assert.True(t, room.OpenedAt.Equal(baseDate))
+
+	// В пустую очередь пускают сразу; истекшее место заменяется новым в конце очереди
+	later := baseDate.Add(time.Hour)
+	ticket, err = rooms.JoinWaitingRoom(ctx, event.Id, "user-1", later)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), ticket.Position)
+	assert.True(t, ticket.AdmitAt.Equal(later), "admit at %v", ticket.AdmitAt)
+
+	stored, err := rooms.GetWaitingRoom(ctx, event.Id)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), stored.Joined)
+
+	// Допуск расходуется одной бронью; место, замененное новым, не подходит
+	booking := newBooking(event.Id)
+	booking.UserId = "user-1"
+	booking.Admission = &domain.QueueTicket{EventId: event.Id, UserId: "user-1", Position: 1}
+	_, err = repo.BookEvent(ctx, booking)
+	assert.ErrorIs(t, err, domain.ErrAdmissionTokenUsed)
+	booking.Admission = ticket
+	_, err = repo.BookEvent(ctx, booking)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(9), availableTickets(t, repo, event.Id))
+
+	second := newBooking(event.Id)
+	second.UserId = "user-1"
+	second.Admission = ticket
+	_, err = repo.BookEvent(ctx, second)
+	assert.ErrorIs(t, err, domain.ErrAdmissionTokenUsed)
+	assert.Equal(t, uint32(9), availableTickets(t, repo, event.Id))
+
+	// Израсходованный допуск не возвращается при повторном входе
+	ticket, err = rooms.JoinWaitingRoom(ctx, event.Id, "user-1", later)
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), ticket.Position)
+
+	require.NoError(t, rooms.DeleteWaitingRoom(ctx, event.Id))
+	assert.ErrorIs(t, rooms.DeleteWaitingRoom(ctx, event.Id), domain.ErrWaitingRoomNotFound)
+	_, err = rooms.GetWaitingRoom(ctx, event.Id)
+	assert.ErrorIs(t, err, domain.ErrWaitingRoomNotFound)
+
+	// Места закрытой очереди удаляются вместе с ней
+	_, err = rooms.SaveWaitingRoom(ctx, &domain.WaitingRoom{EventId: event.Id, RatePerMinute: 60, OpenedAt: later})
+	require.NoError(t, err)
+	second.Admission = ticket
+	_, err = repo.BookEvent(ctx, second)
+	assert.ErrorIs(t, err, domain.ErrAdmissionTokenUsed)
+	ticket, err = rooms.JoinWaitingRoom(ctx, event.Id, "user-1", later)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), ticket.Position)
+}
+
+func ballotIds(ballots []*domain.Ballot) []string {
+	ids := make([]string, 0, len(ballots))
+	for _, ballot := range ballots {
+		ids = append(ids, ballot.EventId)
+	}
+	return ids
+}
+
+func testBallots(t *testing.T, repo port.Repository) {
+	ctx := context.Background()
+	ballots, ok := repo.(port.BallotRepository)
+	require.True(t, ok, "%T does not implement port.BallotRepository", repo)
+
+	event := &domain.Event{
+		Id:               uuid.New().String(),
+		Name:             "Concert",
+		Price:            rub(150000),
+		AvailableTickets: 2,
+		Capacity:         2,
+		Date:             baseDate,
+		SaleMode:         domain.SaleModeBallot,
+	}
+	_, err := repo.CreateEvent(ctx, event)
+	require.NoError(t, err)
+
+	// Места разыгрываются: напрямую не бронируются
+	_, err = repo.BookEvent(ctx, newBooking(event.Id))
+	assert.ErrorIs(t, err, domain.ErrBallotEvent)
+	assert.Equal(t, uint32(2), availableTickets(t, repo, event.Id))
+
+	_, err = ballots.GetBallot(ctx, event.Id)
+	assert.ErrorIs(t, err, domain.ErrBallotNotFound)
+	err = ballots.EnterBallot(ctx, &domain.BallotEntry{EventId: event.Id, UserId: "user-0", Status: domain.BallotEntered, EnteredAt: baseDate})
+	assert.ErrorIs(t, err, domain.ErrBallotEntryClosed)
+
+	closesAt := baseDate.Add(time.Hour)
+	ballot, err := ballots.SaveBallot(ctx, &domain.Ballot{EventId: event.Id, EntryOpensAt: baseDate, EntryClosesAt: closesAt, Waitlist: true})
+	require.NoError(t, err)
+	assert.True(t, ballot.EntryClosesAt.Equal(closesAt))
+	assert.True(t, ballot.Waitlist)
+	assert.False(t, ballot.Drawn())
+
+	userIDs := []string{"user-1", "user-2", "user-3"}
+	for i, userID := range userIDs {
+		entry := &domain.BallotEntry{EventId: event.Id, UserId: userID, Status: domain.BallotEntered, EnteredAt: baseDate.Add(time.Duration(i) * time.Minute)}
+		require.NoError(t, ballots.EnterBallot(ctx, entry))
+	}
+	err = ballots.EnterBallot(ctx, &domain.BallotEntry{EventId: event.Id, UserId: "user-1", Status: domain.BallotEntered, EnteredAt: baseDate})
+	assert.ErrorIs(t, err, domain.ErrAlreadyEntered)
+	_, err = ballots.GetBallotEntry(ctx, event.Id, "user-0")
+	assert.ErrorIs(t, err, domain.ErrBallotEntryNotFound)
+
+	ballot, err = ballots.GetBallot(ctx, event.Id)
+	require.NoError(t, err)
+	assert.Equal(t, 3, ballot.Entries)
+
+	// Розыгрыш становится активным после закрытия приема заявок
+	active, err := ballots.ListActiveBallots(ctx, baseDate)
+	require.NoError(t, err)
+	assert.NotContains(t, ballotIds(active), event.Id)
+	active, err = ballots.ListActiveBallots(ctx, closesAt)
+	require.NoError(t, err)
+	assert.Contains(t, ballotIds(active), event.Id)
+
+	_, err = ballots.DrawBallot(ctx, uuid.New().String(), 42, closesAt)
+	assert.ErrorIs(t, err, domain.ErrBallotNotFound)
+	ballot, err = ballots.DrawBallot(ctx, event.Id, 42, closesAt)
+	require.NoError(t, err)
+	assert.True(t, ballot.Drawn())
+	assert.Equal(t, int64(42), ballot.Seed)
+
+	// Порядок пересчитывается по сохраненному seed
+	order := domain.DrawOrder(userIDs, 42)
+	entries, err := ballots.ListBallotEntries(ctx, event.Id, "")
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+	for i, entry := range entries {
+		assert.Equal(t, order[i], entry.UserId)
+		assert.Equal(t, i+1, entry.Rank)
+		assert.Equal(t, domain.BallotWaitlisted, entry.Status)
+	}
+
+	_, err = ballots.DrawBallot(ctx, event.Id, 7, closesAt)
+	assert.ErrorIs(t, err, domain.ErrBallotAlreadyDrawn)
+	_, err = ballots.SaveBallot(ctx, &domain.Ballot{EventId: event.Id, EntryOpensAt: baseDate, EntryClosesAt: closesAt.Add(time.Hour)})
+	assert.ErrorIs(t, err, domain.ErrBallotAlreadyDrawn)
+	err = ballots.EnterBallot(ctx, &domain.BallotEntry{EventId: event.Id, UserId: "user-4", Status: domain.BallotEntered, EnteredAt: closesAt})
+	assert.ErrorIs(t, err, domain.ErrBallotEntryClosed)
+
+	// Места получают участники в порядке розыгрыша
+	for i := 0; i < 2; i++ {
+		booking := newBooking(event.Id)
+		booking.UserId = ""
+		require.NoError(t, ballots.AllocateBallotTicket(ctx, booking))
+		assert.Equal(t, order[i], booking.UserId)
+
+		stored, err := repo.GetBooking(ctx, booking.Id)
+		require.NoError(t, err)
+		assert.Equal(t, order[i], stored.UserId)
+		assert.Equal(t, domain.PendingStatus, stored.Status)
+		assert.Equal(t, rub(150000), stored.Price)
+
+		entry, err := ballots.GetBallotEntry(ctx, event.Id, order[i])
+		require.NoError(t, err)
+		assert.Equal(t, domain.BallotWon, entry.Status)
+		assert.Equal(t, booking.Id, entry.BookingId)
+	}
+	assert.Equal(t, uint32(0), availableTickets(t, repo, event.Id))
+	err = ballots.AllocateBallotTicket(ctx, newBooking(event.Id))
+	assert.ErrorIs(t, err, domain.ErrNoTicketsAvailable)
+
+	won, err := ballots.ListBallotEntries(ctx, event.Id, domain.BallotWon)
+	require.NoError(t, err)
+	assert.Len(t, won, 2)
+
+	// Пока в листе ожидания кто-то есть, розыгрыш остается активным
+	active, err = ballots.ListActiveBallots(ctx, closesAt)
+	require.NoError(t, err)
+	assert.Contains(t, ballotIds(active), event.Id)
+
+	closed, err := ballots.CloseBallotWaitlist(ctx, event.Id)
+	require.NoError(t, err)
+	require.Len(t, closed, 1)
+	assert.Equal(t, order[2], closed[0].UserId)
+	assert.Equal(t, domain.BallotLost, closed[0].Status)
+	assert.Equal(t, 3, closed[0].Rank)
+
+	active, err = ballots.ListActiveBallots(ctx, closesAt)
+	require.NoError(t, err)
+	assert.NotContains(t, ballotIds(active), event.Id)
+	err = ballots.AllocateBallotTicket(ctx, newBooking(event.Id))
+	assert.ErrorIs(t, err, domain.ErrBallotWaitlistEmpty)
+}
+
+func testPromoCodes(t *testing.T, repo port.Repository) {
+	ctx := context.Background()
+	promos, ok := repo.(port.PromoRepository)
+	require.True(t, ok, "%T does not implement port.PromoRepository", repo)
+
+	event := createEvent(t, repo, 10, baseDate)
+	other := createEvent(t, repo, 10, baseDate)
+
+	// Без промокода бронь стоит как билет мероприятия
+	plain := bookEvent(t, repo, event.Id)
+	stored, err := repo.GetBooking(ctx, plain.Id)
+	require.NoError(t, err)
+	assert.Equal(t, rub(150000), stored.Price)
+	assert.Empty(t, stored.PromoCode)
+
+	global := &domain.PromoCode{Code: "SPRING", DiscountType: domain.DiscountPercent, Percent: 10, CreatedAt: baseDate}
+	require.NoError(t, promos.CreatePromoCode(ctx, global))
+	err = promos.CreatePromoCode(ctx, &domain.PromoCode{Code: "SPRING", DiscountType: domain.DiscountFixed, Amount: rub(100), CreatedAt: baseDate})
+	assert.ErrorIs(t, err, domain.ErrPromoCodeExists)
+	limited := &domain.PromoCode{
+		Code:         "VIP",
+		EventId:      event.Id,
+		DiscountType: domain.DiscountFixed,
+		Amount:       rub(200000),
+		MaxUses:      1,
+		ValidFrom:    baseDate.Add(-time.Hour),
+		ValidUntil:   baseDate.Add(time.Hour),
+		CreatedAt:    baseDate.Add(time.Second),
+	}
+	require.NoError(t, promos.CreatePromoCode(ctx, limited))
+
+	got, err := promos.GetPromoCode(ctx, "VIP")
+	require.NoError(t, err)
+	assert.Equal(t, event.Id, got.EventId)
+	assert.Equal(t, 1, got.MaxUses)
+	assert.Equal(t, rub(200000), got.Amount)
+	assert.True(t, got.ValidUntil.Equal(limited.ValidUntil))
+	_, err = promos.GetPromoCode(ctx, "MISSING")
+	assert.ErrorIs(t, err, domain.ErrPromoCodeNotFound)
+
+	book := func(eventID, userID, code string, date time.Time) (*domain.Booking, error) {
+		booking := newBooking(eventID)
+		booking.UserId = userID
+		booking.PromoCode = code
+		booking.Date = date
+		_, err := repo.BookEvent(ctx, booking)
+		return booking, err
+	}
+
+	// Скидка фиксируется в брони
+	booking, err := book(event.Id, "user-1", "SPRING", baseDate)
+	require.NoError(t, err)
+	assert.Equal(t, rub(135000), booking.Price)
+	stored, err = repo.GetBooking(ctx, booking.Id)
+	require.NoError(t, err)
+	assert.Equal(t, rub(135000), stored.Price)
+	assert.Equal(t, "SPRING", stored.PromoCode)
+
+	page, err := repo.ListBookings(ctx, domain.BookingFilter{UserId: "user-1", Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, page.Bookings, 1)
+	assert.Equal(t, rub(135000), page.Bookings[0].Booking.Price)
+
+	// Отклоненный промокод не списывает место
+	before := availableTickets(t, repo, event.Id)
+	_, err = book(event.Id, "user-1", "SPRING", baseDate)
+	assert.ErrorIs(t, err, domain.ErrPromoCodeUsed)
+	_, err = book(event.Id, "user-2", "MISSING", baseDate)
+	assert.ErrorIs(t, err, domain.ErrPromoCodeNotFound)
+	_, err = book(other.Id, "user-2", "VIP", baseDate)
+	assert.ErrorIs(t, err, domain.ErrPromoCodeNotApplicable)
+	_, err = book(event.Id, "user-2", "VIP", baseDate.Add(time.Hour))
+	assert.ErrorIs(t, err, domain.ErrPromoCodeNotApplicable)
+	assert.Equal(t, before, availableTickets(t, repo, event.Id))
+
+	// Скидка не больше цены
+	vip, err := book(event.Id, "user-2", "VIP", baseDate)
+	require.NoError(t, err)
+	assert.Equal(t, rub(0), vip.Price)
+	_, err = book(event.Id, "user-3", "VIP", baseDate)
+	assert.ErrorIs(t, err, domain.ErrPromoCodeExhausted)
+
+	got, err = promos.GetPromoCode(ctx, "VIP")
+	require.NoError(t, err)
+	assert.Equal(t, 1, got.Uses)
+	assert.Equal(t, []domain.Money{rub(150000)}, got.TotalDiscount)
+
+	// Отмена брони возвращает использование
+	require.NoError(t, repo.CancelBooking(ctx, vip.Id))
+	got, err = promos.GetPromoCode(ctx, "VIP")
+	require.NoError(t, err)
+	assert.Equal(t, 0, got.Uses)
+	_, err = book(event.Id, "user-3", "VIP", baseDate.Add(time.Minute))
+	require.NoError(t, err)
+
+	redemptions, err := promos.ListPromoRedemptions(ctx, "VIP")
+	require.NoError(t, err)
+	require.Len(t, redemptions, 2)
+	assert.Equal(t, vip.Id, redemptions[0].BookingId)
+	assert.Equal(t, domain.CancelledStatus, redemptions[0].BookingStatus)
+	assert.Equal(t, rub(150000), redemptions[0].Discount)
+	assert.Equal(t, "user-3", redemptions[1].UserId)
+	assert.Equal(t, domain.PendingStatus, redemptions[1].BookingStatus)
+
+	// Процентная скидка - в валюте мероприятия, фиксированная - только в своей валюте
+	euro := &domain.Event{
+		Id:               uuid.New().String(),
+		Name:             "Concert",
+		Price:            domain.Money{Amount: 4999, Currency: "EUR"},
+		AvailableTickets: 10,
+		Capacity:         10,
+		Date:             baseDate,
+		SaleMode:         domain.SaleModeFirstCome,
+	}
+	_, err = repo.CreateEvent(ctx, euro)
+	require.NoError(t, err)
+	require.NoError(t, promos.CreatePromoCode(ctx, &domain.PromoCode{
+		Code: "RUBLES", DiscountType: domain.DiscountFixed, Amount: rub(10000), CreatedAt: baseDate.Add(2 * time.Second),
+	}))
+	_, err = book(euro.Id, "user-4", "RUBLES", baseDate)
+	assert.ErrorIs(t, err, domain.ErrPromoCodeNotApplicable)
+	booking, err = book(euro.Id, "user-4", "SPRING", baseDate)
+	require.NoError(t, err)
+	stored, err = repo.GetBooking(ctx, booking.Id)
+	require.NoError(t, err)
+	assert.Equal(t, domain.Money{Amount: 4499, Currency: "EUR"}, stored.Price)
+
+	list, err := promos.ListPromoCodes(ctx)
+	require.NoError(t, err)
+	require.Len(t, list, 3)
+	assert.Equal(t, "SPRING", list[0].Code)
+	assert.Equal(t, 2, list[0].Uses)
+	assert.Equal(t, []domain.Money{{Amount: 500, Currency: "EUR"}, rub(15000)}, list[0].TotalDiscount)
+	assert.Equal(t, "VIP", list[1].Code)
+	assert.Empty(t, list[2].TotalDiscount)
+}
+
+func testPricePhases(t *testing.T, repo port.Repository) {
+	ctx := context.Background()
+	pricing, ok := repo.(port.PricingRepository)
+	require.True(t, ok, "%T does not implement port.PricingRepository", repo)
+
+	event := createEvent(t, repo, 10, baseDate)
+	err := pricing.SetPricePhases(ctx, uuid.New().String(), []domain.PricePhase{{Price: 10000}})
+	assert.ErrorIs(t, err, domain.ErrEventNotFound)
+
+	phases := []domain.PricePhase{
+		{Name: "Early bird", Price: 100000, Until: baseDate.Add(time.Hour), MaxSold: 2},
+		{Name: "Regular", Price: 120000, MaxSold: 4},
+	}
+	require.NoError(t, pricing.SetPricePhases(ctx, event.Id, phases))
+
+	got, err := repo.GetEvent(ctx, event.Id)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), got.Version, "new schedule changes the price and the version")
+	require.Len(t, got.PricePhases, 2)
+	assert.Equal(t, "Early bird", got.PricePhases[0].Name)
+	assert.True(t, got.PricePhases[0].Until.Equal(phases[0].Until))
+	assert.True(t, got.PricePhases[1].Until.IsZero())
+	assert.Equal(t, uint32(4), got.PricePhases[1].MaxSold)
+
+	// Ступени по числу проданных билетов, затем базовая цена
+	for _, want := range []int64{100000, 100000, 120000, 120000, 150000} {
+		booking := bookEvent(t, repo, event.Id)
+		stored, err := repo.GetBooking(ctx, booking.Id)
+		require.NoError(t, err)
+		assert.Equal(t, rub(want), stored.Price)
+	}
+
+	// Ступень по времени: бронь после Until стоит как следующая ступень
+	late := createEvent(t, repo, 10, baseDate)
+	require.NoError(t, pricing.SetPricePhases(ctx, late.Id, phases))
+	booking := newBooking(late.Id)
+	booking.Date = baseDate.Add(time.Hour)
+	_, err = repo.BookEvent(ctx, booking)
+	require.NoError(t, err)
+	assert.Equal(t, rub(120000), booking.Price)
+
+	adminCtx := port.WithActor(ctx, domain.Actor{Type: domain.ActorAdmin, Id: "admin-1"})
+	require.NoError(t, pricing.SetPricePhases(adminCtx, event.Id, nil))
+	got, err = repo.GetEvent(ctx, event.Id)
+	require.NoError(t, err)
+	assert.Empty(t, got.PricePhases)
+
+	// Замена расписания попадает в журнал со старыми и новыми ступенями
+	auditLog, ok := repo.(port.AuditRepository)
+	require.True(t, ok, "%T does not implement port.AuditRepository", repo)
+	entries, err := auditLog.ListAuditLog(ctx, domain.AuditFilter{EventId: event.Id})
+	require.NoError(t, err)
+	var edits []*domain.AuditEntry
+	for _, entry := range entries {
+		if entry.Action == domain.AuditEventUpdated {
+			edits = append(edits, entry)
+		}
+	}
+	require.Len(t, edits, 2)
+	assert.JSONEq(t, `{"price_phases":[]}`, string(edits[0].OldValue))
+	assert.JSONEq(t, `{"price_phases":[{"name":"Early bird","price":100000,"max_sold":2,"until":"`+
+		phases[0].Until.Format(time.RFC3339)+`"},{"name":"Regular","price":120000,"max_sold":4}]}`, string(edits[0].NewValue))
+	assert.JSONEq(t, string(edits[0].NewValue), string(edits[1].OldValue))
+	assert.JSONEq(t, `{"price_phases":[]}`, string(edits[1].NewValue))
+	assert.Equal(t, domain.ActorAdmin, edits[1].ActorType)
+	assert.Equal(t, "admin-1", edits[1].ActorId)
+}
+
+func testFeeRules(t *testing.T, repo port.Repository) {
+	ctx := context.Background()
+	fees, ok := repo.(port.FeeRepository)
+	require.True(t, ok, "%T does not implement port.FeeRepository", repo)
+
+	// Без правил бронь стоит как билет
+	event := createEvent(t, repo, 10, baseDate)
+	booking := bookEvent(t, repo, event.Id)
+	stored, err := repo.GetBooking(ctx, booking.Id)
+	require.NoError(t, err)
+	assert.Equal(t, rub(150000), stored.Price)
+	assert.Equal(t, []domain.BookingLineItem{{Kind: domain.LineItemFaceValue, Amount: rub(150000)}}, stored.LineItems)
+
+	rules := []*domain.FeeRule{
+		{Scope: domain.FeeScopeDefault, FeePercent: 10, UpdatedAt: baseDate},
+		{Scope: domain.FeeScopeOrganizer, ScopeId: "org-1", FixedFee: rub(3000), FeePercent: 5, VatPercent: percent(20), UpdatedAt: baseDate},
+		{Scope: domain.FeeScopeEvent, ScopeId: event.Id, VatPercent: percent(20), UpdatedAt: baseDate},
+	}
+	for _, rule := range rules {
+		require.NoError(t, fees.SaveFeeRule(ctx, rule))
+	}
+	// Повторное сохранение заменяет правило
+	rules[0].FeePercent = 2
+	require.NoError(t, fees.SaveFeeRule(ctx, rules[0]))
+
+	list, err := fees.ListFeeRules(ctx)
+	require.NoError(t, err)
+	require.Len(t, list, 3)
+	assert.Equal(t, domain.FeeScopeDefault, list[0].Scope)
+	assert.Equal(t, 2.0, list[0].FeePercent)
+	assert.Empty(t, list[0].FixedFee.Currency)
+	assert.Nil(t, list[0].VatPercent)
+	assert.Equal(t, domain.FeeScopeEvent, list[1].Scope)
+	assert.Equal(t, domain.FeeScopeOrganizer, list[2].Scope)
+	assert.Equal(t, rub(3000), list[2].FixedFee)
+	assert.Equal(t, percent(20), list[2].VatPercent)
+	assert.True(t, list[2].UpdatedAt.Equal(baseDate))
+
+	// Правило мероприятия: НДС 20% от 1500.00
+	booking = bookEvent(t, repo, event.Id)
+	assert.Equal(t, rub(180000), booking.Price)
+	stored, err = repo.GetBooking(ctx, booking.Id)
+	require.NoError(t, err)
+	assert.Equal(t, rub(180000), stored.Price)
+	assert.Equal(t, []domain.BookingLineItem{
+		{Kind: domain.LineItemFaceValue, Amount: rub(150000)},
+		{Kind: domain.LineItemVat, Amount: rub(30000)},
+	}, stored.LineItems)
+
+	// Правило организатора: сбор 5% + 30.00 = 105.00, НДС 20% от 1605.00 = 321.00
+	organized := &domain.Event{
+		Id:               uuid.New().String(),
+		Name:             "Festival",
+		Price:            rub(150000),
+		OrganizerId:      "org-1",
+		AvailableTickets: 10,
+		Capacity:         10,
+		Date:             baseDate,
+		SaleMode:         domain.SaleModeFirstCome,
+	}
+	_, err = repo.CreateEvent(ctx, organized)
+	require.NoError(t, err)
+	got, err := repo.GetEvent(ctx, organized.Id)
+	require.NoError(t, err)
+	assert.Equal(t, "org-1", got.OrganizerId)
+
+	booking = bookEvent(t, repo, organized.Id)
+	stored, err = repo.GetBooking(ctx, booking.Id)
+	require.NoError(t, err)
+	assert.Equal(t, rub(192600), stored.Price)
+	assert.Equal(t, []domain.BookingLineItem{
+		{Kind: domain.LineItemFaceValue, Amount: rub(150000)},
+		{Kind: domain.LineItemServiceFee, Amount: rub(10500)},
+		{Kind: domain.LineItemVat, Amount: rub(32100)},
+	}, stored.LineItems)
+
+	// Общее правило; состав суммы в списке броней не загружается
+	other := createEvent(t, repo, 10, baseDate)
+	booking = bookEvent(t, repo, other.Id)
+	assert.Equal(t, rub(153000), booking.Price)
+	page, err := repo.ListBookings(ctx, domain.BookingFilter{EventId: other.Id, Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, page.Bookings, 1)
+	assert.Equal(t, rub(153000), page.Bookings[0].Booking.Price)
+	assert.Empty(t, page.Bookings[0].Booking.LineItems)
+	page, err = repo.ListBookings(ctx, domain.BookingFilter{EventId: other.Id, Limit: 10, LineItems: true})
+	require.NoError(t, err)
+	require.Len(t, page.Bookings, 1)
+	assert.Equal(t, []domain.BookingLineItem{
+		{Kind: domain.LineItemFaceValue, Amount: rub(150000)},
+		{Kind: domain.LineItemServiceFee, Amount: rub(3000)},
+	}, page.Bookings[0].Booking.LineItems)
+
+	// Сбор брони фиксируется при создании и не меняется вместе с правилом
+	require.NoError(t, fees.DeleteFeeRule(ctx, domain.FeeScopeDefault, ""))
+	stored, err = repo.GetBooking(ctx, booking.Id)
+	require.NoError(t, err)
+	assert.Equal(t, rub(153000), stored.Price)
+	assert.Len(t, stored.LineItems, 2)
+	assert.Equal(t, rub(150000), bookEvent(t, repo, other.Id).Price)
+
+	err = fees.DeleteFeeRule(ctx, domain.FeeScopeDefault, "")
+	assert.ErrorIs(t, err, domain.ErrFeeRuleNotFound)
+}
@@ -0,0 +1,105 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/dontpanicw/EventBooker/internal/domain"
+)
+
+const (
+	insertAuditQuery = `INSERT INTO audit_log (id, entity_type, entity_id, event_id, user_id, action, actor_type, actor_id, old_value, new_value, reason, created_at)
+						VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?);`
+	selectAuditQuery = `SELECT id, entity_type, entity_id, event_id, COALESCE(user_id, ''), action, actor_type, COALESCE(actor_id, ''),
+							old_value, new_value, COALESCE(reason, ''), created_at
+						FROM audit_log`
+	auditByBookingQuery = selectAuditQuery + ` WHERE entity_type = 'booking' AND entity_id = ? ORDER BY created_at, rowid LIMIT ?;`
+	auditByEventQuery   = selectAuditQuery + ` WHERE event_id = ? ORDER BY created_at, rowid LIMIT ?;`
+	auditByUserQuery    = selectAuditQuery + ` WHERE user_id = ? ORDER BY created_at, rowid LIMIT ?;`
+)
+
+// execer - *sql.DB или *sql.Tx
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// insertAudit пишет записи журнала через q - транзакцию изменения
+func insertAudit(ctx context.Context, q execer, entries ...*domain.AuditEntry) error {
+	for _, entry := range entries {
+		_, err := q.ExecContext(ctx, insertAuditQuery,
+			entry.Id,
+			entry.EntityType,
+			entry.EntityId,
+			entry.EventId,
+			nullString(entry.UserId),
+			entry.Action,
+			entry.ActorType,
+			nullString(entry.ActorId),
+			nullJSON(entry.OldValue),
+			nullJSON(entry.NewValue),
+			nullString(entry.Reason),
+			entry.CreatedAt,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to write audit log: %w", err)
+		}
+	}
+	return nil
+}
+
+func (r *EventRepository) ListAuditLog(ctx context.Context, filter domain.AuditFilter) ([]*domain.AuditEntry, error) {
+	query, arg := auditByEventQuery, filter.EventId
+	switch {
+	case filter.BookingId != "":
+		query, arg = auditByBookingQuery, filter.BookingId
+	case filter.UserId != "":
+		query, arg = auditByUserQuery, filter.UserId
+	}
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = -1 // без ограничения
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, arg, limit)
+	if err != nil {
+		return nil, fmt.Errorf("error querying audit log: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []*domain.AuditEntry
+	for rows.Next() {
+		var entry domain.AuditEntry
+		var oldValue, newValue []byte
+		if err := rows.Scan(&entry.Id, &entry.EntityType, &entry.EntityId, &entry.EventId, &entry.UserId,
+			&entry.Action, &entry.ActorType, &entry.ActorId, &oldValue, &newValue, &entry.Reason, &entry.CreatedAt); err != nil {
+			return nil, fmt.Errorf("error scanning audit log: %w", err)
+		}
+		entry.OldValue, entry.NewValue = rawJSON(oldValue), rawJSON(newValue)
+		entries = append(entries, &entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating audit log: %w", err)
+	}
+	return entries, nil
+}
+
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+func nullJSON(v json.RawMessage) any {
+	if v == nil {
+		return nil
+	}
+	return string(v)
+}
+
+func rawJSON(b []byte) json.RawMessage {
+	if b == nil {
+		return nil
+	}
+	return json.RawMessage(b)
+}
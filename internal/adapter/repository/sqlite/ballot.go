@@ -0,0 +1,338 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/dontpanicw/EventBooker/internal/domain"
+	"github.com/dontpanicw/EventBooker/internal/port"
+)
+
+const (
+	// saveBallotQuery не меняет проведенный розыгрыш: строка не обновится, и RowsAffected будет 0
+	saveBallotQuery = `INSERT INTO ballots (event_id, entry_opens_at, entry_closes_at, waitlist)
+						VALUES (?, ?, ?, ?)
+						ON CONFLICT (event_id) DO UPDATE SET entry_opens_at = excluded.entry_opens_at,
+							entry_closes_at = excluded.entry_closes_at, waitlist = excluded.waitlist
+						WHERE ballots.drawn_at IS NULL;`
+	selectBallotsQuery = `SELECT b.event_id, b.entry_opens_at, b.entry_closes_at, b.waitlist, b.seed, b.drawn_at,
+							(SELECT COUNT(*) FROM ballot_entries e WHERE e.event_id = b.event_id)
+						FROM ballots b`
+	getBallotQuery = selectBallotsQuery + ` WHERE b.event_id = ?;`
+	// listActiveBallotsQuery - розыгрыши, которые пора провести, и проведенные с листом ожидания
+	listActiveBallotsQuery = selectBallotsQuery + `
+						WHERE (b.drawn_at IS NULL AND b.entry_closes_at <= ?)
+							OR (b.drawn_at IS NOT NULL AND EXISTS (
+								SELECT 1 FROM ballot_entries e WHERE e.event_id = b.event_id AND e.status = ?))
+						ORDER BY b.entry_closes_at, b.event_id;`
+	enterBallotQuery = `INSERT INTO ballot_entries (event_id, user_id, status, entered_at)
+						SELECT event_id, ?, ?, ? FROM ballots WHERE event_id = ? AND drawn_at IS NULL
+						ON CONFLICT (event_id, user_id) DO NOTHING;`
+	selectBallotEntriesQuery = `SELECT event_id, user_id, status, rank, COALESCE(booking_id, ''), entered_at
+							FROM ballot_entries`
+	getBallotEntryQuery     = selectBallotEntriesQuery + ` WHERE event_id = ? AND user_id = ?;`
+	ballotEntryExistsQuery  = `SELECT EXISTS (SELECT 1 FROM ballot_entries WHERE event_id = ? AND user_id = ?);`
+	listBallotEntriesQuery  = selectBallotEntriesQuery + ` WHERE event_id = ? AND (? = '' OR status = ?) ORDER BY rank, entered_at, user_id;`
+	markBallotDrawnQuery    = `UPDATE ballots SET seed = ?, drawn_at = ? WHERE event_id = ? AND drawn_at IS NULL;`
+	listBallotEntrantsQuery = `SELECT user_id FROM ballot_entries WHERE event_id = ? AND status = ?;`
+	rankBallotEntryQuery    = `UPDATE ballot_entries SET status = ?, rank = ? WHERE event_id = ? AND user_id = ?;`
+	nextWaitlistedQuery     = `SELECT user_id FROM ballot_entries WHERE event_id = ? AND status = ? ORDER BY rank LIMIT 1;`
+	winBallotEntryQuery     = `UPDATE ballot_entries SET status = ?, booking_id = ? WHERE event_id = ? AND user_id = ?;`
+	closeWaitlistQuery      = `UPDATE ballot_entries SET status = ? WHERE event_id = ? AND status = ?;`
+)
+
+func (r *EventRepository) SaveBallot(ctx context.Context, ballot *domain.Ballot) (*domain.Ballot, error) {
+	result, err := r.db.ExecContext(ctx, saveBallotQuery,
+		ballot.EventId, ballot.EntryOpensAt.UTC(), ballot.EntryClosesAt.UTC(), ballot.Waitlist)
+	if err != nil {
+		return nil, fmt.Errorf("error save ballot: %w", err)
+	}
+	if saved, err := result.RowsAffected(); err != nil {
+		return nil, fmt.Errorf("error save ballot: %w", err)
+	} else if saved == 0 {
+		return nil, fmt.Errorf("error save ballot: %w", domain.ErrBallotAlreadyDrawn)
+	}
+	return r.GetBallot(ctx, ballot.EventId)
+}
+
+func (r *EventRepository) GetBallot(ctx context.Context, eventID string) (*domain.Ballot, error) {
+	ballot, err := scanBallot(r.db.QueryRowContext(ctx, getBallotQuery, eventID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("error get ballot: %w", domain.ErrBallotNotFound)
+		}
+		return nil, fmt.Errorf("error get ballot: %w", err)
+	}
+	return ballot, nil
+}
+
+func (r *EventRepository) ListActiveBallots(ctx context.Context, now time.Time) ([]*domain.Ballot, error) {
+	rows, err := r.db.QueryContext(ctx, listActiveBallotsQuery, now.UTC(), domain.BallotWaitlisted)
+	if err != nil {
+		return nil, fmt.Errorf("error list active ballots: %w", err)
+	}
+	defer rows.Close()
+
+	var ballots []*domain.Ballot
+	for rows.Next() {
+		ballot, err := scanBallot(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error list active ballots: %w", err)
+		}
+		ballots = append(ballots, ballot)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error list active ballots: %w", err)
+	}
+	return ballots, nil
+}
+
+// EnterBallot вставляет заявку, только если розыгрыш еще не проведен; ноль вставленных
+// строк - повторная заявка или прием закрыт
+func (r *EventRepository) EnterBallot(ctx context.Context, entry *domain.BallotEntry) error {
+	result, err := r.db.ExecContext(ctx, enterBallotQuery,
+		entry.UserId, entry.Status, entry.EnteredAt.UTC(), entry.EventId)
+	if err != nil {
+		return fmt.Errorf("error enter ballot: %w", err)
+	}
+	if inserted, err := result.RowsAffected(); err != nil {
+		return fmt.Errorf("error enter ballot: %w", err)
+	} else if inserted > 0 {
+		return nil
+	}
+
+	var exists bool
+	if err := r.db.QueryRowContext(ctx, ballotEntryExistsQuery, entry.EventId, entry.UserId).Scan(&exists); err != nil {
+		return fmt.Errorf("error enter ballot: %w", err)
+	}
+	if exists {
+		return fmt.Errorf("error enter ballot: %w", domain.ErrAlreadyEntered)
+	}
+	return fmt.Errorf("error enter ballot: %w", domain.ErrBallotEntryClosed)
+}
+
+func (r *EventRepository) GetBallotEntry(ctx context.Context, eventID, userID string) (*domain.BallotEntry, error) {
+	entry, err := scanBallotEntry(r.db.QueryRowContext(ctx, getBallotEntryQuery, eventID, userID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("error get ballot entry: %w", domain.ErrBallotEntryNotFound)
+		}
+		return nil, fmt.Errorf("error get ballot entry: %w", err)
+	}
+	return entry, nil
+}
+
+func (r *EventRepository) ListBallotEntries(ctx context.Context, eventID, status string) ([]*domain.BallotEntry, error) {
+	entries, err := listBallotEntries(ctx, r.db, eventID, status)
+	if err != nil {
+		return nil, fmt.Errorf("error list ballot entries: %w", err)
+	}
+	return entries, nil
+}
+
+// DrawBallot выполняется в транзакции на единственном соединении, поэтому заявка,
+// поданная во время розыгрыша, либо попадет в него, либо будет отклонена
+func (r *EventRepository) DrawBallot(ctx context.Context, eventID string, seed int64, drawnAt time.Time) (*domain.Ballot, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		err = tx.Rollback()
+		if err != nil && !errors.Is(err, sql.ErrTxDone) {
+			log.Printf("failed to rollback transaction: %v", err)
+		}
+	}()
+
+	result, err := tx.ExecContext(ctx, markBallotDrawnQuery, seed, drawnAt.UTC(), eventID)
+	if err != nil {
+		return nil, fmt.Errorf("error draw ballot: %w", err)
+	}
+	if marked, err := result.RowsAffected(); err != nil {
+		return nil, fmt.Errorf("error draw ballot: %w", err)
+	} else if marked == 0 {
+		if _, err := scanBallot(tx.QueryRowContext(ctx, getBallotQuery, eventID)); errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("error draw ballot: %w", domain.ErrBallotNotFound)
+		}
+		return nil, fmt.Errorf("error draw ballot: %w", domain.ErrBallotAlreadyDrawn)
+	}
+
+	var userIDs []string
+	rows, err := tx.QueryContext(ctx, listBallotEntrantsQuery, eventID, domain.BallotEntered)
+	if err != nil {
+		return nil, fmt.Errorf("error draw ballot: %w", err)
+	}
+	for rows.Next() {
+		var userID string
+		if err := rows.Scan(&userID); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("error draw ballot: %w", err)
+		}
+		userIDs = append(userIDs, userID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error draw ballot: %w", err)
+	}
+
+	for i, userID := range domain.DrawOrder(userIDs, seed) {
+		if _, err := tx.ExecContext(ctx, rankBallotEntryQuery, domain.BallotWaitlisted, i+1, eventID, userID); err != nil {
+			return nil, fmt.Errorf("error draw ballot: %w", err)
+		}
+	}
+
+	ballot, err := scanBallot(tx.QueryRowContext(ctx, getBallotQuery, eventID))
+	if err != nil {
+		return nil, fmt.Errorf("error draw ballot: %w", err)
+	}
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return ballot, nil
+}
+
+func (r *EventRepository) AllocateBallotTicket(ctx context.Context, booking *domain.Booking) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		err = tx.Rollback()
+		if err != nil && !errors.Is(err, sql.ErrTxDone) {
+			log.Printf("failed to rollback transaction: %v", err)
+		}
+	}()
+
+	err = tx.QueryRowContext(ctx, nextWaitlistedQuery, booking.EventId, domain.BallotWaitlisted).Scan(&booking.UserId)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.ErrBallotWaitlistEmpty
+		}
+		return fmt.Errorf("error allocate ballot ticket: %w", err)
+	}
+
+	event, err := takeTicket(ctx, tx, booking.EventId, domain.SaleModeBallot)
+	if err != nil {
+		return err
+	}
+	newAvailableTickets := int(event.AvailableTickets)
+	face, err := ticketPrice(ctx, tx, event, booking.Date)
+	if err != nil {
+		return err
+	}
+	if err := chargeBooking(ctx, tx, event, booking, face, domain.Money{Currency: face.Currency}); err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, bookEventQuery,
+		booking.Id,
+		booking.UserId,
+		booking.EventId,
+		booking.Status,
+		booking.Date.UTC(),
+		booking.Price.Amount,
+		booking.Price.Currency,
+		booking.PromoCode,
+	)
+	if err != nil {
+		return fmt.Errorf("error allocate ballot ticket: %w", err)
+	}
+	if err := insertLineItems(ctx, tx, booking); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, winBallotEntryQuery, domain.BallotWon, booking.Id, booking.EventId, booking.UserId); err != nil {
+		return fmt.Errorf("error allocate ballot ticket: %w", err)
+	}
+
+	if err := insertAudit(ctx, tx,
+		port.NewBookingAuditEntry(ctx, booking, domain.AuditBookingCreated, ""),
+		port.NewTicketsAuditEntry(ctx, booking.EventId, newAvailableTickets+1, newAvailableTickets),
+	); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+func (r *EventRepository) CloseBallotWaitlist(ctx context.Context, eventID string) ([]*domain.BallotEntry, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		err = tx.Rollback()
+		if err != nil && !errors.Is(err, sql.ErrTxDone) {
+			log.Printf("failed to rollback transaction: %v", err)
+		}
+	}()
+
+	entries, err := listBallotEntries(ctx, tx, eventID, domain.BallotWaitlisted)
+	if err != nil {
+		return nil, fmt.Errorf("error close ballot waitlist: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, closeWaitlistQuery, domain.BallotLost, eventID, domain.BallotWaitlisted); err != nil {
+		return nil, fmt.Errorf("error close ballot waitlist: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	for _, entry := range entries {
+		entry.Status = domain.BallotLost
+	}
+	return entries, nil
+}
+
+// rowsQuerier - *sql.DB или *sql.Tx
+type rowsQuerier interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+func listBallotEntries(ctx context.Context, q rowsQuerier, eventID, status string) ([]*domain.BallotEntry, error) {
+	rows, err := q.QueryContext(ctx, listBallotEntriesQuery, eventID, status, status)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []*domain.BallotEntry
+	for rows.Next() {
+		entry, err := scanBallotEntry(rows)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
+
+func scanBallot(row interface{ Scan(dest ...any) error }) (*domain.Ballot, error) {
+	var ballot domain.Ballot
+	var seed sql.NullInt64
+	var drawnAt sql.NullTime
+	err := row.Scan(&ballot.EventId, &ballot.EntryOpensAt, &ballot.EntryClosesAt, &ballot.Waitlist,
+		&seed, &drawnAt, &ballot.Entries)
+	if err != nil {
+		return nil, err
+	}
+	ballot.Seed = seed.Int64
+	if drawnAt.Valid {
+		ballot.DrawnAt = &drawnAt.Time
+	}
+	return &ballot, nil
+}
+
+func scanBallotEntry(row interface{ Scan(dest ...any) error }) (*domain.BallotEntry, error) {
+	var entry domain.BallotEntry
+	err := row.Scan(&entry.EventId, &entry.UserId, &entry.Status, &entry.Rank, &entry.BookingId, &entry.EnteredAt)
+	if err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
@@ -0,0 +1,160 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/dontpanicw/EventBooker/internal/domain"
+)
+
+const (
+	saveFeeRuleQuery = `INSERT INTO fee_rules (scope, scope_id, fixed_fee, currency, fee_percent, vat_percent, updated_at)
+						VALUES (?, ?, ?, ?, ?, ?, ?)
+						ON CONFLICT (scope, scope_id) DO UPDATE SET
+							fixed_fee = excluded.fixed_fee, currency = excluded.currency, fee_percent = excluded.fee_percent,
+							vat_percent = excluded.vat_percent, updated_at = excluded.updated_at;`
+	deleteFeeRuleQuery  = `DELETE FROM fee_rules WHERE scope = ? AND scope_id = ?;`
+	selectFeeRulesQuery = `SELECT scope, scope_id, fixed_fee, COALESCE(currency, ''), fee_percent, vat_percent, updated_at
+						FROM fee_rules`
+	listFeeRulesQuery = selectFeeRulesQuery + ` ORDER BY scope, scope_id;`
+	// eventFeeRulesQuery - правила, которые могут действовать для мероприятия; выбирает domain.MatchFeeRule
+	eventFeeRulesQuery = selectFeeRulesQuery + ` WHERE scope = 'default'
+							OR (scope = 'event' AND scope_id = ?)
+							OR (scope = 'organizer' AND scope_id = ?);`
+	insertLineItemQuery = `INSERT INTO booking_line_items (booking_id, position, kind, amount) VALUES (?, ?, ?, ?);`
+	listLineItemsQuery  = `SELECT kind, amount FROM booking_line_items WHERE booking_id = ? ORDER BY position;`
+)
+
+func (r *EventRepository) SaveFeeRule(ctx context.Context, rule *domain.FeeRule) error {
+	_, err := r.db.ExecContext(ctx, saveFeeRuleQuery,
+		rule.Scope, rule.ScopeId, rule.FixedFee.Amount, nullString(rule.FixedFee.Currency),
+		rule.FeePercent, rule.VatPercent, rule.UpdatedAt.UTC())
+	if err != nil {
+		return fmt.Errorf("error save fee rule: %w", err)
+	}
+	return nil
+}
+
+func (r *EventRepository) DeleteFeeRule(ctx context.Context, scope, scopeID string) error {
+	result, err := r.db.ExecContext(ctx, deleteFeeRuleQuery, scope, scopeID)
+	if err != nil {
+		return fmt.Errorf("error delete fee rule: %w", err)
+	}
+	if deleted, err := result.RowsAffected(); err != nil {
+		return fmt.Errorf("error delete fee rule: %w", err)
+	} else if deleted == 0 {
+		return fmt.Errorf("error delete fee rule: %w", domain.ErrFeeRuleNotFound)
+	}
+	return nil
+}
+
+func (r *EventRepository) ListFeeRules(ctx context.Context) ([]*domain.FeeRule, error) {
+	rules, err := listFeeRules(ctx, r.db, listFeeRulesQuery)
+	if err != nil {
+		return nil, fmt.Errorf("error list fee rules: %w", err)
+	}
+	return rules, nil
+}
+
+// chargeBooking фиксирует сумму брони мероприятия event по цене билета face и скидке
+// discount со сбором и НДС по правилу мероприятия
+func chargeBooking(ctx context.Context, tx *sql.Tx, event *domain.Event, booking *domain.Booking, face, discount domain.Money) error {
+	rules, err := listFeeRules(ctx, tx, eventFeeRulesQuery, event.Id, event.OrganizerId)
+	if err != nil {
+		return fmt.Errorf("failed to get fee rules: %w", err)
+	}
+	if err := booking.Charge(face, discount, domain.MatchFeeRule(event, rules)); err != nil {
+		return fmt.Errorf("failed to charge booking: %w", err)
+	}
+	return nil
+}
+
+// insertLineItems сохраняет состав суммы брони, рассчитанный chargeBooking
+func insertLineItems(ctx context.Context, tx *sql.Tx, booking *domain.Booking) error {
+	for i, item := range booking.LineItems {
+		if _, err := tx.ExecContext(ctx, insertLineItemQuery, booking.Id, i, item.Kind, item.Amount.Amount); err != nil {
+			return fmt.Errorf("failed to save line items: %w", err)
+		}
+	}
+	return nil
+}
+
+// listLineItems читает состав суммы брони; суммы - в валюте брони currency
+func listLineItems(ctx context.Context, q rowsQuerier, bookingID, currency string) ([]domain.BookingLineItem, error) {
+	rows, err := q.QueryContext(ctx, listLineItemsQuery, bookingID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []domain.BookingLineItem
+	for rows.Next() {
+		item := domain.BookingLineItem{Amount: domain.Money{Currency: currency}}
+		if err := rows.Scan(&item.Kind, &item.Amount.Amount); err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+// loadLineItems читает состав суммы всех броней страницы одним запросом
+func loadLineItems(ctx context.Context, q rowsQuerier, bookings []*domain.BookingDetails) error {
+	if len(bookings) == 0 {
+		return nil
+	}
+	byID := make(map[string]*domain.Booking, len(bookings))
+	placeholders := make([]string, 0, len(bookings))
+	args := make([]any, 0, len(bookings))
+	for _, b := range bookings {
+		byID[b.Booking.Id] = b.Booking
+		placeholders = append(placeholders, "?")
+		args = append(args, b.Booking.Id)
+	}
+
+	query := `SELECT booking_id, kind, amount FROM booking_line_items WHERE booking_id IN (` +
+		strings.Join(placeholders, ", ") + `) ORDER BY booking_id, position;`
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var bookingID string
+		var item domain.BookingLineItem
+		if err := rows.Scan(&bookingID, &item.Kind, &item.Amount.Amount); err != nil {
+			return err
+		}
+		booking := byID[bookingID]
+		item.Amount.Currency = booking.Price.Currency
+		booking.LineItems = append(booking.LineItems, item)
+	}
+	return rows.Err()
+}
+
+func listFeeRules(ctx context.Context, q rowsQuerier, query string, args ...any) ([]*domain.FeeRule, error) {
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rules []*domain.FeeRule
+	for rows.Next() {
+		var rule domain.FeeRule
+		var vat sql.NullFloat64
+		err := rows.Scan(&rule.Scope, &rule.ScopeId, &rule.FixedFee.Amount, &rule.FixedFee.Currency,
+			&rule.FeePercent, &vat, &rule.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+		if vat.Valid {
+			rule.VatPercent = &vat.Float64
+		}
+		rules = append(rules, &rule)
+	}
+	return rules, rows.Err()
+}
@@ -0,0 +1,87 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/dontpanicw/EventBooker/internal/domain"
+)
+
+const (
+	reserveIdempotencyKeyQuery = `INSERT INTO idempotency_keys (idempotency_key, request_hash, created_at)
+								VALUES (?, ?, ?)
+								ON CONFLICT (idempotency_key) DO NOTHING;`
+	getIdempotencyKeyQuery = `SELECT idempotency_key, request_hash, COALESCE(status_code, 0), COALESCE(content_type, ''),
+								response_body, created_at
+							FROM idempotency_keys WHERE idempotency_key = ?;`
+	completeIdempotencyKeyQuery = `UPDATE idempotency_keys SET status_code = ?, content_type = ?, response_body = ?
+								WHERE idempotency_key = ?;`
+	releaseIdempotencyKeyQuery        = `DELETE FROM idempotency_keys WHERE idempotency_key = ?;`
+	releaseExpiredIdempotencyKeyQuery = `DELETE FROM idempotency_keys WHERE idempotency_key = ? AND created_at < ?;`
+	purgeIdempotencyKeysQuery         = `DELETE FROM idempotency_keys WHERE created_at < ?;`
+)
+
+// reserveAttempts - сколько раз повторить вставку, если занявшую ключ запись удалили между INSERT и SELECT
+const reserveAttempts = 3
+
+func (r *EventRepository) ReserveIdempotencyKey(ctx context.Context, record *domain.IdempotencyRecord) (*domain.IdempotencyRecord, error) {
+	for attempt := 0; attempt < reserveAttempts; attempt++ {
+		result, err := r.db.ExecContext(ctx, reserveIdempotencyKeyQuery,
+			record.Key, record.RequestHash, record.CreatedAt.UTC())
+		if err != nil {
+			return nil, fmt.Errorf("error reserving idempotency key: %w", err)
+		}
+		if inserted, err := result.RowsAffected(); err != nil {
+			return nil, fmt.Errorf("error reserving idempotency key: %w", err)
+		} else if inserted == 1 {
+			return nil, nil
+		}
+
+		var existing domain.IdempotencyRecord
+		err = r.db.QueryRowContext(ctx, getIdempotencyKeyQuery, record.Key).Scan(
+			&existing.Key, &existing.RequestHash, &existing.StatusCode, &existing.ContentType,
+			&existing.Body, &existing.CreatedAt)
+		if errors.Is(err, sql.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error get idempotency key: %w", err)
+		}
+		return &existing, nil
+	}
+	return nil, fmt.Errorf("error reserving idempotency key: %s keeps changing", record.Key)
+}
+
+func (r *EventRepository) CompleteIdempotencyKey(ctx context.Context, record *domain.IdempotencyRecord) error {
+	_, err := r.db.ExecContext(ctx, completeIdempotencyKeyQuery,
+		record.StatusCode, record.ContentType, record.Body, record.Key)
+	if err != nil {
+		return fmt.Errorf("error completing idempotency key: %w", err)
+	}
+	return nil
+}
+
+func (r *EventRepository) ReleaseIdempotencyKey(ctx context.Context, key string) error {
+	if _, err := r.db.ExecContext(ctx, releaseIdempotencyKeyQuery, key); err != nil {
+		return fmt.Errorf("error releasing idempotency key: %w", err)
+	}
+	return nil
+}
+
+func (r *EventRepository) ReleaseExpiredIdempotencyKey(ctx context.Context, key string, before time.Time) error {
+	if _, err := r.db.ExecContext(ctx, releaseExpiredIdempotencyKeyQuery, key, before.UTC()); err != nil {
+		return fmt.Errorf("error releasing expired idempotency key: %w", err)
+	}
+	return nil
+}
+
+func (r *EventRepository) PurgeIdempotencyKeys(ctx context.Context, before time.Time) (int64, error) {
+	result, err := r.db.ExecContext(ctx, purgeIdempotencyKeysQuery, before.UTC())
+	if err != nil {
+		return 0, fmt.Errorf("error purging idempotency keys: %w", err)
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,201 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/dontpanicw/EventBooker/internal/domain"
+)
+
+const (
+	createPromoCodeQuery = `INSERT INTO promo_codes (code, event_id, discount_type, value, currency, max_uses, valid_from, valid_until, created_at)
+						VALUES (?, NULLIF(?, ''), ?, ?, ?, ?, ?, ?, ?)
+						ON CONFLICT (code) DO NOTHING;`
+	// selectPromoCodesQuery считает только использования неотмененными бронями
+	selectPromoCodesQuery = `SELECT p.code, COALESCE(p.event_id, ''), p.discount_type, p.value, p.currency, p.max_uses,
+							p.valid_from, p.valid_until, p.created_at,
+							(SELECT COUNT(*) FROM promo_redemptions r JOIN bookings b ON b.id = r.booking_id
+								WHERE r.code = p.code AND b.status <> ?)
+						FROM promo_codes p`
+	getPromoCodeQuery   = selectPromoCodesQuery + ` WHERE p.code = ?;`
+	listPromoCodesQuery = selectPromoCodesQuery + ` ORDER BY p.created_at, p.code;`
+	userUsedPromoQuery  = `SELECT EXISTS (
+							SELECT 1 FROM promo_redemptions r JOIN bookings b ON b.id = r.booking_id
+							WHERE r.code = ? AND r.user_id = ? AND b.status <> ?);`
+	insertRedemptionQuery = `INSERT INTO promo_redemptions (booking_id, code, user_id, event_id, discount, redeemed_at)
+						VALUES (?, ?, ?, ?, ?, ?);`
+	// promoTotalsQuery - суммы скидок неотмененных броней по кодам и валютам; пустой код - все коды
+	promoTotalsQuery = `SELECT r.code, b.currency, SUM(r.discount)
+						FROM promo_redemptions r JOIN bookings b ON b.id = r.booking_id
+						WHERE b.status <> ? AND (? = '' OR r.code = ?)
+						GROUP BY r.code, b.currency
+						ORDER BY b.currency;`
+	listRedemptionsQuery = `SELECT r.code, r.booking_id, r.user_id, r.event_id, r.discount, b.currency, b.status, r.redeemed_at
+						FROM promo_redemptions r JOIN bookings b ON b.id = r.booking_id
+						WHERE r.code = ?
+						ORDER BY r.redeemed_at, r.booking_id;`
+)
+
+func (r *EventRepository) CreatePromoCode(ctx context.Context, promo *domain.PromoCode) error {
+	result, err := r.db.ExecContext(ctx, createPromoCodeQuery,
+		promo.Code, promo.EventId, promo.DiscountType, promoValue(promo), nullString(promo.Amount.Currency), promo.MaxUses,
+		nullTime(promo.ValidFrom), nullTime(promo.ValidUntil), promo.CreatedAt.UTC())
+	if err != nil {
+		return fmt.Errorf("error create promo code: %w", err)
+	}
+	if created, err := result.RowsAffected(); err != nil {
+		return fmt.Errorf("error create promo code: %w", err)
+	} else if created == 0 {
+		return fmt.Errorf("error create promo code: %w", domain.ErrPromoCodeExists)
+	}
+	return nil
+}
+
+func (r *EventRepository) GetPromoCode(ctx context.Context, code string) (*domain.PromoCode, error) {
+	promo, err := getPromoCode(ctx, r.db, code)
+	if err != nil {
+		return nil, fmt.Errorf("error get promo code: %w", err)
+	}
+	if err := r.addPromoTotals(ctx, code, promo); err != nil {
+		return nil, fmt.Errorf("error get promo code: %w", err)
+	}
+	return promo, nil
+}
+
+func (r *EventRepository) ListPromoCodes(ctx context.Context) ([]*domain.PromoCode, error) {
+	rows, err := r.db.QueryContext(ctx, listPromoCodesQuery, domain.CancelledStatus)
+	if err != nil {
+		return nil, fmt.Errorf("error list promo codes: %w", err)
+	}
+	defer rows.Close()
+
+	var promos []*domain.PromoCode
+	for rows.Next() {
+		promo, err := scanPromoCode(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error list promo codes: %w", err)
+		}
+		promos = append(promos, promo)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error list promo codes: %w", err)
+	}
+	if err := r.addPromoTotals(ctx, "", promos...); err != nil {
+		return nil, fmt.Errorf("error list promo codes: %w", err)
+	}
+	return promos, nil
+}
+
+// addPromoTotals заполняет TotalDiscount промокодов; code - единственный код promos или пустой
+func (r *EventRepository) addPromoTotals(ctx context.Context, code string, promos ...*domain.PromoCode) error {
+	rows, err := r.db.QueryContext(ctx, promoTotalsQuery, domain.CancelledStatus, code, code)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	totals := make(map[string][]domain.Money)
+	for rows.Next() {
+		var promoCode string
+		var total domain.Money
+		if err := rows.Scan(&promoCode, &total.Currency, (*minorUnits)(&total.Amount)); err != nil {
+			return err
+		}
+		totals[promoCode] = append(totals[promoCode], total)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	for _, promo := range promos {
+		promo.TotalDiscount = totals[promo.Code]
+	}
+	return nil
+}
+
+func (r *EventRepository) ListPromoRedemptions(ctx context.Context, code string) ([]*domain.PromoRedemption, error) {
+	rows, err := r.db.QueryContext(ctx, listRedemptionsQuery, code)
+	if err != nil {
+		return nil, fmt.Errorf("error list promo redemptions: %w", err)
+	}
+	defer rows.Close()
+
+	var redemptions []*domain.PromoRedemption
+	for rows.Next() {
+		var redemption domain.PromoRedemption
+		err := rows.Scan(&redemption.Code, &redemption.BookingId, &redemption.UserId, &redemption.EventId,
+			(*minorUnits)(&redemption.Discount.Amount), &redemption.Discount.Currency, &redemption.BookingStatus, &redemption.RedeemedAt)
+		if err != nil {
+			return nil, fmt.Errorf("error list promo redemptions: %w", err)
+		}
+		redemptions = append(redemptions, &redemption)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error list promo redemptions: %w", err)
+	}
+	return redemptions, nil
+}
+
+// promoDiscount проверяет промокод брони и возвращает скидку с booking.Price. Вызывается
+// в транзакции BookEvent после списания места: она уже держит блокировку записи,
+// поэтому параллельная бронь не превысит лимит использований.
+func promoDiscount(ctx context.Context, tx *sql.Tx, booking *domain.Booking) (domain.Money, error) {
+	promo, err := getPromoCode(ctx, tx, booking.PromoCode)
+	if err != nil {
+		return domain.Money{}, err
+	}
+	var used bool
+	err = tx.QueryRowContext(ctx, userUsedPromoQuery, booking.PromoCode, booking.UserId, domain.CancelledStatus).Scan(&used)
+	if err != nil {
+		return domain.Money{}, fmt.Errorf("failed to check promo code usage: %w", err)
+	}
+	return promo.Redeem(booking.EventId, booking.Price, booking.Date, used)
+}
+
+func getPromoCode(ctx context.Context, q querier, code string) (*domain.PromoCode, error) {
+	promo, err := scanPromoCode(q.QueryRowContext(ctx, getPromoCodeQuery, domain.CancelledStatus, code))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrPromoCodeNotFound
+		}
+		return nil, err
+	}
+	return promo, nil
+}
+
+func scanPromoCode(row interface{ Scan(dest ...any) error }) (*domain.PromoCode, error) {
+	var promo domain.PromoCode
+	var value float64
+	var currency sql.NullString
+	var validFrom, validUntil sql.NullTime
+	err := row.Scan(&promo.Code, &promo.EventId, &promo.DiscountType, &value, &currency, &promo.MaxUses,
+		&validFrom, &validUntil, &promo.CreatedAt, &promo.Uses)
+	if err != nil {
+		return nil, err
+	}
+	if promo.DiscountType == domain.DiscountFixed {
+		promo.Amount = domain.Money{Amount: int64(math.Round(value)), Currency: currency.String}
+	} else {
+		promo.Percent = value
+	}
+	promo.ValidFrom = validFrom.Time
+	promo.ValidUntil = validUntil.Time
+	return &promo, nil
+}
+
+// promoValue - значение колонки value: процент скидки или сумма фиксированной скидки
+// в минимальных единицах
+func promoValue(promo *domain.PromoCode) float64 {
+	if promo.DiscountType == domain.DiscountFixed {
+		return float64(promo.Amount.Amount)
+	}
+	return promo.Percent
+}
+
+// nullTime - NULL для нулевого времени
+func nullTime(t time.Time) sql.NullTime {
+	return sql.NullTime{Time: t.UTC(), Valid: !t.IsZero()}
+}
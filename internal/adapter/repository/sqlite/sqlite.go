@@ -0,0 +1,698 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"strings"
+
+	"github.com/dontpanicw/EventBooker/internal/domain"
+	"github.com/dontpanicw/EventBooker/internal/port"
+	"github.com/dontpanicw/EventBooker/pkg/migrations"
+
+	_ "modernc.org/sqlite"
+)
+
+// DSNScheme - схема MASTER_DSN для SQLite: sqlite://events.db или sqlite:///var/lib/eventbooker/events.db
+const DSNScheme = "sqlite://"
+
+// connectionPragmas включают внешние ключи и WAL, а время пишется в сортируемом формате
+const connectionPragmas = "_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_time_format=sqlite"
+
+const (
+	createEventQuery = `INSERT INTO events (id, name, description, is_free, price, currency, organizer_id, available_tickets, capacity, date, sale_mode) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?);`
+	// selectEventsQuery считает оплаченные и ожидающие оплаты брони каждого события
+	selectEventsQuery = `SELECT e.id, e.name, e.description, e.is_free, COALESCE(e.price, 0), e.currency, e.organizer_id, e.available_tickets, e.capacity, e.date, e.version, e.sale_mode,
+							COUNT(b.id) FILTER (WHERE b.status = ?),
+							COUNT(b.id) FILTER (WHERE b.status = ?)
+						FROM events e
+						LEFT JOIN bookings b ON b.event_id = e.id`
+	getEventQuery       = selectEventsQuery + ` WHERE e.id = ? GROUP BY e.id;`
+	bookEventQuery      = `INSERT INTO bookings (id, user_id, event_id, status, date, price, currency, promo_code) VALUES (?, ?, ?, ?, ?, ?, ?, NULLIF(?, ''));`
+	getBookingQuery     = `SELECT id, user_id, event_id, status, date, price, currency, COALESCE(promo_code, ''), version FROM bookings WHERE id = ?;`
+	transitionQuery     = `UPDATE bookings SET status = ?, version = version + 1 WHERE id = ? AND status = ? RETURNING user_id, event_id, version;`
+	eventSaleModeQuery  = `SELECT sale_mode FROM events WHERE id = ?;`
+	bookingVersionQuery = `SELECT version FROM bookings WHERE id = ?;`
+	// updateEventQuery списывает билет, только если мероприятие продается в указанном режиме,
+	// и возвращает то, от чего зависит цена билета
+	updateEventQuery = `UPDATE events
+						SET available_tickets = available_tickets - 1, version = version + 1
+						WHERE id = ? AND available_tickets > 0 AND sale_mode = ?
+						RETURNING available_tickets, capacity, is_free, COALESCE(price, 0), currency, organizer_id, name, date;`
+	addAvailableTicketQuery = `UPDATE events
+							   SET available_tickets = available_tickets + 1, version = version + 1
+							   WHERE id = ?
+							   RETURNING available_tickets;`
+	// bumpEventVersionQuery отмечает изменение Sold и Held события при оплате брони
+	bumpEventVersionQuery = `UPDATE events SET version = version + 1 WHERE id = ?;`
+	editEventQuery        = `UPDATE events SET name = ?, description = ?, is_free = ?, price = ?, version = version + 1 WHERE id = ?;`
+	// selectBookingsQuery выбирает брони с мероприятиями; счетчики броней мероприятия
+	// считаются подзапросами только для строк страницы
+	selectBookingsQuery = `SELECT b.id, b.user_id, b.event_id, b.status, b.date, b.price, b.currency, COALESCE(b.promo_code, ''), b.version,
+							e.id, e.name, e.description, e.is_free, COALESCE(e.price, 0), e.currency, e.organizer_id, e.available_tickets, e.capacity, e.date, e.version, e.sale_mode,
+							(SELECT COUNT(*) FROM bookings s WHERE s.event_id = e.id AND s.status = ?),
+							(SELECT COUNT(*) FROM bookings s WHERE s.event_id = e.id AND s.status = ?)
+						FROM bookings b
+						JOIN events e ON e.id = b.event_id`
+	// searchEventsQuery выбирает совпадения из events_fts (bm25 меньше - лучше, название весит вдвое
+	// больше описания) и досчитывает брони только для найденных событий
+	searchEventsQuery = `SELECT e.id, e.name, e.description, e.is_free, COALESCE(e.price, 0), e.currency, e.organizer_id, e.available_tickets, e.capacity, e.date, e.version, e.sale_mode,
+							COUNT(b.id) FILTER (WHERE b.status = ?),
+							COUNT(b.id) FILTER (WHERE b.status = ?),
+							m.rank, m.name_highlight, m.description_highlight
+						FROM (
+							SELECT event_id, bm25(events_fts, 0, 2.0, 1.0) AS rank,
+								highlight(events_fts, 1, '<mark>', '</mark>') AS name_highlight,
+								snippet(events_fts, 2, '<mark>', '</mark>', '…', 20) AS description_highlight
+							FROM events_fts
+							WHERE events_fts MATCH ?
+							ORDER BY rank
+							LIMIT ?
+						) m
+						JOIN events e ON e.id = m.event_id
+						LEFT JOIN bookings b ON b.event_id = e.id
+						GROUP BY e.id
+						ORDER BY m.rank, e.id;`
+)
+
+// EventRepository хранит данные в одном файле SQLite. SQLite допускает одного писателя,
+// поэтому пул ограничен одним соединением: операции выполняются по очереди,
+// и списание билетов остается атомарным без SQLITE_BUSY.
+type EventRepository struct {
+	db *sql.DB
+}
+
+// NewEventRepository открывает файл из DSN вида sqlite://path и применяет миграции
+func NewEventRepository(dsn string) (port.Repository, error) {
+	path := strings.TrimPrefix(dsn, DSNScheme)
+	if path == "" {
+		return nil, fmt.Errorf("empty sqlite path in dsn %q", dsn)
+	}
+
+	separator := "?"
+	if strings.Contains(path, "?") {
+		separator = "&"
+	}
+
+	db, err := sql.Open("sqlite", path+separator+connectionPragmas)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open sqlite: %w", err)
+	}
+	db.SetMaxOpenConns(1)
+
+	if err := migrations.MigrateSQLite(db); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to apply sqlite migrations: %w", err)
+	}
+
+	return &EventRepository{db: db}, nil
+}
+
+// Close закрывает файл БД
+func (r *EventRepository) Close() error {
+	return r.db.Close()
+}
+
+func (r *EventRepository) CreateEvent(ctx context.Context, event *domain.Event) (string, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		err = tx.Rollback()
+		if err != nil && !errors.Is(err, sql.ErrTxDone) {
+			log.Printf("failed to rollback transaction: %v", err)
+		}
+	}()
+
+	_, err = tx.ExecContext(ctx, createEventQuery,
+		event.Id,
+		event.Name,
+		event.Description,
+		event.IsFree,
+		event.Price.Amount,
+		event.Price.Currency,
+		event.OrganizerId,
+		event.AvailableTickets,
+		event.Capacity,
+		event.Date.UTC(),
+		event.SaleMode,
+	)
+	if err != nil {
+		return "", fmt.Errorf("error create event: %w", err)
+	}
+	if err := insertAudit(ctx, tx, port.NewEventCreatedAuditEntry(ctx, event)); err != nil {
+		return "", err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return "", fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return event.Id, nil
+}
+
+func (r *EventRepository) BookEvent(ctx context.Context, booking *domain.Booking) (*domain.Event, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		err = tx.Rollback()
+		if err != nil && !errors.Is(err, sql.ErrTxDone) {
+			log.Printf("failed to rollback transaction: %v", err)
+		}
+	}() // Будет отменен, если не закоммитим
+
+	event, err := takeTicket(ctx, tx, booking.EventId, domain.SaleModeFirstCome)
+	if err != nil {
+		return nil, err
+	}
+	if booking.Admission != nil {
+		if err := useAdmission(ctx, tx, booking); err != nil {
+			return nil, err
+		}
+	}
+	newAvailableTickets := int(event.AvailableTickets)
+	face, err := ticketPrice(ctx, tx, event, booking.Date)
+	if err != nil {
+		return nil, err
+	}
+	booking.Price = face
+	discount := domain.Money{Currency: face.Currency}
+	if booking.PromoCode != "" {
+		if discount, err = promoDiscount(ctx, tx, booking); err != nil {
+			return nil, fmt.Errorf("failed to book event: %w", err)
+		}
+	}
+	if err := chargeBooking(ctx, tx, event, booking, face, discount); err != nil {
+		return nil, err
+	}
+
+	_, err = tx.ExecContext(ctx, bookEventQuery,
+		booking.Id,
+		booking.UserId,
+		booking.EventId,
+		booking.Status,
+		booking.Date.UTC(),
+		booking.Price.Amount,
+		booking.Price.Currency,
+		booking.PromoCode,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to book event: %w", err)
+	}
+	if err := insertLineItems(ctx, tx, booking); err != nil {
+		return nil, err
+	}
+	if booking.PromoCode != "" {
+		_, err = tx.ExecContext(ctx, insertRedemptionQuery,
+			booking.Id, booking.PromoCode, booking.UserId, booking.EventId, discount.Amount, booking.Date.UTC())
+		if err != nil {
+			return nil, fmt.Errorf("failed to redeem promo code: %w", err)
+		}
+	}
+
+	if err := insertAudit(ctx, tx,
+		port.NewBookingAuditEntry(ctx, booking, domain.AuditBookingCreated, ""),
+		port.NewTicketsAuditEntry(ctx, booking.EventId, newAvailableTickets+1, newAvailableTickets),
+	); err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	log.Printf("Ticket booked successfully. Booking ID: %s, Remaining tickets: %d",
+		booking.Id, newAvailableTickets)
+
+	return event, nil
+}
+
+// takeTicket списывает билет мероприятия, продающегося в режиме saleMode, и возвращает
+// мероприятие с оставшимся числом мест и полями, нужными для цены билета (ticketPrice)
+func takeTicket(ctx context.Context, tx *sql.Tx, eventID, saleMode string) (*domain.Event, error) {
+	event := &domain.Event{Id: eventID}
+	err := tx.QueryRowContext(ctx, updateEventQuery, eventID, saleMode).
+		Scan(&event.AvailableTickets, &event.Capacity, &event.IsFree, (*minorUnits)(&event.Price.Amount), &event.Price.Currency, &event.OrganizerId,
+			&event.Name, &event.Date)
+	if err == nil {
+		return event, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("failed to update tickets: %w", err)
+	}
+
+	// Билетов нет, событие не найдено или продается в другом режиме
+	var mode string
+	if err := tx.QueryRowContext(ctx, eventSaleModeQuery, eventID).Scan(&mode); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("failed to book event: %w", domain.ErrEventNotFound)
+		}
+		return nil, fmt.Errorf("failed to check event: %w", err)
+	}
+	if mode != saleMode {
+		if mode == domain.SaleModeBallot {
+			return nil, fmt.Errorf("failed to book event: %w", domain.ErrBallotEvent)
+		}
+		return nil, fmt.Errorf("failed to book event: %w", domain.ErrNotBallotEvent)
+	}
+	return nil, domain.ErrNoTicketsAvailable
+}
+
+func (r *EventRepository) ConfirmBooking(ctx context.Context, bookingID string, version int64) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		err = tx.Rollback()
+		if err != nil && !errors.Is(err, sql.ErrTxDone) {
+			log.Printf("failed to rollback transaction: %v", err)
+		}
+	}()
+
+	booking, err := r.transition(ctx, tx, bookingID, domain.ConfirmedStatus, version)
+	if err != nil {
+		return fmt.Errorf("error confirm booking: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, bumpEventVersionQuery, booking.EventId); err != nil {
+		return fmt.Errorf("error confirm booking: %w", err)
+	}
+	if err := insertAudit(ctx, tx, port.NewBookingAuditEntry(ctx, booking, domain.AuditBookingConfirmed, domain.PendingStatus)); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+func (r *EventRepository) CancelBooking(ctx context.Context, bookingID string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		err = tx.Rollback()
+		if err != nil && !errors.Is(err, sql.ErrTxDone) {
+			log.Printf("failed to rollback transaction: %v", err)
+		}
+	}()
+
+	booking, err := r.transition(ctx, tx, bookingID, domain.CancelledStatus, 0)
+	if err != nil {
+		return fmt.Errorf("error cancel booking: %w", err)
+	}
+
+	// Место возвращаем в той же транзакции, иначе счетчик разойдется с бронями при сбое
+	var newAvailableTickets int
+	if err := tx.QueryRowContext(ctx, addAvailableTicketQuery, booking.EventId).Scan(&newAvailableTickets); err != nil {
+		return fmt.Errorf("failed to release ticket: %w", err)
+	}
+
+	if err := insertAudit(ctx, tx,
+		port.NewBookingAuditEntry(ctx, booking, domain.AuditBookingCancelled, domain.PendingStatus),
+		port.NewTicketsAuditEntry(ctx, booking.EventId, newAvailableTickets-1, newAvailableTickets),
+	); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// querier - *sql.DB или *sql.Tx
+type querier interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// transition переводит бронь из pending в status и возвращает бронь без даты;
+// ненулевой version сверяется с версией брони. Запросы идут через q:
+// внутри транзакции единственное соединение занято ею.
+func (r *EventRepository) transition(ctx context.Context, q querier, bookingID, status string, version int64) (*domain.Booking, error) {
+	var current int64
+	if err := q.QueryRowContext(ctx, bookingVersionQuery, bookingID).Scan(&current); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrBookingNotFound
+		}
+		return nil, err
+	}
+	if version != 0 && current != version {
+		return nil, domain.ErrVersionMismatch
+	}
+
+	booking := &domain.Booking{Id: bookingID, Status: status}
+	err := q.QueryRowContext(ctx, transitionQuery, status, bookingID, domain.PendingStatus).Scan(&booking.UserId, &booking.EventId, &booking.Version)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, domain.ErrBookingNotPending
+	}
+	if err != nil {
+		return nil, err
+	}
+	return booking, nil
+}
+
+func (r *EventRepository) UpdateEvent(ctx context.Context, eventID string, version int64, update domain.EventUpdate) (*domain.Event, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		err = tx.Rollback()
+		if err != nil && !errors.Is(err, sql.ErrTxDone) {
+			log.Printf("failed to rollback transaction: %v", err)
+		}
+	}()
+
+	// Единственное соединение занято транзакцией, поэтому между чтением версии и записью
+	// событие никто не изменит
+	old, err := scanEvent(tx.QueryRowContext(ctx, getEventQuery, domain.ConfirmedStatus, domain.PendingStatus, eventID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("error update event: %w", domain.ErrEventNotFound)
+		}
+		return nil, fmt.Errorf("error update event: %w", err)
+	}
+	if version != 0 && old.Version != version {
+		return nil, fmt.Errorf("error update event: %w", domain.ErrVersionMismatch)
+	}
+	if err := update.ValidateFor(*old); err != nil {
+		return nil, fmt.Errorf("error update event: %w", err)
+	}
+
+	event := *old
+	update.Apply(&event)
+	event.Version++
+	if _, err := tx.ExecContext(ctx, editEventQuery, event.Name, event.Description, event.IsFree, event.Price.Amount, eventID); err != nil {
+		return nil, fmt.Errorf("error update event: %w", err)
+	}
+	if err := insertAudit(ctx, tx, port.NewEventUpdatedAuditEntry(ctx, old, &event)); err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return &event, nil
+}
+
+func (r *EventRepository) GetEvent(ctx context.Context, eventID string) (*domain.Event, error) {
+	row := r.db.QueryRowContext(ctx, getEventQuery, domain.ConfirmedStatus, domain.PendingStatus, eventID)
+	event, err := scanEvent(row)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("error get event: %w", domain.ErrEventNotFound)
+		}
+		return nil, fmt.Errorf("error get event: %w", err)
+	}
+	if event.PricePhases, err = listPricePhases(ctx, r.db, eventID); err != nil {
+		return nil, fmt.Errorf("error get event: %w", err)
+	}
+	return event, nil
+}
+
+// scanEvent читает строку selectEventsQuery
+func scanEvent(row interface{ Scan(dest ...any) error }) (*domain.Event, error) {
+	var event domain.Event
+	err := row.Scan(&event.Id, &event.Name, &event.Description, &event.IsFree, (*minorUnits)(&event.Price.Amount), &event.Price.Currency, &event.OrganizerId,
+		&event.AvailableTickets, &event.Capacity, &event.Date, &event.Version, &event.SaleMode, &event.Sold, &event.Held)
+	if err != nil {
+		return nil, err
+	}
+	return &event, nil
+}
+
+func (r *EventRepository) ListEvents(ctx context.Context, filter domain.EventFilter) (*domain.EventPage, error) {
+	query, args := listEventsQuery(filter)
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error querying all events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []*domain.Event
+	for rows.Next() {
+		event, err := scanEvent(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning event: %w", err)
+		}
+		events = append(events, event)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating events: %w", err)
+	}
+
+	return domain.NewEventPage(events, filter), nil
+}
+
+// listEventsQuery строит выборку страницы: фильтры и курсор уходят в WHERE,
+// сортировка по ключу и id совпадает с индексами миграции 005.
+// Даты хранятся в UTC, поэтому границы тоже переводятся в UTC.
+func listEventsQuery(filter domain.EventFilter) (string, []any) {
+	args := []any{domain.ConfirmedStatus, domain.PendingStatus}
+	var where []string
+	if !filter.DateFrom.IsZero() {
+		where = append(where, "e.date >= ?")
+		args = append(args, filter.DateFrom.UTC())
+	}
+	if !filter.DateTo.IsZero() {
+		where = append(where, "e.date < ?")
+		args = append(args, filter.DateTo.UTC())
+	}
+	if filter.IsFree != nil {
+		where = append(where, "e.is_free = ?")
+		args = append(args, *filter.IsFree)
+	}
+	if filter.Currency != "" {
+		where = append(where, "e.currency = ?")
+		args = append(args, filter.Currency)
+	}
+	if filter.MinPrice != nil {
+		where = append(where, "COALESCE(e.price, 0) >= ?")
+		args = append(args, *filter.MinPrice)
+	}
+	if filter.MaxPrice != nil {
+		where = append(where, "COALESCE(e.price, 0) <= ?")
+		args = append(args, *filter.MaxPrice)
+	}
+	if filter.OnlyAvailable {
+		where = append(where, "e.available_tickets > 0")
+	}
+
+	byPrice := filter.Sort == domain.SortPriceAsc || filter.Sort == domain.SortPriceDesc
+	key, direction, op := "e.date", "ASC", ">"
+	if byPrice {
+		key = "COALESCE(e.price, 0)"
+	}
+	if filter.Sort == domain.SortDateDesc || filter.Sort == domain.SortPriceDesc {
+		direction, op = "DESC", "<"
+	}
+	if c := filter.After; c != nil {
+		var value any = c.Date.UTC()
+		if byPrice {
+			value = c.Price
+		}
+		where = append(where, fmt.Sprintf("(%s, e.id) %s (?, ?)", key, op))
+		args = append(args, value, c.Id)
+	}
+
+	query := selectEventsQuery
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += fmt.Sprintf(" GROUP BY e.id ORDER BY %s %s, e.id %s LIMIT ?;", key, direction, direction)
+	args = append(args, filter.Limit+1)
+	return query, args
+}
+
+func (r *EventRepository) SearchEvents(ctx context.Context, query string, limit int) ([]*domain.EventSearchResult, error) {
+	rows, err := r.db.QueryContext(ctx, searchEventsQuery,
+		domain.ConfirmedStatus, domain.PendingStatus, matchExpression(query), limit)
+	if err != nil {
+		return nil, fmt.Errorf("error searching events: %w", err)
+	}
+	defer rows.Close()
+
+	var results []*domain.EventSearchResult
+	for rows.Next() {
+		var event domain.Event
+		var result domain.EventSearchResult
+		err := rows.Scan(&event.Id, &event.Name, &event.Description, &event.IsFree, (*minorUnits)(&event.Price.Amount), &event.Price.Currency, &event.OrganizerId,
+			&event.AvailableTickets, &event.Capacity, &event.Date, &event.Version, &event.SaleMode, &event.Sold, &event.Held,
+			&result.Rank, &result.NameHighlight, &result.DescriptionHighlight)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning search result: %w", err)
+		}
+		result.Event = &event
+		result.Rank = -result.Rank
+		results = append(results, &result)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating search results: %w", err)
+	}
+
+	return results, nil
+}
+
+// matchExpression переводит запрос в синтаксис FTS5: все слова обязательны и ищутся как префиксы.
+// SearchTerms оставляет только буквы и цифры, поэтому кавычки внутри слов не встречаются.
+func matchExpression(query string) string {
+	terms := domain.SearchTerms(query)
+	for i, term := range terms {
+		terms[i] = `"` + term + `"*`
+	}
+	return strings.Join(terms, " ")
+}
+
+func (r *EventRepository) GetBooking(ctx context.Context, bookingID string) (*domain.Booking, error) {
+	var booking domain.Booking
+	err := r.db.QueryRowContext(ctx, getBookingQuery, bookingID).Scan(
+		&booking.Id,
+		&booking.UserId,
+		&booking.EventId,
+		&booking.Status,
+		&booking.Date,
+		(*minorUnits)(&booking.Price.Amount),
+		&booking.Price.Currency,
+		&booking.PromoCode,
+		&booking.Version,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("error get booking: %w", domain.ErrBookingNotFound)
+		}
+		return nil, fmt.Errorf("error get booking: %w", err)
+	}
+	if booking.LineItems, err = listLineItems(ctx, r.db, booking.Id, booking.Price.Currency); err != nil {
+		return nil, fmt.Errorf("error get booking: %w", err)
+	}
+	return &booking, nil
+}
+
+func (r *EventRepository) ListBookings(ctx context.Context, filter domain.BookingFilter) (*domain.BookingPage, error) {
+	args := []any{domain.ConfirmedStatus, domain.PendingStatus}
+	var where []string
+	if filter.UserId != "" {
+		where = append(where, "b.user_id = ?")
+		args = append(args, filter.UserId)
+	}
+	if filter.EventId != "" {
+		where = append(where, "b.event_id = ?")
+		args = append(args, filter.EventId)
+	}
+	if filter.Status != "" {
+		where = append(where, "b.status = ?")
+		args = append(args, filter.Status)
+	}
+	if c := filter.After; c != nil {
+		where = append(where, "(b.date, b.id) < (?, ?)")
+		args = append(args, c.Date.UTC(), c.Id)
+	}
+	query := selectBookingsQuery + " WHERE " + strings.Join(where, " AND ") +
+		" ORDER BY b.date DESC, b.id DESC LIMIT ?;"
+	args = append(args, filter.Limit+1)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error querying user bookings: %w", err)
+	}
+	defer rows.Close()
+
+	var bookings []*domain.BookingDetails
+	for rows.Next() {
+		var booking domain.Booking
+		var event domain.Event
+		err := rows.Scan(&booking.Id, &booking.UserId, &booking.EventId, &booking.Status, &booking.Date,
+			(*minorUnits)(&booking.Price.Amount), &booking.Price.Currency, &booking.PromoCode, &booking.Version,
+			&event.Id, &event.Name, &event.Description, &event.IsFree, (*minorUnits)(&event.Price.Amount), &event.Price.Currency, &event.OrganizerId,
+			&event.AvailableTickets, &event.Capacity, &event.Date, &event.Version, &event.SaleMode, &event.Sold, &event.Held)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning user booking: %w", err)
+		}
+		bookings = append(bookings, &domain.BookingDetails{Booking: &booking, Event: &event})
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating user bookings: %w", err)
+	}
+	rows.Close()
+
+	page := domain.NewBookingPage(bookings, filter)
+	if filter.LineItems {
+		if err := loadLineItems(ctx, r.db, page.Bookings); err != nil {
+			return nil, fmt.Errorf("error get line items: %w", err)
+		}
+	}
+	return page, nil
+}
+
+func (r *EventRepository) IncrementAvailableTickets(ctx context.Context, eventID string) error {
+	if err := r.addTicket(ctx, eventID); err != nil {
+		return fmt.Errorf("failed to increment tickets: %w", err)
+	}
+	return nil
+}
+
+func (r *EventRepository) AddAvailableTickets(ctx context.Context, eventID string) error {
+	if err := r.addTicket(ctx, eventID); err != nil {
+		return fmt.Errorf("failed to update tickets: %w", err)
+	}
+	return nil
+}
+
+func (r *EventRepository) addTicket(ctx context.Context, eventID string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		err = tx.Rollback()
+		if err != nil && !errors.Is(err, sql.ErrTxDone) {
+			log.Printf("failed to rollback transaction: %v", err)
+		}
+	}()
+
+	var newAvailableTickets int
+	err = tx.QueryRowContext(ctx, addAvailableTicketQuery, eventID).Scan(&newAvailableTickets)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.ErrEventNotFound
+		}
+		return err
+	}
+	if err := insertAudit(ctx, tx, port.NewTicketsAuditEntry(ctx, eventID, newAvailableTickets-1, newAvailableTickets)); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	log.Printf("Tickets incremented successfully. Available tickets: %d", newAvailableTickets)
+	return nil
+}
+
+// minorUnits читает сумму в минимальных единицах валюты. SQLite не меняет тип колонки,
+// поэтому после миграции 015 суммы лежат в колонках REAL и читаются как float64.
+type minorUnits int64
+
+func (m *minorUnits) Scan(src any) error {
+	switch v := src.(type) {
+	case nil:
+		*m = 0
+	case int64:
+		*m = minorUnits(v)
+	case float64:
+		*m = minorUnits(math.Round(v))
+	default:
+		return fmt.Errorf("unsupported amount type %T", src)
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package sqlite
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dontpanicw/EventBooker/internal/adapter/repository/repositorytest"
+	"github.com/dontpanicw/EventBooker/internal/domain"
+	"github.com/dontpanicw/EventBooker/internal/port"
+	"github.com/pressly/goose/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventRepository_Conformance(t *testing.T) {
+	repositorytest.Run(t, func(t *testing.T) port.Repository {
+		repo, err := NewEventRepository(DSNScheme + filepath.Join(t.TempDir(), "events.db"))
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			repo.(*EventRepository).Close()
+		})
+		return repo
+	})
+}
+
+// Бесплатные события, созданные до миграции 015 или вручную, хранят цену NULL
+func TestEventRepository_NullPrice(t *testing.T) {
+	repo, err := NewEventRepository(DSNScheme + filepath.Join(t.TempDir(), "events.db"))
+	require.NoError(t, err)
+	r := repo.(*EventRepository)
+	t.Cleanup(func() {
+		r.Close()
+	})
+
+	ctx := context.Background()
+	date := time.Date(2026, time.March, 1, 19, 0, 0, 0, time.UTC)
+	_, err = r.db.ExecContext(ctx, createEventQuery, "event-1", "Open lecture", "Free lecture", true, nil,
+		domain.DefaultCurrency, "", 10, 10, date, domain.SaleModeFirstCome)
+	require.NoError(t, err)
+
+	event, err := r.GetEvent(ctx, "event-1")
+	require.NoError(t, err)
+	assert.Equal(t, domain.Money{Currency: domain.DefaultCurrency}, event.Price)
+
+	page, err := r.ListEvents(ctx, domain.EventFilter{Limit: domain.DefaultEventsLimit})
+	require.NoError(t, err)
+	require.Len(t, page.Events, 1)
+	assert.Equal(t, int64(0), page.Events[0].Price.Amount)
+
+	results, err := r.SearchEvents(ctx, "lecture", 10)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, int64(0), results[0].Event.Price.Amount)
+}
+
+// Миграция 019 помечает события без записи event_created - созданные до аудита и миграции 002
+func TestMigrationFlagsUnverifiedCapacities(t *testing.T) {
+	repo, err := NewEventRepository(DSNScheme + filepath.Join(t.TempDir(), "events.db"))
+	require.NoError(t, err)
+	r := repo.(*EventRepository)
+	t.Cleanup(func() {
+		r.Close()
+	})
+
+	ctx := context.Background()
+	date := time.Date(2026, time.March, 1, 19, 0, 0, 0, time.UTC)
+	created := &domain.Event{Id: "event-new", Name: "Concert", Price: domain.Money{Amount: 150000, Currency: "RUB"},
+		AvailableTickets: 10, Capacity: 10, Date: date, SaleMode: domain.SaleModeFirstCome}
+	_, err = r.CreateEvent(ctx, created)
+	require.NoError(t, err)
+	_, err = r.db.ExecContext(ctx, createEventQuery, "event-legacy", "Old concert", "", false, 150000,
+		domain.DefaultCurrency, "", 4, 5, date, domain.SaleModeFirstCome)
+	require.NoError(t, err)
+
+	// Применяем 019 заново к базе, где уже есть событие без записи о создании
+	const dir = "../../../../pkg/migrations/sqlite"
+	goose.SetBaseFS(nil)
+	require.NoError(t, goose.SetDialect("sqlite3"))
+	require.NoError(t, goose.DownToContext(ctx, r.db, dir, 18))
+	require.NoError(t, goose.UpContext(ctx, r.db, dir))
+
+	unverified, err := r.ListUnverifiedCapacities(ctx)
+	require.NoError(t, err)
+	require.Len(t, unverified, 1)
+	assert.Equal(t, "event-legacy", unverified[0].EventId)
+	assert.Equal(t, 5, unverified[0].Capacity)
+	assert.Equal(t, 4, unverified[0].AvailableTickets)
+	assert.False(t, unverified[0].FlaggedAt.IsZero())
+
+	require.NoError(t, r.VerifyCapacity(ctx, "event-legacy"))
+	unverified, err = r.ListUnverifiedCapacities(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, unverified)
+	assert.ErrorIs(t, r.VerifyCapacity(ctx, "event-legacy"), domain.ErrUnverifiedCapacityNotFound)
+}
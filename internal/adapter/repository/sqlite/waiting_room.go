@@ -0,0 +1,132 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/dontpanicw/EventBooker/internal/domain"
+)
+
+const (
+	saveWaitingRoomQuery = `INSERT INTO waiting_rooms (event_id, rate_per_minute, joined, last_admit_at, opened_at)
+								VALUES (?, ?, 0, ?, ?)
+								ON CONFLICT (event_id) DO UPDATE SET rate_per_minute = excluded.rate_per_minute;`
+	getWaitingRoomQuery = `SELECT event_id, rate_per_minute, joined, last_admit_at, opened_at
+							FROM waiting_rooms WHERE event_id = ?;`
+	joinWaitingRoomQuery   = `UPDATE waiting_rooms SET joined = ?, last_admit_at = ? WHERE event_id = ?;`
+	deleteWaitingRoomQuery = `DELETE FROM waiting_rooms WHERE event_id = ?;`
+	getQueueTicketQuery    = `SELECT position, admit_at, COALESCE(booking_id, '')
+								FROM waiting_room_tickets WHERE event_id = ? AND user_id = ?;`
+	saveQueueTicketQuery = `INSERT INTO waiting_room_tickets (event_id, user_id, position, admit_at)
+								VALUES (?, ?, ?, ?)
+								ON CONFLICT (event_id, user_id) DO UPDATE SET
+									position = excluded.position, admit_at = excluded.admit_at, booking_id = NULL;`
+	useQueueTicketQuery = `UPDATE waiting_room_tickets SET booking_id = ?
+							WHERE event_id = ? AND user_id = ? AND position = ? AND booking_id IS NULL;`
+)
+
+func (r *EventRepository) SaveWaitingRoom(ctx context.Context, room *domain.WaitingRoom) (*domain.WaitingRoom, error) {
+	_, err := r.db.ExecContext(ctx, saveWaitingRoomQuery,
+		room.EventId, room.RatePerMinute, room.OpenedAt.UTC(), room.OpenedAt.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("error save waiting room: %w", err)
+	}
+	return r.GetWaitingRoom(ctx, room.EventId)
+}
+
+func (r *EventRepository) GetWaitingRoom(ctx context.Context, eventID string) (*domain.WaitingRoom, error) {
+	room, err := scanWaitingRoom(r.db.QueryRowContext(ctx, getWaitingRoomQuery, eventID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("error get waiting room: %w", domain.ErrWaitingRoomNotFound)
+		}
+		return nil, fmt.Errorf("error get waiting room: %w", err)
+	}
+	return room, nil
+}
+
+// JoinWaitingRoom выполняется в транзакции на единственном соединении, поэтому
+// два пользователя не получат одну позицию, а повторные входы одного пользователя - два места
+func (r *EventRepository) JoinWaitingRoom(ctx context.Context, eventID, userID string, now time.Time) (*domain.QueueTicket, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		err = tx.Rollback()
+		if err != nil && !errors.Is(err, sql.ErrTxDone) {
+			log.Printf("failed to rollback transaction: %v", err)
+		}
+	}()
+
+	room, err := scanWaitingRoom(tx.QueryRowContext(ctx, getWaitingRoomQuery, eventID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("error join waiting room: %w", domain.ErrWaitingRoomNotFound)
+		}
+		return nil, fmt.Errorf("error join waiting room: %w", err)
+	}
+
+	ticket := domain.QueueTicket{EventId: eventID, UserId: userID, OpenedAt: room.OpenedAt}
+	err = tx.QueryRowContext(ctx, getQueueTicketQuery, eventID, userID).Scan(&ticket.Position, &ticket.AdmitAt, &ticket.BookingId)
+	if err == nil && ticket.Active(now) {
+		return &ticket, nil
+	}
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("error join waiting room: %w", err)
+	}
+
+	ticket = room.Enqueue(userID, now)
+	if _, err := tx.ExecContext(ctx, joinWaitingRoomQuery, room.Joined, room.LastAdmitAt.UTC(), eventID); err != nil {
+		return nil, fmt.Errorf("error join waiting room: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, saveQueueTicketQuery, eventID, userID, ticket.Position, ticket.AdmitAt.UTC()); err != nil {
+		return nil, fmt.Errorf("error join waiting room: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return &ticket, nil
+}
+
+// useAdmission расходует допуск брони booking из очереди; место должно быть тем же, что
+// подписано в токене, и еще не использованным
+func useAdmission(ctx context.Context, tx *sql.Tx, booking *domain.Booking) error {
+	ticket := booking.Admission
+	result, err := tx.ExecContext(ctx, useQueueTicketQuery, booking.Id, ticket.EventId, ticket.UserId, ticket.Position)
+	if err != nil {
+		return fmt.Errorf("failed to use admission: %w", err)
+	}
+	if used, err := result.RowsAffected(); err != nil {
+		return fmt.Errorf("failed to use admission: %w", err)
+	} else if used == 0 {
+		return fmt.Errorf("failed to use admission: %w", domain.ErrAdmissionTokenUsed)
+	}
+	return nil
+}
+
+func (r *EventRepository) DeleteWaitingRoom(ctx context.Context, eventID string) error {
+	result, err := r.db.ExecContext(ctx, deleteWaitingRoomQuery, eventID)
+	if err != nil {
+		return fmt.Errorf("error delete waiting room: %w", err)
+	}
+	if deleted, err := result.RowsAffected(); err != nil {
+		return fmt.Errorf("error delete waiting room: %w", err)
+	} else if deleted == 0 {
+		return fmt.Errorf("error delete waiting room: %w", domain.ErrWaitingRoomNotFound)
+	}
+	return nil
+}
+
+func scanWaitingRoom(row interface{ Scan(dest ...any) error }) (*domain.WaitingRoom, error) {
+	var room domain.WaitingRoom
+	if err := row.Scan(&room.EventId, &room.RatePerMinute, &room.Joined, &room.LastAdmitAt, &room.OpenedAt); err != nil {
+		return nil, err
+	}
+	return &room, nil
+}
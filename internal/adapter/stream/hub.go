@@ -0,0 +1,165 @@
+package stream
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/dontpanicw/EventBooker/internal/domain"
+	"github.com/dontpanicw/EventBooker/internal/port"
+)
+
+// subscriberBuffer - сколько обновлений может ждать чтения; подписчик, переполнивший
+// буфер, отключается и при переподключении получает свежее состояние
+const subscriberBuffer = 64
+
+type subscriber struct {
+	filter  domain.UpdateFilter
+	updates chan *domain.Update
+}
+
+// Hub рассылает обновления подписчикам этого экземпляра приложения. Доменные события
+// приходят из тех же мест, что и в брокер: бронирование, оплата, отмена по таймауту.
+// Счетчики измененных событий перечитываются раз в flushInterval, поэтому во время
+// распродажи сотни броней одного события дают одно чтение и одно сообщение.
+type Hub struct {
+	repo          port.Repository
+	flushInterval time.Duration
+
+	mu          sync.Mutex
+	subscribers map[*subscriber]struct{}
+	dirty       map[string]struct{}
+}
+
+func NewHub(repo port.Repository, flushInterval time.Duration) *Hub {
+	return &Hub{
+		repo:          repo,
+		flushInterval: flushInterval,
+		subscribers:   make(map[*subscriber]struct{}),
+		dirty:         make(map[string]struct{}),
+	}
+}
+
+// Run рассылает счетчики измененных событий, пока ctx не отменен
+func (h *Hub) Run(ctx context.Context) {
+	ticker := time.NewTicker(h.flushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			h.flush(ctx)
+		}
+	}
+}
+
+// PublishLifecycleEvent принимает доменное событие: статус брони рассылается сразу,
+// а счетчики события - при следующем flush
+func (h *Hub) PublishLifecycleEvent(ctx context.Context, event *domain.LifecycleEvent) error {
+	switch data := event.Data.(type) {
+	case domain.BookingPayload:
+		h.broadcast(&domain.Update{
+			Type:    domain.UpdateBooking,
+			EventId: data.EventId,
+			Booking: &domain.Booking{
+				Id:      data.BookingId,
+				UserId:  data.UserId,
+				EventId: data.EventId,
+				Status:  data.Status,
+			},
+			Cause: event.Type,
+		})
+		h.markDirty(data.EventId)
+	case domain.EventPayload:
+		h.markDirty(data.EventId)
+	case domain.BallotPayload:
+		h.broadcast(&domain.Update{
+			Type:    domain.UpdateBallot,
+			EventId: data.EventId,
+			Entry: &domain.BallotEntry{
+				EventId:   data.EventId,
+				UserId:    data.UserId,
+				Status:    data.Status,
+				Rank:      data.Rank,
+				BookingId: data.BookingId,
+			},
+			Cause: event.Type,
+		})
+	}
+	return nil
+}
+
+func (h *Hub) Subscribe(filter domain.UpdateFilter) (<-chan *domain.Update, func()) {
+	sub := &subscriber{filter: filter, updates: make(chan *domain.Update, subscriberBuffer)}
+
+	h.mu.Lock()
+	h.subscribers[sub] = struct{}{}
+	h.mu.Unlock()
+
+	return sub.updates, func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		h.remove(sub)
+	}
+}
+
+// markDirty запоминает событие для flush, если его счетчики кому-то нужны
+func (h *Hub) markDirty(eventID string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for sub := range h.subscribers {
+		if sub.filter.WantsAvailability(eventID) {
+			h.dirty[eventID] = struct{}{}
+			return
+		}
+	}
+}
+
+func (h *Hub) flush(ctx context.Context) {
+	h.mu.Lock()
+	dirty := h.dirty
+	h.dirty = make(map[string]struct{})
+	h.mu.Unlock()
+
+	for eventID := range dirty {
+		// Из master: реплика может еще не увидеть изменение, о котором пришло событие
+		event, err := h.repo.GetEvent(port.WithStrongConsistency(ctx), eventID)
+		if err != nil {
+			if !errors.Is(err, domain.ErrEventNotFound) && ctx.Err() == nil {
+				log.Printf("Failed to read availability of event %s: %v", eventID, err)
+			}
+			continue
+		}
+		h.broadcast(&domain.Update{Type: domain.UpdateAvailability, EventId: eventID, Event: event})
+	}
+}
+
+// broadcast не ждет медленных подписчиков: переполненный канал закрывается
+func (h *Hub) broadcast(update *domain.Update) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for sub := range h.subscribers {
+		if !sub.filter.Matches(update) {
+			continue
+		}
+		select {
+		case sub.updates <- update:
+		default:
+			h.remove(sub)
+		}
+	}
+}
+
+// remove отписывает и закрывает канал; вызывается под h.mu
+func (h *Hub) remove(sub *subscriber) {
+	if _, ok := h.subscribers[sub]; ok {
+		delete(h.subscribers, sub)
+		close(sub.updates)
+	}
+}
@@ -0,0 +1,132 @@
+package stream
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dontpanicw/EventBooker/internal/adapter/repository/memory"
+	"github.com/dontpanicw/EventBooker/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestHub(t *testing.T) (*Hub, *domain.Event) {
+	t.Helper()
+
+	repo := memory.NewEventRepository()
+	event := &domain.Event{Id: "event-1", Name: "Concert", AvailableTickets: 3, Capacity: 3}
+	_, err := repo.CreateEvent(context.Background(), event)
+	require.NoError(t, err)
+	return NewHub(repo, time.Hour), event
+}
+
+func receive(t *testing.T, updates <-chan *domain.Update) *domain.Update {
+	t.Helper()
+
+	select {
+	case update, ok := <-updates:
+		require.True(t, ok, "updates channel closed")
+		return update
+	case <-time.After(time.Second):
+		t.Fatal("no update received")
+		return nil
+	}
+}
+
+func assertNoUpdate(t *testing.T, updates <-chan *domain.Update) {
+	t.Helper()
+
+	select {
+	case update := <-updates:
+		t.Fatalf("unexpected update %+v", update)
+	default:
+	}
+}
+
+func bookingEvent(eventType, status string) *domain.LifecycleEvent {
+	return domain.NewBookingLifecycleEvent(eventType, &domain.Booking{
+		Id: "booking-1", UserId: "user-1", EventId: "event-1", Status: status,
+	})
+}
+
+func TestHub_BookingUpdatesOnlyForOwner(t *testing.T) {
+	hub, _ := newTestHub(t)
+	owner, unsubscribeOwner := hub.Subscribe(domain.UpdateFilter{UserId: "user-1"})
+	defer unsubscribeOwner()
+	other, unsubscribeOther := hub.Subscribe(domain.UpdateFilter{EventId: "event-1", UserId: "user-2"})
+	defer unsubscribeOther()
+
+	require.NoError(t, hub.PublishLifecycleEvent(context.Background(), bookingEvent(domain.BookingExpiredEvent, domain.CancelledStatus)))
+
+	update := receive(t, owner)
+	assert.Equal(t, domain.UpdateBooking, update.Type)
+	assert.Equal(t, "booking-1", update.Booking.Id)
+	assert.Equal(t, domain.CancelledStatus, update.Booking.Status)
+	assert.Equal(t, domain.BookingExpiredEvent, update.Cause)
+	assertNoUpdate(t, other)
+}
+
+func TestHub_AvailabilityCoalesced(t *testing.T) {
+	hub, event := newTestHub(t)
+	all, unsubscribeAll := hub.Subscribe(domain.UpdateFilter{})
+	defer unsubscribeAll()
+	otherEvent, unsubscribeOther := hub.Subscribe(domain.UpdateFilter{EventId: "event-2"})
+	defer unsubscribeOther()
+
+	ctx := context.Background()
+	for range 3 {
+		require.NoError(t, hub.PublishLifecycleEvent(ctx, bookingEvent(domain.BookingCreatedEvent, domain.PendingStatus)))
+	}
+	require.NoError(t, hub.PublishLifecycleEvent(ctx, domain.NewEventLifecycleEvent(domain.EventSoldOutEvent, event)))
+	assertNoUpdate(t, all)
+
+	// Несколько изменений одного события до flush дают одно сообщение со счетчиками
+	hub.flush(ctx)
+	update := receive(t, all)
+	assert.Equal(t, domain.UpdateAvailability, update.Type)
+	assert.Equal(t, "event-1", update.EventId)
+	assert.Equal(t, uint32(3), update.Event.AvailableTickets)
+	assertNoUpdate(t, all)
+	assertNoUpdate(t, otherEvent)
+
+	hub.flush(ctx)
+	assertNoUpdate(t, all)
+}
+
+func TestHub_NoReadsWithoutSubscribers(t *testing.T) {
+	hub, _ := newTestHub(t)
+
+	require.NoError(t, hub.PublishLifecycleEvent(context.Background(), bookingEvent(domain.BookingCreatedEvent, domain.PendingStatus)))
+
+	assert.Empty(t, hub.dirty)
+}
+
+func TestHub_SlowSubscriberDisconnected(t *testing.T) {
+	hub, _ := newTestHub(t)
+	updates, unsubscribe := hub.Subscribe(domain.UpdateFilter{UserId: "user-1"})
+	defer unsubscribe()
+
+	for range subscriberBuffer + 1 {
+		require.NoError(t, hub.PublishLifecycleEvent(context.Background(), bookingEvent(domain.BookingCreatedEvent, domain.PendingStatus)))
+	}
+
+	received := 0
+	for range updates {
+		received++
+	}
+	assert.Equal(t, subscriberBuffer, received)
+	assert.Empty(t, hub.subscribers)
+}
+
+func TestHub_Unsubscribe(t *testing.T) {
+	hub, _ := newTestHub(t)
+	updates, unsubscribe := hub.Subscribe(domain.UpdateFilter{})
+
+	unsubscribe()
+	unsubscribe()
+
+	_, ok := <-updates
+	assert.False(t, ok)
+	assert.Empty(t, hub.subscribers)
+}
@@ -2,77 +2,138 @@ package app
 
 import (
 	"context"
-	"database/sql"
+	"crypto/rand"
+	"errors"
 	"fmt"
 	"github.com/dontpanicw/EventBooker/config"
-	"github.com/dontpanicw/EventBooker/internal/adapter/broker"
 	"github.com/dontpanicw/EventBooker/internal/adapter/consumer"
-	"github.com/dontpanicw/EventBooker/internal/adapter/repository/postgres"
+	"github.com/dontpanicw/EventBooker/internal/adapter/stream"
 	"github.com/dontpanicw/EventBooker/internal/input/http"
 	"github.com/dontpanicw/EventBooker/internal/usecases"
-	"github.com/dontpanicw/EventBooker/pkg/migrations"
 	"log"
+	nethttp "net/http"
+	"os/signal"
+	"syscall"
 	"time"
 
 	_ "github.com/lib/pq"
 )
 
+const shutdownTimeout = 30 * time.Second
+
+// updatesFlushInterval - как часто поток обновлений рассылает счетчики измененных мероприятий
+const updatesFlushInterval = 250 * time.Millisecond
+
 func Start(cfg *config.Config) error {
-	ctx := context.Background()
-
-	// Retry подключения к PostgreSQL
-	var db *sql.DB
-	var err error
-
-	for i := 0; i < 10; i++ {
-		db, err = sql.Open("postgres", cfg.MasterDSN)
-		if err == nil {
-			err = db.Ping()
-			if err == nil {
-				break
-			}
-		}
-		log.Printf("Waiting for PostgreSQL... (attempt %d/10): %v", i+1, err)
-		time.Sleep(3 * time.Second)
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	imageRepo, err := newRepository(ctx, cfg)
+	if err != nil {
+		return err
 	}
+	log.Printf("Storage %s initialized", cfg.Storage)
 
+	// Инициализируем брокер сообщений
+	msgBroker, err := newBroker(cfg)
 	if err != nil {
-		return fmt.Errorf("failed to connect to database after 10 attempts: %w", err)
+		return fmt.Errorf("failed to initialize %s broker: %w", cfg.Broker, err)
 	}
-	defer func() {
-		err = db.Close()
-		if err != nil {
-			log.Printf("Failed to close database: %v", err)
-		}
-	}()
-	log.Print("Connected to PostgreSQL")
+	defer msgBroker.Close()
+	log.Printf("Broker %s initialized", cfg.Broker)
+
+	// Запускаем consumers
+	consumerCtx, stopConsumer := context.WithCancel(ctx)
+	defer stopConsumer()
 
-	if err := migrations.Migrate(db); err != nil {
-		fmt.Println(err)
+	// Поток обновлений получает доменные события через брокер, поэтому клиенты
+	// видят изменения, сделанные любым экземпляром приложения
+	updatesHub := stream.NewHub(imageRepo, updatesFlushInterval)
+	go updatesHub.Run(ctx)
+	updatesConsumer, err := newUpdatesConsumer(msgBroker, updatesHub)
+	if err != nil {
 		return err
 	}
-	log.Print("Migrations applied successfully")
+	if err := updatesConsumer.Start(consumerCtx); err != nil {
+		return fmt.Errorf("failed to start updates consumer: %w", err)
+	}
+	log.Print("Updates consumer started")
 
-	// Инициализируем RabbitMQ
-	rabbitBroker, err := broker.NewRabbitMQBroker(cfg.RabbitMQURL)
+	cancellationHandler := consumer.NewCancellationHandler(imageRepo, msgBroker)
+	cancellationConsumer, err := newCancellationConsumer(cfg, msgBroker, cancellationHandler)
 	if err != nil {
-		return fmt.Errorf("failed to initialize RabbitMQ: %w", err)
+		return err
 	}
-	defer rabbitBroker.Close()
-	log.Print("RabbitMQ initialized")
-
-	imageRepo := postgres.NewEventRepository(cfg)
-
-	// Запускаем consumers
-	cancellationConsumer := consumer.NewCancellationConsumer(rabbitBroker.GetChannel(), imageRepo)
-	if err := cancellationConsumer.Start(ctx); err != nil {
+	if err := cancellationConsumer.Start(consumerCtx); err != nil {
 		return fmt.Errorf("failed to start cancellation consumer: %w", err)
 	}
 	log.Print("Cancellation consumer started")
 
-	imageUsecase := usecases.NewEventsUsecases(imageRepo, rabbitBroker)
+	waitingRoomSecret, err := newWaitingRoomSecret(cfg)
+	if err != nil {
+		return err
+	}
+	waitingRoomUsecase := usecases.NewWaitingRoomUsecases(imageRepo, imageRepo, waitingRoomSecret)
+
+	imageUsecase := usecases.NewEventsUsecases(imageRepo, msgBroker, msgBroker, waitingRoomUsecase)
 
-	srv := http.NewServer(cfg.HTTPPort, imageUsecase)
+	adminUsecase := usecases.NewAdminUsecases(msgBroker, imageRepo, imageRepo)
 
-	return srv.Start()
+	go runInventoryReconciliation(ctx, adminUsecase, cfg.ReconcileInterval, cfg.ReconcileRepair)
+
+	idempotencyUsecase := usecases.NewIdempotencyUsecases(imageRepo, cfg.IdempotencyRetention)
+
+	go runIdempotencyPurge(ctx, idempotencyUsecase, idempotencyPurgeInterval)
+
+	ballotUsecase := usecases.NewBallotUsecases(imageRepo, imageRepo, msgBroker, msgBroker)
+	promoUsecase := usecases.NewPromoUsecases(imageRepo, imageRepo)
+	pricingUsecase := usecases.NewPricingUsecases(imageRepo, imageRepo, msgBroker)
+	feeUsecase := usecases.NewFeeUsecases(imageRepo, imageRepo)
+
+	go runBallots(ctx, ballotUsecase, cfg.BallotInterval)
+
+	srv := http.NewServer(cfg.HTTPPort, imageUsecase, adminUsecase, idempotencyUsecase, updatesHub, waitingRoomUsecase, ballotUsecase, promoUsecase, pricingUsecase, feeUsecase)
+
+	serverErr := make(chan error, 1)
+	go func() {
+		serverErr <- srv.Start()
+	}()
+
+	select {
+	case err := <-serverErr:
+		if !errors.Is(err, nethttp.ErrServerClosed) {
+			return err
+		}
+	case <-ctx.Done():
+		log.Print("Shutdown signal received")
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	if err := srv.Stop(shutdownCtx); err != nil {
+		log.Printf("Failed to stop HTTP server: %v", err)
+	}
+
+	// Дочитываем уже полученные сообщения до закрытия канала RabbitMQ
+	stopConsumer()
+	if err := cancellationConsumer.Wait(shutdownCtx); err != nil {
+		log.Printf("Failed to drain cancellation consumer: %v", err)
+	}
+
+	return nil
+}
+
+// newWaitingRoomSecret - ключ подписи токенов очереди. Без WAITING_ROOM_SECRET ключ
+// случайный: токены не переживут рестарт и не подойдут другим экземплярам.
+func newWaitingRoomSecret(cfg *config.Config) ([]byte, error) {
+	if cfg.WaitingRoomSecret != "" {
+		return []byte(cfg.WaitingRoomSecret), nil
+	}
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, fmt.Errorf("failed to generate waiting room secret: %w", err)
+	}
+	log.Print("WAITING_ROOM_SECRET is not set, queue tokens are valid only for this instance")
+	return secret, nil
 }
@@ -0,0 +1,27 @@
+package app
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/dontpanicw/EventBooker/internal/port"
+)
+
+// runBallots периодически проводит розыгрыши, прием заявок в которых закончился, и отдает
+// места, освободившиеся после отмены неоплаченных броней, листам ожидания
+func runBallots(ctx context.Context, ballots port.BallotUsecases, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := ballots.RunBallots(ctx); err != nil {
+				log.Printf("Ballot run failed: %v", err)
+			}
+		}
+	}
+}
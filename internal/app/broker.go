@@ -0,0 +1,69 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"github.com/dontpanicw/EventBooker/config"
+	"github.com/dontpanicw/EventBooker/internal/adapter/broker"
+	"github.com/dontpanicw/EventBooker/internal/adapter/consumer"
+	"github.com/dontpanicw/EventBooker/internal/port"
+)
+
+// messageBroker - все, что приложению нужно от брокера сообщений
+type messageBroker interface {
+	port.Broker
+	port.EventPublisher
+	port.DeadLetterQueue
+	Close() error
+}
+
+// cancellationConsumer - consumer отложенных отмен конкретного брокера
+type cancellationConsumer interface {
+	Start(ctx context.Context) error
+	Wait(ctx context.Context) error
+}
+
+func newBroker(cfg *config.Config) (messageBroker, error) {
+	switch cfg.Broker {
+	case config.BrokerKafka:
+		return broker.NewKafkaBroker(cfg.KafkaBrokers, cfg.KafkaTaskTopic)
+	case config.BrokerMemory:
+		return broker.NewMemoryBroker(broker.BookingHold), nil
+	default:
+		return broker.NewRabbitMQBroker(cfg.RabbitMQURL)
+	}
+}
+
+// updatesConsumer - подписка экземпляра на доменные события всех экземпляров
+type updatesConsumer interface {
+	Start(ctx context.Context) error
+}
+
+func newCancellationConsumer(cfg *config.Config, msgBroker messageBroker, handler *consumer.CancellationHandler) (cancellationConsumer, error) {
+	switch b := msgBroker.(type) {
+	case *broker.MemoryBroker:
+		return consumer.NewMemoryCancellationConsumer(b, handler, cfg.ConsumerWorkers), nil
+	case *broker.KafkaBroker:
+		return consumer.NewKafkaCancellationConsumer(b.Brokers(), b.DelayTopic(), handler, b), nil
+	case *broker.RabbitMQBroker:
+		return consumer.NewCancellationConsumer(b.GetChannel(), handler, b, consumer.Options{
+			Workers:  cfg.ConsumerWorkers,
+			Prefetch: cfg.ConsumerPrefetch,
+		}), nil
+	default:
+		return nil, fmt.Errorf("no cancellation consumer for broker %T", msgBroker)
+	}
+}
+
+func newUpdatesConsumer(msgBroker messageBroker, sink port.EventPublisher) (updatesConsumer, error) {
+	switch b := msgBroker.(type) {
+	case *broker.MemoryBroker:
+		return consumer.NewMemoryUpdatesConsumer(b, sink), nil
+	case *broker.KafkaBroker:
+		return consumer.NewKafkaUpdatesConsumer(b.Brokers(), sink), nil
+	case *broker.RabbitMQBroker:
+		return consumer.NewUpdatesConsumer(b.GetChannel(), sink), nil
+	default:
+		return nil, fmt.Errorf("no updates consumer for broker %T", msgBroker)
+	}
+}
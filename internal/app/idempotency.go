@@ -0,0 +1,33 @@
+package app
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/dontpanicw/EventBooker/internal/port"
+)
+
+// idempotencyPurgeInterval - как часто удалять ключи идемпотентности старше срока хранения
+const idempotencyPurgeInterval = time.Hour
+
+func runIdempotencyPurge(ctx context.Context, idempotency port.IdempotencyUsecases, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			purged, err := idempotency.PurgeExpired(ctx)
+			if err != nil {
+				log.Printf("Idempotency keys purge failed: %v", err)
+				continue
+			}
+			if purged > 0 {
+				log.Printf("Purged %d expired idempotency keys", purged)
+			}
+		}
+	}
+}
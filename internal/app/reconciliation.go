@@ -0,0 +1,87 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"github.com/dontpanicw/EventBooker/config"
+	"github.com/dontpanicw/EventBooker/internal/domain"
+	"github.com/dontpanicw/EventBooker/internal/port"
+	"github.com/dontpanicw/EventBooker/internal/usecases"
+	"log"
+	"time"
+)
+
+// runInventoryReconciliation периодически сверяет счетчики мест, пока ctx не отменен
+func runInventoryReconciliation(ctx context.Context, admin port.AdminUsecases, interval time.Duration, repair bool) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			report, err := admin.ReconcileInventory(ctx, repair)
+			if err != nil {
+				log.Printf("Inventory reconciliation failed: %v", err)
+				continue
+			}
+			log.Printf("Inventory reconciliation: %d discrepancies, %d repaired, %d unverified capacities",
+				len(report.Discrepancies), len(report.Adjustments), len(report.Unverified))
+		}
+	}
+}
+
+// Reconcile однократно сверяет счетчики мест и печатает отчет; используется командой cmd/reconcile
+func Reconcile(cfg *config.Config, repair bool) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	repo, err := newRepository(ctx, cfg)
+	if err != nil {
+		return err
+	}
+
+	// Очередь dead letters команде не нужна
+	admin := usecases.NewAdminUsecases(nil, repo, repo)
+	// Команду запускает оператор - исправления записываются в журнал от его имени
+	ctx = port.WithActor(ctx, domain.Actor{Type: domain.ActorAdmin})
+	report, err := admin.ReconcileInventory(ctx, repair)
+	if err != nil {
+		return err
+	}
+
+	for _, d := range report.Discrepancies {
+		fmt.Printf("event %s: capacity %d, active bookings %d, available %d, expected %d (drift %+d)\n",
+			d.EventId, d.Capacity, d.ActiveBookings, d.AvailableTickets, d.ExpectedAvailable, d.Drift())
+	}
+	for _, a := range report.Adjustments {
+		fmt.Printf("event %s repaired: available %d -> %d (adjustment %s)\n",
+			a.EventId, a.OldAvailable, a.NewAvailable, a.Id)
+	}
+	for _, u := range report.Unverified {
+		fmt.Printf("event %s: capacity %d backfilled by migration 002, verify it manually (available %d)\n",
+			u.EventId, u.Capacity, u.AvailableTickets)
+	}
+	fmt.Printf("%d discrepancies, %d repaired, %d unverified capacities\n",
+		len(report.Discrepancies), len(report.Adjustments), len(report.Unverified))
+	return nil
+}
+
+// VerifyCapacity снимает пометку непроверенной вместимости события; используется командой cmd/reconcile
+func VerifyCapacity(cfg *config.Config, eventID string) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	repo, err := newRepository(ctx, cfg)
+	if err != nil {
+		return err
+	}
+
+	admin := usecases.NewAdminUsecases(nil, repo, repo)
+	if err := admin.VerifyCapacity(ctx, eventID); err != nil {
+		return err
+	}
+	fmt.Printf("event %s: capacity verified\n", eventID)
+	return nil
+}
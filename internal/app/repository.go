@@ -0,0 +1,91 @@
+package app
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/dontpanicw/EventBooker/config"
+	"github.com/dontpanicw/EventBooker/internal/adapter/repository/memory"
+	"github.com/dontpanicw/EventBooker/internal/adapter/repository/postgres"
+	"github.com/dontpanicw/EventBooker/internal/adapter/repository/sqlite"
+	"github.com/dontpanicw/EventBooker/internal/port"
+	"github.com/dontpanicw/EventBooker/pkg/migrations"
+	"log"
+	"time"
+)
+
+// storage - все, что приложению нужно от хранилища
+type storage interface {
+	port.Repository
+	port.InventoryRepository
+	port.AuditRepository
+	port.IdempotencyRepository
+	port.WaitingRoomRepository
+	port.BallotRepository
+	port.PromoRepository
+	port.PricingRepository
+	port.FeeRepository
+}
+
+// newRepository создает хранилище, выбранное схемой MASTER_DSN
+func newRepository(ctx context.Context, cfg *config.Config) (storage, error) {
+	var repo port.Repository
+	switch cfg.Storage {
+	case config.StorageMemory:
+		log.Print("Using in-memory storage, data will be lost on restart")
+		repo = memory.NewEventRepository()
+	case config.StorageSQLite:
+		sqliteRepo, err := sqlite.NewEventRepository(cfg.MasterDSN)
+		if err != nil {
+			return nil, err
+		}
+		repo = sqliteRepo
+	default:
+		if err := migratePostgres(cfg.MasterDSN); err != nil {
+			return nil, err
+		}
+		repo = postgres.NewEventRepository(ctx, cfg)
+	}
+
+	s, ok := repo.(storage)
+	if !ok {
+		return nil, fmt.Errorf("%s storage does not implement all required repositories", cfg.Storage)
+	}
+	return s, nil
+}
+
+// migratePostgres дожидается PostgreSQL и применяет миграции
+func migratePostgres(dsn string) error {
+	// Retry подключения к PostgreSQL
+	var db *sql.DB
+	var err error
+
+	for i := 0; i < 10; i++ {
+		db, err = sql.Open("postgres", dsn)
+		if err == nil {
+			err = db.Ping()
+			if err == nil {
+				break
+			}
+		}
+		log.Printf("Waiting for PostgreSQL... (attempt %d/10): %v", i+1, err)
+		time.Sleep(3 * time.Second)
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to connect to database after 10 attempts: %w", err)
+	}
+	defer func() {
+		err = db.Close()
+		if err != nil {
+			log.Printf("Failed to close database: %v", err)
+		}
+	}()
+	log.Print("Connected to PostgreSQL")
+
+	if err := migrations.Migrate(db); err != nil {
+		return fmt.Errorf("failed to apply migrations: %w", err)
+	}
+	log.Print("Migrations applied successfully")
+	return nil
+}
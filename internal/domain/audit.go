@@ -0,0 +1,80 @@
+package domain
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// Типы инициаторов изменений в журнале аудита
+const (
+	ActorUser     = "user"
+	ActorConsumer = "consumer"
+	ActorAdmin    = "admin"
+	// ActorSystem - фоновые задачи приложения и изменения без известного инициатора
+	ActorSystem = "system"
+)
+
+// Сущности журнала аудита
+const (
+	AuditEntityEvent   = "event"
+	AuditEntityBooking = "booking"
+)
+
+// Действия журнала аудита
+const (
+	AuditEventCreated     = "event_created"
+	AuditEventUpdated     = "event_updated"
+	AuditTicketsChanged   = "tickets_changed"
+	AuditBookingCreated   = "booking_created"
+	AuditBookingConfirmed = "booking_confirmed"
+	AuditBookingCancelled = "booking_cancelled"
+)
+
+// Actor - кто инициировал изменение; Id пустой, если инициатор не идентифицирован
+type Actor struct {
+	Type string
+	Id   string
+}
+
+// AuditEntry - неизменяемая запись журнала аудита об одном изменении брони или события.
+// EventId заполнен и у записей о бронях, поэтому история события включает его брони;
+// UserId - владелец брони.
+type AuditEntry struct {
+	Id         string
+	EntityType string
+	EntityId   string
+	EventId    string
+	UserId     string
+	Action     string
+	ActorType  string
+	ActorId    string
+	OldValue   json.RawMessage
+	NewValue   json.RawMessage
+	Reason     string
+	CreatedAt  time.Time
+}
+
+// AuditFilter выбирает историю одной брони, события или пользователя; задается одно из полей
+type AuditFilter struct {
+	BookingId string
+	EventId   string
+	UserId    string
+	Limit     int
+}
+
+// ErrInvalidAuditFilter - фильтр не выбирает ни бронь, ни событие, ни пользователя
+var ErrInvalidAuditFilter = errors.New("audit filter must select a booking, event or user")
+
+// AuditValue сериализует значение для OldValue и NewValue; nil остается пустым
+func AuditValue(v any) json.RawMessage {
+	if v == nil {
+		return nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		// В журнал пишутся только статусы, счетчики и события - они всегда сериализуются
+		panic(err)
+	}
+	return data
+}
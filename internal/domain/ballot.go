@@ -0,0 +1,107 @@
+package domain
+
+import (
+	"errors"
+	"math/rand/v2"
+	"slices"
+	"time"
+)
+
+var (
+	ErrBallotNotFound      = errors.New("ballot not found")
+	ErrBallotEntryNotFound = errors.New("ballot entry not found")
+	ErrInvalidBallot       = errors.New("entry_closes_at must be after entry_opens_at")
+	ErrNotBallotEvent      = errors.New("event is not sold by ballot")
+	// ErrBallotEvent - места мероприятия разыгрываются, забронировать напрямую нельзя
+	ErrBallotEvent        = errors.New("tickets of this event are allocated by ballot")
+	ErrBallotEntryClosed  = errors.New("ballot is not accepting entries")
+	ErrAlreadyEntered     = errors.New("user has already entered the ballot")
+	ErrBallotAlreadyDrawn = errors.New("ballot has already been drawn")
+	ErrBallotEntryOpen    = errors.New("ballot entry window has not closed yet")
+	ErrBallotNotDrawn     = errors.New("ballot has not been drawn yet")
+	// ErrBallotWaitlistEmpty - все участники розыгрыша уже получили места или выбыли
+	ErrBallotWaitlistEmpty = errors.New("no ballot entries waiting for a ticket")
+)
+
+// Статусы заявок на розыгрыш
+const (
+	// BallotEntered - заявка принята, розыгрыша еще не было
+	BallotEntered = "entered"
+	// BallotWon - участнику выделено место: создана pending-бронь BookingId
+	BallotWon = "won"
+	// BallotWaitlisted - участник ждет освободившегося места в порядке Rank
+	BallotWaitlisted = "waitlisted"
+	// BallotLost - места участнику не досталось
+	BallotLost = "lost"
+)
+
+// Ballot - розыгрыш мест мероприятия с SaleMode ballot. Заявки принимаются с
+// EntryOpensAt до EntryClosesAt, затем участники перемешиваются по Seed, и места
+// получают первые из них. Seed записывается, поэтому порядок можно пересчитать
+// и проверить (DrawOrder).
+type Ballot struct {
+	EventId       string
+	EntryOpensAt  time.Time
+	EntryClosesAt time.Time
+	// Waitlist - не получившие места остаются в листе ожидания и получают
+	// освободившиеся места в порядке розыгрыша; без него они выбывают сразу
+	Waitlist bool
+	// Seed и DrawnAt заполняются при розыгрыше
+	Seed    int64
+	DrawnAt *time.Time
+	Entries int // количество заявок; вычисляется при чтении
+}
+
+func (b *Ballot) Validate() error {
+	if b.EntryOpensAt.IsZero() || !b.EntryClosesAt.After(b.EntryOpensAt) {
+		return ErrInvalidBallot
+	}
+	return nil
+}
+
+func (b *Ballot) Drawn() bool {
+	return b.DrawnAt != nil
+}
+
+// AcceptsEntries - открыт ли прием заявок в момент now
+func (b *Ballot) AcceptsEntries(now time.Time) bool {
+	return !b.Drawn() && !now.Before(b.EntryOpensAt) && now.Before(b.EntryClosesAt)
+}
+
+// Due - прием заявок закончился, а розыгрыша еще не было
+func (b *Ballot) Due(now time.Time) bool {
+	return !b.Drawn() && !now.Before(b.EntryClosesAt)
+}
+
+// WaitlistClosed - лист ожидания закрывается, когда наступает дата мероприятия:
+// освободившееся место уже некому оплатить, оставшиеся участники выбывают
+func WaitlistClosed(event *Event, now time.Time) bool {
+	return !now.Before(event.Date)
+}
+
+// BallotEntry - заявка пользователя на розыгрыш. Rank - место в порядке розыгрыша,
+// начиная с 1; до розыгрыша 0.
+type BallotEntry struct {
+	EventId   string
+	UserId    string
+	Status    string
+	Rank      int
+	BookingId string
+	EnteredAt time.Time
+}
+
+// DrawOrder перемешивает участников по seed и возвращает их в порядке розыгрыша.
+// Участники сначала сортируются, а перемешивание - Фишер-Йейтс на PCG из math/rand/v2,
+// выход которого зафиксирован, поэтому одни и те же seed и участники всегда дают
+// один и тот же порядок.
+func DrawOrder(userIDs []string, seed int64) []string {
+	order := slices.Clone(userIDs)
+	slices.Sort(order)
+
+	src := rand.NewPCG(uint64(seed), 0)
+	for i := len(order) - 1; i > 0; i-- {
+		j := int(src.Uint64() % uint64(i+1))
+		order[i], order[j] = order[j], order[i]
+	}
+	return order
+}
@@ -0,0 +1,44 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBallotWindow(t *testing.T) {
+	opensAt := time.Date(2026, time.March, 1, 10, 0, 0, 0, time.UTC)
+	closesAt := opensAt.Add(time.Hour)
+	ballot := &Ballot{EntryOpensAt: opensAt, EntryClosesAt: closesAt}
+
+	assert.NoError(t, ballot.Validate())
+	assert.ErrorIs(t, (&Ballot{EntryOpensAt: closesAt, EntryClosesAt: opensAt}).Validate(), ErrInvalidBallot)
+	assert.ErrorIs(t, (&Ballot{EntryClosesAt: closesAt}).Validate(), ErrInvalidBallot)
+
+	assert.False(t, ballot.AcceptsEntries(opensAt.Add(-time.Second)))
+	assert.True(t, ballot.AcceptsEntries(opensAt))
+	assert.False(t, ballot.Due(opensAt))
+	assert.False(t, ballot.AcceptsEntries(closesAt))
+	assert.True(t, ballot.Due(closesAt))
+
+	ballot.DrawnAt = &closesAt
+	assert.False(t, ballot.Due(closesAt.Add(time.Hour)))
+}
+
+func TestDrawOrder(t *testing.T) {
+	users := []string{"user-1", "user-2", "user-3", "user-4", "user-5"}
+
+	order := DrawOrder(users, 42)
+	assert.ElementsMatch(t, users, order)
+	assert.Equal(t, []string{"user-1", "user-2", "user-3", "user-4", "user-5"}, users, "input must not be modified")
+
+	// Порядок зависит только от seed и состава участников, но не от порядка их чтения
+	reversed := []string{"user-5", "user-4", "user-3", "user-2", "user-1"}
+	assert.Equal(t, order, DrawOrder(reversed, 42))
+
+	// Зафиксированный результат: изменение алгоритма сломает проверку прошлых розыгрышей
+	assert.Equal(t, []string{"user-3", "user-2", "user-5", "user-1", "user-4"}, order)
+	assert.NotEqual(t, order, DrawOrder(users, 43))
+	assert.Empty(t, DrawOrder(nil, 42))
+}
@@ -0,0 +1,113 @@
+package domain
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+const (
+	DefaultBookingsLimit = 20
+	MaxBookingsLimit     = 100
+)
+
+var ErrInvalidBookingFilter = errors.New("invalid booking filter")
+
+// BookingFilter - параметры страницы броней пользователя или мероприятия. Брони идут
+// от новых к старым, при равной дате - по убыванию Id.
+type BookingFilter struct {
+	UserId  string
+	EventId string
+	Status  string // пустой - любой статус
+	Limit   int
+	After   *BookingCursor // страница начинается после этой брони
+	// LineItems - загрузить состав суммы броней страницы (для выгрузки)
+	LineItems bool
+}
+
+// Resolve проверяет фильтр и подставляет значения по умолчанию. Без UserId и EventId
+// выборка не ограничена, поэтому хотя бы одно из них обязательно.
+func (f BookingFilter) Resolve() (BookingFilter, error) {
+	if f.UserId == "" && f.EventId == "" {
+		return f, ErrInvalidBookingFilter
+	}
+	switch f.Status {
+	case "", PendingStatus, ConfirmedStatus, CancelledStatus:
+	default:
+		return f, ErrInvalidBookingFilter
+	}
+	switch {
+	case f.Limit <= 0:
+		f.Limit = DefaultBookingsLimit
+	case f.Limit > MaxBookingsLimit:
+		f.Limit = MaxBookingsLimit
+	}
+	return f, nil
+}
+
+// Matches сообщает, проходит ли бронь фильтр без учета курсора
+func (f *BookingFilter) Matches(booking *Booking) bool {
+	return (f.UserId == "" || booking.UserId == f.UserId) &&
+		(f.EventId == "" || booking.EventId == f.EventId) &&
+		(f.Status == "" || booking.Status == f.Status)
+}
+
+// BookingCursor - позиция последней брони страницы
+type BookingCursor struct {
+	Date time.Time
+	Id   string
+}
+
+// Encode возвращает непрозрачную строку для клиента
+func (c *BookingCursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeBookingCursor разбирает строку, полученную из Encode
+func DecodeBookingCursor(s string) (*BookingCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var c BookingCursor
+	if err := json.Unmarshal(data, &c); err != nil || c.Id == "" {
+		return nil, ErrInvalidCursor
+	}
+	return &c, nil
+}
+
+// Before сообщает, идет ли бронь после позиции курсора в порядке от новых к старым
+func (c *BookingCursor) Before(booking *Booking) bool {
+	if order := c.Date.Compare(booking.Date); order != 0 {
+		return order > 0
+	}
+	return c.Id > booking.Id
+}
+
+// BookingDetails - бронь вместе с мероприятием, на которое она оформлена
+type BookingDetails struct {
+	Booking *Booking
+	Event   *Event
+}
+
+// BookingPage - страница броней; NextCursor пустой на последней странице
+type BookingPage struct {
+	Bookings   []*BookingDetails
+	NextCursor string
+}
+
+// NewBookingPage собирает страницу из выборки хранилища, запрошенной с запасом в одну бронь
+func NewBookingPage(bookings []*BookingDetails, filter BookingFilter) *BookingPage {
+	page := &BookingPage{Bookings: bookings}
+	if len(bookings) > filter.Limit {
+		page.Bookings = bookings[:filter.Limit]
+		last := page.Bookings[filter.Limit-1].Booking
+		page.NextCursor = (&BookingCursor{Date: last.Date, Id: last.Id}).Encode()
+	}
+	if page.Bookings == nil {
+		page.Bookings = []*BookingDetails{}
+	}
+	return page
+}
@@ -0,0 +1,46 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBookingFilterResolve(t *testing.T) {
+	filter, err := BookingFilter{UserId: "user-1"}.Resolve()
+	require.NoError(t, err)
+	assert.Equal(t, DefaultBookingsLimit, filter.Limit)
+
+	filter, err = BookingFilter{UserId: "user-1", Status: CancelledStatus, Limit: 1000}.Resolve()
+	require.NoError(t, err)
+	assert.Equal(t, MaxBookingsLimit, filter.Limit)
+
+	_, err = BookingFilter{}.Resolve()
+	assert.ErrorIs(t, err, ErrInvalidBookingFilter)
+
+	_, err = BookingFilter{UserId: "user-1", Status: "expired"}.Resolve()
+	assert.ErrorIs(t, err, ErrInvalidBookingFilter)
+}
+
+func TestBookingCursor_RoundTrip(t *testing.T) {
+	cursor := &BookingCursor{Date: time.Date(2026, time.March, 1, 19, 0, 0, 0, time.UTC), Id: "booking-1"}
+
+	decoded, err := DecodeBookingCursor(cursor.Encode())
+	require.NoError(t, err)
+	assert.Equal(t, cursor, decoded)
+
+	_, err = DecodeBookingCursor("!!!")
+	assert.ErrorIs(t, err, ErrInvalidCursor)
+}
+
+func TestBookingExpiresAt(t *testing.T) {
+	date := time.Date(2026, time.March, 1, 19, 0, 0, 0, time.UTC)
+
+	pending := &Booking{Status: PendingStatus, Date: date}
+	assert.Equal(t, date.Add(BookingHold), pending.ExpiresAt())
+
+	confirmed := &Booking{Status: ConfirmedStatus, Date: date}
+	assert.True(t, confirmed.ExpiresAt().IsZero())
+}
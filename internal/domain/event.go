@@ -1,6 +1,9 @@
 package domain
 
-import "time"
+import (
+	"errors"
+	"time"
+)
 
 const (
 	PendingStatus   = "pending"
@@ -8,26 +11,85 @@ const (
 	CancelledStatus = "cancelled"
 )
 
+// Режимы продажи мероприятия
+const (
+	// SaleModeFirstCome - места достаются тем, кто забронировал первым
+	SaleModeFirstCome = "first_come"
+	// SaleModeBallot - места разыгрываются среди подавших заявку (см. Ballot)
+	SaleModeBallot = "ballot"
+)
+
+var (
+	ErrEventNotFound      = errors.New("event not found")
+	ErrBookingNotFound    = errors.New("booking not found")
+	ErrDeadLetterNotFound = errors.New("dead letter not found")
+	ErrNoTicketsAvailable = errors.New("no tickets available")
+	// ErrBookingNotPending - бронь уже оплачена или отменена, переход статуса невозможен
+	ErrBookingNotPending = errors.New("booking is not pending")
+	// ErrVersionMismatch - событие или бронь изменились после чтения клиентом (If-Match)
+	ErrVersionMismatch  = errors.New("version mismatch")
+	ErrInvalidSaleMode  = errors.New("sale_mode must be first_come or ballot")
+	ErrInvalidEventDate = errors.New("event date is required")
+)
+
 type Event struct {
 	Id               string
 	Name             string
 	Description      string
 	IsFree           bool
-	Price            float64
+	Price            Money  // валюта цены - валюта мероприятия и его PricePhases
+	OrganizerId      string // пустой - без организатора; для правил сбора (FeeRule)
 	AvailableTickets uint32
+	Capacity         uint32
+	Sold             uint32 // оплаченные брони; вычисляется при чтении
+	Held             uint32 // брони, ожидающие оплаты; вычисляется при чтении
 	Date             time.Time
+	// SaleMode задается при создании и не меняется: first_come или ballot
+	SaleMode string
+	// Version растет при каждом изменении события, в том числе счетчиков и статусов его броней
+	Version int64
+	// PricePhases - расписание цены; загружается только GetEvent, пустое - билет стоит Price
+	PricePhases []PricePhase `json:",omitempty"`
+	// Pricing - текущая и следующая цена по PricePhases; вычисляется в EventsUsecases.GetEvent
+	Pricing *PriceQuote `json:",omitempty"`
 }
 
+// BookingHold - сколько pending-бронь ждет оплаты до отмены
+const BookingHold = 1 * time.Minute
+
 type Booking struct {
 	Id      string
 	UserId  string
 	EventId string
 	Status  string
 	Date    time.Time
+	// Price - сумма к оплате с учетом скидки, сбора и НДС в валюте мероприятия;
+	// фиксируется при бронировании (Booking.Charge)
+	Price Money
+	// PromoCode - примененный промокод; пустой - без скидки
+	PromoCode string
+	Version   int64 // растет при каждой смене статуса
+	// LineItems - состав Price; загружается только GetBooking
+	LineItems []BookingLineItem `json:",omitempty"`
+	// Admission - место в очереди, по допуску которого сделана бронь; BookEvent расходует
+	// допуск в той же транзакции. nil - очередь не открыта.
+	Admission *QueueTicket `json:"-"`
+}
+
+// ExpiresAt - момент автоматической отмены pending-брони; у оплаченных и отмененных нулевой
+func (b *Booking) ExpiresAt() time.Time {
+	if b.Status != PendingStatus {
+		return time.Time{}
+	}
+	return b.Date.Add(BookingHold)
 }
 
-// TaskMessage - структура сообщения для Kafka
-type TaskMessage struct {
-	EventID  string    `json:"image_id"`
-	Deadline time.Time `json:"deadline"`
+// DeadLetter - сообщение, которое не удалось обработать за все попытки
+type DeadLetter struct {
+	Id       string
+	Queue    string
+	Body     string
+	Attempts int
+	Reason   string
+	FailedAt time.Time
 }
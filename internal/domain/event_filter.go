@@ -0,0 +1,190 @@
+package domain
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// Порядок списка событий; при равных значениях события упорядочиваются по Id
+const (
+	SortDateAsc   = "date_asc"
+	SortDateDesc  = "date_desc"
+	SortPriceAsc  = "price_asc"
+	SortPriceDesc = "price_desc"
+)
+
+// Период проведения события относительно текущего момента
+const (
+	PeriodUpcoming = "upcoming"
+	PeriodPast     = "past"
+)
+
+const (
+	DefaultEventsLimit = 20
+	MaxEventsLimit     = 100
+)
+
+var (
+	ErrInvalidCursor      = errors.New("invalid cursor")
+	ErrInvalidEventFilter = errors.New("invalid event filter")
+)
+
+// EventFilter - параметры страницы списка событий. Нулевые поля не ограничивают выборку.
+// Цена бесплатного события считается равной 0. Цены сравниваются в минимальных единицах
+// без пересчета валют, поэтому фильтр по цене осмыслен вместе с Currency.
+type EventFilter struct {
+	DateFrom      time.Time // включительно
+	DateTo        time.Time // не включительно
+	Period        string    // PeriodUpcoming или PeriodPast; Resolve переводит его в DateFrom/DateTo
+	IsFree        *bool
+	Currency      string
+	MinPrice      *int64
+	MaxPrice      *int64
+	OnlyAvailable bool // скрыть распроданные
+	Sort          string
+	Limit         int
+	After         *EventCursor // страница начинается после этого события
+}
+
+// Resolve проверяет фильтр, подставляет значения по умолчанию и переводит Period в
+// границы дат относительно now. Хранилища получают только разрешенный фильтр.
+func (f EventFilter) Resolve(now time.Time) (EventFilter, error) {
+	switch f.Sort {
+	case "":
+		f.Sort = SortDateAsc
+	case SortDateAsc, SortDateDesc, SortPriceAsc, SortPriceDesc:
+	default:
+		return f, ErrInvalidEventFilter
+	}
+
+	switch {
+	case f.Limit <= 0:
+		f.Limit = DefaultEventsLimit
+	case f.Limit > MaxEventsLimit:
+		f.Limit = MaxEventsLimit
+	}
+
+	switch f.Period {
+	case "":
+	case PeriodUpcoming:
+		if f.DateFrom.Before(now) {
+			f.DateFrom = now
+		}
+	case PeriodPast:
+		if f.DateTo.IsZero() || f.DateTo.After(now) {
+			f.DateTo = now
+		}
+	default:
+		return f, ErrInvalidEventFilter
+	}
+	f.Period = ""
+
+	if f.Currency != "" {
+		currency, err := ParseCurrency(f.Currency)
+		if err != nil {
+			return f, ErrInvalidEventFilter
+		}
+		f.Currency = currency
+	}
+	if f.MinPrice != nil && f.MaxPrice != nil && *f.MinPrice > *f.MaxPrice {
+		return f, ErrInvalidEventFilter
+	}
+	if f.After != nil && f.After.Sort != f.Sort {
+		// Курсор от другой сортировки указывает не на ту позицию
+		return f, ErrInvalidCursor
+	}
+	return f, nil
+}
+
+// Matches сообщает, проходит ли событие фильтр без учета курсора
+func (f *EventFilter) Matches(event *Event) bool {
+	price := event.SortPrice()
+	switch {
+	case !f.DateFrom.IsZero() && event.Date.Before(f.DateFrom):
+		return false
+	case !f.DateTo.IsZero() && !event.Date.Before(f.DateTo):
+		return false
+	case f.IsFree != nil && event.IsFree != *f.IsFree:
+		return false
+	case f.Currency != "" && event.Price.Currency != f.Currency:
+		return false
+	case f.MinPrice != nil && price < *f.MinPrice:
+		return false
+	case f.MaxPrice != nil && price > *f.MaxPrice:
+		return false
+	case f.OnlyAvailable && event.AvailableTickets == 0:
+		return false
+	}
+	return true
+}
+
+// NormalizePrice обнуляет цену бесплатного события, как того требует CHECK check_price_for_free
+// в БД: иначе хранилище в памяти сохранило бы цену, а фильтры и сортировка по цене в SQL
+// и курсор (SortPrice) разошлись бы
+func (e *Event) NormalizePrice() {
+	if e.IsFree {
+		e.Price.Amount = 0
+	}
+}
+
+// SortPrice - цена для фильтров и сортировки: у бесплатных событий 0
+func (e *Event) SortPrice() int64 {
+	if e.IsFree {
+		return 0
+	}
+	return e.Price.Amount
+}
+
+// EventCursor - позиция последнего события страницы в порядке Sort
+type EventCursor struct {
+	Sort  string
+	Date  time.Time
+	Price int64
+	Id    string
+}
+
+// NewEventCursor создает курсор, указывающий на event
+func NewEventCursor(sort string, event *Event) *EventCursor {
+	return &EventCursor{Sort: sort, Date: event.Date, Price: event.SortPrice(), Id: event.Id}
+}
+
+// Encode возвращает непрозрачную строку для клиента
+func (c *EventCursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeEventCursor разбирает строку, полученную из Encode
+func DecodeEventCursor(s string) (*EventCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var c EventCursor
+	if err := json.Unmarshal(data, &c); err != nil || c.Id == "" {
+		return nil, ErrInvalidCursor
+	}
+	return &c, nil
+}
+
+// EventPage - страница списка событий; NextCursor пустой на последней странице
+type EventPage struct {
+	Events     []*Event
+	NextCursor string
+}
+
+// NewEventPage собирает страницу из выборки хранилища. Хранилища запрашивают на одно
+// событие больше filter.Limit: лишнее событие означает, что есть следующая страница.
+func NewEventPage(events []*Event, filter EventFilter) *EventPage {
+	page := &EventPage{Events: events}
+	if len(events) > filter.Limit {
+		page.Events = events[:filter.Limit]
+		page.NextCursor = NewEventCursor(filter.Sort, page.Events[filter.Limit-1]).Encode()
+	}
+	if page.Events == nil {
+		page.Events = []*Event{}
+	}
+	return page
+}
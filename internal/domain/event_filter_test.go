@@ -0,0 +1,90 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventFilterResolve_Defaults(t *testing.T) {
+	filter, err := EventFilter{}.Resolve(time.Now())
+
+	require.NoError(t, err)
+	assert.Equal(t, SortDateAsc, filter.Sort)
+	assert.Equal(t, DefaultEventsLimit, filter.Limit)
+	assert.True(t, filter.DateFrom.IsZero())
+	assert.True(t, filter.DateTo.IsZero())
+
+	filter, err = EventFilter{Limit: 1000}.Resolve(time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, MaxEventsLimit, filter.Limit)
+}
+
+func TestEventFilterResolve_Period(t *testing.T) {
+	now := time.Date(2026, time.March, 1, 12, 0, 0, 0, time.UTC)
+	later := now.Add(24 * time.Hour)
+
+	filter, err := EventFilter{Period: PeriodUpcoming}.Resolve(now)
+	require.NoError(t, err)
+	assert.Equal(t, now, filter.DateFrom)
+	assert.Empty(t, filter.Period)
+
+	// Более поздняя граница из запроса сохраняется
+	filter, err = EventFilter{Period: PeriodUpcoming, DateFrom: later}.Resolve(now)
+	require.NoError(t, err)
+	assert.Equal(t, later, filter.DateFrom)
+
+	filter, err = EventFilter{Period: PeriodPast, DateTo: later}.Resolve(now)
+	require.NoError(t, err)
+	assert.Equal(t, now, filter.DateTo)
+}
+
+func TestEventFilterResolve_Invalid(t *testing.T) {
+	minPrice, maxPrice := int64(50000), int64(10000)
+	cursor := NewEventCursor(SortPriceAsc, &Event{Id: "event-1", Price: Money{Amount: 10000, Currency: "RUB"}})
+
+	for name, filter := range map[string]EventFilter{
+		"sort":         {Sort: "popularity"},
+		"period":       {Period: "tomorrow"},
+		"price range":  {MinPrice: &minPrice, MaxPrice: &maxPrice},
+		"currency":     {Currency: "XYZ"},
+		"cursor order": {Sort: SortDateAsc, After: cursor},
+	} {
+		_, err := filter.Resolve(time.Now())
+		assert.Error(t, err, name)
+	}
+}
+
+func TestEventCursor_RoundTrip(t *testing.T) {
+	event := &Event{Id: "event-1", IsFree: true, Date: time.Date(2026, time.March, 1, 19, 0, 0, 123000, time.UTC)}
+	cursor := NewEventCursor(SortDateDesc, event)
+
+	decoded, err := DecodeEventCursor(cursor.Encode())
+
+	require.NoError(t, err)
+	assert.Equal(t, cursor.Sort, decoded.Sort)
+	assert.Equal(t, cursor.Id, decoded.Id)
+	assert.True(t, cursor.Date.Equal(decoded.Date))
+
+	_, err = DecodeEventCursor("not a cursor")
+	assert.ErrorIs(t, err, ErrInvalidCursor)
+}
+
+func TestEventFilterMatches(t *testing.T) {
+	date := time.Date(2026, time.March, 1, 19, 0, 0, 0, time.UTC)
+	free := &Event{IsFree: true, AvailableTickets: 0, Date: date}
+	paid := &Event{Price: Money{Amount: 150000, Currency: "RUB"}, AvailableTickets: 3, Date: date}
+	isFree, maxPrice := true, int64(100000)
+
+	assert.True(t, (&EventFilter{IsFree: &isFree}).Matches(free))
+	assert.False(t, (&EventFilter{IsFree: &isFree}).Matches(paid))
+	assert.True(t, (&EventFilter{MaxPrice: &maxPrice}).Matches(free))
+	assert.False(t, (&EventFilter{MaxPrice: &maxPrice}).Matches(paid))
+	assert.True(t, (&EventFilter{Currency: "RUB"}).Matches(paid))
+	assert.False(t, (&EventFilter{Currency: "EUR"}).Matches(paid))
+	assert.False(t, (&EventFilter{OnlyAvailable: true}).Matches(free))
+	assert.True(t, (&EventFilter{DateFrom: date}).Matches(paid))
+	assert.False(t, (&EventFilter{DateTo: date}).Matches(paid))
+}
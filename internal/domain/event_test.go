@@ -1,6 +1,7 @@
 package domain
 
 import (
+	"encoding/json"
 	"testing"
 	"time"
 
@@ -19,7 +20,7 @@ func TestEventCreation(t *testing.T) {
 		Name:             "Test Event",
 		Description:      "Test Description",
 		IsFree:           false,
-		Price:            100.0,
+		Price:            Money{Amount: 10000, Currency: "RUB"},
 		AvailableTickets: 50,
 		Date:             time.Now(),
 	}
@@ -28,7 +29,7 @@ func TestEventCreation(t *testing.T) {
 	assert.Equal(t, "Test Event", event.Name)
 	assert.Equal(t, "Test Description", event.Description)
 	assert.False(t, event.IsFree)
-	assert.Equal(t, 100.0, event.Price)
+	assert.Equal(t, Money{Amount: 10000, Currency: "RUB"}, event.Price)
 	assert.Equal(t, uint32(50), event.AvailableTickets)
 	assert.NotZero(t, event.Date)
 }
@@ -38,13 +39,12 @@ func TestFreeEvent(t *testing.T) {
 		Id:               "event-123",
 		Name:             "Free Event",
 		IsFree:           true,
-		Price:            0,
 		AvailableTickets: 100,
 		Date:             time.Now(),
 	}
 
 	assert.True(t, event.IsFree)
-	assert.Equal(t, 0.0, event.Price)
+	assert.Zero(t, event.Price.Amount)
 }
 
 func TestBookingCreation(t *testing.T) {
@@ -83,3 +83,54 @@ func TestBookingStatusTransitions(t *testing.T) {
 	booking.Status = CancelledStatus
 	assert.Equal(t, CancelledStatus, booking.Status)
 }
+
+func TestBookingLifecycleEvent(t *testing.T) {
+	booking := &Booking{
+		Id:      "booking-123",
+		UserId:  "user-123",
+		EventId: "event-123",
+		Status:  ConfirmedStatus,
+	}
+
+	event := NewBookingLifecycleEvent(BookingConfirmedEvent, booking)
+
+	assert.Equal(t, BookingConfirmedEvent, event.Type)
+	assert.Equal(t, LifecycleEventVersion, event.Version)
+	assert.NotZero(t, event.OccurredAt)
+
+	data, err := json.Marshal(event)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `"type":"booking.confirmed"`)
+	assert.Contains(t, string(data), `"booking_id":"booking-123"`)
+	assert.Contains(t, string(data), `"version":1`)
+}
+
+func TestEventUpdate(t *testing.T) {
+	name, empty := "New name", "  "
+	free, price, negative := true, int64(0), int64(-1)
+
+	assert.ErrorIs(t, EventUpdate{}.Validate(), ErrInvalidEventUpdate)
+	assert.ErrorIs(t, EventUpdate{Name: &empty}.Validate(), ErrInvalidEventUpdate)
+	assert.ErrorIs(t, EventUpdate{Price: &negative}.Validate(), ErrInvalidEventUpdate)
+
+	update := EventUpdate{Name: &name, IsFree: &free, Price: &price}
+	assert.NoError(t, update.Validate())
+
+	event := Event{Name: "Old name", Description: "Description", Price: Money{Amount: 10000, Currency: "RUB"}}
+	update.Apply(&event)
+	assert.Equal(t, Event{Name: name, Description: "Description", IsFree: true, Price: Money{Currency: "RUB"}}, event)
+
+	// Событие, ставшее бесплатным без новой цены, теряет прежнюю цену
+	event = Event{Name: "Old name", Price: Money{Amount: 10000, Currency: "RUB"}}
+	EventUpdate{IsFree: &free}.Apply(&event)
+	assert.Equal(t, int64(0), event.Price.Amount)
+	assert.Equal(t, int64(0), event.SortPrice())
+
+	// Правка допустима, только если платное событие остается с ценой
+	paid := false
+	assert.ErrorIs(t, EventUpdate{IsFree: &paid}.ValidateFor(event), ErrInvalidEventUpdate)
+	assert.ErrorIs(t, EventUpdate{Price: &price}.ValidateFor(Event{Price: Money{Amount: 10000}}), ErrInvalidEventUpdate)
+	newPrice := int64(5000)
+	assert.NoError(t, EventUpdate{IsFree: &paid, Price: &newPrice}.ValidateFor(event))
+	assert.NoError(t, EventUpdate{Price: &price}.ValidateFor(event))
+}
@@ -0,0 +1,63 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+)
+
+// ErrInvalidEventUpdate - правка без полей или с недопустимыми значениями
+var ErrInvalidEventUpdate = errors.New("invalid event update")
+
+// EventUpdate - правка события администратором; nil-поля не меняются.
+// Вместимость и счетчики меняются только бронями и сверкой.
+type EventUpdate struct {
+	Name        *string
+	Description *string
+	IsFree      *bool
+	// Price - новая цена в минимальных единицах валюты мероприятия; валюта не меняется
+	Price *int64
+}
+
+// Validate проверяет, что правка что-то меняет и не оставляет событие без названия
+// или с отрицательной ценой
+func (u EventUpdate) Validate() error {
+	if u.Name == nil && u.Description == nil && u.IsFree == nil && u.Price == nil {
+		return ErrInvalidEventUpdate
+	}
+	if u.Name != nil && strings.TrimSpace(*u.Name) == "" {
+		return ErrInvalidEventUpdate
+	}
+	if u.Price != nil && *u.Price < 0 {
+		return ErrInvalidEventUpdate
+	}
+	return nil
+}
+
+// ValidateFor проверяет событие, которым event станет после правки: платное событие
+// должно остаться с положительной ценой (CHECK check_price_for_free). Validate этого не
+// видит - например, is_free=false без цены допустимо только для события с ценой.
+func (u EventUpdate) ValidateFor(event Event) error {
+	u.Apply(&event)
+	if !event.IsFree && event.Price.Amount <= 0 {
+		return ErrInvalidEventUpdate
+	}
+	return nil
+}
+
+// Apply переносит заданные поля правки в event. Цена события, ставшего бесплатным, - 0
+// (Event.NormalizePrice).
+func (u EventUpdate) Apply(event *Event) {
+	if u.Name != nil {
+		event.Name = *u.Name
+	}
+	if u.Description != nil {
+		event.Description = *u.Description
+	}
+	if u.IsFree != nil {
+		event.IsFree = *u.IsFree
+	}
+	if u.Price != nil {
+		event.Price.Amount = *u.Price
+	}
+	event.NormalizePrice()
+}
@@ -0,0 +1,164 @@
+package domain
+
+import (
+	"cmp"
+	"errors"
+	"time"
+)
+
+var (
+	ErrInvalidFeeRule  = errors.New("invalid fee rule")
+	ErrFeeRuleNotFound = errors.New("fee rule not found")
+)
+
+// Области правил сбора: правило мероприятия важнее правила его организатора,
+// а то - общего правила
+const (
+	FeeScopeDefault   = "default"
+	FeeScopeOrganizer = "organizer"
+	FeeScopeEvent     = "event"
+)
+
+// FeeRule - сервисный сбор и НДС броней. Сбор - FeePercent процентов от цены билета со
+// скидкой плюс FixedFee; НДС - VatPercent процентов от цены билета со скидкой и сбора.
+type FeeRule struct {
+	Scope string
+	// ScopeId - организатор или мероприятие; у общего правила пустой
+	ScopeId string
+	// FixedFee - фиксированная часть сбора; правило с ней действует только для мероприятий
+	// в ее валюте, нулевая - для любых
+	FixedFee   Money
+	FeePercent float64
+	// VatPercent - ставка НДС; nil - ставка менее конкретного правила (организатора, затем
+	// общего), а если ее нет ни у одного - без НДС
+	VatPercent *float64
+	UpdatedAt  time.Time
+}
+
+func (r *FeeRule) Validate() error {
+	switch r.Scope {
+	case FeeScopeDefault:
+		if r.ScopeId != "" {
+			return ErrInvalidFeeRule
+		}
+	case FeeScopeOrganizer, FeeScopeEvent:
+		if r.ScopeId == "" {
+			return ErrInvalidFeeRule
+		}
+	default:
+		return ErrInvalidFeeRule
+	}
+	if r.FixedFee.Amount < 0 || r.FeePercent < 0 || r.FeePercent > 100 {
+		return ErrInvalidFeeRule
+	}
+	if r.VatPercent != nil && (*r.VatPercent < 0 || *r.VatPercent > 100) {
+		return ErrInvalidFeeRule
+	}
+	if r.FixedFee.Amount > 0 {
+		if _, ok := currencyExponents[r.FixedFee.Currency]; !ok {
+			return ErrInvalidFeeRule
+		}
+	} else if r.FixedFee.Currency != "" {
+		return ErrInvalidFeeRule
+	}
+	return nil
+}
+
+func (r *FeeRule) appliesTo(currency string) bool {
+	return r.FixedFee.Amount == 0 || r.FixedFee.Currency == currency
+}
+
+// MatchFeeRule выбирает из rules правило для брони мероприятия event: мероприятия,
+// затем организатора, затем общее. Ставка НДС выбирается так же, но отдельно от сбора:
+// среди правил с заданной ставкой, в том числе с фиксированным сбором в другой валюте.
+// Возвращает копию правила сбора со ставкой НДС; nil - бронь без сбора и НДС.
+func MatchFeeRule(event *Event, rules []*FeeRule) *FeeRule {
+	// По уровням: мероприятие, организатор, общее правило
+	var fees [3]*FeeRule
+	var vats [3]*float64
+	for _, rule := range rules {
+		level := -1
+		switch {
+		case rule.Scope == FeeScopeEvent && rule.ScopeId == event.Id:
+			level = 0
+		case rule.Scope == FeeScopeOrganizer && event.OrganizerId != "" && rule.ScopeId == event.OrganizerId:
+			level = 1
+		case rule.Scope == FeeScopeDefault:
+			level = 2
+		}
+		if level < 0 {
+			continue
+		}
+		if rule.appliesTo(event.Price.Currency) {
+			fees[level] = rule
+		}
+		if rule.VatPercent != nil {
+			vats[level] = rule.VatPercent
+		}
+	}
+
+	fee := cmp.Or(fees[0], fees[1], fees[2])
+	vat := cmp.Or(vats[0], vats[1], vats[2])
+	if fee == nil && vat == nil {
+		return nil
+	}
+	matched := FeeRule{}
+	if fee != nil {
+		matched = *fee
+	}
+	matched.VatPercent = vat
+	return &matched
+}
+
+// Строки состава суммы брони
+const (
+	LineItemFaceValue  = "face_value"
+	LineItemDiscount   = "discount"
+	LineItemServiceFee = "service_fee"
+	LineItemVat        = "vat"
+)
+
+// BookingLineItem - строка состава суммы брони; скидка - отрицательная сумма
+type BookingLineItem struct {
+	Kind   string
+	Amount Money
+}
+
+// Charge фиксирует сумму брони к оплате и ее состав: цена билета face, скидка промокода
+// discount, сбор и НДС по rule (nil - без них). Сбор и НДС округляются до минимальной
+// единицы валюты (Money.Percent) и не начисляются, если билет со скидкой бесплатный.
+// Скидка или фиксированный сбор в другой валюте - ErrCurrencyMismatch, бронь не меняется.
+func (b *Booking) Charge(face, discount Money, rule *FeeRule) error {
+	items := []BookingLineItem{{Kind: LineItemFaceValue, Amount: face}}
+	price := face
+	if discount.Amount > 0 {
+		var err error
+		if price, err = price.Sub(discount); err != nil {
+			return err
+		}
+		items = append(items, BookingLineItem{Kind: LineItemDiscount, Amount: Money{Amount: -discount.Amount, Currency: face.Currency}})
+	}
+	if rule != nil && price.Amount > 0 {
+		fee := price.Percent(rule.FeePercent)
+		if rule.FixedFee.Amount > 0 {
+			var err error
+			if fee, err = fee.Add(rule.FixedFee); err != nil {
+				return err
+			}
+		}
+		vat := Money{Currency: price.Currency}
+		if rule.VatPercent != nil {
+			// fee уже в валюте price
+			vat = Money{Amount: price.Amount + fee.Amount, Currency: price.Currency}.Percent(*rule.VatPercent)
+		}
+		for _, item := range []BookingLineItem{{Kind: LineItemServiceFee, Amount: fee}, {Kind: LineItemVat, Amount: vat}} {
+			if item.Amount.Amount > 0 {
+				items = append(items, item)
+				price.Amount += item.Amount.Amount
+			}
+		}
+	}
+	b.LineItems = items
+	b.Price = price
+	return nil
+}
@@ -0,0 +1,114 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFeeRuleValidate(t *testing.T) {
+	valid := []FeeRule{
+		{Scope: FeeScopeDefault, FeePercent: 5},
+		{Scope: FeeScopeOrganizer, ScopeId: "org-1", FixedFee: rub(5000), VatPercent: percent(20)},
+		{Scope: FeeScopeEvent, ScopeId: "event-1", VatPercent: percent(0)},
+		{Scope: FeeScopeEvent, ScopeId: "event-1"},
+	}
+	for _, rule := range valid {
+		assert.NoError(t, rule.Validate(), "%+v", rule)
+	}
+
+	invalid := []FeeRule{
+		{Scope: "venue", ScopeId: "venue-1"},
+		{Scope: FeeScopeDefault, ScopeId: "org-1"},
+		{Scope: FeeScopeEvent},
+		{Scope: FeeScopeDefault, FeePercent: 101},
+		{Scope: FeeScopeDefault, VatPercent: percent(-1)},
+		{Scope: FeeScopeDefault, FixedFee: Money{Amount: -100, Currency: "RUB"}},
+		{Scope: FeeScopeDefault, FixedFee: Money{Amount: 100, Currency: "XXX"}},
+		{Scope: FeeScopeDefault, FixedFee: Money{Currency: "RUB"}},
+	}
+	for _, rule := range invalid {
+		assert.ErrorIs(t, rule.Validate(), ErrInvalidFeeRule, "%+v", rule)
+	}
+}
+
+func TestMatchFeeRule(t *testing.T) {
+	fallback := &FeeRule{Scope: FeeScopeDefault, FeePercent: 10, VatPercent: percent(20)}
+	organizer := &FeeRule{Scope: FeeScopeOrganizer, ScopeId: "org-1", FeePercent: 5}
+	eventRule := &FeeRule{Scope: FeeScopeEvent, ScopeId: "event-1", FeePercent: 1, VatPercent: percent(10)}
+	euroOnly := &FeeRule{Scope: FeeScopeOrganizer, ScopeId: "org-2", FixedFee: Money{Amount: 100, Currency: "EUR"}, VatPercent: percent(0)}
+	rules := []*FeeRule{fallback, organizer, eventRule, euroOnly}
+
+	event := &Event{Id: "event-1", OrganizerId: "org-1", Price: rub(100000)}
+	assert.Equal(t, eventRule, MatchFeeRule(event, rules))
+
+	// Правило без ставки НДС берет ставку общего правила
+	event.Id = "event-2"
+	assert.Equal(t, &FeeRule{Scope: FeeScopeOrganizer, ScopeId: "org-1", FeePercent: 5, VatPercent: percent(20)}, MatchFeeRule(event, rules))
+	assert.Nil(t, organizer.VatPercent)
+	event.OrganizerId = ""
+	assert.Equal(t, fallback, MatchFeeRule(event, rules))
+
+	// Правило с фиксированным сбором в другой валюте пропускается, но его ставка НДС действует
+	event.OrganizerId = "org-2"
+	assert.Equal(t, &FeeRule{Scope: FeeScopeDefault, FeePercent: 10, VatPercent: percent(0)}, MatchFeeRule(event, rules))
+	event.Price = Money{Amount: 5000, Currency: "EUR"}
+	assert.Equal(t, euroOnly, MatchFeeRule(event, rules))
+
+	// Только ставка НДС - бронь без сбора, но с НДС
+	event.Price = rub(100000)
+	event.OrganizerId = ""
+	assert.Equal(t, &FeeRule{VatPercent: percent(20)}, MatchFeeRule(event, []*FeeRule{
+		{Scope: FeeScopeDefault, FixedFee: Money{Amount: 100, Currency: "EUR"}, VatPercent: percent(20)},
+	}))
+
+	assert.Nil(t, MatchFeeRule(event, nil))
+}
+
+func percent(value float64) *float64 {
+	return &value
+}
+
+func TestBookingCharge(t *testing.T) {
+	rule := &FeeRule{Scope: FeeScopeDefault, FixedFee: rub(3000), FeePercent: 5, VatPercent: percent(20)}
+
+	booking := &Booking{}
+	assert.NoError(t, booking.Charge(rub(150000), rub(15000), rule))
+	// Сбор 5% от 1350.00 + 30.00 = 97.50, НДС 20% от 1447.50 = 289.50
+	assert.Equal(t, []BookingLineItem{
+		{Kind: LineItemFaceValue, Amount: rub(150000)},
+		{Kind: LineItemDiscount, Amount: rub(-15000)},
+		{Kind: LineItemServiceFee, Amount: rub(9750)},
+		{Kind: LineItemVat, Amount: rub(28950)},
+	}, booking.LineItems)
+	assert.Equal(t, rub(173700), booking.Price)
+
+	// Процент сбора и НДС округляется до копейки
+	assert.NoError(t, booking.Charge(rub(999), Money{Currency: "RUB"}, &FeeRule{FeePercent: 5, VatPercent: percent(20)}))
+	assert.Equal(t, []BookingLineItem{
+		{Kind: LineItemFaceValue, Amount: rub(999)},
+		{Kind: LineItemServiceFee, Amount: rub(50)},
+		{Kind: LineItemVat, Amount: rub(210)},
+	}, booking.LineItems)
+	assert.Equal(t, rub(1259), booking.Price)
+
+	// Без ставки НДС - только сбор
+	assert.NoError(t, booking.Charge(rub(999), Money{Currency: "RUB"}, &FeeRule{FeePercent: 5}))
+	assert.Equal(t, rub(1049), booking.Price)
+	assert.Len(t, booking.LineItems, 2)
+
+	// Бесплатный после скидки билет - без сбора и НДС
+	assert.NoError(t, booking.Charge(rub(150000), rub(150000), rule))
+	assert.Equal(t, rub(0), booking.Price)
+	assert.Len(t, booking.LineItems, 2)
+
+	assert.NoError(t, booking.Charge(rub(150000), Money{Currency: "RUB"}, nil))
+	assert.Equal(t, []BookingLineItem{{Kind: LineItemFaceValue, Amount: rub(150000)}}, booking.LineItems)
+	assert.Equal(t, rub(150000), booking.Price)
+
+	// Скидка в другой валюте не применяется, бронь не меняется
+	err := booking.Charge(rub(150000), Money{Amount: 1000, Currency: "EUR"}, rule)
+	assert.ErrorIs(t, err, ErrCurrencyMismatch)
+	assert.Equal(t, rub(150000), booking.Price)
+	assert.Len(t, booking.LineItems, 1)
+}
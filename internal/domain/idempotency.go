@@ -0,0 +1,29 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	// ErrIdempotencyKeyReused - ключ уже использован для другого запроса (другой метод, путь или тело)
+	ErrIdempotencyKeyReused = errors.New("idempotency key reused with a different request")
+	// ErrIdempotencyInProgress - запрос с этим ключом еще выполняется
+	ErrIdempotencyInProgress = errors.New("request with this idempotency key is in progress")
+)
+
+// IdempotencyRecord - запрос с заголовком Idempotency-Key и сохраненный ответ на него.
+// Пока запрос выполняется, StatusCode равен 0.
+type IdempotencyRecord struct {
+	Key         string
+	RequestHash string
+	StatusCode  int
+	ContentType string
+	Body        []byte
+	CreatedAt   time.Time
+}
+
+// Completed сообщает, сохранен ли ответ
+func (r *IdempotencyRecord) Completed() bool {
+	return r.StatusCode != 0
+}
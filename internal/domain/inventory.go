@@ -0,0 +1,63 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrUnverifiedCapacityNotFound - вместимость события не помечена как непроверенная
+var ErrUnverifiedCapacityNotFound = errors.New("unverified capacity not found")
+
+// InventoryDiscrepancy - расхождение счетчика свободных мест с активными бронями.
+// Ожидаемое число мест = вместимость - брони в статусах pending и confirmed.
+type InventoryDiscrepancy struct {
+	EventId           string
+	Capacity          int
+	ActiveBookings    int
+	AvailableTickets  int
+	ExpectedAvailable int
+}
+
+// Drift - на сколько мест счетчик больше ожидаемого (отрицательный - меньше)
+func (d *InventoryDiscrepancy) Drift() int {
+	return d.AvailableTickets - d.ExpectedAvailable
+}
+
+// InventoryAdjustment - запись об исправлении счетчика свободных мест
+type InventoryAdjustment struct {
+	Id             string
+	EventId        string
+	OldAvailable   int
+	NewAvailable   int
+	ActiveBookings int
+	Reason         string
+	CreatedAt      time.Time
+}
+
+// UnverifiedCapacity - событие, вместимость которого восстановлена миграцией 002 по
+// счетчику и броням, а не задана при создании. Расхождение, накопленное до миграции,
+// вошло в вместимость, поэтому сверка его не находит: вместимость нужно сверить с
+// исходными данными вручную и снять пометку.
+type UnverifiedCapacity struct {
+	EventId          string
+	Capacity         int
+	AvailableTickets int
+	FlaggedAt        time.Time
+}
+
+// InventoryReport - результат сверки счетчиков свободных мест. Unverified - события,
+// расхождение которых сверка обнаружить не может.
+type InventoryReport struct {
+	CheckedAt     time.Time
+	Discrepancies []*InventoryDiscrepancy
+	Adjustments   []*InventoryAdjustment
+	Unverified    []*UnverifiedCapacity
+}
+
+// ExpectedAvailable считает, сколько мест должно быть свободно; перепроданное событие дает 0
+func ExpectedAvailable(capacity, activeBookings int) int {
+	if activeBookings >= capacity {
+		return 0
+	}
+	return capacity - activeBookings
+}
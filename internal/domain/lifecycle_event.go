@@ -0,0 +1,148 @@
+package domain
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Типы доменных событий, они же routing key в topic exchange
+const (
+	BookingCreatedEvent   = "booking.created"
+	BookingConfirmedEvent = "booking.confirmed"
+	// BookingExpiredEvent - неоплаченная бронь отменена по истечении BookingHold
+	BookingExpiredEvent = "booking.expired"
+	// BookingCancelledEvent - бронь перешла в CancelledStatus по любой причине;
+	// при истечении срока публикуется вместе с booking.expired
+	BookingCancelledEvent = "booking.cancelled"
+	EventCreatedEvent     = "event.created"
+	EventUpdatedEvent     = "event.updated"
+	EventSoldOutEvent     = "event.sold_out"
+	BallotWonEvent        = "ballot.won"
+	BallotWaitlistedEvent = "ballot.waitlisted"
+	BallotLostEvent       = "ballot.lost"
+)
+
+// LifecycleEventVersion - версия формата сообщений; меняется при несовместимых изменениях payload
+const LifecycleEventVersion = 1
+
+// LifecycleEvent - доменное событие об изменении состояния брони или мероприятия
+type LifecycleEvent struct {
+	Id         string      `json:"id"`
+	Type       string      `json:"type"`
+	Version    int         `json:"version"`
+	OccurredAt time.Time   `json:"occurred_at"`
+	Data       interface{} `json:"data"`
+}
+
+// BookingPayload - данные событий booking.*
+type BookingPayload struct {
+	BookingId string `json:"booking_id"`
+	EventId   string `json:"event_id"`
+	UserId    string `json:"user_id"`
+	Status    string `json:"status"`
+}
+
+// EventPayload - данные событий event.*
+type EventPayload struct {
+	EventId          string    `json:"event_id"`
+	Name             string    `json:"name"`
+	AvailableTickets uint32    `json:"available_tickets"`
+	Date             time.Time `json:"date"`
+}
+
+// BallotPayload - данные событий ballot.*: итог розыгрыша для участника
+type BallotPayload struct {
+	EventId   string `json:"event_id"`
+	UserId    string `json:"user_id"`
+	Status    string `json:"status"`
+	Rank      int    `json:"rank"`
+	BookingId string `json:"booking_id,omitempty"`
+}
+
+func NewBookingLifecycleEvent(eventType string, booking *Booking) *LifecycleEvent {
+	return newLifecycleEvent(eventType, BookingPayload{
+		BookingId: booking.Id,
+		EventId:   booking.EventId,
+		UserId:    booking.UserId,
+		Status:    booking.Status,
+	})
+}
+
+func NewEventLifecycleEvent(eventType string, event *Event) *LifecycleEvent {
+	return newLifecycleEvent(eventType, EventPayload{
+		EventId:          event.Id,
+		Name:             event.Name,
+		AvailableTickets: event.AvailableTickets,
+		Date:             event.Date,
+	})
+}
+
+func NewBallotLifecycleEvent(eventType string, entry *BallotEntry) *LifecycleEvent {
+	return newLifecycleEvent(eventType, BallotPayload{
+		EventId:   entry.EventId,
+		UserId:    entry.UserId,
+		Status:    entry.Status,
+		Rank:      entry.Rank,
+		BookingId: entry.BookingId,
+	})
+}
+
+// AggregateId - id брони или мероприятия, к которому относится событие. По нему партиционируются
+// сообщения, чтобы события одного агрегата читались в порядке публикации.
+// Итоги розыгрыша относятся к мероприятию.
+func (e *LifecycleEvent) AggregateId() string {
+	switch data := e.Data.(type) {
+	case BookingPayload:
+		return data.BookingId
+	case EventPayload:
+		return data.EventId
+	case BallotPayload:
+		return data.EventId
+	}
+	return ""
+}
+
+func newLifecycleEvent(eventType string, data interface{}) *LifecycleEvent {
+	return &LifecycleEvent{
+		Type:       eventType,
+		Version:    LifecycleEventVersion,
+		OccurredAt: time.Now().UTC(),
+		Data:       data,
+	}
+}
+
+// DecodeLifecycleEvent разбирает сообщение брокера; Data получает тип payload
+// по префиксу типа события, как при публикации
+func DecodeLifecycleEvent(body []byte) (*LifecycleEvent, error) {
+	var data json.RawMessage
+	event := &LifecycleEvent{Data: &data}
+	if err := json.Unmarshal(body, event); err != nil {
+		return nil, fmt.Errorf("failed to decode lifecycle event: %w", err)
+	}
+
+	switch {
+	case strings.HasPrefix(event.Type, "booking."):
+		var payload BookingPayload
+		if err := json.Unmarshal(data, &payload); err != nil {
+			return nil, fmt.Errorf("failed to decode %s payload: %w", event.Type, err)
+		}
+		event.Data = payload
+	case strings.HasPrefix(event.Type, "event."):
+		var payload EventPayload
+		if err := json.Unmarshal(data, &payload); err != nil {
+			return nil, fmt.Errorf("failed to decode %s payload: %w", event.Type, err)
+		}
+		event.Data = payload
+	case strings.HasPrefix(event.Type, "ballot."):
+		var payload BallotPayload
+		if err := json.Unmarshal(data, &payload); err != nil {
+			return nil, fmt.Errorf("failed to decode %s payload: %w", event.Type, err)
+		}
+		event.Data = payload
+	default:
+		return nil, fmt.Errorf("unknown lifecycle event type %q", event.Type)
+	}
+	return event, nil
+}
@@ -0,0 +1,36 @@
+package domain
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeLifecycleEvent(t *testing.T) {
+	booking := &Booking{Id: "booking-1", EventId: "event-1", UserId: "user-1", Status: CancelledStatus}
+	sent := NewBookingLifecycleEvent(BookingExpiredEvent, booking)
+	sent.Id = "message-1"
+	body, err := json.Marshal(sent)
+	require.NoError(t, err)
+
+	decoded, err := DecodeLifecycleEvent(body)
+	require.NoError(t, err)
+	assert.Equal(t, "message-1", decoded.Id)
+	assert.Equal(t, BookingExpiredEvent, decoded.Type)
+	assert.Equal(t, BookingPayload{BookingId: "booking-1", EventId: "event-1", UserId: "user-1", Status: CancelledStatus}, decoded.Data)
+	assert.Equal(t, "booking-1", decoded.AggregateId())
+
+	body, err = json.Marshal(NewEventLifecycleEvent(EventSoldOutEvent, &Event{Id: "event-1", Name: "Concert"}))
+	require.NoError(t, err)
+	decoded, err = DecodeLifecycleEvent(body)
+	require.NoError(t, err)
+	assert.Equal(t, "event-1", decoded.Data.(EventPayload).EventId)
+	assert.Equal(t, "event-1", decoded.AggregateId())
+
+	_, err = DecodeLifecycleEvent([]byte(`{"type":"unknown","data":{}}`))
+	assert.Error(t, err)
+	_, err = DecodeLifecycleEvent([]byte(`not json`))
+	assert.Error(t, err)
+}
@@ -0,0 +1,118 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrInvalidCurrency = errors.New("unsupported currency")
+	// ErrCurrencyMismatch - суммы в разных валютах нельзя сравнивать и складывать
+	ErrCurrencyMismatch = errors.New("currency mismatch")
+)
+
+// DefaultCurrency - валюта мероприятий, созданных без currency, и всех сумм до миграции 015
+const DefaultCurrency = "RUB"
+
+// currencyExponents - поддерживаемые валюты ISO 4217 и число знаков минимальной единицы
+var currencyExponents = map[string]int{
+	"RUB": 2,
+	"BYN": 2,
+	"KZT": 2,
+	"UZS": 2,
+	"AMD": 2,
+	"GEL": 2,
+	"USD": 2,
+	"EUR": 2,
+	"GBP": 2,
+	"CHF": 2,
+	"CNY": 2,
+	"TRY": 2,
+	"AED": 2,
+	"JPY": 0,
+	"KRW": 0,
+}
+
+// ParseCurrency приводит код валюты к верхнему регистру и проверяет, что валюта
+// поддерживается; пустой код - DefaultCurrency
+func ParseCurrency(code string) (string, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if code == "" {
+		return DefaultCurrency, nil
+	}
+	if _, ok := currencyExponents[code]; !ok {
+		return "", fmt.Errorf("%w: %q", ErrInvalidCurrency, code)
+	}
+	return code, nil
+}
+
+// Money - сумма в минимальных единицах валюты (копейках, центах) и код валюты ISO 4217.
+// Суммы хранятся целыми числами, поэтому не накапливают ошибок округления float64.
+type Money struct {
+	Amount   int64  `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+// Add и Sub складывают суммы в одной валюте; суммы в разных валютах не складываются,
+// а возвращают ErrCurrencyMismatch
+func (m Money) Add(other Money) (Money, error) {
+	if err := m.match(other); err != nil {
+		return Money{}, err
+	}
+	return Money{Amount: m.Amount + other.Amount, Currency: m.Currency}, nil
+}
+
+func (m Money) Sub(other Money) (Money, error) {
+	if err := m.match(other); err != nil {
+		return Money{}, err
+	}
+	return Money{Amount: m.Amount - other.Amount, Currency: m.Currency}, nil
+}
+
+func (m Money) match(other Money) error {
+	if m.Currency != other.Currency {
+		return fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, m.Currency, other.Currency)
+	}
+	return nil
+}
+
+// Percent - percent процентов от суммы. Процент учитывается до сотых, результат
+// округляется до минимальной единицы по правилу половина - от нуля.
+func (m Money) Percent(percent float64) Money {
+	basisPoints := int64(math.Round(percent * 100))
+	return Money{Amount: divRound(m.Amount*basisPoints, 100*100), Currency: m.Currency}
+}
+
+// String - сумма в основных единицах валюты: "1500.00 RUB"
+func (m Money) String() string {
+	exponent := currencyExponents[m.Currency]
+	if exponent == 0 {
+		return strconv.FormatInt(m.Amount, 10) + " " + m.Currency
+	}
+	unit := int64(math.Pow10(exponent))
+	sign := ""
+	amount := m.Amount
+	if amount < 0 {
+		sign, amount = "-", -amount
+	}
+	return fmt.Sprintf("%s%d.%0*d %s", sign, amount/unit, exponent, amount%unit, m.Currency)
+}
+
+// divRound делит с округлением половины от нуля
+func divRound(a, b int64) int64 {
+	quotient, remainder := a/b, a%b
+	if remainder < 0 {
+		remainder = -remainder
+	}
+	if 2*remainder >= b {
+		if a < 0 {
+			quotient--
+		} else {
+			quotient++
+		}
+	}
+	return quotient
+}
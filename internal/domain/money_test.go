@@ -0,0 +1,52 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseCurrency(t *testing.T) {
+	currency, err := ParseCurrency(" eur ")
+	assert.NoError(t, err)
+	assert.Equal(t, "EUR", currency)
+
+	currency, err = ParseCurrency("")
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultCurrency, currency)
+
+	_, err = ParseCurrency("XYZ")
+	assert.ErrorIs(t, err, ErrInvalidCurrency)
+}
+
+func TestMoneyPercent(t *testing.T) {
+	// Половина минимальной единицы округляется от нуля
+	assert.Equal(t, rub(50), rub(999).Percent(5))
+	assert.Equal(t, rub(49), rub(989).Percent(5))
+	assert.Equal(t, rub(-50), rub(-999).Percent(5))
+	// Учитываются сотые доли процента
+	assert.Equal(t, rub(1250), rub(10000).Percent(12.5))
+	assert.Equal(t, Money{Amount: 2, Currency: "JPY"}, Money{Amount: 10, Currency: "JPY"}.Percent(15))
+}
+
+func TestMoneyString(t *testing.T) {
+	assert.Equal(t, "1500.05 RUB", rub(150005).String())
+	assert.Equal(t, "-0.50 RUB", rub(-50).String())
+	assert.Equal(t, "1200 JPY", Money{Amount: 1200, Currency: "JPY"}.String())
+}
+
+func TestMoneyAddSub(t *testing.T) {
+	sum, err := rub(300).Add(rub(50))
+	assert.NoError(t, err)
+	assert.Equal(t, rub(350), sum)
+	diff, err := sum.Sub(rub(100))
+	assert.NoError(t, err)
+	assert.Equal(t, "2.50 RUB", diff.String())
+
+	eur := Money{Amount: 100, Currency: "EUR"}
+	_, err = rub(300).Add(eur)
+	assert.ErrorIs(t, err, ErrCurrencyMismatch)
+	assert.EqualError(t, err, "currency mismatch: RUB and EUR")
+	_, err = eur.Sub(rub(100))
+	assert.ErrorIs(t, err, ErrCurrencyMismatch)
+}
@@ -0,0 +1,122 @@
+package domain
+
+import (
+	"errors"
+	"time"
+	"unicode/utf8"
+)
+
+var ErrInvalidPriceSchedule = errors.New("invalid price schedule")
+
+const (
+	// MaxPricePhases - сколько ступеней цены может быть у мероприятия
+	MaxPricePhases = 10
+	// maxPricePhaseName - длина названия ступени в символах
+	maxPricePhaseName = 100
+)
+
+// PricePhase - ступень цены мероприятия (ранняя продажа, последние места и т.п.).
+// Действует, пока не наступил Until и продано меньше MaxSold билетов; нулевые - без ограничения.
+// Price - в минимальных единицах валюты мероприятия.
+type PricePhase struct {
+	Name    string
+	Price   int64
+	Until   time.Time
+	MaxSold uint32
+}
+
+func (p *PricePhase) active(sold uint32, now time.Time) bool {
+	return (p.Until.IsZero() || now.Before(p.Until)) && (p.MaxSold == 0 || sold < p.MaxSold)
+}
+
+// ValidatePricePhases проверяет расписание цен; пустое расписание - цена мероприятия без ступеней
+func ValidatePricePhases(phases []PricePhase) error {
+	if len(phases) > MaxPricePhases {
+		return ErrInvalidPriceSchedule
+	}
+	for i, phase := range phases {
+		if phase.Price < 0 || utf8.RuneCountInString(phase.Name) > maxPricePhaseName {
+			return ErrInvalidPriceSchedule
+		}
+		// Ступень без ограничений действует всегда: следующие за ней недостижимы
+		if phase.Until.IsZero() && phase.MaxSold == 0 && i < len(phases)-1 {
+			return ErrInvalidPriceSchedule
+		}
+	}
+	return nil
+}
+
+// PriceQuote - цена следующего билета по расписанию. Phase - номер действующей ступени
+// с 1; 0 - базовая цена мероприятия (Event.Price).
+type PriceQuote struct {
+	Price Money
+	Phase int
+	Name  string
+	// EndsAt - когда ступень закончится по времени; nil - не по времени
+	EndsAt *time.Time
+	// Remaining - сколько билетов осталось продать по этой цене; 0 - не ограничено продажами
+	Remaining uint32
+	// Next - цена после окончания ступени; nil - цена больше не изменится
+	Next *NextPrice
+}
+
+type NextPrice struct {
+	Price Money
+	Phase int
+	Name  string
+}
+
+// Quote - цена следующего билета на момент now: первая действующая ступень из PricePhases
+// или базовая цена. Проданными считаются и оплаченные, и ожидающие оплаты места.
+func (e *Event) Quote(now time.Time) PriceQuote {
+	if e.IsFree {
+		return PriceQuote{Price: Money{Currency: e.Price.Currency}}
+	}
+	var sold uint32
+	if e.Capacity > e.AvailableTickets {
+		sold = e.Capacity - e.AvailableTickets
+	}
+
+	current := -1
+	for i := range e.PricePhases {
+		if e.PricePhases[i].active(sold, now) {
+			current = i
+			break
+		}
+	}
+	if current < 0 {
+		return PriceQuote{Price: e.Price}
+	}
+
+	phase := e.PricePhases[current]
+	quote := PriceQuote{Price: e.phasePrice(phase), Phase: current + 1, Name: phase.Name}
+	if !phase.Until.IsZero() {
+		endsAt := phase.Until
+		quote.EndsAt = &endsAt
+	}
+	if phase.MaxSold > 0 {
+		quote.Remaining = phase.MaxSold - sold
+	}
+	if quote.EndsAt == nil && quote.Remaining == 0 {
+		return quote
+	}
+
+	// Следующей будет первая из дальнейших ступеней, которая еще не закончилась
+	quote.Next = &NextPrice{Price: e.Price}
+	for i := current + 1; i < len(e.PricePhases); i++ {
+		if next := e.PricePhases[i]; next.active(sold, now) {
+			quote.Next = &NextPrice{Price: e.phasePrice(next), Phase: i + 1, Name: next.Name}
+			break
+		}
+	}
+	return quote
+}
+
+// TicketPrice - сумма, которую спишет бронь, созданная в момент now
+func (e *Event) TicketPrice(now time.Time) Money {
+	return e.Quote(now).Price
+}
+
+func (e *Event) phasePrice(phase PricePhase) Money {
+	return Money{Amount: phase.Price, Currency: e.Price.Currency}
+}
@@ -0,0 +1,54 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidatePricePhases(t *testing.T) {
+	until := time.Date(2026, time.March, 1, 0, 0, 0, 0, time.UTC)
+
+	assert.NoError(t, ValidatePricePhases(nil))
+	assert.NoError(t, ValidatePricePhases([]PricePhase{{Price: 100000, Until: until}, {Price: 120000, MaxSold: 50}, {Price: 150000}}))
+	assert.ErrorIs(t, ValidatePricePhases([]PricePhase{{Price: 100000}, {Price: 120000}}), ErrInvalidPriceSchedule)
+	assert.ErrorIs(t, ValidatePricePhases([]PricePhase{{Price: -1}}), ErrInvalidPriceSchedule)
+	assert.ErrorIs(t, ValidatePricePhases(make([]PricePhase, MaxPricePhases+1)), ErrInvalidPriceSchedule)
+}
+
+func TestEventQuote(t *testing.T) {
+	until := time.Date(2026, time.March, 1, 0, 0, 0, 0, time.UTC)
+	event := &Event{
+		Price:            rub(150000),
+		Capacity:         100,
+		AvailableTickets: 100,
+		PricePhases: []PricePhase{
+			{Name: "Early bird", Price: 100000, Until: until, MaxSold: 10},
+			{Name: "Regular", Price: 120000, MaxSold: 50},
+		},
+	}
+
+	quote := event.Quote(until.Add(-time.Hour))
+	assert.Equal(t, rub(100000), quote.Price)
+	assert.Equal(t, 1, quote.Phase)
+	require.NotNil(t, quote.EndsAt)
+	assert.Equal(t, until, *quote.EndsAt)
+	assert.Equal(t, uint32(10), quote.Remaining)
+	assert.Equal(t, &NextPrice{Price: rub(120000), Phase: 2, Name: "Regular"}, quote.Next)
+
+	// Ранняя цена закончилась по времени
+	quote = event.Quote(until)
+	assert.Equal(t, rub(120000), quote.Price)
+	assert.Nil(t, quote.EndsAt)
+	assert.Equal(t, uint32(50), quote.Remaining)
+	assert.Equal(t, &NextPrice{Price: rub(150000)}, quote.Next)
+
+	// Все ступени распроданы: базовая цена больше не меняется
+	event.AvailableTickets = 50
+	assert.Equal(t, PriceQuote{Price: rub(150000)}, event.Quote(until.Add(-time.Hour)))
+
+	event.IsFree = true
+	assert.Equal(t, rub(0), event.TicketPrice(until))
+}
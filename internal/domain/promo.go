@@ -0,0 +1,134 @@
+package domain
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var (
+	ErrPromoCodeNotFound = errors.New("promo code not found")
+	ErrPromoCodeExists   = errors.New("promo code already exists")
+	ErrInvalidPromoCode  = errors.New("invalid promo code")
+	// ErrPromoCodeNotApplicable - промокод другого мероприятия, вне срока действия или для бесплатного мероприятия
+	ErrPromoCodeNotApplicable = errors.New("promo code is not valid for this booking")
+	ErrPromoCodeExhausted     = errors.New("promo code usage limit reached")
+	ErrPromoCodeUsed          = errors.New("promo code has already been used by this user")
+)
+
+// Виды скидки промокода
+const (
+	// DiscountPercent - скидка Percent процентов от цены, от 0 до 100
+	DiscountPercent = "percent"
+	// DiscountFixed - скидка Amount, но не больше цены
+	DiscountFixed = "fixed"
+)
+
+// promoCodePattern - коды хранятся в верхнем регистре (NormalizePromoCode)
+var promoCodePattern = regexp.MustCompile(`^[A-Z0-9_-]{1,64}$`)
+
+// NormalizePromoCode приводит введенный пользователем код к виду, в котором он хранится
+func NormalizePromoCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// PromoCode - скидка при бронировании. Каждый пользователь может использовать код один раз;
+// использования отмененных броней не считаются ни в лимит, ни пользователю.
+type PromoCode struct {
+	Code string
+	// EventId - мероприятие, для которого действует код; пустой - для всех
+	EventId      string
+	DiscountType string
+	// Percent - размер скидки DiscountPercent в процентах, учитываются сотые
+	Percent float64
+	// Amount - скидка DiscountFixed; код применим только к ценам в валюте Amount.Currency
+	Amount Money
+	// MaxUses - сколько броней может использовать код; 0 - без ограничения
+	MaxUses int
+	// ValidFrom и ValidUntil - срок действия; нулевые - без ограничения
+	ValidFrom  time.Time
+	ValidUntil time.Time
+	CreatedAt  time.Time
+	// Uses и TotalDiscount - использования неотмененными бронями и сумма их скидок
+	// по валютам в порядке кодов валют; вычисляются при чтении
+	Uses          int
+	TotalDiscount []Money
+}
+
+func (p *PromoCode) Validate() error {
+	if !promoCodePattern.MatchString(p.Code) {
+		return ErrInvalidPromoCode
+	}
+	switch p.DiscountType {
+	case DiscountPercent:
+		if p.Percent <= 0 || p.Percent > 100 || p.Amount != (Money{}) {
+			return ErrInvalidPromoCode
+		}
+	case DiscountFixed:
+		if p.Amount.Amount <= 0 || p.Percent != 0 {
+			return ErrInvalidPromoCode
+		}
+		if _, ok := currencyExponents[p.Amount.Currency]; !ok {
+			return ErrInvalidPromoCode
+		}
+	default:
+		return ErrInvalidPromoCode
+	}
+	if p.MaxUses < 0 {
+		return ErrInvalidPromoCode
+	}
+	if !p.ValidFrom.IsZero() && !p.ValidUntil.IsZero() && !p.ValidUntil.After(p.ValidFrom) {
+		return ErrInvalidPromoCode
+	}
+	return nil
+}
+
+// Redeem проверяет, что код можно применить к брони мероприятия eventID по цене price,
+// и возвращает скидку. userUsed - использовал ли пользователь код в неотмененной брони.
+func (p *PromoCode) Redeem(eventID string, price Money, now time.Time, userUsed bool) (Money, error) {
+	none := Money{Currency: price.Currency}
+	if p.EventId != "" && p.EventId != eventID {
+		return none, ErrPromoCodeNotApplicable
+	}
+	if (!p.ValidFrom.IsZero() && now.Before(p.ValidFrom)) || (!p.ValidUntil.IsZero() && !now.Before(p.ValidUntil)) {
+		return none, ErrPromoCodeNotApplicable
+	}
+	if price.Amount <= 0 || (p.DiscountType == DiscountFixed && p.Amount.Currency != price.Currency) {
+		return none, ErrPromoCodeNotApplicable
+	}
+	if userUsed {
+		return none, ErrPromoCodeUsed
+	}
+	if p.MaxUses > 0 && p.Uses >= p.MaxUses {
+		return none, ErrPromoCodeExhausted
+	}
+	return p.Discount(price), nil
+}
+
+// Discount - скидка с цены price в минимальных единицах ее валюты, не больше цены.
+// Процентная скидка округляется половиной от нуля (Money.Percent).
+func (p *PromoCode) Discount(price Money) Money {
+	discount := Money{Currency: price.Currency}
+	switch p.DiscountType {
+	case DiscountPercent:
+		discount = price.Percent(p.Percent)
+	case DiscountFixed:
+		discount.Amount = p.Amount.Amount
+	}
+	discount.Amount = min(discount.Amount, price.Amount)
+	return discount
+}
+
+// PromoRedemption - использование промокода бронью
+type PromoRedemption struct {
+	Code      string
+	BookingId string
+	UserId    string
+	EventId   string
+	// Discount - в валюте брони
+	Discount Money
+	// BookingStatus - текущий статус брони; использование отмененной брони не считается
+	BookingStatus string
+	RedeemedAt    time.Time
+}
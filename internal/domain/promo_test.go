@@ -0,0 +1,65 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPromoCodeValidate(t *testing.T) {
+	from := time.Date(2026, time.March, 1, 19, 0, 0, 0, time.UTC)
+
+	assert.ErrorIs(t, (&PromoCode{Code: "spring", DiscountType: DiscountPercent, Percent: 10}).Validate(), ErrInvalidPromoCode)
+	assert.ErrorIs(t, (&PromoCode{Code: "SPRING", DiscountType: DiscountPercent, Percent: 101}).Validate(), ErrInvalidPromoCode)
+	assert.ErrorIs(t, (&PromoCode{Code: "SPRING", DiscountType: "gift", Percent: 10}).Validate(), ErrInvalidPromoCode)
+	assert.ErrorIs(t, (&PromoCode{Code: "SPRING", DiscountType: DiscountFixed, Amount: rub(1000), MaxUses: -1}).Validate(), ErrInvalidPromoCode)
+	assert.ErrorIs(t, (&PromoCode{Code: "SPRING", DiscountType: DiscountFixed, Amount: rub(1000), ValidFrom: from, ValidUntil: from}).Validate(), ErrInvalidPromoCode)
+	assert.NoError(t, (&PromoCode{Code: "SPRING-26", DiscountType: DiscountFixed, Amount: rub(1000000), ValidFrom: from}).Validate())
+	assert.ErrorIs(t, (&PromoCode{Code: "SPRING", DiscountType: DiscountFixed, Amount: Money{Amount: 1000}}).Validate(), ErrInvalidPromoCode)
+	assert.ErrorIs(t, (&PromoCode{Code: "SPRING", DiscountType: DiscountPercent, Percent: 10, Amount: rub(1000)}).Validate(), ErrInvalidPromoCode)
+	assert.Equal(t, "SPRING", NormalizePromoCode(" spring "))
+}
+
+func TestPromoCodeRedeem(t *testing.T) {
+	from := time.Date(2026, time.March, 1, 19, 0, 0, 0, time.UTC)
+	promo := &PromoCode{
+		Code: "VIP", EventId: "event-1", DiscountType: DiscountPercent, Percent: 33,
+		MaxUses: 2, ValidFrom: from, ValidUntil: from.Add(time.Hour),
+	}
+
+	// 33% от 999.99 - 329.9967, скидка округляется до копеек
+	discount, err := promo.Redeem("event-1", rub(99999), from, false)
+	assert.NoError(t, err)
+	assert.Equal(t, rub(33000), discount)
+
+	_, err = promo.Redeem("event-2", rub(100000), from, false)
+	assert.ErrorIs(t, err, ErrPromoCodeNotApplicable)
+	_, err = promo.Redeem("event-1", rub(100000), from.Add(-time.Second), false)
+	assert.ErrorIs(t, err, ErrPromoCodeNotApplicable)
+	_, err = promo.Redeem("event-1", rub(100000), from.Add(time.Hour), false)
+	assert.ErrorIs(t, err, ErrPromoCodeNotApplicable)
+	_, err = promo.Redeem("event-1", rub(0), from, false)
+	assert.ErrorIs(t, err, ErrPromoCodeNotApplicable)
+	_, err = promo.Redeem("event-1", rub(100000), from, true)
+	assert.ErrorIs(t, err, ErrPromoCodeUsed)
+
+	promo.Uses = 2
+	_, err = promo.Redeem("event-1", rub(100000), from, false)
+	assert.ErrorIs(t, err, ErrPromoCodeExhausted)
+
+	// Фиксированная скидка не больше цены
+	fixed := &PromoCode{Code: "GIFT", DiscountType: DiscountFixed, Amount: rub(500000)}
+	assert.Equal(t, rub(150000), fixed.Discount(rub(150000)))
+	booking := &Booking{}
+	assert.NoError(t, booking.Charge(rub(150000), fixed.Discount(rub(150000)), nil))
+	assert.Equal(t, rub(0), booking.Price)
+
+	// Фиксированная скидка в рублях не применяется к цене в евро
+	_, err = fixed.Redeem("event-1", Money{Amount: 5000, Currency: "EUR"}, from, false)
+	assert.ErrorIs(t, err, ErrPromoCodeNotApplicable)
+}
+
+func rub(amount int64) Money {
+	return Money{Amount: amount, Currency: "RUB"}
+}
@@ -0,0 +1,34 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+)
+
+// ErrInvalidSearchQuery - в поисковом запросе нет ни одного слова
+var ErrInvalidSearchQuery = errors.New("search query must contain at least one word")
+
+// Разметка совпадений в подсветке. Текст вокруг нее экранирован для HTML,
+// поэтому подсветку можно вставлять в страницу как есть.
+const (
+	HighlightStart = "<mark>"
+	HighlightStop  = "</mark>"
+)
+
+// EventSearchResult - событие, найденное полнотекстовым поиском. Rank - релевантность
+// (больше - лучше), сравнима только внутри одной выдачи.
+type EventSearchResult struct {
+	Event                *Event
+	Rank                 float64
+	NameHighlight        string
+	DescriptionHighlight string
+}
+
+// SearchTerms разбивает запрос на слова в нижнем регистре; знаки препинания и
+// операторы поиска отбрасываются
+func SearchTerms(query string) []string {
+	return strings.FieldsFunc(strings.ToLower(query), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
@@ -0,0 +1,13 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSearchTerms(t *testing.T) {
+	assert.Equal(t, []string{"джаз", "rock", "2026"}, SearchTerms("  Джаз, ROCK-2026! "))
+	assert.Equal(t, []string{"концерт", "or", "опера"}, SearchTerms(`"концерт" OR -опера`))
+	assert.Empty(t, SearchTerms(" -- * "))
+}
@@ -0,0 +1,58 @@
+package domain
+
+// Типы обновлений в потоке для клиентов
+const (
+	// UpdateAvailability - текущие счетчики мест события
+	UpdateAvailability = "availability"
+	// UpdateBooking - смена статуса брони
+	UpdateBooking = "booking"
+	// UpdateBallot - итог розыгрыша для участника
+	UpdateBallot = "ballot"
+)
+
+// Update - изменение, которое получают подписчики потока обновлений.
+// У UpdateAvailability заполнено Event, у UpdateBooking - Booking и Cause
+// (тип доменного события: booking.confirmed, booking.expired и т.д.),
+// у UpdateBallot - Entry и Cause.
+type Update struct {
+	Type    string
+	EventId string
+	Event   *Event
+	Booking *Booking
+	Entry   *BallotEntry
+	Cause   string
+}
+
+// UpdateFilter выбирает обновления для подписчика. Пустой EventId - все события.
+// Статусы броней получает только подписчик с UserId владельца: id брони позволяет
+// ее оплатить, поэтому чужие брони в поток не попадают; итоги розыгрыша - так же.
+// С BookingId подписчик получает только статусы этой брони, без счетчиков мест.
+type UpdateFilter struct {
+	EventId   string
+	UserId    string
+	BookingId string
+}
+
+func (f UpdateFilter) Matches(u *Update) bool {
+	if u.Type == UpdateBooking {
+		if f.EventId != "" && f.EventId != u.EventId {
+			return false
+		}
+		if f.BookingId != "" && (u.Booking == nil || f.BookingId != u.Booking.Id) {
+			return false
+		}
+		return f.UserId != "" && u.Booking != nil && f.UserId == u.Booking.UserId
+	}
+	if u.Type == UpdateBallot {
+		if f.BookingId != "" || (f.EventId != "" && f.EventId != u.EventId) {
+			return false
+		}
+		return f.UserId != "" && u.Entry != nil && f.UserId == u.Entry.UserId
+	}
+	return f.WantsAvailability(u.EventId)
+}
+
+// WantsAvailability - нужны ли подписчику счетчики мест события
+func (f UpdateFilter) WantsAvailability(eventID string) bool {
+	return f.BookingId == "" && (f.EventId == "" || f.EventId == eventID)
+}
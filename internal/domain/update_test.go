@@ -0,0 +1,34 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUpdateFilterMatches(t *testing.T) {
+	availability := &Update{Type: UpdateAvailability, EventId: "event-1", Event: &Event{Id: "event-1"}}
+	booking := &Update{Type: UpdateBooking, EventId: "event-1", Booking: &Booking{Id: "booking-1", UserId: "user-1", EventId: "event-1"}}
+
+	tests := []struct {
+		name         string
+		filter       UpdateFilter
+		availability bool
+		booking      bool
+	}{
+		{"all events, anonymous", UpdateFilter{}, true, false},
+		{"all events, owner", UpdateFilter{UserId: "user-1"}, true, true},
+		{"event, other user", UpdateFilter{EventId: "event-1", UserId: "user-2"}, true, false},
+		{"event, owner", UpdateFilter{EventId: "event-1", UserId: "user-1"}, true, true},
+		{"other event, owner", UpdateFilter{EventId: "event-2", UserId: "user-1"}, false, false},
+		{"booking, owner", UpdateFilter{UserId: "user-1", BookingId: "booking-1"}, false, true},
+		{"other booking, owner", UpdateFilter{UserId: "user-1", BookingId: "booking-2"}, false, false},
+		{"booking, other user", UpdateFilter{UserId: "user-2", BookingId: "booking-1"}, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.availability, tt.filter.Matches(availability))
+			assert.Equal(t, tt.booking, tt.filter.Matches(booking))
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrWaitingRoomNotFound   = errors.New("waiting room not found")
+	ErrInvalidWaitingRoom    = errors.New("rate_per_minute must be between 1 and 60000")
+	ErrInvalidQueueToken     = errors.New("invalid queue token")
+	ErrQueueTokenExpired     = errors.New("queue token expired, join the queue again")
+	ErrAdmissionRequired     = errors.New("waiting room is active, admission token required")
+	ErrInvalidAdmissionToken = errors.New("invalid or expired admission token")
+	ErrAdmissionTokenUsed    = errors.New("admission token already used, join the queue again")
+)
+
+// MaxWaitingRoomRate - предел скорости допуска: не чаще раза в миллисекунду
+const MaxWaitingRoomRate = 60000
+
+// AdmissionWindow - сколько после наступления очереди действует допуск к бронированию
+const AdmissionWindow = 10 * time.Minute
+
+// WaitingRoom - очередь на бронирование мероприятия с высоким спросом. Пока она открыта,
+// бронировать можно только с допуском, а допуски выдаются не чаще RatePerMinute в минуту.
+// Время допуска назначается при входе в очередь и подписывается в токене; места хранятся,
+// только чтобы повторный вход возвращал прежнее место, а допуск расходовался один раз.
+type WaitingRoom struct {
+	EventId       string
+	RatePerMinute int
+	// Joined - сколько раз в очередь вставали; позиция вошедшего последним
+	Joined int64
+	// LastAdmitAt - время допуска вошедшего последним
+	LastAdmitAt time.Time
+	OpenedAt    time.Time
+}
+
+func (w *WaitingRoom) Validate() error {
+	if w.RatePerMinute < 1 || w.RatePerMinute > MaxWaitingRoomRate {
+		return ErrInvalidWaitingRoom
+	}
+	return nil
+}
+
+// Interval - промежуток между допусками соседних позиций
+func (w *WaitingRoom) Interval() time.Duration {
+	return time.Minute / time.Duration(w.RatePerMinute)
+}
+
+// Join ставит пользователя в конец очереди и возвращает его позицию и время допуска.
+// Следующий допуск - через Interval после предыдущего, но не раньше now: пока очередь
+// пуста, неиспользованные допуски не копятся и не пропускают толпу разом.
+func (w *WaitingRoom) Join(now time.Time) (int64, time.Time) {
+	admitAt := w.LastAdmitAt.Add(w.Interval())
+	if admitAt.Before(now) {
+		admitAt = now
+	}
+	w.Joined++
+	w.LastAdmitAt = admitAt
+	return w.Joined, admitAt
+}
+
+// Enqueue ставит пользователя userID в конец очереди (Join) и возвращает его место
+func (w *WaitingRoom) Enqueue(userID string, now time.Time) QueueTicket {
+	position, admitAt := w.Join(now)
+	return QueueTicket{EventId: w.EventId, UserId: userID, Position: position, AdmitAt: admitAt, OpenedAt: w.OpenedAt}
+}
+
+// QueueTicket - место пользователя в очереди мероприятия
+type QueueTicket struct {
+	EventId  string
+	UserId   string
+	Position int64
+	AdmitAt  time.Time
+	// OpenedAt - открытие очереди; после закрытия и повторного открытия место недействительно
+	OpenedAt time.Time
+	// BookingId - бронь, на которую израсходован допуск; пустой - допуск не использован
+	BookingId string
+}
+
+// ExpiresAt - после этого момента место и выданный по нему допуск недействительны
+func (t QueueTicket) ExpiresAt() time.Time {
+	return t.AdmitAt.Add(AdmissionWindow)
+}
+
+// Active - место еще можно вернуть при повторном входе в очередь: допуск по нему
+// не израсходован и не истек. Иначе пользователь встает в конец очереди.
+func (t QueueTicket) Active(now time.Time) bool {
+	return t.BookingId == "" && now.Before(t.ExpiresAt())
+}
+
+// QueueStatus - состояние места в очереди. AdmissionToken заполнен, когда очередь
+// подошла; пустой при Admitted означает, что очередь закрыта и допуск не нужен.
+type QueueStatus struct {
+	Ticket         QueueTicket
+	QueueToken     string
+	Admitted       bool
+	AdmissionToken string
+}
@@ -0,0 +1,39 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWaitingRoomValidate(t *testing.T) {
+	assert.ErrorIs(t, (&WaitingRoom{RatePerMinute: 0}).Validate(), ErrInvalidWaitingRoom)
+	assert.ErrorIs(t, (&WaitingRoom{RatePerMinute: MaxWaitingRoomRate + 1}).Validate(), ErrInvalidWaitingRoom)
+	assert.NoError(t, (&WaitingRoom{RatePerMinute: MaxWaitingRoomRate}).Validate())
+	assert.Equal(t, 100*time.Millisecond, (&WaitingRoom{RatePerMinute: 600}).Interval())
+}
+
+func TestWaitingRoomJoin(t *testing.T) {
+	opened := time.Date(2026, time.March, 1, 19, 0, 0, 0, time.UTC)
+	room := &WaitingRoom{RatePerMinute: 120, LastAdmitAt: opened, OpenedAt: opened}
+
+	// Толпа в момент открытия: допуски через полсекунды
+	for i := int64(1); i <= 3; i++ {
+		position, admitAt := room.Join(opened)
+		assert.Equal(t, i, position)
+		assert.Equal(t, opened.Add(time.Duration(i)*500*time.Millisecond), admitAt)
+	}
+
+	// Пока очередь пустовала, допуски не накопились: пришедший позже пускается сразу,
+	// а следующий за ним - через интервал
+	later := opened.Add(time.Hour)
+	position, admitAt := room.Join(later)
+	assert.Equal(t, int64(4), position)
+	assert.Equal(t, later, admitAt)
+	_, admitAt = room.Join(later)
+	assert.Equal(t, later.Add(500*time.Millisecond), admitAt)
+
+	ticket := QueueTicket{AdmitAt: later}
+	assert.Equal(t, later.Add(AdmissionWindow), ticket.ExpiresAt())
+}
@@ -0,0 +1,179 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/dontpanicw/EventBooker/internal/domain"
+	"github.com/dontpanicw/EventBooker/internal/port"
+	"github.com/gorilla/mux"
+	"net/http"
+	"strconv"
+)
+
+type AdminHandler struct {
+	admin port.AdminUsecases
+}
+
+func NewAdminHandler(admin port.AdminUsecases) *AdminHandler {
+	return &AdminHandler{
+		admin: admin,
+	}
+}
+
+func (h *AdminHandler) ListDeadLetters(w http.ResponseWriter, r *http.Request) {
+	limit := 0
+	if v := r.URL.Query().Get("limit"); v != "" {
+		parsed, err := strconv.Atoi(v)
+		if err != nil || parsed < 0 {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+		limit = parsed
+	}
+
+	deadLetters, err := h.admin.ListDeadLetters(r.Context(), limit)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(deadLetters)
+}
+
+func (h *AdminHandler) GetDeadLetter(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	deadLetter, err := h.admin.GetDeadLetter(r.Context(), id)
+	if err != nil {
+		http.Error(w, err.Error(), deadLetterErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(deadLetter)
+}
+
+func (h *AdminHandler) ReplayDeadLetter(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	if err := h.admin.ReplayDeadLetter(r.Context(), id); err != nil {
+		http.Error(w, err.Error(), deadLetterErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"status": "replayed"})
+}
+
+func (h *AdminHandler) DiscardDeadLetter(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	if err := h.admin.DiscardDeadLetter(r.Context(), id); err != nil {
+		http.Error(w, err.Error(), deadLetterErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"status": "discarded"})
+}
+
+// ReconcileInventory сверяет счетчики мест; ?repair=true исправляет расхождения
+func (h *AdminHandler) ReconcileInventory(w http.ResponseWriter, r *http.Request) {
+	repair := false
+	if v := r.URL.Query().Get("repair"); v != "" {
+		parsed, err := strconv.ParseBool(v)
+		if err != nil {
+			http.Error(w, "Invalid repair", http.StatusBadRequest)
+			return
+		}
+		repair = parsed
+	}
+
+	ctx := port.WithActor(r.Context(), domain.Actor{Type: domain.ActorAdmin})
+	report, err := h.admin.ReconcileInventory(ctx, repair)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(report)
+}
+
+// VerifyCapacity снимает пометку непроверенной вместимости после ручной проверки
+func (h *AdminHandler) VerifyCapacity(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	eventID := vars["id"]
+
+	if err := h.admin.VerifyCapacity(r.Context(), eventID); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, domain.ErrUnverifiedCapacityNotFound) {
+			status = http.StatusNotFound
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"status": "verified"})
+}
+
+// BookingAuditLog возвращает историю брони
+func (h *AdminHandler) BookingAuditLog(w http.ResponseWriter, r *http.Request) {
+	h.writeAuditLog(w, r, domain.AuditFilter{BookingId: mux.Vars(r)["id"]})
+}
+
+// EventAuditLog возвращает историю события вместе с его бронями
+func (h *AdminHandler) EventAuditLog(w http.ResponseWriter, r *http.Request) {
+	h.writeAuditLog(w, r, domain.AuditFilter{EventId: mux.Vars(r)["id"]})
+}
+
+// UserAuditLog возвращает историю броней пользователя
+func (h *AdminHandler) UserAuditLog(w http.ResponseWriter, r *http.Request) {
+	h.writeAuditLog(w, r, domain.AuditFilter{UserId: mux.Vars(r)["id"]})
+}
+
+func (h *AdminHandler) writeAuditLog(w http.ResponseWriter, r *http.Request, filter domain.AuditFilter) {
+	if v := r.URL.Query().Get("limit"); v != "" {
+		parsed, err := strconv.Atoi(v)
+		if err != nil || parsed < 0 {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+		filter.Limit = parsed
+	}
+
+	entries, err := h.admin.AuditLog(r.Context(), filter)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, domain.ErrInvalidAuditFilter) {
+			status = http.StatusBadRequest
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+	if entries == nil {
+		entries = []*domain.AuditEntry{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(entries)
+}
+
+func deadLetterErrorStatus(err error) int {
+	if errors.Is(err, domain.ErrDeadLetterNotFound) {
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
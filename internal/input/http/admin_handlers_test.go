@@ -0,0 +1,274 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dontpanicw/EventBooker/internal/domain"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockAdminUsecases - мок админских usecases
+type MockAdminUsecases struct {
+	mock.Mock
+}
+
+func (m *MockAdminUsecases) ListDeadLetters(ctx context.Context, limit int) ([]*domain.DeadLetter, error) {
+	args := m.Called(ctx, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.DeadLetter), args.Error(1)
+}
+
+func (m *MockAdminUsecases) GetDeadLetter(ctx context.Context, id string) (*domain.DeadLetter, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.DeadLetter), args.Error(1)
+}
+
+func (m *MockAdminUsecases) ReplayDeadLetter(ctx context.Context, id string) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockAdminUsecases) DiscardDeadLetter(ctx context.Context, id string) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockAdminUsecases) AuditLog(ctx context.Context, filter domain.AuditFilter) ([]*domain.AuditEntry, error) {
+	args := m.Called(ctx, filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.AuditEntry), args.Error(1)
+}
+
+func (m *MockAdminUsecases) ReconcileInventory(ctx context.Context, repair bool) (*domain.InventoryReport, error) {
+	args := m.Called(ctx, repair)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.InventoryReport), args.Error(1)
+}
+
+func (m *MockAdminUsecases) VerifyCapacity(ctx context.Context, eventID string) error {
+	args := m.Called(ctx, eventID)
+	return args.Error(0)
+}
+
+func TestListDeadLetters_Success(t *testing.T) {
+	mockAdmin := new(MockAdminUsecases)
+	handler := NewAdminHandler(mockAdmin)
+
+	expected := []*domain.DeadLetter{
+		{Id: "msg-1", Queue: "dead_letter_cancellations", Attempts: 5, Reason: "retries exhausted"},
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/admin/dead-letters?limit=10", nil)
+	w := httptest.NewRecorder()
+
+	mockAdmin.On("ListDeadLetters", mock.Anything, 10).Return(expected, nil)
+
+	handler.ListDeadLetters(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var deadLetters []*domain.DeadLetter
+	json.Unmarshal(w.Body.Bytes(), &deadLetters)
+	assert.Len(t, deadLetters, 1)
+	assert.Equal(t, "msg-1", deadLetters[0].Id)
+	mockAdmin.AssertExpectations(t)
+}
+
+func TestListDeadLetters_InvalidLimit(t *testing.T) {
+	mockAdmin := new(MockAdminUsecases)
+	handler := NewAdminHandler(mockAdmin)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/admin/dead-letters?limit=abc", nil)
+	w := httptest.NewRecorder()
+
+	handler.ListDeadLetters(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestGetDeadLetter_NotFound(t *testing.T) {
+	mockAdmin := new(MockAdminUsecases)
+	handler := NewAdminHandler(mockAdmin)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/admin/dead-letters/msg-1", nil)
+	req = mux.SetURLVars(req, map[string]string{"id": "msg-1"})
+	w := httptest.NewRecorder()
+
+	mockAdmin.On("GetDeadLetter", mock.Anything, "msg-1").Return(nil, fmt.Errorf("failed to get dead letter: %w", domain.ErrDeadLetterNotFound))
+
+	handler.GetDeadLetter(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	mockAdmin.AssertExpectations(t)
+}
+
+func TestReplayDeadLetter_Success(t *testing.T) {
+	mockAdmin := new(MockAdminUsecases)
+	handler := NewAdminHandler(mockAdmin)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/admin/dead-letters/msg-1/replay", nil)
+	req = mux.SetURLVars(req, map[string]string{"id": "msg-1"})
+	w := httptest.NewRecorder()
+
+	mockAdmin.On("ReplayDeadLetter", mock.Anything, "msg-1").Return(nil)
+
+	handler.ReplayDeadLetter(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockAdmin.AssertExpectations(t)
+}
+
+func TestDiscardDeadLetter_Error(t *testing.T) {
+	mockAdmin := new(MockAdminUsecases)
+	handler := NewAdminHandler(mockAdmin)
+
+	req := httptest.NewRequest(http.MethodDelete, "/api/admin/dead-letters/msg-1", nil)
+	req = mux.SetURLVars(req, map[string]string{"id": "msg-1"})
+	w := httptest.NewRecorder()
+
+	mockAdmin.On("DiscardDeadLetter", mock.Anything, "msg-1").Return(errors.New("channel closed"))
+
+	handler.DiscardDeadLetter(w, req)
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	mockAdmin.AssertExpectations(t)
+}
+
+func TestReconcileInventory_Repair(t *testing.T) {
+	mockAdmin := new(MockAdminUsecases)
+	handler := NewAdminHandler(mockAdmin)
+
+	expected := &domain.InventoryReport{
+		Discrepancies: []*domain.InventoryDiscrepancy{{EventId: "event-1", Capacity: 10, AvailableTickets: 7, ExpectedAvailable: 6}},
+		Adjustments:   []*domain.InventoryAdjustment{{Id: "adj-1", EventId: "event-1", OldAvailable: 7, NewAvailable: 6}},
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/api/admin/inventory/reconcile?repair=true", nil)
+	w := httptest.NewRecorder()
+
+	mockAdmin.On("ReconcileInventory", mock.Anything, true).Return(expected, nil)
+
+	handler.ReconcileInventory(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var report domain.InventoryReport
+	json.Unmarshal(w.Body.Bytes(), &report)
+	assert.Len(t, report.Adjustments, 1)
+	assert.Equal(t, 6, report.Adjustments[0].NewAvailable)
+	mockAdmin.AssertExpectations(t)
+}
+
+func TestReconcileInventory_InvalidRepair(t *testing.T) {
+	mockAdmin := new(MockAdminUsecases)
+	handler := NewAdminHandler(mockAdmin)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/admin/inventory/reconcile?repair=maybe", nil)
+	w := httptest.NewRecorder()
+
+	handler.ReconcileInventory(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	mockAdmin.AssertNotCalled(t, "ReconcileInventory", mock.Anything, mock.Anything)
+}
+
+func TestVerifyCapacity(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		status int
+	}{
+		{"verified", nil, http.StatusOK},
+		{"not flagged", fmt.Errorf("failed to verify capacity: %w", domain.ErrUnverifiedCapacityNotFound), http.StatusNotFound},
+		{"storage error", errors.New("connection refused"), http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockAdmin := new(MockAdminUsecases)
+			handler := NewAdminHandler(mockAdmin)
+			mockAdmin.On("VerifyCapacity", mock.Anything, "event-1").Return(tt.err)
+
+			req := httptest.NewRequest(http.MethodPost, "/api/admin/inventory/unverified/event-1/verify", nil)
+			req = mux.SetURLVars(req, map[string]string{"id": "event-1"})
+			w := httptest.NewRecorder()
+			handler.VerifyCapacity(w, req)
+
+			assert.Equal(t, tt.status, w.Code)
+			mockAdmin.AssertExpectations(t)
+		})
+	}
+}
+
+func TestBookingAuditLog_Success(t *testing.T) {
+	mockAdmin := new(MockAdminUsecases)
+	handler := NewAdminHandler(mockAdmin)
+
+	expected := []*domain.AuditEntry{
+		{Id: "audit-1", EntityType: domain.AuditEntityBooking, EntityId: "booking-1", Action: domain.AuditBookingCreated, ActorType: domain.ActorUser},
+		{Id: "audit-2", EntityType: domain.AuditEntityBooking, EntityId: "booking-1", Action: domain.AuditBookingCancelled, ActorType: domain.ActorConsumer, Reason: "payment timeout"},
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/admin/audit/bookings/booking-1?limit=10", nil)
+	req = mux.SetURLVars(req, map[string]string{"id": "booking-1"})
+	w := httptest.NewRecorder()
+
+	mockAdmin.On("AuditLog", mock.Anything, domain.AuditFilter{BookingId: "booking-1", Limit: 10}).Return(expected, nil)
+
+	handler.BookingAuditLog(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var entries []*domain.AuditEntry
+	json.Unmarshal(w.Body.Bytes(), &entries)
+	assert.Len(t, entries, 2)
+	assert.Equal(t, "payment timeout", entries[1].Reason)
+	mockAdmin.AssertExpectations(t)
+}
+
+func TestUserAuditLog_Empty(t *testing.T) {
+	mockAdmin := new(MockAdminUsecases)
+	handler := NewAdminHandler(mockAdmin)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/admin/audit/users/user-1", nil)
+	req = mux.SetURLVars(req, map[string]string{"id": "user-1"})
+	w := httptest.NewRecorder()
+
+	mockAdmin.On("AuditLog", mock.Anything, domain.AuditFilter{UserId: "user-1"}).Return(nil, nil)
+
+	handler.UserAuditLog(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `[]`, w.Body.String())
+	mockAdmin.AssertExpectations(t)
+}
+
+func TestEventAuditLog_InvalidLimit(t *testing.T) {
+	mockAdmin := new(MockAdminUsecases)
+	handler := NewAdminHandler(mockAdmin)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/admin/audit/events/event-1?limit=-1", nil)
+	req = mux.SetURLVars(req, map[string]string{"id": "event-1"})
+	w := httptest.NewRecorder()
+
+	handler.EventAuditLog(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	mockAdmin.AssertNotCalled(t, "AuditLog", mock.Anything, mock.Anything)
+}
@@ -0,0 +1,165 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/dontpanicw/EventBooker/internal/domain"
+	"github.com/dontpanicw/EventBooker/internal/port"
+	"github.com/gorilla/mux"
+)
+
+// BallotHandler - розыгрыш мест: заявки и их статус для пользователей, настройка,
+// проведение и результаты для администраторов
+type BallotHandler struct {
+	ballots port.BallotUsecases
+}
+
+func NewBallotHandler(ballots port.BallotUsecases) *BallotHandler {
+	return &BallotHandler{
+		ballots: ballots,
+	}
+}
+
+// EnterBallot подает заявку пользователя на розыгрыш мест мероприятия
+func (h *BallotHandler) EnterBallot(w http.ResponseWriter, r *http.Request) {
+	var req EnterBallotRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.UserId == "" {
+		http.Error(w, "user_id is required", http.StatusBadRequest)
+		return
+	}
+
+	entry, err := h.ballots.EnterBallot(r.Context(), mux.Vars(r)["id"], req.UserId)
+	if err != nil {
+		http.Error(w, err.Error(), ballotErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(NewBallotEntryResponse(entry))
+}
+
+// GetBallotEntry - статус заявки; после розыгрыша у выигравшего в ней бронь для оплаты
+func (h *BallotHandler) GetBallotEntry(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	entry, err := h.ballots.GetBallotEntry(r.Context(), vars["id"], vars["user_id"])
+	if err != nil {
+		http.Error(w, err.Error(), ballotErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(NewBallotEntryResponse(entry))
+}
+
+// ConfigureBallot задает окно приема заявок и лист ожидания
+func (h *BallotHandler) ConfigureBallot(w http.ResponseWriter, r *http.Request) {
+	var req BallotRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	ballot, err := h.ballots.ConfigureBallot(r.Context(), &domain.Ballot{
+		EventId:       mux.Vars(r)["id"],
+		EntryOpensAt:  req.EntryOpensAt.UTC(),
+		EntryClosesAt: req.EntryClosesAt.UTC(),
+		Waitlist:      req.Waitlist,
+	})
+	if err != nil {
+		http.Error(w, err.Error(), ballotErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(NewBallotResponse(ballot))
+}
+
+func (h *BallotHandler) GetBallot(w http.ResponseWriter, r *http.Request) {
+	ballot, err := h.ballots.GetBallot(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, err.Error(), ballotErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(NewBallotResponse(ballot))
+}
+
+// DrawBallot проводит розыгрыш сразу после закрытия приема заявок, не дожидаясь фоновой задачи
+func (h *BallotHandler) DrawBallot(w http.ResponseWriter, r *http.Request) {
+	ctx := port.WithActor(r.Context(), domain.Actor{Type: domain.ActorAdmin})
+	ballot, err := h.ballots.DrawBallot(ctx, mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, err.Error(), ballotErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(NewBallotResponse(ballot))
+}
+
+// CloseWaitlist закрывает лист ожидания до даты мероприятия и возвращает выбывших
+func (h *BallotHandler) CloseWaitlist(w http.ResponseWriter, r *http.Request) {
+	ctx := port.WithActor(r.Context(), domain.Actor{Type: domain.ActorAdmin})
+	lost, err := h.ballots.CloseWaitlist(ctx, mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, err.Error(), ballotErrorStatus(err))
+		return
+	}
+
+	resp := make([]BallotEntryResponse, 0, len(lost))
+	for _, entry := range lost {
+		resp = append(resp, NewBallotEntryResponse(entry))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(resp)
+}
+
+// ListBallotEntries - все заявки в порядке розыгрыша: вместе с seed их достаточно,
+// чтобы пересчитать результат
+func (h *BallotHandler) ListBallotEntries(w http.ResponseWriter, r *http.Request) {
+	entries, err := h.ballots.ListBallotEntries(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, err.Error(), ballotErrorStatus(err))
+		return
+	}
+
+	resp := make([]BallotEntryResponse, 0, len(entries))
+	for _, entry := range entries {
+		resp = append(resp, NewBallotEntryResponse(entry))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(resp)
+}
+
+func ballotErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, domain.ErrEventNotFound), errors.Is(err, domain.ErrBallotNotFound),
+		errors.Is(err, domain.ErrBallotEntryNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, domain.ErrInvalidBallot):
+		return http.StatusBadRequest
+	case errors.Is(err, domain.ErrNotBallotEvent), errors.Is(err, domain.ErrBallotEntryClosed),
+		errors.Is(err, domain.ErrAlreadyEntered), errors.Is(err, domain.ErrBallotAlreadyDrawn),
+		errors.Is(err, domain.ErrBallotEntryOpen), errors.Is(err, domain.ErrBallotNotDrawn):
+		return http.StatusConflict
+	case errors.Is(err, domain.ErrCurrencyMismatch):
+		return http.StatusUnprocessableEntity
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,266 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dontpanicw/EventBooker/internal/domain"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockBallotUsecases - мок usecases розыгрыша
+type MockBallotUsecases struct {
+	mock.Mock
+}
+
+func (m *MockBallotUsecases) ConfigureBallot(ctx context.Context, ballot *domain.Ballot) (*domain.Ballot, error) {
+	args := m.Called(ctx, ballot)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Ballot), args.Error(1)
+}
+
+func (m *MockBallotUsecases) GetBallot(ctx context.Context, eventID string) (*domain.Ballot, error) {
+	args := m.Called(ctx, eventID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Ballot), args.Error(1)
+}
+
+func (m *MockBallotUsecases) EnterBallot(ctx context.Context, eventID, userID string) (*domain.BallotEntry, error) {
+	args := m.Called(ctx, eventID, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.BallotEntry), args.Error(1)
+}
+
+func (m *MockBallotUsecases) GetBallotEntry(ctx context.Context, eventID, userID string) (*domain.BallotEntry, error) {
+	args := m.Called(ctx, eventID, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.BallotEntry), args.Error(1)
+}
+
+func (m *MockBallotUsecases) ListBallotEntries(ctx context.Context, eventID string) ([]*domain.BallotEntry, error) {
+	args := m.Called(ctx, eventID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.BallotEntry), args.Error(1)
+}
+
+func (m *MockBallotUsecases) DrawBallot(ctx context.Context, eventID string) (*domain.Ballot, error) {
+	args := m.Called(ctx, eventID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Ballot), args.Error(1)
+}
+
+func (m *MockBallotUsecases) CloseWaitlist(ctx context.Context, eventID string) ([]*domain.BallotEntry, error) {
+	args := m.Called(ctx, eventID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.BallotEntry), args.Error(1)
+}
+
+func (m *MockBallotUsecases) RunBallots(ctx context.Context) error {
+	args := m.Called(ctx)
+	return args.Error(0)
+}
+
+func TestEnterBallot_Success(t *testing.T) {
+	mockBallots := new(MockBallotUsecases)
+	handler := NewBallotHandler(mockBallots)
+
+	body, _ := json.Marshal(EnterBallotRequest{UserId: "user-123"})
+	req := httptest.NewRequest(http.MethodPost, "/api/events/event-123/ballot/entries", bytes.NewBuffer(body))
+	req = mux.SetURLVars(req, map[string]string{"id": "event-123"})
+	w := httptest.NewRecorder()
+
+	mockBallots.On("EnterBallot", mock.Anything, "event-123", "user-123").Return(&domain.BallotEntry{
+		EventId: "event-123", UserId: "user-123", Status: domain.BallotEntered, EnteredAt: time.Now(),
+	}, nil)
+
+	handler.EnterBallot(w, req)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	var response BallotEntryResponse
+	json.Unmarshal(w.Body.Bytes(), &response)
+	assert.Equal(t, domain.BallotEntered, response.Status)
+	assert.Equal(t, 0, response.Rank)
+	mockBallots.AssertExpectations(t)
+}
+
+func TestEnterBallot_Errors(t *testing.T) {
+	mockBallots := new(MockBallotUsecases)
+	handler := NewBallotHandler(mockBallots)
+
+	mockBallots.On("EnterBallot", mock.Anything, "event-123", "again").
+		Return(nil, fmt.Errorf("failed to enter ballot: %w", domain.ErrAlreadyEntered))
+	mockBallots.On("EnterBallot", mock.Anything, "event-123", "late").Return(nil, domain.ErrBallotEntryClosed)
+	mockBallots.On("EnterBallot", mock.Anything, "event-123", "lost").
+		Return(nil, fmt.Errorf("failed to enter ballot: %w", domain.ErrBallotNotFound))
+
+	tests := []struct {
+		name   string
+		body   string
+		status int
+	}{
+		{"missing user", `{}`, http.StatusBadRequest},
+		{"already entered", `{"user_id":"again"}`, http.StatusConflict},
+		{"entry closed", `{"user_id":"late"}`, http.StatusConflict},
+		{"no ballot", `{"user_id":"lost"}`, http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/events/event-123/ballot/entries", bytes.NewBufferString(tt.body))
+			req = mux.SetURLVars(req, map[string]string{"id": "event-123"})
+			w := httptest.NewRecorder()
+
+			handler.EnterBallot(w, req)
+
+			assert.Equal(t, tt.status, w.Code)
+		})
+	}
+}
+
+func TestConfigureBallot_Success(t *testing.T) {
+	mockBallots := new(MockBallotUsecases)
+	handler := NewBallotHandler(mockBallots)
+
+	opensAt := time.Date(2026, time.March, 1, 10, 0, 0, 0, time.UTC)
+	closesAt := opensAt.Add(48 * time.Hour)
+	body, _ := json.Marshal(BallotRequest{EntryOpensAt: opensAt, EntryClosesAt: closesAt, Waitlist: true})
+	req := httptest.NewRequest(http.MethodPut, "/api/admin/events/event-123/ballot", bytes.NewBuffer(body))
+	req = mux.SetURLVars(req, map[string]string{"id": "event-123"})
+	w := httptest.NewRecorder()
+
+	ballot := &domain.Ballot{EventId: "event-123", EntryOpensAt: opensAt, EntryClosesAt: closesAt, Waitlist: true}
+	mockBallots.On("ConfigureBallot", mock.Anything, ballot).Return(ballot, nil)
+
+	handler.ConfigureBallot(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response map[string]interface{}
+	json.Unmarshal(w.Body.Bytes(), &response)
+	assert.Equal(t, true, response["waitlist"])
+	assert.NotContains(t, response, "seed", "seed is published only after the draw")
+	mockBallots.AssertExpectations(t)
+}
+
+func TestConfigureBallot_NotBallotEvent(t *testing.T) {
+	mockBallots := new(MockBallotUsecases)
+	handler := NewBallotHandler(mockBallots)
+
+	req := httptest.NewRequest(http.MethodPut, "/api/admin/events/event-123/ballot", bytes.NewBufferString(`{}`))
+	req = mux.SetURLVars(req, map[string]string{"id": "event-123"})
+	w := httptest.NewRecorder()
+
+	mockBallots.On("ConfigureBallot", mock.Anything, mock.Anything).Return(nil, domain.ErrNotBallotEvent)
+
+	handler.ConfigureBallot(w, req)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+}
+
+func TestDrawBallot_Success(t *testing.T) {
+	mockBallots := new(MockBallotUsecases)
+	handler := NewBallotHandler(mockBallots)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/admin/events/event-123/ballot/draw", nil)
+	req = mux.SetURLVars(req, map[string]string{"id": "event-123"})
+	w := httptest.NewRecorder()
+
+	drawnAt := time.Now()
+	mockBallots.On("DrawBallot", mock.Anything, "event-123").Return(&domain.Ballot{
+		EventId: "event-123", Seed: 42, DrawnAt: &drawnAt, Entries: 3,
+	}, nil)
+
+	handler.DrawBallot(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response BallotResponse
+	json.Unmarshal(w.Body.Bytes(), &response)
+	if assert.NotNil(t, response.Seed) {
+		assert.Equal(t, int64(42), *response.Seed)
+	}
+	assert.Equal(t, 3, response.Entries)
+	mockBallots.AssertExpectations(t)
+}
+
+func TestDrawBallot_EntryOpen(t *testing.T) {
+	mockBallots := new(MockBallotUsecases)
+	handler := NewBallotHandler(mockBallots)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/admin/events/event-123/ballot/draw", nil)
+	req = mux.SetURLVars(req, map[string]string{"id": "event-123"})
+	w := httptest.NewRecorder()
+
+	mockBallots.On("DrawBallot", mock.Anything, "event-123").Return(nil, domain.ErrBallotEntryOpen)
+
+	handler.DrawBallot(w, req)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+}
+
+func TestCloseWaitlist(t *testing.T) {
+	mockBallots := new(MockBallotUsecases)
+	handler := NewBallotHandler(mockBallots)
+
+	mockBallots.On("CloseWaitlist", mock.Anything, "event-123").Return([]*domain.BallotEntry{
+		{EventId: "event-123", UserId: "user-1", Status: domain.BallotLost, Rank: 2},
+	}, nil)
+	mockBallots.On("CloseWaitlist", mock.Anything, "open").Return(nil, domain.ErrBallotNotDrawn)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/admin/events/event-123/ballot/close-waitlist", nil)
+	req = mux.SetURLVars(req, map[string]string{"id": "event-123"})
+	w := httptest.NewRecorder()
+	handler.CloseWaitlist(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response []BallotEntryResponse
+	json.Unmarshal(w.Body.Bytes(), &response)
+	if assert.Len(t, response, 1) {
+		assert.Equal(t, domain.BallotLost, response[0].Status)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/api/admin/events/open/ballot/close-waitlist", nil)
+	req = mux.SetURLVars(req, map[string]string{"id": "open"})
+	w = httptest.NewRecorder()
+	handler.CloseWaitlist(w, req)
+	assert.Equal(t, http.StatusConflict, w.Code)
+}
+
+func TestBookEvent_BallotEvent(t *testing.T) {
+	mockUsecases := new(MockUsecases)
+	handler := NewHandler(mockUsecases)
+
+	body, _ := json.Marshal(BookEventRequest{UserId: "user-123"})
+	req := httptest.NewRequest(http.MethodPost, "/api/events/event-123/book", bytes.NewBuffer(body))
+	req = mux.SetURLVars(req, map[string]string{"id": "event-123"})
+	w := httptest.NewRecorder()
+
+	mockUsecases.On("BookEvent", mock.Anything, mock.AnythingOfType("*domain.Booking")).
+		Return("", fmt.Errorf("failed to book event: %w", domain.ErrBallotEvent))
+
+	handler.BookEvent(w, req)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+}
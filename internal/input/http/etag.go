@@ -0,0 +1,82 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/dontpanicw/EventBooker/internal/domain"
+)
+
+var errInvalidIfMatch = errors.New("invalid If-Match: expected ETag from GET or *")
+
+// etag - сильный ETag по версии события или брони
+func etag(version int64) string {
+	return `"` + strconv.FormatInt(version, 10) + `"`
+}
+
+// eventETag - ETag мероприятия. Ступень цены может смениться по времени без записи
+// в хранилище, поэтому у мероприятия с расписанием цены к версии добавляется номер ступени.
+func eventETag(event *domain.Event) string {
+	if event.Pricing == nil {
+		return etag(event.Version)
+	}
+	return `"` + strconv.FormatInt(event.Version, 10) + "." + strconv.Itoa(event.Pricing.Phase) + `"`
+}
+
+// ifMatchVersion разбирает If-Match в версию для проверки в хранилище: "*" - любая версия (0).
+// If-Match сравнивается строго, поэтому слабые ETag (W/"...") не принимаются.
+// Номер ступени цены из ETag мероприятия не проверяется: правки защищает версия.
+func ifMatchVersion(header string) (int64, error) {
+	header = strings.TrimSpace(header)
+	if header == "*" {
+		return 0, nil
+	}
+	unquoted, ok := strings.CutPrefix(header, `"`)
+	if !ok {
+		return 0, errInvalidIfMatch
+	}
+	unquoted, ok = strings.CutSuffix(unquoted, `"`)
+	if !ok {
+		return 0, errInvalidIfMatch
+	}
+	unquoted, _, _ = strings.Cut(unquoted, ".")
+	version, err := strconv.ParseInt(unquoted, 10, 64)
+	if err != nil || version <= 0 {
+		return 0, errInvalidIfMatch
+	}
+	return version, nil
+}
+
+// notModified сообщает, есть ли tag среди ETag из If-None-Match; сравнение слабое
+func notModified(r *http.Request, tag string) bool {
+	header := r.Header.Get("If-None-Match")
+	if header == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// writeETag отдает ETag и просит клиента перепроверять кэш при каждом запросе.
+// Возвращает true, если ответ уже отправлен как 304 Not Modified.
+func writeETag(w http.ResponseWriter, r *http.Request, version int64) bool {
+	return writeTag(w, r, etag(version))
+}
+
+// writeTag - writeETag с готовым ETag
+func writeTag(w http.ResponseWriter, r *http.Request, tag string) bool {
+	w.Header().Set("ETag", tag)
+	w.Header().Set("Cache-Control", "no-cache")
+	if notModified(r, tag) {
+		w.WriteHeader(http.StatusNotModified)
+		return true
+	}
+	return false
+}
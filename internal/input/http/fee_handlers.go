@@ -0,0 +1,96 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/dontpanicw/EventBooker/internal/domain"
+	"github.com/dontpanicw/EventBooker/internal/port"
+	"github.com/gorilla/mux"
+)
+
+// FeeHandler - правила сервисного сбора и НДС для администраторов
+type FeeHandler struct {
+	fees port.FeeUsecases
+}
+
+func NewFeeHandler(fees port.FeeUsecases) *FeeHandler {
+	return &FeeHandler{
+		fees: fees,
+	}
+}
+
+// feeRuleScopes - область правила по сегменту пути /api/admin/fee-rules/{scope}
+var feeRuleScopes = map[string]string{
+	"default":    domain.FeeScopeDefault,
+	"organizers": domain.FeeScopeOrganizer,
+	"events":     domain.FeeScopeEvent,
+}
+
+// SetFeeRule создает или заменяет правило; действует для броней, созданных после него
+func (h *FeeHandler) SetFeeRule(w http.ResponseWriter, r *http.Request) {
+	var req FeeRuleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	vars := mux.Vars(r)
+	rule := &domain.FeeRule{
+		Scope:      feeRuleScopes[vars["scope"]],
+		ScopeId:    vars["id"],
+		FeePercent: req.FeePercent,
+		VatPercent: req.VatPercent,
+	}
+	if req.FixedFee != nil {
+		rule.FixedFee = *req.FixedFee
+	}
+
+	rule, err := h.fees.SetFeeRule(r.Context(), rule)
+	if err != nil {
+		http.Error(w, err.Error(), feeErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(NewFeeRuleResponse(rule))
+}
+
+func (h *FeeHandler) DeleteFeeRule(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	if err := h.fees.DeleteFeeRule(r.Context(), feeRuleScopes[vars["scope"]], vars["id"]); err != nil {
+		http.Error(w, err.Error(), feeErrorStatus(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *FeeHandler) ListFeeRules(w http.ResponseWriter, r *http.Request) {
+	rules, err := h.fees.ListFeeRules(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), feeErrorStatus(err))
+		return
+	}
+
+	resp := make([]FeeRuleResponse, 0, len(rules))
+	for _, rule := range rules {
+		resp = append(resp, NewFeeRuleResponse(rule))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(resp)
+}
+
+func feeErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, domain.ErrFeeRuleNotFound), errors.Is(err, domain.ErrEventNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, domain.ErrInvalidFeeRule):
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,183 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dontpanicw/EventBooker/internal/domain"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockFeeUsecases - мок usecases правил сбора
+type MockFeeUsecases struct {
+	mock.Mock
+}
+
+func (m *MockFeeUsecases) SetFeeRule(ctx context.Context, rule *domain.FeeRule) (*domain.FeeRule, error) {
+	args := m.Called(ctx, rule)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.FeeRule), args.Error(1)
+}
+
+func (m *MockFeeUsecases) DeleteFeeRule(ctx context.Context, scope, scopeID string) error {
+	args := m.Called(ctx, scope, scopeID)
+	return args.Error(0)
+}
+
+func (m *MockFeeUsecases) ListFeeRules(ctx context.Context) ([]*domain.FeeRule, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.FeeRule), args.Error(1)
+}
+
+func TestSetFeeRule_Success(t *testing.T) {
+	mockFees := new(MockFeeUsecases)
+	handler := NewFeeHandler(mockFees)
+
+	updatedAt := time.Date(2026, time.March, 1, 12, 0, 0, 0, time.UTC)
+	rule := &domain.FeeRule{Scope: domain.FeeScopeOrganizer, ScopeId: "org-1", FixedFee: domain.Money{Amount: 3000, Currency: "RUB"}, FeePercent: 5, VatPercent: percent(20)}
+	mockFees.On("SetFeeRule", mock.Anything, rule).
+		Return(&domain.FeeRule{Scope: rule.Scope, ScopeId: rule.ScopeId, FixedFee: rule.FixedFee, FeePercent: 5, VatPercent: percent(20), UpdatedAt: updatedAt}, nil)
+
+	body := `{"fixed_fee":{"amount":3000,"currency":"RUB"},"fee_percent":5,"vat_percent":20}`
+	req := httptest.NewRequest(http.MethodPut, "/api/admin/fee-rules/organizers/org-1", bytes.NewBufferString(body))
+	req = mux.SetURLVars(req, map[string]string{"scope": "organizers", "id": "org-1"})
+	w := httptest.NewRecorder()
+	handler.SetFeeRule(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"scope":"organizer","scope_id":"org-1","fixed_fee":{"amount":3000,"currency":"RUB"},"fee_percent":5,"vat_percent":20,"updated_at":"2026-03-01T12:00:00Z"}`, w.Body.String())
+	mockFees.AssertExpectations(t)
+}
+
+func TestSetFeeRule_InheritVat(t *testing.T) {
+	mockFees := new(MockFeeUsecases)
+	handler := NewFeeHandler(mockFees)
+
+	updatedAt := time.Date(2026, time.March, 1, 12, 0, 0, 0, time.UTC)
+	rule := &domain.FeeRule{Scope: domain.FeeScopeEvent, ScopeId: "event-1", FeePercent: 5}
+	mockFees.On("SetFeeRule", mock.Anything, rule).
+		Return(&domain.FeeRule{Scope: rule.Scope, ScopeId: rule.ScopeId, FeePercent: 5, UpdatedAt: updatedAt}, nil)
+
+	req := httptest.NewRequest(http.MethodPut, "/api/admin/fee-rules/events/event-1", bytes.NewBufferString(`{"fee_percent":5}`))
+	req = mux.SetURLVars(req, map[string]string{"scope": "events", "id": "event-1"})
+	w := httptest.NewRecorder()
+	handler.SetFeeRule(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"scope":"event","scope_id":"event-1","fee_percent":5,"updated_at":"2026-03-01T12:00:00Z"}`, w.Body.String())
+	mockFees.AssertExpectations(t)
+}
+
+func TestSetFeeRule_Errors(t *testing.T) {
+	mockFees := new(MockFeeUsecases)
+	handler := NewFeeHandler(mockFees)
+
+	mockFees.On("SetFeeRule", mock.Anything, mock.MatchedBy(func(rule *domain.FeeRule) bool { return rule.ScopeId == "missing" })).
+		Return(nil, fmt.Errorf("failed to set fee rule: %w", domain.ErrEventNotFound))
+	mockFees.On("SetFeeRule", mock.Anything, mock.Anything).Return(nil, domain.ErrInvalidFeeRule)
+
+	tests := []struct {
+		vars   map[string]string
+		body   string
+		status int
+	}{
+		{map[string]string{"scope": "default"}, `{"fee_percent":`, http.StatusBadRequest},
+		{map[string]string{"scope": "default"}, `{"fee_percent":150}`, http.StatusBadRequest},
+		{map[string]string{"scope": "events", "id": "missing"}, `{"vat_percent":20}`, http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodPut, "/api/admin/fee-rules", bytes.NewBufferString(tt.body))
+		req = mux.SetURLVars(req, tt.vars)
+		w := httptest.NewRecorder()
+		handler.SetFeeRule(w, req)
+
+		assert.Equal(t, tt.status, w.Code, tt.body)
+	}
+}
+
+func TestDeleteFeeRule(t *testing.T) {
+	mockFees := new(MockFeeUsecases)
+	handler := NewFeeHandler(mockFees)
+
+	mockFees.On("DeleteFeeRule", mock.Anything, domain.FeeScopeDefault, "").Return(nil)
+	mockFees.On("DeleteFeeRule", mock.Anything, domain.FeeScopeEvent, "event-1").
+		Return(fmt.Errorf("failed to delete fee rule: %w", domain.ErrFeeRuleNotFound))
+
+	req := httptest.NewRequest(http.MethodDelete, "/api/admin/fee-rules/default", nil)
+	req = mux.SetURLVars(req, map[string]string{"scope": "default"})
+	w := httptest.NewRecorder()
+	handler.DeleteFeeRule(w, req)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	req = httptest.NewRequest(http.MethodDelete, "/api/admin/fee-rules/events/event-1", nil)
+	req = mux.SetURLVars(req, map[string]string{"scope": "events", "id": "event-1"})
+	w = httptest.NewRecorder()
+	handler.DeleteFeeRule(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestListFeeRules_Empty(t *testing.T) {
+	mockFees := new(MockFeeUsecases)
+	handler := NewFeeHandler(mockFees)
+
+	mockFees.On("ListFeeRules", mock.Anything).Return(nil, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/admin/fee-rules", nil)
+	w := httptest.NewRecorder()
+	handler.ListFeeRules(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `[]`, w.Body.String())
+}
+
+func percent(value float64) *float64 {
+	return &value
+}
+
+func TestGetBooking_LineItems(t *testing.T) {
+	mockUsecases := new(MockUsecases)
+	handler := NewHandler(mockUsecases)
+
+	booking := &domain.Booking{Id: "booking-1", Status: domain.PendingStatus}
+	assert.NoError(t, booking.Charge(domain.Money{Amount: 150000, Currency: "RUB"}, domain.Money{Currency: "RUB"}, &domain.FeeRule{FeePercent: 10, VatPercent: percent(20)}))
+	mockUsecases.On("GetBooking", mock.Anything, "booking-1").Return(booking, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/bookings/booking-1", nil)
+	req = mux.SetURLVars(req, map[string]string{"id": "booking-1"})
+	w := httptest.NewRecorder()
+	handler.GetBooking(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"Price":{"amount":198000,"currency":"RUB"}`)
+	assert.Contains(t, w.Body.String(), `"LineItems":[{"Kind":"face_value","Amount":{"amount":150000,"currency":"RUB"}},`+
+		`{"Kind":"service_fee","Amount":{"amount":15000,"currency":"RUB"}},{"Kind":"vat","Amount":{"amount":33000,"currency":"RUB"}}]`)
+}
+
+func TestBookEvent_CurrencyMismatch(t *testing.T) {
+	mockUsecases := new(MockUsecases)
+	handler := NewHandler(mockUsecases)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/events/event-123/book", bytes.NewBufferString(`{"user_id":"user-123"}`))
+	req = mux.SetURLVars(req, map[string]string{"id": "event-123"})
+	w := httptest.NewRecorder()
+
+	mockUsecases.On("BookEvent", mock.Anything, mock.Anything).
+		Return("", fmt.Errorf("failed to book event: %w", domain.ErrCurrencyMismatch))
+
+	handler.BookEvent(w, req)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	mockUsecases.AssertExpectations(t)
+}
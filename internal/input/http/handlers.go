@@ -2,10 +2,15 @@ package http
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"github.com/dontpanicw/EventBooker/internal/domain"
 	"github.com/dontpanicw/EventBooker/internal/port"
 	"github.com/gorilla/mux"
+	"log"
 	"net/http"
+	"net/url"
+	"strconv"
 	"time"
 )
 
@@ -30,14 +35,24 @@ func (h *Handler) CreateEvent(w http.ResponseWriter, r *http.Request) {
 		Name:             req.Name,
 		Description:      req.Description,
 		IsFree:           req.IsFree,
-		Price:            req.Price,
+		Price:            domain.Money{Amount: req.Price, Currency: req.Currency},
 		AvailableTickets: req.AvailableTickets,
+		Capacity:         req.Capacity,
 		Date:             req.Date,
+		SaleMode:         req.SaleMode,
+		OrganizerId:      req.OrganizerId,
 	}
 
-	eventID, err := h.usecases.CreateEvent(r.Context(), event)
+	// События создаются из панели администратора
+	ctx := port.WithActor(r.Context(), domain.Actor{Type: domain.ActorAdmin})
+	eventID, err := h.usecases.CreateEvent(ctx, event)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		status := http.StatusInternalServerError
+		if errors.Is(err, domain.ErrInvalidSaleMode) || errors.Is(err, domain.ErrInvalidCurrency) ||
+			errors.Is(err, domain.ErrInvalidEventDate) {
+			status = http.StatusBadRequest
+		}
+		http.Error(w, err.Error(), status)
 		return
 	}
 
@@ -57,15 +72,18 @@ func (h *Handler) BookEvent(w http.ResponseWriter, r *http.Request) {
 	}
 
 	booking := &domain.Booking{
-		UserId:  req.UserId,
-		EventId: eventID,
-		Status:  domain.PendingStatus,
-		Date:    time.Now(),
+		UserId:    req.UserId,
+		EventId:   eventID,
+		Status:    domain.PendingStatus,
+		Date:      time.Now(),
+		PromoCode: req.PromoCode,
 	}
 
-	bookingID, err := h.usecases.BookEvent(r.Context(), booking)
+	ctx := port.WithActor(r.Context(), domain.Actor{Type: domain.ActorUser, Id: req.UserId})
+	ctx = port.WithAdmissionToken(ctx, r.Header.Get(AdmissionTokenHeader))
+	bookingID, err := h.usecases.BookEvent(ctx, booking)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		http.Error(w, err.Error(), bookingErrorStatus(err))
 		return
 	}
 
@@ -74,12 +92,26 @@ func (h *Handler) BookEvent(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(map[string]string{"booking_id": bookingID, "event_id": eventID})
 }
 
+// ConfirmBooking - оплата брони. If-Match с ETag брони обязателен, как и при правке
+// события: оплата брони, изменившейся после чтения, отклоняется с 412.
 func (h *Handler) ConfirmBooking(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	bookingID := vars["id"]
 
-	if err := h.usecases.ConfirmBooking(r.Context(), bookingID); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	header := r.Header.Get("If-Match")
+	if header == "" {
+		http.Error(w, "If-Match header is required", http.StatusPreconditionRequired)
+		return
+	}
+	version, err := ifMatchVersion(header)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ctx := port.WithActor(r.Context(), domain.Actor{Type: domain.ActorUser})
+	if err := h.usecases.ConfirmBooking(ctx, bookingID, version); err != nil {
+		http.Error(w, err.Error(), bookingErrorStatus(err))
 		return
 	}
 
@@ -97,22 +129,199 @@ func (h *Handler) GetEvent(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusNotFound)
 		return
 	}
+	if writeTag(w, r, eventETag(event)) {
+		return
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(event)
 }
 
-func (h *Handler) GetAllEvents(w http.ResponseWriter, r *http.Request) {
-	events, err := h.usecases.GetAllEvents(r.Context())
+// UpdateEvent - правка события администратором. If-Match с ETag из GET обязателен:
+// правка поверх чужой отклоняется с 412, и клиент должен перечитать событие.
+func (h *Handler) UpdateEvent(w http.ResponseWriter, r *http.Request) {
+	header := r.Header.Get("If-Match")
+	if header == "" {
+		http.Error(w, "If-Match header is required", http.StatusPreconditionRequired)
+		return
+	}
+	version, err := ifMatchVersion(header)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var req UpdateEventRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	update := domain.EventUpdate{
+		Name:        req.Name,
+		Description: req.Description,
+		IsFree:      req.IsFree,
+		Price:       req.Price,
+	}
+
+	ctx := port.WithActor(r.Context(), domain.Actor{Type: domain.ActorAdmin})
+	event, err := h.usecases.UpdateEvent(ctx, mux.Vars(r)["id"], version, update)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, domain.ErrInvalidEventUpdate):
+			status = http.StatusBadRequest
+		case errors.Is(err, domain.ErrEventNotFound):
+			status = http.StatusNotFound
+		case errors.Is(err, domain.ErrVersionMismatch):
+			status = http.StatusPreconditionFailed
+		}
+		http.Error(w, err.Error(), status)
 		return
 	}
 
+	w.Header().Set("ETag", etag(event.Version))
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(events)
+	json.NewEncoder(w).Encode(event)
+}
+
+func (h *Handler) ListEvents(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseEventFilter(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	page, err := h.usecases.ListEvents(r.Context(), filter)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, domain.ErrInvalidEventFilter) || errors.Is(err, domain.ErrInvalidCursor) {
+			status = http.StatusBadRequest
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(EventsPageResponse{Events: page.Events, NextCursor: page.NextCursor})
+}
+
+// SearchEvents - полнотекстовый поиск: ?q= - запрос, ?limit= - число результатов
+func (h *Handler) SearchEvents(w http.ResponseWriter, r *http.Request) {
+	limit := 0
+	if v := r.URL.Query().Get("limit"); v != "" {
+		var err error
+		if limit, err = strconv.Atoi(v); err != nil || limit < 0 {
+			http.Error(w, "invalid limit", http.StatusBadRequest)
+			return
+		}
+	}
+
+	results, err := h.usecases.SearchEvents(r.Context(), r.URL.Query().Get("q"), limit)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, domain.ErrInvalidSearchQuery) {
+			status = http.StatusBadRequest
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	response := EventSearchResponse{Results: make([]EventSearchResult, 0, len(results))}
+	for _, result := range results {
+		response.Results = append(response.Results, EventSearchResult{
+			Event:                result.Event,
+			Rank:                 result.Rank,
+			NameHighlight:        result.NameHighlight,
+			DescriptionHighlight: result.DescriptionHighlight,
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+// parseEventFilter читает параметры списка событий:
+// cursor, limit, sort, period, date_from, date_to (RFC 3339), is_free, currency,
+// min_price, max_price (в минимальных единицах валюты), available
+func parseEventFilter(query url.Values) (domain.EventFilter, error) {
+	filter := domain.EventFilter{
+		Sort:     query.Get("sort"),
+		Period:   query.Get("period"),
+		Currency: query.Get("currency"),
+	}
+
+	if v := query.Get("cursor"); v != "" {
+		cursor, err := domain.DecodeEventCursor(v)
+		if err != nil {
+			return filter, err
+		}
+		filter.After = cursor
+	}
+	if v := query.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 0 {
+			return filter, errors.New("invalid limit")
+		}
+		filter.Limit = limit
+	}
+	var err error
+	if filter.DateFrom, err = parseTimeParam(query, "date_from"); err != nil {
+		return filter, err
+	}
+	if filter.DateTo, err = parseTimeParam(query, "date_to"); err != nil {
+		return filter, err
+	}
+	if v := query.Get("is_free"); v != "" {
+		isFree, err := strconv.ParseBool(v)
+		if err != nil {
+			return filter, errors.New("invalid is_free")
+		}
+		filter.IsFree = &isFree
+	}
+	if filter.MinPrice, err = parsePriceParam(query, "min_price"); err != nil {
+		return filter, err
+	}
+	if filter.MaxPrice, err = parsePriceParam(query, "max_price"); err != nil {
+		return filter, err
+	}
+	if v := query.Get("available"); v != "" {
+		available, err := strconv.ParseBool(v)
+		if err != nil {
+			return filter, errors.New("invalid available")
+		}
+		filter.OnlyAvailable = available
+	}
+	return filter, nil
+}
+
+// parseTimeParam читает необязательную дату RFC 3339
+func parseTimeParam(query url.Values, name string) (time.Time, error) {
+	v := query.Get(name)
+	if v == "" {
+		return time.Time{}, nil
+	}
+	date, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid %s", name)
+	}
+	return date, nil
+}
+
+// parsePriceParam читает необязательную неотрицательную цену в минимальных единицах валюты
+func parsePriceParam(query url.Values, name string) (*int64, error) {
+	v := query.Get(name)
+	if v == "" {
+		return nil, nil
+	}
+	price, err := strconv.ParseInt(v, 10, 64)
+	if err != nil || price < 0 {
+		return nil, fmt.Errorf("invalid %s", name)
+	}
+	return &price, nil
 }
 
 
@@ -125,8 +334,217 @@ func (h *Handler) GetBooking(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusNotFound)
 		return
 	}
+	if writeETag(w, r, booking.Version) {
+		return
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(booking)
 }
+
+// ListUserBookings - брони пользователя от новых к старым: ?status=, ?limit=, ?cursor=
+func (h *Handler) ListUserBookings(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseBookingFilter(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	filter.UserId = mux.Vars(r)["id"]
+
+	page, err := h.usecases.ListUserBookings(r.Context(), filter)
+	if err != nil {
+		http.Error(w, err.Error(), bookingsListErrorStatus(err))
+		return
+	}
+	writeBookingsPage(w, page)
+}
+
+// ListEventBookings - брони мероприятия для организатора, параметры те же, что у броней пользователя
+func (h *Handler) ListEventBookings(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseBookingFilter(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	filter.EventId = mux.Vars(r)["id"]
+
+	page, err := h.usecases.ListEventBookings(r.Context(), filter)
+	if err != nil {
+		http.Error(w, err.Error(), bookingsListErrorStatus(err))
+		return
+	}
+	writeBookingsPage(w, page)
+}
+
+// exportColumns - заголовок выгрузки списка броней. Суммы - в минимальных единицах валюты
+// currency; price - итог, остальные - его состав, скидка отрицательная.
+var exportColumns = []string{"booking_id", "user_id", "status", "booked_at", "expires_at",
+	"price", "currency", "face_value", "discount", "service_fee", "vat"}
+
+// exportLineItems - виды строк состава суммы в порядке колонок exportColumns
+var exportLineItems = []string{domain.LineItemFaceValue, domain.LineItemDiscount, domain.LineItemServiceFee, domain.LineItemVat}
+
+// ExportEventBookings выгружает всех участников мероприятия: ?format=csv (по умолчанию) или xlsx,
+// ?status= ограничивает статус брони. Строки пишутся в ответ по мере чтения из хранилища.
+func (h *Handler) ExportEventBookings(w http.ResponseWriter, r *http.Request) {
+	eventID := mux.Vars(r)["id"]
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = exportFormatCSV
+	}
+	contentType := "text/csv; charset=utf-8"
+	switch format {
+	case exportFormatCSV:
+	case exportFormatXLSX:
+		contentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	default:
+		http.Error(w, "invalid format", http.StatusBadRequest)
+		return
+	}
+
+	// Заголовки ответа отправляются с первой строкой: до нее об ошибке еще можно сообщить статусом
+	var table tableWriter
+	started := false
+	start := func() error {
+		started = true
+		w.Header().Set("Content-Type", contentType)
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="event-%s-bookings.%s"`, eventID, format))
+		w.WriteHeader(http.StatusOK)
+		if format == exportFormatXLSX {
+			x, err := newXLSXTableWriter(w)
+			if err != nil {
+				return err
+			}
+			table = x
+		} else {
+			table = newCSVTableWriter(w)
+		}
+		return table.WriteRow(exportColumns)
+	}
+
+	filter := domain.BookingFilter{EventId: eventID, Status: r.URL.Query().Get("status")}
+	err := h.usecases.ExportEventBookings(r.Context(), filter, func(booking *domain.Booking) error {
+		if !started {
+			if err := start(); err != nil {
+				return err
+			}
+		}
+		return table.WriteRow(exportRow(booking))
+	})
+	if err == nil && !started {
+		err = start()
+	}
+	if err != nil {
+		if !started {
+			http.Error(w, err.Error(), bookingsListErrorStatus(err))
+			return
+		}
+		// Файл уже частично отправлен, статус не изменить: клиент получит оборванный архив или CSV
+		log.Printf("failed to export bookings of event %s: %v", eventID, err)
+		return
+	}
+	if err := table.Close(); err != nil {
+		log.Printf("failed to export bookings of event %s: %v", eventID, err)
+	}
+}
+
+// exportRow - строка выгрузки в порядке exportColumns; даты в RFC 3339, UTC
+func exportRow(booking *domain.Booking) []string {
+	expiresAt := ""
+	if t := booking.ExpiresAt(); !t.IsZero() {
+		expiresAt = t.UTC().Format(time.RFC3339)
+	}
+	row := []string{booking.Id, booking.UserId, booking.Status, booking.Date.UTC().Format(time.RFC3339), expiresAt,
+		strconv.FormatInt(booking.Price.Amount, 10), booking.Price.Currency}
+	for _, kind := range exportLineItems {
+		amount := ""
+		for _, item := range booking.LineItems {
+			if item.Kind == kind {
+				amount = strconv.FormatInt(item.Amount.Amount, 10)
+			}
+		}
+		row = append(row, amount)
+	}
+	return row
+}
+
+// parseBookingFilter читает общие параметры списков броней: status, limit, cursor
+func parseBookingFilter(query url.Values) (domain.BookingFilter, error) {
+	filter := domain.BookingFilter{Status: query.Get("status")}
+	if v := query.Get("cursor"); v != "" {
+		cursor, err := domain.DecodeBookingCursor(v)
+		if err != nil {
+			return filter, err
+		}
+		filter.After = cursor
+	}
+	if v := query.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 0 {
+			return filter, errors.New("invalid limit")
+		}
+		filter.Limit = limit
+	}
+	return filter, nil
+}
+
+// bookingsListErrorStatus выбирает HTTP-статус для ошибок списков и выгрузки броней
+func bookingsListErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, domain.ErrInvalidBookingFilter):
+		return http.StatusBadRequest
+	case errors.Is(err, domain.ErrEventNotFound):
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
+
+func writeBookingsPage(w http.ResponseWriter, page *domain.BookingPage) {
+	now := time.Now()
+	response := BookingsPageResponse{
+		Bookings:   make([]BookingResponse, 0, len(page.Bookings)),
+		NextCursor: page.NextCursor,
+	}
+	for _, b := range page.Bookings {
+		response.Bookings = append(response.Bookings, newBookingResponse(b, now))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+// newBookingResponse добавляет к pending-брони срок оплаты и оставшиеся секунды
+func newBookingResponse(b *domain.BookingDetails, now time.Time) BookingResponse {
+	response := BookingResponse{Booking: b.Booking, Event: b.Event}
+	if expiresAt := b.Booking.ExpiresAt(); !expiresAt.IsZero() {
+		response.ExpiresAt = &expiresAt
+		response.RemainingSeconds = max(0, int(expiresAt.Sub(now).Seconds()))
+	}
+	return response
+}
+
+// bookingErrorStatus выбирает HTTP-статус для ошибок бронирования и оплаты
+func bookingErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, domain.ErrEventNotFound), errors.Is(err, domain.ErrBookingNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, domain.ErrNoTicketsAvailable), errors.Is(err, domain.ErrBookingNotPending),
+		errors.Is(err, domain.ErrBallotEvent):
+		return http.StatusConflict
+	case errors.Is(err, domain.ErrVersionMismatch):
+		return http.StatusPreconditionFailed
+	case errors.Is(err, domain.ErrAdmissionRequired), errors.Is(err, domain.ErrInvalidAdmissionToken),
+		errors.Is(err, domain.ErrAdmissionTokenUsed):
+		return http.StatusForbidden
+	// Промокод проверяется при бронировании: неизвестный код - ошибка в запросе, а не отсутствующий ресурс
+	case errors.Is(err, domain.ErrPromoCodeNotFound), errors.Is(err, domain.ErrPromoCodeNotApplicable),
+		errors.Is(err, domain.ErrPromoCodeExhausted), errors.Is(err, domain.ErrPromoCodeUsed):
+		return http.StatusUnprocessableEntity
+	// Скидка или сбор в валюте, отличной от валюты мероприятия: бронь в ней не посчитать
+	case errors.Is(err, domain.ErrCurrencyMismatch):
+		return http.StatusUnprocessableEntity
+	}
+	return http.StatusInternalServerError
+}
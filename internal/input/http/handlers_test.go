@@ -1,10 +1,14 @@
 package http
 
 import (
+	"archive/zip"
 	"bytes"
 	"context"
 	"encoding/json"
+	"encoding/xml"
 	"errors"
+	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -31,11 +35,19 @@ func (m *MockUsecases) BookEvent(ctx context.Context, booking *domain.Booking) (
 	return args.String(0), args.Error(1)
 }
 
-func (m *MockUsecases) ConfirmBooking(ctx context.Context, bookingID string) error {
-	args := m.Called(ctx, bookingID)
+func (m *MockUsecases) ConfirmBooking(ctx context.Context, bookingID string, version int64) error {
+	args := m.Called(ctx, bookingID, version)
 	return args.Error(0)
 }
 
+func (m *MockUsecases) UpdateEvent(ctx context.Context, eventID string, version int64, update domain.EventUpdate) (*domain.Event, error) {
+	args := m.Called(ctx, eventID, version, update)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Event), args.Error(1)
+}
+
 func (m *MockUsecases) GetEvent(ctx context.Context, eventID string) (*domain.Event, error) {
 	args := m.Called(ctx, eventID)
 	if args.Get(0) == nil {
@@ -44,12 +56,42 @@ func (m *MockUsecases) GetEvent(ctx context.Context, eventID string) (*domain.Ev
 	return args.Get(0).(*domain.Event), args.Error(1)
 }
 
-func (m *MockUsecases) GetAllEvents(ctx context.Context) ([]*domain.Event, error) {
-	args := m.Called(ctx)
+func (m *MockUsecases) SearchEvents(ctx context.Context, query string, limit int) ([]*domain.EventSearchResult, error) {
+	args := m.Called(ctx, query, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.EventSearchResult), args.Error(1)
+}
+
+func (m *MockUsecases) ListUserBookings(ctx context.Context, filter domain.BookingFilter) (*domain.BookingPage, error) {
+	args := m.Called(ctx, filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.BookingPage), args.Error(1)
+}
+
+func (m *MockUsecases) ListEventBookings(ctx context.Context, filter domain.BookingFilter) (*domain.BookingPage, error) {
+	args := m.Called(ctx, filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.BookingPage), args.Error(1)
+}
+
+// ExportEventBookings передает в fn брони, заданные через Run, или возвращает ошибку из Return
+func (m *MockUsecases) ExportEventBookings(ctx context.Context, filter domain.BookingFilter, fn func(*domain.Booking) error) error {
+	args := m.Called(ctx, filter, fn)
+	return args.Error(0)
+}
+
+func (m *MockUsecases) ListEvents(ctx context.Context, filter domain.EventFilter) (*domain.EventPage, error) {
+	args := m.Called(ctx, filter)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
-	return args.Get(0).([]*domain.Event), args.Error(1)
+	return args.Get(0).(*domain.EventPage), args.Error(1)
 }
 
 func (m *MockUsecases) GetBooking(ctx context.Context, bookingID string) (*domain.Booking, error) {
@@ -68,7 +110,8 @@ func TestCreateEvent_Success(t *testing.T) {
 		Name:             "Test Event",
 		Description:      "Test Description",
 		IsFree:           false,
-		Price:            100.0,
+		Price:            10000,
+		Currency:         "EUR",
 		AvailableTickets: 50,
 		Date:             time.Now(),
 	}
@@ -78,7 +121,9 @@ func TestCreateEvent_Success(t *testing.T) {
 	req.Header.Set("Content-Type", "application/json")
 	w := httptest.NewRecorder()
 
-	mockUsecases.On("CreateEvent", mock.Anything, mock.AnythingOfType("*domain.Event")).Return("event-123", nil)
+	mockUsecases.On("CreateEvent", mock.Anything, mock.MatchedBy(func(event *domain.Event) bool {
+		return event.Price == domain.Money{Amount: 10000, Currency: "EUR"}
+	})).Return("event-123", nil)
 
 	handler.CreateEvent(w, req)
 
@@ -90,6 +135,36 @@ func TestCreateEvent_Success(t *testing.T) {
 	mockUsecases.AssertExpectations(t)
 }
 
+func TestCreateEvent_InvalidCurrency(t *testing.T) {
+	mockUsecases := new(MockUsecases)
+	handler := NewHandler(mockUsecases)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/events", bytes.NewBufferString(`{"name":"Test Event","price":10000,"currency":"XXX"}`))
+	w := httptest.NewRecorder()
+
+	mockUsecases.On("CreateEvent", mock.Anything, mock.AnythingOfType("*domain.Event")).Return("", domain.ErrInvalidCurrency)
+
+	handler.CreateEvent(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestCreateEvent_MissingDate(t *testing.T) {
+	mockUsecases := new(MockUsecases)
+	handler := NewHandler(mockUsecases)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/events", bytes.NewBufferString(`{"name":"Test Event","price":10000}`))
+	w := httptest.NewRecorder()
+
+	mockUsecases.On("CreateEvent", mock.Anything, mock.MatchedBy(func(event *domain.Event) bool {
+		return event.Date.IsZero()
+	})).Return("", domain.ErrInvalidEventDate)
+
+	handler.CreateEvent(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
 func TestCreateEvent_InvalidJSON(t *testing.T) {
 	mockUsecases := new(MockUsecases)
 	handler := NewHandler(mockUsecases)
@@ -173,9 +248,10 @@ func TestConfirmBooking_Success(t *testing.T) {
 
 	req := httptest.NewRequest(http.MethodPost, "/api/bookings/booking-123/confirm", nil)
 	req = mux.SetURLVars(req, map[string]string{"id": "booking-123"})
+	req.Header.Set("If-Match", "*")
 	w := httptest.NewRecorder()
 
-	mockUsecases.On("ConfirmBooking", mock.Anything, "booking-123").Return(nil)
+	mockUsecases.On("ConfirmBooking", mock.Anything, "booking-123", int64(0)).Return(nil)
 
 	handler.ConfirmBooking(w, req)
 
@@ -193,9 +269,10 @@ func TestConfirmBooking_Error(t *testing.T) {
 
 	req := httptest.NewRequest(http.MethodPost, "/api/bookings/booking-123/confirm", nil)
 	req = mux.SetURLVars(req, map[string]string{"id": "booking-123"})
+	req.Header.Set("If-Match", "*")
 	w := httptest.NewRecorder()
 
-	mockUsecases.On("ConfirmBooking", mock.Anything, "booking-123").Return(errors.New("booking not found"))
+	mockUsecases.On("ConfirmBooking", mock.Anything, "booking-123", int64(0)).Return(errors.New("booking not found"))
 
 	handler.ConfirmBooking(w, req)
 
@@ -203,6 +280,70 @@ func TestConfirmBooking_Error(t *testing.T) {
 	mockUsecases.AssertExpectations(t)
 }
 
+func TestConfirmBooking_NotPending(t *testing.T) {
+	mockUsecases := new(MockUsecases)
+	handler := NewHandler(mockUsecases)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/bookings/booking-123/confirm", nil)
+	req = mux.SetURLVars(req, map[string]string{"id": "booking-123"})
+	req.Header.Set("If-Match", "*")
+	w := httptest.NewRecorder()
+
+	mockUsecases.On("ConfirmBooking", mock.Anything, "booking-123", int64(0)).Return(fmt.Errorf("failed to confirm booking: %w", domain.ErrBookingNotPending))
+
+	handler.ConfirmBooking(w, req)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+	mockUsecases.AssertExpectations(t)
+}
+
+func TestConfirmBooking_IfMatch(t *testing.T) {
+	mockUsecases := new(MockUsecases)
+	handler := NewHandler(mockUsecases)
+
+	mockUsecases.On("ConfirmBooking", mock.Anything, "booking-123", int64(1)).
+		Return(fmt.Errorf("failed to confirm booking: %w", domain.ErrVersionMismatch))
+
+	req := httptest.NewRequest(http.MethodPost, "/api/bookings/booking-123/confirm", nil)
+	req = mux.SetURLVars(req, map[string]string{"id": "booking-123"})
+	req.Header.Set("If-Match", `"1"`)
+	w := httptest.NewRecorder()
+	handler.ConfirmBooking(w, req)
+
+	assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+	mockUsecases.AssertExpectations(t)
+}
+
+func TestConfirmBooking_IfMatchRequired(t *testing.T) {
+	mockUsecases := new(MockUsecases)
+	handler := NewHandler(mockUsecases)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/bookings/booking-123/confirm", nil)
+	req = mux.SetURLVars(req, map[string]string{"id": "booking-123"})
+	w := httptest.NewRecorder()
+	handler.ConfirmBooking(w, req)
+
+	assert.Equal(t, http.StatusPreconditionRequired, w.Code)
+	mockUsecases.AssertNotCalled(t, "ConfirmBooking", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestBookEvent_NoTicketsAvailable(t *testing.T) {
+	mockUsecases := new(MockUsecases)
+	handler := NewHandler(mockUsecases)
+
+	body, _ := json.Marshal(BookEventRequest{UserId: "user-123"})
+	req := httptest.NewRequest(http.MethodPost, "/api/events/event-123/book", bytes.NewBuffer(body))
+	req = mux.SetURLVars(req, map[string]string{"id": "event-123"})
+	w := httptest.NewRecorder()
+
+	mockUsecases.On("BookEvent", mock.Anything, mock.AnythingOfType("*domain.Booking")).Return("", fmt.Errorf("failed to book event: %w", domain.ErrNoTicketsAvailable))
+
+	handler.BookEvent(w, req)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+	mockUsecases.AssertExpectations(t)
+}
+
 func TestGetEvent_Success(t *testing.T) {
 	mockUsecases := new(MockUsecases)
 	handler := NewHandler(mockUsecases)
@@ -246,30 +387,260 @@ func TestGetEvent_NotFound(t *testing.T) {
 	mockUsecases.AssertExpectations(t)
 }
 
-func TestGetAllEvents_Success(t *testing.T) {
+func TestGetEvent_ETag(t *testing.T) {
+	mockUsecases := new(MockUsecases)
+	handler := NewHandler(mockUsecases)
+
+	mockUsecases.On("GetEvent", mock.Anything, "event-123").Return(&domain.Event{Id: "event-123", Version: 7}, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/events/event-123", nil)
+	req = mux.SetURLVars(req, map[string]string{"id": "event-123"})
+	w := httptest.NewRecorder()
+	handler.GetEvent(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `"7"`, w.Header().Get("ETag"))
+
+	// Кэш клиента актуален - тело не передается
+	req = httptest.NewRequest(http.MethodGet, "/api/events/event-123", nil)
+	req = mux.SetURLVars(req, map[string]string{"id": "event-123"})
+	req.Header.Set("If-None-Match", `"6", W/"7"`)
+	w = httptest.NewRecorder()
+	handler.GetEvent(w, req)
+
+	assert.Equal(t, http.StatusNotModified, w.Code)
+	assert.Equal(t, `"7"`, w.Header().Get("ETag"))
+	assert.Empty(t, w.Body.Bytes())
+
+	// Событие изменилось - полный ответ
+	req = httptest.NewRequest(http.MethodGet, "/api/events/event-123", nil)
+	req = mux.SetURLVars(req, map[string]string{"id": "event-123"})
+	req.Header.Set("If-None-Match", `"6"`)
+	w = httptest.NewRecorder()
+	handler.GetEvent(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockUsecases.AssertExpectations(t)
+}
+
+func TestUpdateEvent_Success(t *testing.T) {
 	mockUsecases := new(MockUsecases)
 	handler := NewHandler(mockUsecases)
 
-	expectedEvents := []*domain.Event{
-		{Id: "event-1", Name: "Event 1"},
-		{Id: "event-2", Name: "Event 2"},
+	name := "New name"
+	updated := &domain.Event{Id: "event-123", Name: name, Version: 4}
+	mockUsecases.On("UpdateEvent", mock.Anything, "event-123", int64(3), domain.EventUpdate{Name: &name}).Return(updated, nil)
+
+	req := httptest.NewRequest(http.MethodPatch, "/api/events/event-123", bytes.NewBufferString(`{"name":"New name"}`))
+	req = mux.SetURLVars(req, map[string]string{"id": "event-123"})
+	req.Header.Set("If-Match", `"3"`)
+	w := httptest.NewRecorder()
+	handler.UpdateEvent(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `"4"`, w.Header().Get("ETag"))
+	var event domain.Event
+	json.Unmarshal(w.Body.Bytes(), &event)
+	assert.Equal(t, name, event.Name)
+	mockUsecases.AssertExpectations(t)
+}
+
+func TestUpdateEvent_Preconditions(t *testing.T) {
+	tests := []struct {
+		name    string
+		ifMatch string
+		err     error
+		status  int
+	}{
+		{"missing If-Match", "", nil, http.StatusPreconditionRequired},
+		{"weak ETag", `W/"3"`, nil, http.StatusBadRequest},
+		{"stale version", `"3"`, fmt.Errorf("failed to update event: %w", domain.ErrVersionMismatch), http.StatusPreconditionFailed},
+		{"not found", `"3"`, fmt.Errorf("failed to update event: %w", domain.ErrEventNotFound), http.StatusNotFound},
+		{"invalid update", `"3"`, domain.ErrInvalidEventUpdate, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockUsecases := new(MockUsecases)
+			handler := NewHandler(mockUsecases)
+			if tt.err != nil {
+				mockUsecases.On("UpdateEvent", mock.Anything, "event-123", int64(3), mock.Anything).Return(nil, tt.err)
+			}
+
+			req := httptest.NewRequest(http.MethodPatch, "/api/events/event-123", bytes.NewBufferString(`{"name":"New name"}`))
+			req = mux.SetURLVars(req, map[string]string{"id": "event-123"})
+			if tt.ifMatch != "" {
+				req.Header.Set("If-Match", tt.ifMatch)
+			}
+			w := httptest.NewRecorder()
+			handler.UpdateEvent(w, req)
+
+			assert.Equal(t, tt.status, w.Code)
+			mockUsecases.AssertExpectations(t)
+		})
+	}
+}
+
+func TestListEvents_Success(t *testing.T) {
+	mockUsecases := new(MockUsecases)
+	handler := NewHandler(mockUsecases)
+
+	expectedPage := &domain.EventPage{
+		Events: []*domain.Event{
+			{Id: "event-1", Name: "Event 1"},
+			{Id: "event-2", Name: "Event 2"},
+		},
+		NextCursor: "next",
 	}
 
 	req := httptest.NewRequest(http.MethodGet, "/api/events", nil)
 	w := httptest.NewRecorder()
 
-	mockUsecases.On("GetAllEvents", mock.Anything).Return(expectedEvents, nil)
+	mockUsecases.On("ListEvents", mock.Anything, domain.EventFilter{}).Return(expectedPage, nil)
 
-	handler.GetAllEvents(w, req)
+	handler.ListEvents(w, req)
 
 	assert.Equal(t, http.StatusOK, w.Code)
-	
-	var events []*domain.Event
-	json.Unmarshal(w.Body.Bytes(), &events)
-	assert.Len(t, events, 2)
+
+	var page EventsPageResponse
+	json.Unmarshal(w.Body.Bytes(), &page)
+	assert.Len(t, page.Events, 2)
+	assert.Equal(t, "next", page.NextCursor)
+	mockUsecases.AssertExpectations(t)
+}
+
+func TestListEvents_Filters(t *testing.T) {
+	mockUsecases := new(MockUsecases)
+	handler := NewHandler(mockUsecases)
+
+	cursor := domain.NewEventCursor(domain.SortPriceDesc, &domain.Event{Id: "event-9", Price: domain.Money{Amount: 70000, Currency: "RUB"}})
+	isFree, minPrice, maxPrice := false, int64(10000), int64(250000)
+	expected := domain.EventFilter{
+		DateFrom:      time.Date(2026, time.March, 1, 0, 0, 0, 0, time.UTC),
+		DateTo:        time.Date(2026, time.April, 1, 0, 0, 0, 0, time.UTC),
+		Period:        domain.PeriodUpcoming,
+		IsFree:        &isFree,
+		Currency:      "RUB",
+		MinPrice:      &minPrice,
+		MaxPrice:      &maxPrice,
+		OnlyAvailable: true,
+		Sort:          domain.SortPriceDesc,
+		Limit:         10,
+		After:         cursor,
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/events?date_from=2026-03-01T00:00:00Z&date_to=2026-04-01T00:00:00Z"+
+		"&period=upcoming&is_free=false&currency=RUB&min_price=10000&max_price=250000&available=true&sort=price_desc&limit=10&cursor="+cursor.Encode(), nil)
+	w := httptest.NewRecorder()
+
+	mockUsecases.On("ListEvents", mock.Anything, expected).Return(&domain.EventPage{Events: []*domain.Event{}}, nil)
+
+	handler.ListEvents(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockUsecases.AssertExpectations(t)
+}
+
+func TestListEvents_InvalidParams(t *testing.T) {
+	for _, query := range []string{"limit=abc", "date_from=yesterday", "min_price=-1", "max_price=1.5", "is_free=maybe", "cursor=!!!"} {
+		mockUsecases := new(MockUsecases)
+		handler := NewHandler(mockUsecases)
+
+		req := httptest.NewRequest(http.MethodGet, "/api/events?"+query, nil)
+		w := httptest.NewRecorder()
+
+		handler.ListEvents(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code, query)
+		mockUsecases.AssertNotCalled(t, "ListEvents", mock.Anything, mock.Anything)
+	}
+}
+
+func TestListEvents_InvalidFilter(t *testing.T) {
+	mockUsecases := new(MockUsecases)
+	handler := NewHandler(mockUsecases)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/events?sort=popularity", nil)
+	w := httptest.NewRecorder()
+
+	mockUsecases.On("ListEvents", mock.Anything, domain.EventFilter{Sort: "popularity"}).
+		Return(nil, domain.ErrInvalidEventFilter)
+
+	handler.ListEvents(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	mockUsecases.AssertExpectations(t)
+}
+
+func TestSearchEvents_Success(t *testing.T) {
+	mockUsecases := new(MockUsecases)
+	handler := NewHandler(mockUsecases)
+
+	results := []*domain.EventSearchResult{{
+		Event:         &domain.Event{Id: "event-1", Name: "Джазовый концерт"},
+		Rank:          0.6,
+		NameHighlight: "Джазовый <mark>концерт</mark>",
+	}}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/events/search?q=%D0%BA%D0%BE%D0%BD%D1%86%D0%B5%D1%80%D1%82&limit=5", nil)
+	w := httptest.NewRecorder()
+
+	mockUsecases.On("SearchEvents", mock.Anything, "концерт", 5).Return(results, nil)
+
+	handler.SearchEvents(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response EventSearchResponse
+	json.Unmarshal(w.Body.Bytes(), &response)
+	assert.Len(t, response.Results, 1)
+	assert.Equal(t, "event-1", response.Results[0].Event.Id)
+	assert.Equal(t, "Джазовый <mark>концерт</mark>", response.Results[0].NameHighlight)
+	mockUsecases.AssertExpectations(t)
+}
+
+func TestSearchEvents_NoResults(t *testing.T) {
+	mockUsecases := new(MockUsecases)
+	handler := NewHandler(mockUsecases)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/events/search?q=opera", nil)
+	w := httptest.NewRecorder()
+
+	mockUsecases.On("SearchEvents", mock.Anything, "opera", 0).Return(nil, nil)
+
+	handler.SearchEvents(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"results":[]}`, w.Body.String())
+}
+
+func TestSearchEvents_InvalidQuery(t *testing.T) {
+	mockUsecases := new(MockUsecases)
+	handler := NewHandler(mockUsecases)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/events/search?q=", nil)
+	w := httptest.NewRecorder()
+
+	mockUsecases.On("SearchEvents", mock.Anything, "", 0).Return(nil, domain.ErrInvalidSearchQuery)
+
+	handler.SearchEvents(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
 	mockUsecases.AssertExpectations(t)
 }
 
+func TestSearchEvents_InvalidLimit(t *testing.T) {
+	mockUsecases := new(MockUsecases)
+	handler := NewHandler(mockUsecases)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/events/search?q=jazz&limit=-1", nil)
+	w := httptest.NewRecorder()
+
+	handler.SearchEvents(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	mockUsecases.AssertNotCalled(t, "SearchEvents", mock.Anything, mock.Anything, mock.Anything)
+}
+
 func TestGetBooking_Success(t *testing.T) {
 	mockUsecases := new(MockUsecases)
 	handler := NewHandler(mockUsecases)
@@ -297,3 +668,257 @@ func TestGetBooking_Success(t *testing.T) {
 	assert.Equal(t, domain.PendingStatus, booking.Status)
 	mockUsecases.AssertExpectations(t)
 }
+
+func TestListUserBookings_Success(t *testing.T) {
+	mockUsecases := new(MockUsecases)
+	handler := NewHandler(mockUsecases)
+
+	cursor := &domain.BookingCursor{Date: time.Date(2026, time.March, 1, 19, 0, 0, 0, time.UTC), Id: "booking-9"}
+	page := &domain.BookingPage{
+		Bookings: []*domain.BookingDetails{
+			{
+				Booking: &domain.Booking{Id: "booking-2", UserId: "user-1", Status: domain.PendingStatus, Date: time.Now()},
+				Event:   &domain.Event{Id: "event-1", Name: "Concert"},
+			},
+			{
+				Booking: &domain.Booking{Id: "booking-1", UserId: "user-1", Status: domain.ConfirmedStatus, Date: time.Now()},
+				Event:   &domain.Event{Id: "event-1", Name: "Concert"},
+			},
+		},
+		NextCursor: "next",
+	}
+	expected := domain.BookingFilter{UserId: "user-1", Status: domain.PendingStatus, Limit: 2, After: cursor}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/users/user-1/bookings?status=pending&limit=2&cursor="+cursor.Encode(), nil)
+	req = mux.SetURLVars(req, map[string]string{"id": "user-1"})
+	w := httptest.NewRecorder()
+
+	mockUsecases.On("ListUserBookings", mock.Anything, expected).Return(page, nil)
+
+	handler.ListUserBookings(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response BookingsPageResponse
+	json.Unmarshal(w.Body.Bytes(), &response)
+	assert.Len(t, response.Bookings, 2)
+	assert.Equal(t, "next", response.NextCursor)
+	assert.Equal(t, "Concert", response.Bookings[0].Event.Name)
+	// У pending-брони есть срок оплаты, у оплаченной - нет
+	assert.NotNil(t, response.Bookings[0].ExpiresAt)
+	assert.InDelta(t, domain.BookingHold.Seconds(), response.Bookings[0].RemainingSeconds, 2)
+	assert.Nil(t, response.Bookings[1].ExpiresAt)
+	assert.Zero(t, response.Bookings[1].RemainingSeconds)
+	mockUsecases.AssertExpectations(t)
+}
+
+func TestListUserBookings_InvalidParams(t *testing.T) {
+	for _, query := range []string{"limit=abc", "cursor=!!!"} {
+		mockUsecases := new(MockUsecases)
+		handler := NewHandler(mockUsecases)
+
+		req := httptest.NewRequest(http.MethodGet, "/api/users/user-1/bookings?"+query, nil)
+		req = mux.SetURLVars(req, map[string]string{"id": "user-1"})
+		w := httptest.NewRecorder()
+
+		handler.ListUserBookings(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code, query)
+		mockUsecases.AssertNotCalled(t, "ListUserBookings", mock.Anything, mock.Anything)
+	}
+}
+
+func TestListUserBookings_InvalidStatus(t *testing.T) {
+	mockUsecases := new(MockUsecases)
+	handler := NewHandler(mockUsecases)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/users/user-1/bookings?status=paid", nil)
+	req = mux.SetURLVars(req, map[string]string{"id": "user-1"})
+	w := httptest.NewRecorder()
+
+	mockUsecases.On("ListUserBookings", mock.Anything, domain.BookingFilter{UserId: "user-1", Status: "paid"}).
+		Return(nil, domain.ErrInvalidBookingFilter)
+
+	handler.ListUserBookings(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	mockUsecases.AssertExpectations(t)
+}
+
+func TestListEventBookings_Success(t *testing.T) {
+	mockUsecases := new(MockUsecases)
+	handler := NewHandler(mockUsecases)
+
+	page := &domain.BookingPage{Bookings: []*domain.BookingDetails{{
+		Booking: &domain.Booking{Id: "booking-1", UserId: "user-1", EventId: "event-1", Status: domain.ConfirmedStatus},
+		Event:   &domain.Event{Id: "event-1"},
+	}}}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/events/event-1/bookings?status=confirmed&limit=50", nil)
+	req = mux.SetURLVars(req, map[string]string{"id": "event-1"})
+	w := httptest.NewRecorder()
+
+	expected := domain.BookingFilter{EventId: "event-1", Status: domain.ConfirmedStatus, Limit: 50}
+	mockUsecases.On("ListEventBookings", mock.Anything, expected).Return(page, nil)
+
+	handler.ListEventBookings(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response BookingsPageResponse
+	json.Unmarshal(w.Body.Bytes(), &response)
+	assert.Len(t, response.Bookings, 1)
+	assert.Equal(t, "user-1", response.Bookings[0].Booking.UserId)
+	mockUsecases.AssertExpectations(t)
+}
+
+func TestListEventBookings_EventNotFound(t *testing.T) {
+	mockUsecases := new(MockUsecases)
+	handler := NewHandler(mockUsecases)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/events/missing/bookings", nil)
+	req = mux.SetURLVars(req, map[string]string{"id": "missing"})
+	w := httptest.NewRecorder()
+
+	mockUsecases.On("ListEventBookings", mock.Anything, domain.BookingFilter{EventId: "missing"}).
+		Return(nil, fmt.Errorf("failed to list event bookings: %w", domain.ErrEventNotFound))
+
+	handler.ListEventBookings(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+// exportBookings настраивает мок выгрузки, передающий bookings в fn
+func exportBookings(mockUsecases *MockUsecases, filter domain.BookingFilter, bookings ...*domain.Booking) {
+	mockUsecases.On("ExportEventBookings", mock.Anything, filter, mock.Anything).
+		Run(func(args mock.Arguments) {
+			fn := args.Get(2).(func(*domain.Booking) error)
+			for _, booking := range bookings {
+				if err := fn(booking); err != nil {
+					return
+				}
+			}
+		}).
+		Return(nil)
+}
+
+func TestExportEventBookings_CSV(t *testing.T) {
+	mockUsecases := new(MockUsecases)
+	handler := NewHandler(mockUsecases)
+
+	date := time.Date(2026, time.March, 1, 19, 0, 0, 0, time.UTC)
+	charged := &domain.Booking{Id: "booking-3", UserId: "=HYPERLINK(\"x\")", Status: domain.ConfirmedStatus, Date: date}
+	assert.NoError(t, charged.Charge(domain.Money{Amount: 150000, Currency: "RUB"}, domain.Money{Amount: 50000, Currency: "RUB"}, &domain.FeeRule{FeePercent: 10, VatPercent: percent(20)}))
+	exportBookings(mockUsecases, domain.BookingFilter{EventId: "event-1"},
+		&domain.Booking{Id: "booking-2", UserId: "user, \"quoted\"", Status: domain.PendingStatus, Date: date},
+		&domain.Booking{Id: "booking-1", UserId: "user-1", Status: domain.ConfirmedStatus, Date: date},
+		charged,
+	)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/events/event-1/bookings/export", nil)
+	req = mux.SetURLVars(req, map[string]string{"id": "event-1"})
+	w := httptest.NewRecorder()
+
+	handler.ExportEventBookings(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/csv; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Header().Get("Content-Disposition"), `filename="event-event-1-bookings.csv"`)
+	assert.Equal(t, "booking_id,user_id,status,booked_at,expires_at,price,currency,face_value,discount,service_fee,vat\n"+
+		"booking-2,\"user, \"\"quoted\"\"\",pending,2026-03-01T19:00:00Z,2026-03-01T19:01:00Z,0,,,,,\n"+
+		"booking-1,user-1,confirmed,2026-03-01T19:00:00Z,,0,,,,,\n"+
+		"booking-3,\"'=HYPERLINK(\"\"x\"\")\",confirmed,2026-03-01T19:00:00Z,,132000,RUB,150000,-50000,10000,22000\n", w.Body.String())
+}
+
+func TestExportEventBookings_XLSX(t *testing.T) {
+	mockUsecases := new(MockUsecases)
+	handler := NewHandler(mockUsecases)
+
+	date := time.Date(2026, time.March, 1, 19, 0, 0, 0, time.UTC)
+	exportBookings(mockUsecases, domain.BookingFilter{EventId: "event-1", Status: domain.ConfirmedStatus},
+		&domain.Booking{Id: "booking-1", UserId: "<user & co>", Status: domain.ConfirmedStatus, Date: date},
+	)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/events/event-1/bookings/export?format=xlsx&status=confirmed", nil)
+	req = mux.SetURLVars(req, map[string]string{"id": "event-1"})
+	w := httptest.NewRecorder()
+
+	handler.ExportEventBookings(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", w.Header().Get("Content-Type"))
+
+	archive, err := zip.NewReader(bytes.NewReader(w.Body.Bytes()), int64(w.Body.Len()))
+	assert.NoError(t, err)
+	var names []string
+	var sheet []byte
+	for _, f := range archive.File {
+		names = append(names, f.Name)
+		if f.Name == "xl/worksheets/sheet1.xml" {
+			rc, err := f.Open()
+			assert.NoError(t, err)
+			sheet, _ = io.ReadAll(rc)
+			rc.Close()
+		}
+	}
+	assert.ElementsMatch(t, []string{"[Content_Types].xml", "_rels/.rels", "xl/workbook.xml",
+		"xl/_rels/workbook.xml.rels", "xl/worksheets/sheet1.xml"}, names)
+
+	// Лист - корректный XML: две строки, значения экранированы
+	var parsed struct {
+		Rows []struct {
+			Cells []string `xml:"c>is>t"`
+		} `xml:"sheetData>row"`
+	}
+	assert.NoError(t, xml.Unmarshal(sheet, &parsed))
+	assert.Len(t, parsed.Rows, 2)
+	assert.Equal(t, exportColumns, parsed.Rows[0].Cells)
+	assert.Equal(t, []string{"booking-1", "<user & co>", "confirmed", "2026-03-01T19:00:00Z", "", "0", "", "", "", "", ""}, parsed.Rows[1].Cells)
+}
+
+func TestExportEventBookings_Empty(t *testing.T) {
+	mockUsecases := new(MockUsecases)
+	handler := NewHandler(mockUsecases)
+
+	exportBookings(mockUsecases, domain.BookingFilter{EventId: "event-1"})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/events/event-1/bookings/export?format=csv", nil)
+	req = mux.SetURLVars(req, map[string]string{"id": "event-1"})
+	w := httptest.NewRecorder()
+
+	handler.ExportEventBookings(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "booking_id,user_id,status,booked_at,expires_at,price,currency,face_value,discount,service_fee,vat\n", w.Body.String())
+}
+
+func TestExportEventBookings_EventNotFound(t *testing.T) {
+	mockUsecases := new(MockUsecases)
+	handler := NewHandler(mockUsecases)
+
+	mockUsecases.On("ExportEventBookings", mock.Anything, domain.BookingFilter{EventId: "missing"}, mock.Anything).
+		Return(fmt.Errorf("failed to export event bookings: %w", domain.ErrEventNotFound))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/events/missing/bookings/export", nil)
+	req = mux.SetURLVars(req, map[string]string{"id": "missing"})
+	w := httptest.NewRecorder()
+
+	handler.ExportEventBookings(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Empty(t, w.Header().Get("Content-Disposition"))
+}
+
+func TestExportEventBookings_InvalidFormat(t *testing.T) {
+	mockUsecases := new(MockUsecases)
+	handler := NewHandler(mockUsecases)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/events/event-1/bookings/export?format=pdf", nil)
+	req = mux.SetURLVars(req, map[string]string{"id": "event-1"})
+	w := httptest.NewRecorder()
+
+	handler.ExportEventBookings(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	mockUsecases.AssertNotCalled(t, "ExportEventBookings", mock.Anything, mock.Anything, mock.Anything)
+}
@@ -0,0 +1,184 @@
+package http
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/dontpanicw/EventBooker/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockIdempotencyUsecases - мок usecases ключей идемпотентности
+type MockIdempotencyUsecases struct {
+	mock.Mock
+}
+
+func (m *MockIdempotencyUsecases) Begin(ctx context.Context, key, requestHash string) (*domain.IdempotencyRecord, error) {
+	args := m.Called(ctx, key, requestHash)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.IdempotencyRecord), args.Error(1)
+}
+
+func (m *MockIdempotencyUsecases) Complete(ctx context.Context, record *domain.IdempotencyRecord) error {
+	args := m.Called(ctx, record)
+	return args.Error(0)
+}
+
+func (m *MockIdempotencyUsecases) Release(ctx context.Context, key string) error {
+	args := m.Called(ctx, key)
+	return args.Error(0)
+}
+
+func (m *MockIdempotencyUsecases) PurgeExpired(ctx context.Context) (int64, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+// countingHandler отвечает status и считает вызовы, проверяя, что тело запроса дочитывается
+func countingHandler(status int, calls *int) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*calls++
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		w.Write([]byte(`{"echo":` + string(body) + `}`))
+	})
+}
+
+func newIdempotentRequest(key, body string) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, "/api/events/event-1/book", strings.NewReader(body))
+	if key != "" {
+		req.Header.Set(IdempotencyKeyHeader, key)
+	}
+	return req
+}
+
+func TestIdempotencyMiddleware_WithoutKey(t *testing.T) {
+	mockIdempotency := new(MockIdempotencyUsecases)
+	calls := 0
+	handler := idempotencyMiddleware(mockIdempotency)(countingHandler(http.StatusCreated, &calls))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, newIdempotentRequest("", `"a"`))
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, 1, calls)
+	mockIdempotency.AssertNotCalled(t, "Begin", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestIdempotencyMiddleware_StoresResponse(t *testing.T) {
+	mockIdempotency := new(MockIdempotencyUsecases)
+	calls := 0
+	handler := idempotencyMiddleware(mockIdempotency)(countingHandler(http.StatusCreated, &calls))
+
+	req := newIdempotentRequest("key-1", `"a"`)
+	mockIdempotency.On("Begin", mock.Anything, "key-1", requestHash(req, []byte(`"a"`))).Return(nil, nil)
+	mockIdempotency.On("Complete", mock.Anything, &domain.IdempotencyRecord{
+		Key:         "key-1",
+		StatusCode:  http.StatusCreated,
+		ContentType: "application/json",
+		Body:        []byte(`{"echo":"a"}`),
+	}).Return(nil)
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, `{"echo":"a"}`, w.Body.String())
+	assert.Equal(t, 1, calls)
+	mockIdempotency.AssertExpectations(t)
+}
+
+func TestIdempotencyMiddleware_ReplaysSavedResponse(t *testing.T) {
+	mockIdempotency := new(MockIdempotencyUsecases)
+	calls := 0
+	handler := idempotencyMiddleware(mockIdempotency)(countingHandler(http.StatusCreated, &calls))
+
+	saved := &domain.IdempotencyRecord{
+		Key:         "key-1",
+		StatusCode:  http.StatusCreated,
+		ContentType: "application/json",
+		Body:        []byte(`{"booking_id":"booking-1"}`),
+	}
+	mockIdempotency.On("Begin", mock.Anything, "key-1", mock.Anything).Return(saved, nil)
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, newIdempotentRequest("key-1", `"a"`))
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, `{"booking_id":"booking-1"}`, w.Body.String())
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	assert.Equal(t, "true", w.Header().Get(IdempotentReplayedHeader))
+	assert.Zero(t, calls)
+	mockIdempotency.AssertNotCalled(t, "Complete", mock.Anything, mock.Anything)
+}
+
+func TestIdempotencyMiddleware_Conflicts(t *testing.T) {
+	tests := []struct {
+		err    error
+		status int
+	}{
+		{domain.ErrIdempotencyKeyReused, http.StatusUnprocessableEntity},
+		{domain.ErrIdempotencyInProgress, http.StatusConflict},
+	}
+
+	for _, tt := range tests {
+		mockIdempotency := new(MockIdempotencyUsecases)
+		calls := 0
+		handler := idempotencyMiddleware(mockIdempotency)(countingHandler(http.StatusCreated, &calls))
+
+		mockIdempotency.On("Begin", mock.Anything, "key-1", mock.Anything).Return(nil, tt.err)
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, newIdempotentRequest("key-1", `"a"`))
+
+		assert.Equal(t, tt.status, w.Code, tt.err.Error())
+		assert.Zero(t, calls)
+	}
+}
+
+func TestIdempotencyMiddleware_ReleasesKeyOnServerError(t *testing.T) {
+	mockIdempotency := new(MockIdempotencyUsecases)
+	calls := 0
+	handler := idempotencyMiddleware(mockIdempotency)(countingHandler(http.StatusInternalServerError, &calls))
+
+	mockIdempotency.On("Begin", mock.Anything, "key-1", mock.Anything).Return(nil, nil)
+	mockIdempotency.On("Release", mock.Anything, "key-1").Return(nil)
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, newIdempotentRequest("key-1", `"a"`))
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	mockIdempotency.AssertExpectations(t)
+	mockIdempotency.AssertNotCalled(t, "Complete", mock.Anything, mock.Anything)
+}
+
+func TestIdempotencyMiddleware_IgnoresReads(t *testing.T) {
+	mockIdempotency := new(MockIdempotencyUsecases)
+	calls := 0
+	handler := idempotencyMiddleware(mockIdempotency)(countingHandler(http.StatusOK, &calls))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/events", nil)
+	req.Header.Set(IdempotencyKeyHeader, "key-1")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	assert.Equal(t, 1, calls)
+	mockIdempotency.AssertNotCalled(t, "Begin", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestRequestHash_CoversMethodPathAndBody(t *testing.T) {
+	post := httptest.NewRequest(http.MethodPost, "/api/events/event-1/book", nil)
+	other := httptest.NewRequest(http.MethodPost, "/api/events/event-2/book", nil)
+
+	assert.Equal(t, requestHash(post, []byte("a")), requestHash(post, []byte("a")))
+	assert.NotEqual(t, requestHash(post, []byte("a")), requestHash(post, []byte("b")))
+	assert.NotEqual(t, requestHash(post, []byte("a")), requestHash(other, []byte("a")))
+}
@@ -0,0 +1,60 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/dontpanicw/EventBooker/internal/domain"
+	"github.com/dontpanicw/EventBooker/internal/port"
+	"github.com/gorilla/mux"
+)
+
+// PricingHandler - расписания цен мероприятий для администраторов
+type PricingHandler struct {
+	pricing port.PricingUsecases
+}
+
+func NewPricingHandler(pricing port.PricingUsecases) *PricingHandler {
+	return &PricingHandler{
+		pricing: pricing,
+	}
+}
+
+// SetPriceSchedule заменяет расписание цены и возвращает мероприятие с текущей ценой
+func (h *PricingHandler) SetPriceSchedule(w http.ResponseWriter, r *http.Request) {
+	var req PriceScheduleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	phases := make([]domain.PricePhase, 0, len(req.Phases))
+	for _, phase := range req.Phases {
+		pricePhase := domain.PricePhase{Name: phase.Name, Price: phase.Price, MaxSold: phase.MaxSold}
+		if phase.Until != nil {
+			pricePhase.Until = *phase.Until
+		}
+		phases = append(phases, pricePhase)
+	}
+
+	event, err := h.pricing.SetPricePhases(r.Context(), mux.Vars(r)["id"], phases)
+	if err != nil {
+		http.Error(w, err.Error(), pricingErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(event)
+}
+
+func pricingErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, domain.ErrEventNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, domain.ErrInvalidPriceSchedule):
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,103 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dontpanicw/EventBooker/internal/domain"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockPricingUsecases - мок usecases расписаний цен
+type MockPricingUsecases struct {
+	mock.Mock
+}
+
+func (m *MockPricingUsecases) SetPricePhases(ctx context.Context, eventID string, phases []domain.PricePhase) (*domain.Event, error) {
+	args := m.Called(ctx, eventID, phases)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Event), args.Error(1)
+}
+
+func TestSetPriceSchedule_Success(t *testing.T) {
+	mockPricing := new(MockPricingUsecases)
+	handler := NewPricingHandler(mockPricing)
+
+	until := time.Date(2026, time.April, 1, 0, 0, 0, 0, time.UTC)
+	phases := []domain.PricePhase{
+		{Name: "Early bird", Price: 1000, Until: until},
+		{Name: "Last tickets", Price: 2000, MaxSold: 90},
+	}
+	mockPricing.On("SetPricePhases", mock.Anything, "event-1", phases).
+		Return(&domain.Event{Id: "event-1", PricePhases: phases, Pricing: &domain.PriceQuote{Price: domain.Money{Amount: 1000, Currency: "RUB"}, Phase: 1}}, nil)
+
+	body := `{"phases":[{"name":"Early bird","price":1000,"until":"2026-04-01T00:00:00Z"},{"name":"Last tickets","price":2000,"max_sold":90}]}`
+	req := httptest.NewRequest(http.MethodPut, "/api/admin/events/event-1/pricing", bytes.NewBufferString(body))
+	req = mux.SetURLVars(req, map[string]string{"id": "event-1"})
+	w := httptest.NewRecorder()
+	handler.SetPriceSchedule(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"Pricing":{"Price":{"amount":1000,"currency":"RUB"},"Phase":1`)
+	mockPricing.AssertExpectations(t)
+}
+
+func TestSetPriceSchedule_Errors(t *testing.T) {
+	mockPricing := new(MockPricingUsecases)
+	handler := NewPricingHandler(mockPricing)
+
+	mockPricing.On("SetPricePhases", mock.Anything, "missing", mock.Anything).
+		Return(nil, fmt.Errorf("failed to set price phases: %w", domain.ErrEventNotFound))
+	mockPricing.On("SetPricePhases", mock.Anything, "event-1", mock.Anything).
+		Return(nil, domain.ErrInvalidPriceSchedule)
+
+	tests := []struct {
+		id     string
+		body   string
+		status int
+	}{
+		{"event-1", `{"phases":`, http.StatusBadRequest},
+		{"event-1", `{"phases":[{"price":-1}]}`, http.StatusBadRequest},
+		{"missing", `{"phases":[]}`, http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodPut, "/api/admin/events/"+tt.id+"/pricing", bytes.NewBufferString(tt.body))
+		req = mux.SetURLVars(req, map[string]string{"id": tt.id})
+		w := httptest.NewRecorder()
+		handler.SetPriceSchedule(w, req)
+
+		assert.Equal(t, tt.status, w.Code, tt.body)
+	}
+}
+
+func TestGetEvent_PricingETag(t *testing.T) {
+	mockUsecases := new(MockUsecases)
+	handler := NewHandler(mockUsecases)
+
+	mockUsecases.On("GetEvent", mock.Anything, "event-1").
+		Return(&domain.Event{Id: "event-1", Version: 7, Pricing: &domain.PriceQuote{Price: domain.Money{Amount: 1200, Currency: "RUB"}, Phase: 2}}, nil)
+
+	// Ступень цены сменилась по времени: кэш с прежней ступенью устарел
+	req := httptest.NewRequest(http.MethodGet, "/api/events/event-1", nil)
+	req = mux.SetURLVars(req, map[string]string{"id": "event-1"})
+	req.Header.Set("If-None-Match", `"7.1"`)
+	w := httptest.NewRecorder()
+	handler.GetEvent(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `"7.2"`, w.Header().Get("ETag"))
+
+	// ETag с номером ступени подходит для If-Match
+	version, err := ifMatchVersion(`"7.2"`)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(7), version)
+}
@@ -0,0 +1,123 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/dontpanicw/EventBooker/internal/domain"
+	"github.com/dontpanicw/EventBooker/internal/port"
+	"github.com/gorilla/mux"
+)
+
+// PromoHandler - создание промокодов и отчеты об их использовании для администраторов
+type PromoHandler struct {
+	promos port.PromoUsecases
+}
+
+func NewPromoHandler(promos port.PromoUsecases) *PromoHandler {
+	return &PromoHandler{
+		promos: promos,
+	}
+}
+
+func (h *PromoHandler) CreatePromoCode(w http.ResponseWriter, r *http.Request) {
+	var req PromoCodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	promo := &domain.PromoCode{
+		Code:         req.Code,
+		EventId:      req.EventId,
+		DiscountType: req.DiscountType,
+		Percent:      req.Percent,
+		MaxUses:      req.MaxUses,
+	}
+	if req.Amount != nil {
+		promo.Amount = *req.Amount
+	}
+	if req.ValidFrom != nil {
+		promo.ValidFrom = req.ValidFrom.UTC()
+	}
+	if req.ValidUntil != nil {
+		promo.ValidUntil = req.ValidUntil.UTC()
+	}
+
+	promo, err := h.promos.CreatePromoCode(r.Context(), promo)
+	if err != nil {
+		http.Error(w, err.Error(), promoErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(NewPromoCodeResponse(promo))
+}
+
+func (h *PromoHandler) GetPromoCode(w http.ResponseWriter, r *http.Request) {
+	promo, err := h.promos.GetPromoCode(r.Context(), mux.Vars(r)["code"])
+	if err != nil {
+		http.Error(w, err.Error(), promoErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(NewPromoCodeResponse(promo))
+}
+
+func (h *PromoHandler) ListPromoCodes(w http.ResponseWriter, r *http.Request) {
+	promos, err := h.promos.ListPromoCodes(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), promoErrorStatus(err))
+		return
+	}
+
+	resp := make([]PromoCodeResponse, 0, len(promos))
+	for _, promo := range promos {
+		resp = append(resp, NewPromoCodeResponse(promo))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(resp)
+}
+
+// ListPromoRedemptions - отчет по коду: какие брони его использовали и на какую сумму
+func (h *PromoHandler) ListPromoRedemptions(w http.ResponseWriter, r *http.Request) {
+	redemptions, err := h.promos.ListPromoRedemptions(r.Context(), mux.Vars(r)["code"])
+	if err != nil {
+		http.Error(w, err.Error(), promoErrorStatus(err))
+		return
+	}
+
+	resp := make([]PromoRedemptionResponse, 0, len(redemptions))
+	for _, redemption := range redemptions {
+		resp = append(resp, PromoRedemptionResponse{
+			BookingId:     redemption.BookingId,
+			UserId:        redemption.UserId,
+			EventId:       redemption.EventId,
+			Discount:      redemption.Discount,
+			BookingStatus: redemption.BookingStatus,
+			RedeemedAt:    redemption.RedeemedAt,
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(resp)
+}
+
+func promoErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, domain.ErrPromoCodeNotFound), errors.Is(err, domain.ErrEventNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, domain.ErrInvalidPromoCode):
+		return http.StatusBadRequest
+	case errors.Is(err, domain.ErrPromoCodeExists):
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,173 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dontpanicw/EventBooker/internal/domain"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockPromoUsecases - мок usecases промокодов
+type MockPromoUsecases struct {
+	mock.Mock
+}
+
+func (m *MockPromoUsecases) CreatePromoCode(ctx context.Context, promo *domain.PromoCode) (*domain.PromoCode, error) {
+	args := m.Called(ctx, promo)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.PromoCode), args.Error(1)
+}
+
+func (m *MockPromoUsecases) GetPromoCode(ctx context.Context, code string) (*domain.PromoCode, error) {
+	args := m.Called(ctx, code)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.PromoCode), args.Error(1)
+}
+
+func (m *MockPromoUsecases) ListPromoCodes(ctx context.Context) ([]*domain.PromoCode, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.PromoCode), args.Error(1)
+}
+
+func (m *MockPromoUsecases) ListPromoRedemptions(ctx context.Context, code string) ([]*domain.PromoRedemption, error) {
+	args := m.Called(ctx, code)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.PromoRedemption), args.Error(1)
+}
+
+func TestCreatePromoCode_Success(t *testing.T) {
+	mockPromos := new(MockPromoUsecases)
+	handler := NewPromoHandler(mockPromos)
+
+	until := time.Date(2026, time.April, 1, 0, 0, 0, 0, time.UTC)
+	body, _ := json.Marshal(PromoCodeRequest{Code: "spring", DiscountType: domain.DiscountPercent, Percent: 10, MaxUses: 100, ValidUntil: &until})
+	req := httptest.NewRequest(http.MethodPost, "/api/admin/promo-codes", bytes.NewBuffer(body))
+	w := httptest.NewRecorder()
+
+	mockPromos.On("CreatePromoCode", mock.Anything, mock.MatchedBy(func(promo *domain.PromoCode) bool {
+		return promo.Code == "spring" && promo.Percent == 10 && promo.ValidFrom.IsZero() && promo.ValidUntil.Equal(until) && promo.MaxUses == 100
+	})).Return(&domain.PromoCode{Code: "SPRING", DiscountType: domain.DiscountPercent, Percent: 10, MaxUses: 100, ValidUntil: until}, nil)
+
+	handler.CreatePromoCode(w, req)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	var response map[string]interface{}
+	json.Unmarshal(w.Body.Bytes(), &response)
+	assert.Equal(t, "SPRING", response["code"])
+	assert.NotContains(t, response, "valid_from")
+	assert.NotContains(t, response, "event_id")
+	assert.NotContains(t, response, "amount")
+	assert.Equal(t, []interface{}{}, response["total_discount"])
+	mockPromos.AssertExpectations(t)
+}
+
+func TestCreatePromoCode_Errors(t *testing.T) {
+	mockPromos := new(MockPromoUsecases)
+	handler := NewPromoHandler(mockPromos)
+
+	mockPromos.On("CreatePromoCode", mock.Anything, mock.MatchedBy(func(promo *domain.PromoCode) bool { return promo.Code == "BAD" })).
+		Return(nil, domain.ErrInvalidPromoCode)
+	mockPromos.On("CreatePromoCode", mock.Anything, mock.MatchedBy(func(promo *domain.PromoCode) bool { return promo.Code == "TAKEN" })).
+		Return(nil, fmt.Errorf("failed to create promo code: %w", domain.ErrPromoCodeExists))
+	mockPromos.On("CreatePromoCode", mock.Anything, mock.MatchedBy(func(promo *domain.PromoCode) bool { return promo.Code == "LOST" })).
+		Return(nil, fmt.Errorf("failed to create promo code: %w", domain.ErrEventNotFound))
+
+	tests := []struct {
+		name   string
+		body   string
+		status int
+	}{
+		{"malformed", `{`, http.StatusBadRequest},
+		{"invalid", `{"code":"BAD"}`, http.StatusBadRequest},
+		{"exists", `{"code":"TAKEN"}`, http.StatusConflict},
+		{"unknown event", `{"code":"LOST","event_id":"missing"}`, http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/admin/promo-codes", bytes.NewBufferString(tt.body))
+			w := httptest.NewRecorder()
+
+			handler.CreatePromoCode(w, req)
+
+			assert.Equal(t, tt.status, w.Code)
+		})
+	}
+}
+
+func TestListPromoRedemptions_Success(t *testing.T) {
+	mockPromos := new(MockPromoUsecases)
+	handler := NewPromoHandler(mockPromos)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/admin/promo-codes/SPRING/redemptions", nil)
+	req = mux.SetURLVars(req, map[string]string{"code": "SPRING"})
+	w := httptest.NewRecorder()
+
+	mockPromos.On("ListPromoRedemptions", mock.Anything, "SPRING").Return([]*domain.PromoRedemption{
+		{Code: "SPRING", BookingId: "booking-1", UserId: "user-1", EventId: "event-123", Discount: domain.Money{Amount: 15000, Currency: "RUB"}, BookingStatus: domain.ConfirmedStatus},
+	}, nil)
+
+	handler.ListPromoRedemptions(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response []PromoRedemptionResponse
+	json.Unmarshal(w.Body.Bytes(), &response)
+	if assert.Len(t, response, 1) {
+		assert.Equal(t, domain.Money{Amount: 15000, Currency: "RUB"}, response[0].Discount)
+		assert.Equal(t, domain.ConfirmedStatus, response[0].BookingStatus)
+	}
+	mockPromos.AssertExpectations(t)
+}
+
+func TestGetPromoCode_NotFound(t *testing.T) {
+	mockPromos := new(MockPromoUsecases)
+	handler := NewPromoHandler(mockPromos)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/admin/promo-codes/MISSING", nil)
+	req = mux.SetURLVars(req, map[string]string{"code": "MISSING"})
+	w := httptest.NewRecorder()
+
+	mockPromos.On("GetPromoCode", mock.Anything, "MISSING").Return(nil, fmt.Errorf("failed to get promo code: %w", domain.ErrPromoCodeNotFound))
+
+	handler.GetPromoCode(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestBookEvent_PromoCodeRejected(t *testing.T) {
+	mockUsecases := new(MockUsecases)
+	handler := NewHandler(mockUsecases)
+
+	body, _ := json.Marshal(BookEventRequest{UserId: "user-123", PromoCode: "spring"})
+	req := httptest.NewRequest(http.MethodPost, "/api/events/event-123/book", bytes.NewBuffer(body))
+	req = mux.SetURLVars(req, map[string]string{"id": "event-123"})
+	w := httptest.NewRecorder()
+
+	mockUsecases.On("BookEvent", mock.Anything, mock.MatchedBy(func(booking *domain.Booking) bool {
+		return booking.PromoCode == "spring"
+	})).Return("", fmt.Errorf("failed to book event: %w", domain.ErrPromoCodeExhausted))
+
+	handler.BookEvent(w, req)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	mockUsecases.AssertExpectations(t)
+}
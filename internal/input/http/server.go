@@ -7,21 +7,35 @@ import (
 	"github.com/gorilla/mux"
 	"log"
 	"net/http"
+	"sync"
 	"time"
 )
 
 type Server struct {
-	handler *Handler
-	server  *http.Server
+	handler            *Handler
+	adminHandler       *AdminHandler
+	streamHandler      *StreamHandler
+	waitingRoomHandler *WaitingRoomHandler
+	ballotHandler      *BallotHandler
+	server             *http.Server
 }
 
-func NewServer(port string, usecases port.Usecases) *Server {
+func NewServer(port string, usecases port.Usecases, admin port.AdminUsecases, idempotency port.IdempotencyUsecases, updates port.UpdatesStream, waitingRoom port.WaitingRoomUsecases, ballots port.BallotUsecases, promos port.PromoUsecases, pricing port.PricingUsecases, fees port.FeeUsecases) *Server {
 	handler := NewHandler(usecases)
+	adminHandler := NewAdminHandler(admin)
+	streamsDone := make(chan struct{})
+	streamHandler := NewStreamHandler(usecases, updates, streamsDone)
+	waitingRoomHandler := NewWaitingRoomHandler(waitingRoom)
+	ballotHandler := NewBallotHandler(ballots)
+	promoHandler := NewPromoHandler(promos)
+	pricingHandler := NewPricingHandler(pricing)
+	feeHandler := NewFeeHandler(fees)
 
 	router := mux.NewRouter()
 
 	// CORS middleware
 	router.Use(corsMiddleware)
+	router.Use(idempotencyMiddleware(idempotency))
 
 	// Статические файлы
 	router.PathPrefix("/static/").Handler(http.StripPrefix("/static/", http.FileServer(http.Dir("./web/static"))))
@@ -35,12 +49,54 @@ func NewServer(port string, usecases port.Usecases) *Server {
 	})
 
 	// API маршруты
-	router.HandleFunc("/api/events", handler.GetAllEvents).Methods("GET", "OPTIONS")
+	router.HandleFunc("/api/events", handler.ListEvents).Methods("GET", "OPTIONS")
 	router.HandleFunc("/api/events", handler.CreateEvent).Methods("POST", "OPTIONS")
+	// Маршрут поиска регистрируется до /api/events/{id}, иначе "search" примется за id
+	router.HandleFunc("/api/events/search", handler.SearchEvents).Methods("GET", "OPTIONS")
+	router.HandleFunc("/api/events/stream", streamHandler.AllEventsStream).Methods("GET", "OPTIONS")
+	router.HandleFunc("/api/events/{id}/stream", streamHandler.EventStream).Methods("GET", "OPTIONS")
 	router.HandleFunc("/api/events/{id}/book", handler.BookEvent).Methods("POST", "OPTIONS")
+	router.HandleFunc("/api/events/{id}/queue", waitingRoomHandler.JoinQueue).Methods("POST", "OPTIONS")
+	router.HandleFunc("/api/events/{id}/queue", waitingRoomHandler.QueueStatus).Methods("GET", "OPTIONS")
+	router.HandleFunc("/api/events/{id}/ballot/entries", ballotHandler.EnterBallot).Methods("POST", "OPTIONS")
+	router.HandleFunc("/api/events/{id}/ballot/entries/{user_id}", ballotHandler.GetBallotEntry).Methods("GET", "OPTIONS")
+	router.HandleFunc("/api/events/{id}/bookings", handler.ListEventBookings).Methods("GET", "OPTIONS")
+	router.HandleFunc("/api/events/{id}/bookings/export", handler.ExportEventBookings).Methods("GET", "OPTIONS")
 	router.HandleFunc("/api/events/{id}", handler.GetEvent).Methods("GET", "OPTIONS")
+	router.HandleFunc("/api/events/{id}", handler.UpdateEvent).Methods("PATCH", "OPTIONS")
 	router.HandleFunc("/api/bookings/{id}", handler.GetBooking).Methods("GET", "OPTIONS")
 	router.HandleFunc("/api/bookings/{id}/confirm", handler.ConfirmBooking).Methods("POST", "OPTIONS")
+	router.HandleFunc("/api/bookings/{id}/ws", streamHandler.BookingSocket).Methods("GET")
+	router.HandleFunc("/api/users/{id}/bookings", handler.ListUserBookings).Methods("GET", "OPTIONS")
+
+	// Админские маршруты
+	router.HandleFunc("/api/admin/dead-letters", adminHandler.ListDeadLetters).Methods("GET", "OPTIONS")
+	router.HandleFunc("/api/admin/dead-letters/{id}", adminHandler.GetDeadLetter).Methods("GET", "OPTIONS")
+	router.HandleFunc("/api/admin/dead-letters/{id}", adminHandler.DiscardDeadLetter).Methods("DELETE", "OPTIONS")
+	router.HandleFunc("/api/admin/dead-letters/{id}/replay", adminHandler.ReplayDeadLetter).Methods("POST", "OPTIONS")
+	router.HandleFunc("/api/admin/inventory/reconcile", adminHandler.ReconcileInventory).Methods("POST", "OPTIONS")
+	router.HandleFunc("/api/admin/inventory/unverified/{id}/verify", adminHandler.VerifyCapacity).Methods("POST", "OPTIONS")
+	router.HandleFunc("/api/admin/events/{id}/waiting-room", waitingRoomHandler.GetWaitingRoom).Methods("GET", "OPTIONS")
+	router.HandleFunc("/api/admin/events/{id}/waiting-room", waitingRoomHandler.OpenWaitingRoom).Methods("PUT", "OPTIONS")
+	router.HandleFunc("/api/admin/events/{id}/waiting-room", waitingRoomHandler.CloseWaitingRoom).Methods("DELETE", "OPTIONS")
+	router.HandleFunc("/api/admin/events/{id}/ballot", ballotHandler.GetBallot).Methods("GET", "OPTIONS")
+	router.HandleFunc("/api/admin/events/{id}/ballot", ballotHandler.ConfigureBallot).Methods("PUT", "OPTIONS")
+	router.HandleFunc("/api/admin/events/{id}/ballot/draw", ballotHandler.DrawBallot).Methods("POST", "OPTIONS")
+	router.HandleFunc("/api/admin/events/{id}/ballot/close-waitlist", ballotHandler.CloseWaitlist).Methods("POST", "OPTIONS")
+	router.HandleFunc("/api/admin/events/{id}/ballot/entries", ballotHandler.ListBallotEntries).Methods("GET", "OPTIONS")
+	router.HandleFunc("/api/admin/events/{id}/pricing", pricingHandler.SetPriceSchedule).Methods("PUT", "OPTIONS")
+	router.HandleFunc("/api/admin/promo-codes", promoHandler.ListPromoCodes).Methods("GET", "OPTIONS")
+	router.HandleFunc("/api/admin/promo-codes", promoHandler.CreatePromoCode).Methods("POST", "OPTIONS")
+	router.HandleFunc("/api/admin/promo-codes/{code}", promoHandler.GetPromoCode).Methods("GET", "OPTIONS")
+	router.HandleFunc("/api/admin/promo-codes/{code}/redemptions", promoHandler.ListPromoRedemptions).Methods("GET", "OPTIONS")
+	router.HandleFunc("/api/admin/fee-rules", feeHandler.ListFeeRules).Methods("GET", "OPTIONS")
+	router.HandleFunc("/api/admin/fee-rules/{scope:default}", feeHandler.SetFeeRule).Methods("PUT", "OPTIONS")
+	router.HandleFunc("/api/admin/fee-rules/{scope:default}", feeHandler.DeleteFeeRule).Methods("DELETE", "OPTIONS")
+	router.HandleFunc("/api/admin/fee-rules/{scope:organizers|events}/{id}", feeHandler.SetFeeRule).Methods("PUT", "OPTIONS")
+	router.HandleFunc("/api/admin/fee-rules/{scope:organizers|events}/{id}", feeHandler.DeleteFeeRule).Methods("DELETE", "OPTIONS")
+	router.HandleFunc("/api/admin/audit/bookings/{id}", adminHandler.BookingAuditLog).Methods("GET", "OPTIONS")
+	router.HandleFunc("/api/admin/audit/events/{id}", adminHandler.EventAuditLog).Methods("GET", "OPTIONS")
+	router.HandleFunc("/api/admin/audit/users/{id}", adminHandler.UserAuditLog).Methods("GET", "OPTIONS")
 
 	server := &http.Server{
 		Addr:         port,
@@ -49,18 +105,26 @@ func NewServer(port string, usecases port.Usecases) *Server {
 		WriteTimeout: 60 * time.Second,
 		IdleTimeout:  300 * time.Second,
 	}
+	// Shutdown ждет завершения запросов, а потоки SSE сами не завершаются;
+	// захваченные соединения WebSocket Shutdown не ждет, но закрывать их тоже нужно
+	server.RegisterOnShutdown(sync.OnceFunc(func() { close(streamsDone) }))
 
 	return &Server{
-		handler: handler,
-		server:  server,
+		handler:            handler,
+		adminHandler:       adminHandler,
+		streamHandler:      streamHandler,
+		waitingRoomHandler: waitingRoomHandler,
+		ballotHandler:      ballotHandler,
+		server:             server,
 	}
 }
 
 func corsMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, If-Match, If-None-Match, "+IdempotencyKeyHeader+", "+QueueTokenHeader+", "+AdmissionTokenHeader)
+		w.Header().Set("Access-Control-Expose-Headers", "ETag, Retry-After")
 
 		if r.Method == "OPTIONS" {
 			w.WriteHeader(http.StatusOK)
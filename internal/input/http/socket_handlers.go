@@ -0,0 +1,126 @@
+package http
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/dontpanicw/EventBooker/internal/domain"
+	"github.com/dontpanicw/EventBooker/internal/port"
+	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
+)
+
+// holdTick - как часто WebSocket брони присылает оставшееся время
+const holdTick = time.Second
+
+// BookingSocket - WebSocket одной брони владельца (?user_id=): каждую секунду оставшееся
+// до отмены время, затем итог - оплата или отмена, после которого сервер закрывает
+// соединение. Статусы приходят через брокер, поэтому оплата или отмена на другом
+// экземпляре приложения тоже видны.
+func (h *StreamHandler) BookingSocket(w http.ResponseWriter, r *http.Request) {
+	filter := domain.UpdateFilter{BookingId: mux.Vars(r)["id"], UserId: r.URL.Query().Get("user_id")}
+	if filter.UserId == "" {
+		http.Error(w, "user_id is required", http.StatusBadRequest)
+		return
+	}
+	// Подписываемся до чтения, чтобы не потерять оплату или отмену между чтением и подпиской
+	updates, unsubscribe := h.updates.Subscribe(filter)
+	defer unsubscribe()
+
+	// Из master: бронь могли создать только что
+	booking, err := h.usecases.GetBooking(port.WithStrongConsistency(r.Context()), filter.BookingId)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, domain.ErrBookingNotFound) {
+			status = http.StatusNotFound
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+	// Чужая бронь неотличима от несуществующей
+	if booking.UserId != filter.UserId {
+		http.Error(w, domain.ErrBookingNotFound.Error(), http.StatusNotFound)
+		return
+	}
+
+	conn, err := upgradeWebSocket(w, r)
+	if err != nil {
+		return
+	}
+	readErr := make(chan error, 1)
+	go func() {
+		readErr <- conn.readLoop()
+	}()
+
+	if err := conn.WriteJSON(holdMessage(booking, "", time.Now())); err != nil {
+		conn.Close(websocket.CloseGoingAway, "")
+		return
+	}
+	if booking.Status != domain.PendingStatus {
+		conn.Close(websocket.CloseNormalClosure, booking.Status)
+		return
+	}
+
+	ticker := time.NewTicker(holdTick)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-readErr:
+			// Клиент закрыл соединение или нарушил протокол; кадр закрытия уже отправлен
+			conn.Close(websocket.CloseNormalClosure, "")
+			return
+		case <-h.done:
+			conn.Close(websocket.CloseGoingAway, "server is shutting down")
+			return
+		case now := <-ticker.C:
+			if err := conn.WriteJSON(holdMessage(booking, "", now)); err != nil {
+				conn.Close(websocket.CloseGoingAway, "")
+				return
+			}
+		case update, ok := <-updates:
+			if !ok {
+				// Хаб отключил отстающего подписчика; клиент переподключится и получит текущее состояние
+				conn.Close(websocket.CloseTryAgainLater, "")
+				return
+			}
+			booking.Status = update.Booking.Status
+			if err := conn.WriteJSON(holdMessage(booking, update.Cause, time.Now())); err != nil {
+				conn.Close(websocket.CloseGoingAway, "")
+				return
+			}
+			if booking.Status != domain.PendingStatus {
+				conn.Close(websocket.CloseNormalClosure, booking.Status)
+				return
+			}
+		}
+	}
+}
+
+// holdMessage описывает состояние брони на момент now; cause - доменное событие,
+// которое его изменило, если оно известно
+func holdMessage(booking *domain.Booking, cause string, now time.Time) HoldMessage {
+	msg := HoldMessage{
+		BookingId:  booking.Id,
+		EventId:    booking.EventId,
+		Status:     booking.Status,
+		ServerTime: now.UTC(),
+	}
+	switch booking.Status {
+	case domain.PendingStatus:
+		msg.Type = HoldMessageHold
+		expiresAt := booking.ExpiresAt().UTC()
+		msg.ExpiresAt = &expiresAt
+		msg.RemainingSeconds = int(math.Max(0, math.Ceil(expiresAt.Sub(now).Seconds())))
+	case domain.ConfirmedStatus:
+		msg.Type = HoldMessageConfirmed
+	default:
+		msg.Type = HoldMessageCancelled
+		if cause == domain.BookingExpiredEvent {
+			msg.Type = HoldMessageExpired
+		}
+	}
+	return msg
+}
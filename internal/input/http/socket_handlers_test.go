@@ -0,0 +1,273 @@
+package http
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dontpanicw/EventBooker/internal/domain"
+	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// testSocket - клиент WebSocket для тестов: рукопожатие и чтение кадров сервера
+type testSocket struct {
+	conn net.Conn
+	br   *bufio.Reader
+}
+
+func dialTestSocket(t *testing.T, serverURL, path string) *testSocket {
+	t.Helper()
+	conn, err := net.Dial("tcp", strings.TrimPrefix(serverURL, "http://"))
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	key := "dGhlIHNhbXBsZSBub25jZQ=="
+	fmt.Fprintf(conn, "GET %s HTTP/1.1\r\nHost: test\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n"+
+		"Sec-WebSocket-Key: %s\r\nSec-WebSocket-Version: 13\r\n\r\n", path, key)
+
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, nil)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusSwitchingProtocols, resp.StatusCode)
+	// Значение из примера RFC 6455
+	require.Equal(t, "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=", resp.Header.Get("Sec-WebSocket-Accept"))
+	return &testSocket{conn: conn, br: br}
+}
+
+func (s *testSocket) readFrame(t *testing.T) (byte, []byte) {
+	t.Helper()
+	var head [2]byte
+	_, err := io.ReadFull(s.br, head[:])
+	require.NoError(t, err)
+	length := int(head[1] & 0x7F)
+	if length == 126 {
+		var ext [2]byte
+		_, err := io.ReadFull(s.br, ext[:])
+		require.NoError(t, err)
+		length = int(binary.BigEndian.Uint16(ext[:]))
+	}
+	payload := make([]byte, length)
+	_, err = io.ReadFull(s.br, payload)
+	require.NoError(t, err)
+	return head[0] & 0x0F, payload
+}
+
+func (s *testSocket) readHold(t *testing.T) HoldMessage {
+	t.Helper()
+	opcode, payload := s.readFrame(t)
+	require.Equal(t, byte(websocket.TextMessage), opcode)
+	var msg HoldMessage
+	require.NoError(t, json.Unmarshal(payload, &msg))
+	return msg
+}
+
+func (s *testSocket) readClose(t *testing.T) int {
+	t.Helper()
+	opcode, payload := s.readFrame(t)
+	require.Equal(t, byte(websocket.CloseMessage), opcode)
+	return int(binary.BigEndian.Uint16(payload))
+}
+
+// writeFrame отправляет замаскированный кадр, как обязан клиент
+func (s *testSocket) writeFrame(t *testing.T, opcode int, payload []byte) {
+	t.Helper()
+	s.writeRawFrame(t, 0x80|byte(opcode), payload)
+}
+
+// writeRawFrame отправляет замаскированный кадр с первым байтом head: FIN, RSV-биты и opcode
+func (s *testSocket) writeRawFrame(t *testing.T, head byte, payload []byte) {
+	t.Helper()
+	mask := []byte{1, 2, 3, 4}
+	frame := []byte{head}
+	if len(payload) < 126 {
+		frame = append(frame, 0x80|byte(len(payload)))
+	} else {
+		frame = binary.BigEndian.AppendUint16(append(frame, 0x80|126), uint16(len(payload)))
+	}
+	frame = append(frame, mask...)
+	for i, b := range payload {
+		frame = append(frame, b^mask[i%4])
+	}
+	_, err := s.conn.Write(frame)
+	require.NoError(t, err)
+}
+
+func newSocketServer(t *testing.T, handler *StreamHandler) *httptest.Server {
+	router := mux.NewRouter()
+	router.HandleFunc("/api/bookings/{id}/ws", handler.BookingSocket)
+	server := httptest.NewServer(router)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func pendingBooking() *domain.Booking {
+	return &domain.Booking{
+		Id:      "booking-1",
+		EventId: "event-1",
+		UserId:  "user-1",
+		Status:  domain.PendingStatus,
+		Date:    time.Now().Add(-domain.BookingHold / 2),
+	}
+}
+
+func TestBookingSocket_HoldThenExpired(t *testing.T) {
+	mockUsecases := new(MockUsecases)
+	updates := &fakeUpdatesStream{updates: make(chan *domain.Update, 1)}
+	server := newSocketServer(t, NewStreamHandler(mockUsecases, updates, make(chan struct{})))
+
+	booking := pendingBooking()
+	mockUsecases.On("GetBooking", mock.Anything, "booking-1").Return(booking, nil)
+
+	socket := dialTestSocket(t, server.URL, "/api/bookings/booking-1/ws?user_id=user-1")
+	assert.Equal(t, domain.UpdateFilter{BookingId: "booking-1", UserId: "user-1"}, updates.filter)
+
+	hold := socket.readHold(t)
+	assert.Equal(t, HoldMessageHold, hold.Type)
+	assert.Equal(t, domain.PendingStatus, hold.Status)
+	require.NotNil(t, hold.ExpiresAt)
+	assert.WithinDuration(t, booking.ExpiresAt(), *hold.ExpiresAt, time.Second)
+	assert.InDelta(t, (domain.BookingHold / 2).Seconds(), hold.RemainingSeconds, 2)
+
+	// ping не мешает потоку
+	socket.writeFrame(t, websocket.PingMessage, []byte("ping"))
+	opcode, payload := socket.readFrame(t)
+	assert.Equal(t, byte(websocket.PongMessage), opcode)
+	assert.Equal(t, "ping", string(payload))
+
+	updates.updates <- &domain.Update{
+		Type:    domain.UpdateBooking,
+		EventId: "event-1",
+		Booking: &domain.Booking{Id: "booking-1", EventId: "event-1", UserId: "user-1", Status: domain.CancelledStatus},
+		Cause:   domain.BookingExpiredEvent,
+	}
+	// Пока обновление в пути, сервер может успеть прислать очередной тик
+	msg := socket.readHold(t)
+	for msg.Type == HoldMessageHold {
+		msg = socket.readHold(t)
+	}
+	assert.Equal(t, HoldMessageExpired, msg.Type)
+	assert.Equal(t, domain.CancelledStatus, msg.Status)
+	assert.Nil(t, msg.ExpiresAt)
+	assert.Equal(t, websocket.CloseNormalClosure, socket.readClose(t))
+}
+
+func TestBookingSocket_AlreadyConfirmed(t *testing.T) {
+	mockUsecases := new(MockUsecases)
+	server := newSocketServer(t, NewStreamHandler(mockUsecases, &fakeUpdatesStream{}, make(chan struct{})))
+
+	booking := pendingBooking()
+	booking.Status = domain.ConfirmedStatus
+	mockUsecases.On("GetBooking", mock.Anything, "booking-1").Return(booking, nil)
+
+	socket := dialTestSocket(t, server.URL, "/api/bookings/booking-1/ws?user_id=user-1")
+	msg := socket.readHold(t)
+	assert.Equal(t, HoldMessageConfirmed, msg.Type)
+	assert.Equal(t, 0, msg.RemainingSeconds)
+	assert.Equal(t, websocket.CloseNormalClosure, socket.readClose(t))
+}
+
+func TestBookingSocket_ClosesOnShutdown(t *testing.T) {
+	mockUsecases := new(MockUsecases)
+	done := make(chan struct{})
+	server := newSocketServer(t, NewStreamHandler(mockUsecases, &fakeUpdatesStream{}, done))
+
+	mockUsecases.On("GetBooking", mock.Anything, "booking-1").Return(pendingBooking(), nil)
+
+	socket := dialTestSocket(t, server.URL, "/api/bookings/booking-1/ws?user_id=user-1")
+	assert.Equal(t, HoldMessageHold, socket.readHold(t).Type)
+	close(done)
+
+	opcode, payload := socket.readFrame(t)
+	for opcode == websocket.TextMessage {
+		opcode, payload = socket.readFrame(t)
+	}
+	assert.Equal(t, byte(websocket.CloseMessage), opcode)
+	assert.Equal(t, websocket.CloseGoingAway, int(binary.BigEndian.Uint16(payload)))
+}
+
+func TestBookingSocket_ProtocolViolations(t *testing.T) {
+	tests := []struct {
+		name    string
+		head    byte
+		payload []byte
+		code    int
+	}{
+		{"rsv bit", 0x80 | 0x40 | websocket.TextMessage, []byte("x"), websocket.CloseProtocolError},
+		{"fragmented ping", websocket.PingMessage, []byte("ping"), websocket.CloseProtocolError},
+		{"oversized message", 0x80 | websocket.TextMessage, make([]byte, wsMaxMessageSize+1), websocket.CloseMessageTooBig},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockUsecases := new(MockUsecases)
+			server := newSocketServer(t, NewStreamHandler(mockUsecases, &fakeUpdatesStream{}, make(chan struct{})))
+			mockUsecases.On("GetBooking", mock.Anything, "booking-1").Return(pendingBooking(), nil)
+
+			socket := dialTestSocket(t, server.URL, "/api/bookings/booking-1/ws?user_id=user-1")
+			assert.Equal(t, HoldMessageHold, socket.readHold(t).Type)
+			socket.writeRawFrame(t, tt.head, tt.payload)
+
+			opcode, payload := socket.readFrame(t)
+			for opcode == websocket.TextMessage {
+				opcode, payload = socket.readFrame(t)
+			}
+			assert.Equal(t, byte(websocket.CloseMessage), opcode)
+			assert.Equal(t, tt.code, int(binary.BigEndian.Uint16(payload)))
+		})
+	}
+}
+
+func TestBookingSocket_Rejected(t *testing.T) {
+	mockUsecases := new(MockUsecases)
+	handler := NewStreamHandler(mockUsecases, &fakeUpdatesStream{}, make(chan struct{}))
+
+	mockUsecases.On("GetBooking", mock.Anything, "booking-1").Return(pendingBooking(), nil)
+	mockUsecases.On("GetBooking", mock.Anything, "missing").
+		Return(nil, fmt.Errorf("failed to get booking: %w", domain.ErrBookingNotFound))
+
+	tests := []struct {
+		name    string
+		id      string
+		query   string
+		upgrade bool
+		origin  string
+		status  int
+	}{
+		{"no user", "booking-1", "", true, "", http.StatusBadRequest},
+		{"other user", "booking-1", "?user_id=user-2", true, "", http.StatusNotFound},
+		{"missing booking", "missing", "?user_id=user-1", true, "", http.StatusNotFound},
+		{"not a websocket", "booking-1", "?user_id=user-1", false, "", http.StatusBadRequest},
+		{"cross-origin", "booking-1", "?user_id=user-1", true, "https://evil.example", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/bookings/"+tt.id+"/ws"+tt.query, nil)
+			req = mux.SetURLVars(req, map[string]string{"id": tt.id})
+			if tt.upgrade {
+				req.Header.Set("Connection", "Upgrade")
+				req.Header.Set("Upgrade", "websocket")
+				req.Header.Set("Sec-WebSocket-Version", "13")
+				req.Header.Set("Sec-WebSocket-Key", "dGhlIHNhbXBsZSBub25jZQ==")
+			}
+			if tt.origin != "" {
+				req.Header.Set("Origin", tt.origin)
+			}
+			w := httptest.NewRecorder()
+			handler.BookingSocket(w, req)
+
+			assert.Equal(t, tt.status, w.Code)
+		})
+	}
+}
@@ -0,0 +1,160 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/dontpanicw/EventBooker/internal/domain"
+	"github.com/dontpanicw/EventBooker/internal/port"
+	"github.com/gorilla/mux"
+)
+
+const (
+	// streamHeartbeat - период комментариев-пингов: прокси не закрывают простаивающее
+	// соединение, а сервер замечает отключившегося клиента
+	streamHeartbeat = 15 * time.Second
+	// streamRetry - через сколько миллисекунд EventSource переподключается после обрыва
+	streamRetry = 3000
+)
+
+// StreamHandler отдает обновления мест и статусов броней как Server-Sent Events
+type StreamHandler struct {
+	usecases port.Usecases
+	updates  port.UpdatesStream
+	// done закрывается в начале Server.Stop, чтобы потоки не держали остановку сервера
+	done <-chan struct{}
+}
+
+func NewStreamHandler(usecases port.Usecases, updates port.UpdatesStream, done <-chan struct{}) *StreamHandler {
+	return &StreamHandler{
+		usecases: usecases,
+		updates:  updates,
+		done:     done,
+	}
+}
+
+// AllEventsStream - обновления всех мероприятий; с ?user_id= - и статусы броней пользователя
+func (h *StreamHandler) AllEventsStream(w http.ResponseWriter, r *http.Request) {
+	filter := domain.UpdateFilter{UserId: r.URL.Query().Get("user_id")}
+	updates, unsubscribe := h.updates.Subscribe(filter)
+	defer unsubscribe()
+
+	h.serve(w, r, updates, nil)
+}
+
+// EventStream - обновления одного мероприятия. Первым сообщением идут текущие счетчики,
+// поэтому после переподключения клиенту не нужно перечитывать мероприятие.
+func (h *StreamHandler) EventStream(w http.ResponseWriter, r *http.Request) {
+	filter := domain.UpdateFilter{EventId: mux.Vars(r)["id"], UserId: r.URL.Query().Get("user_id")}
+	// Подписываемся до чтения, чтобы не потерять изменения между чтением и подпиской
+	updates, unsubscribe := h.updates.Subscribe(filter)
+	defer unsubscribe()
+
+	event, err := h.usecases.GetEvent(r.Context(), filter.EventId)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, domain.ErrEventNotFound) {
+			status = http.StatusNotFound
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	h.serve(w, r, updates, &domain.Update{Type: domain.UpdateAvailability, EventId: event.Id, Event: event})
+}
+
+// serve пишет поток, пока клиент не отключится, сервер не начнет остановку
+// или хаб не закроет канал медленного подписчика
+func (h *StreamHandler) serve(w http.ResponseWriter, r *http.Request, updates <-chan *domain.Update, snapshot *domain.Update) {
+	rc := http.NewResponseController(w)
+	// Поток живет дольше WriteTimeout сервера
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		log.Printf("Failed to disable write deadline for stream: %v", err)
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	if _, err := fmt.Fprintf(w, "retry: %d\n\n", streamRetry); err != nil {
+		return
+	}
+	if snapshot != nil {
+		if err := writeUpdate(w, snapshot); err != nil {
+			return
+		}
+	}
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		var err error
+		select {
+		case <-r.Context().Done():
+			return
+		case <-h.done:
+			return
+		case <-heartbeat.C:
+			_, err = fmt.Fprint(w, ": heartbeat\n\n")
+		case update, ok := <-updates:
+			if !ok {
+				return
+			}
+			err = writeUpdate(w, update)
+		}
+		if err == nil {
+			err = rc.Flush()
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// writeUpdate пишет одно сообщение SSE: имя - тип обновления, данные - JSON
+func writeUpdate(w http.ResponseWriter, update *domain.Update) error {
+	var data any
+	switch update.Type {
+	case domain.UpdateAvailability:
+		data = AvailabilityMessage{
+			EventId:          update.Event.Id,
+			AvailableTickets: update.Event.AvailableTickets,
+			Capacity:         update.Event.Capacity,
+			Sold:             update.Event.Sold,
+			Held:             update.Event.Held,
+			Version:          update.Event.Version,
+		}
+	case domain.UpdateBooking:
+		data = BookingStatusMessage{
+			BookingId: update.Booking.Id,
+			EventId:   update.Booking.EventId,
+			Status:    update.Booking.Status,
+			Cause:     update.Cause,
+		}
+	case domain.UpdateBallot:
+		data = BallotResultMessage{
+			EventId:   update.Entry.EventId,
+			Status:    update.Entry.Status,
+			Rank:      update.Entry.Rank,
+			BookingId: update.Entry.BookingId,
+		}
+	default:
+		return nil
+	}
+
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", update.Type, payload)
+	return err
+}
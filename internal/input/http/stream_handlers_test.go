@@ -0,0 +1,116 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dontpanicw/EventBooker/internal/domain"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// fakeUpdatesStream отдает подписчику заранее заполненный канал
+type fakeUpdatesStream struct {
+	updates chan *domain.Update
+	filter  domain.UpdateFilter
+}
+
+func (f *fakeUpdatesStream) Subscribe(filter domain.UpdateFilter) (<-chan *domain.Update, func()) {
+	f.filter = filter
+	return f.updates, func() {}
+}
+
+func TestEventStream_SnapshotAndUpdates(t *testing.T) {
+	mockUsecases := new(MockUsecases)
+	updates := &fakeUpdatesStream{updates: make(chan *domain.Update, 2)}
+	handler := NewStreamHandler(mockUsecases, updates, make(chan struct{}))
+
+	mockUsecases.On("GetEvent", mock.Anything, "event-123").
+		Return(&domain.Event{Id: "event-123", AvailableTickets: 2, Capacity: 3, Held: 1, Version: 2}, nil)
+	updates.updates <- &domain.Update{
+		Type:    domain.UpdateBooking,
+		EventId: "event-123",
+		Booking: &domain.Booking{Id: "booking-1", UserId: "user-1", EventId: "event-123", Status: domain.ConfirmedStatus},
+		Cause:   domain.BookingConfirmedEvent,
+	}
+	// Закрытый канал - хаб отключил подписчика, поток завершается
+	close(updates.updates)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/events/event-123/stream?user_id=user-1", nil)
+	req = mux.SetURLVars(req, map[string]string{"id": "event-123"})
+	w := httptest.NewRecorder()
+	handler.EventStream(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/event-stream", w.Header().Get("Content-Type"))
+	assert.Equal(t, domain.UpdateFilter{EventId: "event-123", UserId: "user-1"}, updates.filter)
+	assert.Equal(t, fmt.Sprintf("retry: %d\n\n", streamRetry)+
+		"event: availability\n"+
+		`data: {"event_id":"event-123","available_tickets":2,"capacity":3,"sold":0,"held":1,"version":2}`+"\n\n"+
+		"event: booking\n"+
+		`data: {"booking_id":"booking-1","event_id":"event-123","status":"confirmed","cause":"booking.confirmed"}`+"\n\n",
+		w.Body.String())
+	mockUsecases.AssertExpectations(t)
+}
+
+func TestEventStream_NotFound(t *testing.T) {
+	mockUsecases := new(MockUsecases)
+	handler := NewStreamHandler(mockUsecases, &fakeUpdatesStream{}, make(chan struct{}))
+
+	mockUsecases.On("GetEvent", mock.Anything, "event-123").
+		Return(nil, fmt.Errorf("failed to confirm event: %w", domain.ErrEventNotFound))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/events/event-123/stream", nil)
+	req = mux.SetURLVars(req, map[string]string{"id": "event-123"})
+	w := httptest.NewRecorder()
+	handler.EventStream(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestAllEventsStream_StopsOnShutdown(t *testing.T) {
+	updates := &fakeUpdatesStream{updates: make(chan *domain.Update)}
+	done := make(chan struct{})
+	handler := NewStreamHandler(new(MockUsecases), updates, done)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/events/stream", nil)
+	w := httptest.NewRecorder()
+	finished := make(chan struct{})
+	go func() {
+		handler.AllEventsStream(w, req)
+		close(finished)
+	}()
+
+	close(done)
+	select {
+	case <-finished:
+	case <-time.After(time.Second):
+		t.Fatal("stream did not stop on shutdown")
+	}
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, domain.UpdateFilter{}, updates.filter)
+}
+
+func TestAllEventsStream_StopsOnDisconnect(t *testing.T) {
+	handler := NewStreamHandler(new(MockUsecases), &fakeUpdatesStream{updates: make(chan *domain.Update)}, make(chan struct{}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req := httptest.NewRequest(http.MethodGet, "/api/events/stream", nil).WithContext(ctx)
+	finished := make(chan struct{})
+	go func() {
+		handler.AllEventsStream(httptest.NewRecorder(), req)
+		close(finished)
+	}()
+
+	cancel()
+	select {
+	case <-finished:
+	case <-time.After(time.Second):
+		t.Fatal("stream did not stop after client disconnect")
+	}
+}
@@ -1,16 +1,348 @@
 package http
 
-import "time"
+import (
+	"github.com/dontpanicw/EventBooker/internal/domain"
+	"math"
+	"time"
+)
 
 type CreateEventRequest struct {
 	Name             string    `json:"name"`
 	Description      string    `json:"description"`
 	IsFree           bool      `json:"is_free"`
-	Price            float64   `json:"price"`
+	Price            int64     `json:"price"`    // в минимальных единицах валюты (копейках, центах)
+	Currency         string    `json:"currency"` // ISO 4217, по умолчанию RUB
 	AvailableTickets uint32    `json:"available_tickets"`
+	Capacity         uint32    `json:"capacity"` // если не задана, равна available_tickets
 	Date             time.Time `json:"date"`
+	SaleMode         string    `json:"sale_mode"`    // first_come (по умолчанию) или ballot
+	OrganizerId      string    `json:"organizer_id"` // необязательный; для правил сбора организатора
+}
+
+// UpdateEventRequest - тело PATCH /api/events/{id}; отсутствующие поля не меняются.
+// Price - в минимальных единицах валюты мероприятия, валюту сменить нельзя.
+type UpdateEventRequest struct {
+	Name        *string `json:"name"`
+	Description *string `json:"description"`
+	IsFree      *bool   `json:"is_free"`
+	Price       *int64  `json:"price"`
+}
+
+// EventsPageResponse - страница GET /api/events; next_cursor передается в ?cursor= за следующей
+type EventsPageResponse struct {
+	Events     []*domain.Event `json:"events"`
+	NextCursor string          `json:"next_cursor,omitempty"`
+}
+
+// EventSearchResponse - ответ GET /api/events/search, результаты по убыванию релевантности
+type EventSearchResponse struct {
+	Results []EventSearchResult `json:"results"`
+}
+
+// EventSearchResult - найденное событие; подсветки экранированы для HTML, совпадения в <mark>
+type EventSearchResult struct {
+	Event                *domain.Event `json:"event"`
+	Rank                 float64       `json:"rank"`
+	NameHighlight        string        `json:"name_highlight"`
+	DescriptionHighlight string        `json:"description_highlight"`
 }
 
 type BookEventRequest struct {
 	UserId string `json:"user_id"`
+	// PromoCode - необязательный промокод; регистр не важен
+	PromoCode string `json:"promo_code"`
+}
+
+// BookingsPageResponse - страница броней пользователя или мероприятия; next_cursor передается в ?cursor=
+type BookingsPageResponse struct {
+	Bookings   []BookingResponse `json:"bookings"`
+	NextCursor string            `json:"next_cursor,omitempty"`
+}
+
+// BookingResponse - бронь с мероприятием. У pending-брони expires_at - момент автоматической
+// отмены, remaining_seconds - сколько осталось на оплату.
+type BookingResponse struct {
+	Booking          *domain.Booking `json:"booking"`
+	Event            *domain.Event   `json:"event"`
+	ExpiresAt        *time.Time      `json:"expires_at,omitempty"`
+	RemainingSeconds int             `json:"remaining_seconds"`
+}
+
+// AvailabilityMessage - данные сообщения availability в потоке обновлений; сообщения
+// с version меньше уже полученной клиент может пропустить
+type AvailabilityMessage struct {
+	EventId          string `json:"event_id"`
+	AvailableTickets uint32 `json:"available_tickets"`
+	Capacity         uint32 `json:"capacity"`
+	Sold             uint32 `json:"sold"`
+	Held             uint32 `json:"held"`
+	Version          int64  `json:"version"`
+}
+
+// BookingStatusMessage - данные сообщения booking; cause - доменное событие
+// (booking.created, booking.confirmed, booking.expired, booking.cancelled)
+type BookingStatusMessage struct {
+	BookingId string `json:"booking_id"`
+	EventId   string `json:"event_id"`
+	Status    string `json:"status"`
+	Cause     string `json:"cause"`
+}
+
+// BallotResultMessage - данные сообщения ballot: итог розыгрыша для участника;
+// у выигравшего booking_id - pending-бронь, которую нужно оплатить
+type BallotResultMessage struct {
+	EventId   string `json:"event_id"`
+	Status    string `json:"status"`
+	Rank      int    `json:"rank"`
+	BookingId string `json:"booking_id,omitempty"`
+}
+
+// Типы сообщений WebSocket брони
+const (
+	HoldMessageHold      = "hold"      // бронь ждет оплаты, remaining_seconds - сколько осталось
+	HoldMessageConfirmed = "confirmed" // оплачена
+	HoldMessageExpired   = "expired"   // отменена: не оплачена вовремя
+	HoldMessageCancelled = "cancelled" // отменена
+)
+
+// HoldMessage - сообщение WebSocket о брони. Время считает сервер, клиенту не нужно
+// полагаться на свои часы; server_time позволяет оценить расхождение.
+type HoldMessage struct {
+	Type             string     `json:"type"`
+	BookingId        string     `json:"booking_id"`
+	EventId          string     `json:"event_id"`
+	Status           string     `json:"status"`
+	ExpiresAt        *time.Time `json:"expires_at,omitempty"`
+	RemainingSeconds int        `json:"remaining_seconds"`
+	ServerTime       time.Time  `json:"server_time"`
+}
+
+// WaitingRoomRequest - открыть очередь мероприятия или изменить скорость допуска
+type WaitingRoomRequest struct {
+	RatePerMinute int `json:"rate_per_minute"`
+}
+
+type WaitingRoomResponse struct {
+	EventId       string    `json:"event_id"`
+	RatePerMinute int       `json:"rate_per_minute"`
+	Joined        int64     `json:"joined"`
+	LastAdmitAt   time.Time `json:"last_admit_at"`
+	OpenedAt      time.Time `json:"opened_at"`
+}
+
+func NewWaitingRoomResponse(room *domain.WaitingRoom) WaitingRoomResponse {
+	return WaitingRoomResponse{
+		EventId:       room.EventId,
+		RatePerMinute: room.RatePerMinute,
+		Joined:        room.Joined,
+		LastAdmitAt:   room.LastAdmitAt,
+		OpenedAt:      room.OpenedAt,
+	}
+}
+
+type JoinQueueRequest struct {
+	UserId string `json:"user_id"`
+}
+
+// QueueStatusResponse - место в очереди. admission_token передается в X-Admission-Token
+// при бронировании; пустой при admitted - очередь закрыта и допуск не нужен.
+type QueueStatusResponse struct {
+	EventId        string    `json:"event_id"`
+	Position       int64     `json:"position"`
+	AdmitAt        time.Time `json:"admit_at"`
+	WaitSeconds    int       `json:"wait_seconds"`
+	ExpiresAt      time.Time `json:"expires_at"`
+	Admitted       bool      `json:"admitted"`
+	QueueToken     string    `json:"queue_token"`
+	AdmissionToken string    `json:"admission_token,omitempty"`
+}
+
+func NewQueueStatusResponse(status *domain.QueueStatus, now time.Time) QueueStatusResponse {
+	wait := 0
+	if !status.Admitted {
+		wait = int(math.Ceil(status.Ticket.AdmitAt.Sub(now).Seconds()))
+	}
+	return QueueStatusResponse{
+		EventId:        status.Ticket.EventId,
+		Position:       status.Ticket.Position,
+		AdmitAt:        status.Ticket.AdmitAt,
+		WaitSeconds:    wait,
+		ExpiresAt:      status.Ticket.ExpiresAt(),
+		Admitted:       status.Admitted,
+		QueueToken:     status.QueueToken,
+		AdmissionToken: status.AdmissionToken,
+	}
+}
+
+// BallotRequest - окно приема заявок на розыгрыш и лист ожидания
+type BallotRequest struct {
+	EntryOpensAt  time.Time `json:"entry_opens_at"`
+	EntryClosesAt time.Time `json:"entry_closes_at"`
+	Waitlist      bool      `json:"waitlist"`
+}
+
+// BallotResponse - розыгрыш; seed и drawn_at появляются после розыгрыша
+type BallotResponse struct {
+	EventId       string     `json:"event_id"`
+	EntryOpensAt  time.Time  `json:"entry_opens_at"`
+	EntryClosesAt time.Time  `json:"entry_closes_at"`
+	Waitlist      bool       `json:"waitlist"`
+	Entries       int        `json:"entries"`
+	Seed          *int64     `json:"seed,omitempty"`
+	DrawnAt       *time.Time `json:"drawn_at,omitempty"`
+}
+
+func NewBallotResponse(ballot *domain.Ballot) BallotResponse {
+	resp := BallotResponse{
+		EventId:       ballot.EventId,
+		EntryOpensAt:  ballot.EntryOpensAt,
+		EntryClosesAt: ballot.EntryClosesAt,
+		Waitlist:      ballot.Waitlist,
+		Entries:       ballot.Entries,
+		DrawnAt:       ballot.DrawnAt,
+	}
+	if ballot.Drawn() {
+		seed := ballot.Seed
+		resp.Seed = &seed
+	}
+	return resp
+}
+
+type EnterBallotRequest struct {
+	UserId string `json:"user_id"`
+}
+
+// BallotEntryResponse - заявка на розыгрыш; rank - место в порядке розыгрыша (0 до него)
+type BallotEntryResponse struct {
+	EventId   string    `json:"event_id"`
+	UserId    string    `json:"user_id"`
+	Status    string    `json:"status"`
+	Rank      int       `json:"rank"`
+	BookingId string    `json:"booking_id,omitempty"`
+	EnteredAt time.Time `json:"entered_at"`
+}
+
+func NewBallotEntryResponse(entry *domain.BallotEntry) BallotEntryResponse {
+	return BallotEntryResponse{
+		EventId:   entry.EventId,
+		UserId:    entry.UserId,
+		Status:    entry.Status,
+		Rank:      entry.Rank,
+		BookingId: entry.BookingId,
+		EnteredAt: entry.EnteredAt,
+	}
+}
+
+// PromoCodeRequest - новый промокод; пустой event_id - для всех мероприятий,
+// max_uses 0 - без ограничения, valid_from и valid_until необязательны.
+// Скидка percent задается в percent, fixed - в amount (минимальные единицы и валюта).
+type PromoCodeRequest struct {
+	Code         string        `json:"code"`
+	EventId      string        `json:"event_id"`
+	DiscountType string        `json:"discount_type"` // percent или fixed
+	Percent      float64       `json:"percent"`
+	Amount       *domain.Money `json:"amount"`
+	MaxUses      int           `json:"max_uses"`
+	ValidFrom    *time.Time    `json:"valid_from"`
+	ValidUntil   *time.Time    `json:"valid_until"`
+}
+
+// PromoCodeResponse - промокод с использованиями неотмененными бронями и суммами их скидок
+// по валютам
+type PromoCodeResponse struct {
+	Code          string         `json:"code"`
+	EventId       string         `json:"event_id,omitempty"`
+	DiscountType  string         `json:"discount_type"`
+	Percent       float64        `json:"percent,omitempty"`
+	Amount        *domain.Money  `json:"amount,omitempty"`
+	MaxUses       int            `json:"max_uses"`
+	ValidFrom     *time.Time     `json:"valid_from,omitempty"`
+	ValidUntil    *time.Time     `json:"valid_until,omitempty"`
+	CreatedAt     time.Time      `json:"created_at"`
+	Uses          int            `json:"uses"`
+	TotalDiscount []domain.Money `json:"total_discount"`
+}
+
+func NewPromoCodeResponse(promo *domain.PromoCode) PromoCodeResponse {
+	resp := PromoCodeResponse{
+		Code:          promo.Code,
+		EventId:       promo.EventId,
+		DiscountType:  promo.DiscountType,
+		Percent:       promo.Percent,
+		MaxUses:       promo.MaxUses,
+		CreatedAt:     promo.CreatedAt,
+		Uses:          promo.Uses,
+		TotalDiscount: promo.TotalDiscount,
+	}
+	if resp.TotalDiscount == nil {
+		resp.TotalDiscount = []domain.Money{}
+	}
+	if promo.DiscountType == domain.DiscountFixed {
+		resp.Amount = &promo.Amount
+	}
+	if !promo.ValidFrom.IsZero() {
+		resp.ValidFrom = &promo.ValidFrom
+	}
+	if !promo.ValidUntil.IsZero() {
+		resp.ValidUntil = &promo.ValidUntil
+	}
+	return resp
+}
+
+// PromoRedemptionResponse - использование промокода; у отмененной брони оно не считается
+type PromoRedemptionResponse struct {
+	BookingId     string       `json:"booking_id"`
+	UserId        string       `json:"user_id"`
+	EventId       string       `json:"event_id"`
+	Discount      domain.Money `json:"discount"`
+	BookingStatus string       `json:"booking_status"`
+	RedeemedAt    time.Time    `json:"redeemed_at"`
+}
+
+// PriceScheduleRequest - расписание цены мероприятия; пустой список phases возвращает
+// постоянную цену. Ступени проверяются по порядку, действует первая незакончившаяся.
+type PriceScheduleRequest struct {
+	Phases []PricePhaseRequest `json:"phases"`
+}
+
+// PricePhaseRequest - ступень цены: действует до until и пока продано меньше max_sold
+// билетов; пропущенные ограничения не действуют
+type PricePhaseRequest struct {
+	Name    string     `json:"name"`
+	Price   int64      `json:"price"` // в минимальных единицах валюты мероприятия
+	Until   *time.Time `json:"until"`
+	MaxSold uint32     `json:"max_sold"`
+}
+
+// FeeRuleRequest - сервисный сбор и НДС: fee_percent процентов от цены билета со скидкой
+// плюс fixed_fee (минимальные единицы и валюта), НДС - vat_percent процентов от цены со сбором;
+// без vat_percent действует ставка правила организатора, затем общего
+type FeeRuleRequest struct {
+	FixedFee   *domain.Money `json:"fixed_fee"`
+	FeePercent float64       `json:"fee_percent"`
+	VatPercent *float64      `json:"vat_percent"`
+}
+
+// FeeRuleResponse - правило сбора; scope - default, organizer или event
+type FeeRuleResponse struct {
+	Scope      string        `json:"scope"`
+	ScopeId    string        `json:"scope_id,omitempty"`
+	FixedFee   *domain.Money `json:"fixed_fee,omitempty"`
+	FeePercent float64       `json:"fee_percent"`
+	VatPercent *float64      `json:"vat_percent,omitempty"`
+	UpdatedAt  time.Time     `json:"updated_at"`
+}
+
+func NewFeeRuleResponse(rule *domain.FeeRule) FeeRuleResponse {
+	resp := FeeRuleResponse{
+		Scope:      rule.Scope,
+		ScopeId:    rule.ScopeId,
+		FeePercent: rule.FeePercent,
+		VatPercent: rule.VatPercent,
+		UpdatedAt:  rule.UpdatedAt,
+	}
+	if rule.FixedFee.Amount > 0 {
+		resp.FixedFee = &rule.FixedFee
+	}
+	return resp
 }
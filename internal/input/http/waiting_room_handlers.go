@@ -0,0 +1,139 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/dontpanicw/EventBooker/internal/domain"
+	"github.com/dontpanicw/EventBooker/internal/port"
+	"github.com/gorilla/mux"
+)
+
+const (
+	// QueueTokenHeader - токен места в очереди при проверке статуса
+	QueueTokenHeader = "X-Queue-Token"
+	// AdmissionTokenHeader - токен допуска при бронировании, пока очередь открыта
+	AdmissionTokenHeader = "X-Admission-Token"
+)
+
+// maxQueueRetryAfter - предел Retry-After: клиент не должен пропустить закрытие очереди
+const maxQueueRetryAfter = 30
+
+// WaitingRoomHandler - очередь на бронирование: вход и статус для пользователей,
+// открытие и закрытие для администраторов
+type WaitingRoomHandler struct {
+	rooms port.WaitingRoomUsecases
+}
+
+func NewWaitingRoomHandler(rooms port.WaitingRoomUsecases) *WaitingRoomHandler {
+	return &WaitingRoomHandler{
+		rooms: rooms,
+	}
+}
+
+// JoinQueue ставит пользователя в очередь и отдает токен места
+func (h *WaitingRoomHandler) JoinQueue(w http.ResponseWriter, r *http.Request) {
+	var req JoinQueueRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.UserId == "" {
+		http.Error(w, "user_id is required", http.StatusBadRequest)
+		return
+	}
+
+	status, err := h.rooms.JoinQueue(r.Context(), mux.Vars(r)["id"], req.UserId)
+	if err != nil {
+		http.Error(w, err.Error(), waitingRoomErrorStatus(err))
+		return
+	}
+	writeQueueStatus(w, status, http.StatusCreated)
+}
+
+// QueueStatus - место в очереди по токену из X-Queue-Token; когда очередь подошла,
+// в ответе токен допуска
+func (h *WaitingRoomHandler) QueueStatus(w http.ResponseWriter, r *http.Request) {
+	token := r.Header.Get(QueueTokenHeader)
+	if token == "" {
+		http.Error(w, QueueTokenHeader+" header is required", http.StatusBadRequest)
+		return
+	}
+
+	status, err := h.rooms.QueueStatus(r.Context(), mux.Vars(r)["id"], token)
+	if err != nil {
+		http.Error(w, err.Error(), waitingRoomErrorStatus(err))
+		return
+	}
+	writeQueueStatus(w, status, http.StatusOK)
+}
+
+func writeQueueStatus(w http.ResponseWriter, status *domain.QueueStatus, code int) {
+	resp := NewQueueStatusResponse(status, time.Now())
+	if !resp.Admitted {
+		w.Header().Set("Retry-After", strconv.Itoa(min(max(resp.WaitSeconds, 1), maxQueueRetryAfter)))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(resp)
+}
+
+// OpenWaitingRoom открывает очередь или меняет скорость допуска
+func (h *WaitingRoomHandler) OpenWaitingRoom(w http.ResponseWriter, r *http.Request) {
+	var req WaitingRoomRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	room, err := h.rooms.OpenWaitingRoom(r.Context(), &domain.WaitingRoom{
+		EventId:       mux.Vars(r)["id"],
+		RatePerMinute: req.RatePerMinute,
+	})
+	if err != nil {
+		http.Error(w, err.Error(), waitingRoomErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(NewWaitingRoomResponse(room))
+}
+
+func (h *WaitingRoomHandler) GetWaitingRoom(w http.ResponseWriter, r *http.Request) {
+	room, err := h.rooms.GetWaitingRoom(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, err.Error(), waitingRoomErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(NewWaitingRoomResponse(room))
+}
+
+func (h *WaitingRoomHandler) CloseWaitingRoom(w http.ResponseWriter, r *http.Request) {
+	if err := h.rooms.CloseWaitingRoom(r.Context(), mux.Vars(r)["id"]); err != nil {
+		http.Error(w, err.Error(), waitingRoomErrorStatus(err))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func waitingRoomErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, domain.ErrEventNotFound), errors.Is(err, domain.ErrWaitingRoomNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, domain.ErrInvalidWaitingRoom):
+		return http.StatusBadRequest
+	case errors.Is(err, domain.ErrInvalidQueueToken):
+		return http.StatusForbidden
+	case errors.Is(err, domain.ErrQueueTokenExpired):
+		return http.StatusGone
+	}
+	return http.StatusInternalServerError
+}
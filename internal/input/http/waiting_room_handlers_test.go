@@ -0,0 +1,244 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dontpanicw/EventBooker/internal/domain"
+	"github.com/dontpanicw/EventBooker/internal/port"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockWaitingRoomUsecases - мок usecases очереди
+type MockWaitingRoomUsecases struct {
+	mock.Mock
+}
+
+func (m *MockWaitingRoomUsecases) OpenWaitingRoom(ctx context.Context, room *domain.WaitingRoom) (*domain.WaitingRoom, error) {
+	args := m.Called(ctx, room)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.WaitingRoom), args.Error(1)
+}
+
+func (m *MockWaitingRoomUsecases) GetWaitingRoom(ctx context.Context, eventID string) (*domain.WaitingRoom, error) {
+	args := m.Called(ctx, eventID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.WaitingRoom), args.Error(1)
+}
+
+func (m *MockWaitingRoomUsecases) CloseWaitingRoom(ctx context.Context, eventID string) error {
+	args := m.Called(ctx, eventID)
+	return args.Error(0)
+}
+
+func (m *MockWaitingRoomUsecases) JoinQueue(ctx context.Context, eventID, userID string) (*domain.QueueStatus, error) {
+	args := m.Called(ctx, eventID, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.QueueStatus), args.Error(1)
+}
+
+func (m *MockWaitingRoomUsecases) QueueStatus(ctx context.Context, eventID, queueToken string) (*domain.QueueStatus, error) {
+	args := m.Called(ctx, eventID, queueToken)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.QueueStatus), args.Error(1)
+}
+
+func (m *MockWaitingRoomUsecases) CheckAdmission(ctx context.Context, booking *domain.Booking) error {
+	args := m.Called(ctx, booking)
+	return args.Error(0)
+}
+
+func TestJoinQueue_Waiting(t *testing.T) {
+	mockRooms := new(MockWaitingRoomUsecases)
+	handler := NewWaitingRoomHandler(mockRooms)
+
+	body, _ := json.Marshal(JoinQueueRequest{UserId: "user-123"})
+	req := httptest.NewRequest(http.MethodPost, "/api/events/event-123/queue", bytes.NewBuffer(body))
+	req = mux.SetURLVars(req, map[string]string{"id": "event-123"})
+	w := httptest.NewRecorder()
+
+	mockRooms.On("JoinQueue", mock.Anything, "event-123", "user-123").Return(&domain.QueueStatus{
+		Ticket:     domain.QueueTicket{EventId: "event-123", UserId: "user-123", Position: 42, AdmitAt: time.Now().Add(2 * time.Minute)},
+		QueueToken: "queue-token",
+	}, nil)
+
+	handler.JoinQueue(w, req)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, "30", w.Header().Get("Retry-After"))
+
+	var response QueueStatusResponse
+	json.Unmarshal(w.Body.Bytes(), &response)
+	assert.Equal(t, int64(42), response.Position)
+	assert.False(t, response.Admitted)
+	assert.Equal(t, "queue-token", response.QueueToken)
+	assert.InDelta(t, 120, response.WaitSeconds, 2)
+	mockRooms.AssertExpectations(t)
+}
+
+func TestJoinQueue_MissingUser(t *testing.T) {
+	handler := NewWaitingRoomHandler(new(MockWaitingRoomUsecases))
+
+	req := httptest.NewRequest(http.MethodPost, "/api/events/event-123/queue", bytes.NewBufferString(`{}`))
+	req = mux.SetURLVars(req, map[string]string{"id": "event-123"})
+	w := httptest.NewRecorder()
+
+	handler.JoinQueue(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestQueueStatus_Admitted(t *testing.T) {
+	mockRooms := new(MockWaitingRoomUsecases)
+	handler := NewWaitingRoomHandler(mockRooms)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/events/event-123/queue", nil)
+	req.Header.Set(QueueTokenHeader, "queue-token")
+	req = mux.SetURLVars(req, map[string]string{"id": "event-123"})
+	w := httptest.NewRecorder()
+
+	mockRooms.On("QueueStatus", mock.Anything, "event-123", "queue-token").Return(&domain.QueueStatus{
+		Ticket:         domain.QueueTicket{EventId: "event-123", UserId: "user-123", Position: 42, AdmitAt: time.Now().Add(-time.Second)},
+		QueueToken:     "queue-token",
+		Admitted:       true,
+		AdmissionToken: "admission-token",
+	}, nil)
+
+	handler.QueueStatus(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Header().Get("Retry-After"))
+
+	var response QueueStatusResponse
+	json.Unmarshal(w.Body.Bytes(), &response)
+	assert.True(t, response.Admitted)
+	assert.Equal(t, "admission-token", response.AdmissionToken)
+	assert.Equal(t, 0, response.WaitSeconds)
+	mockRooms.AssertExpectations(t)
+}
+
+func TestQueueStatus_Errors(t *testing.T) {
+	mockRooms := new(MockWaitingRoomUsecases)
+	handler := NewWaitingRoomHandler(mockRooms)
+
+	mockRooms.On("QueueStatus", mock.Anything, "event-123", "forged").Return(nil, domain.ErrInvalidQueueToken)
+	mockRooms.On("QueueStatus", mock.Anything, "event-123", "stale").Return(nil, domain.ErrQueueTokenExpired)
+
+	tests := []struct {
+		name   string
+		token  string
+		status int
+	}{
+		{"no token", "", http.StatusBadRequest},
+		{"forged token", "forged", http.StatusForbidden},
+		{"expired token", "stale", http.StatusGone},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/events/event-123/queue", nil)
+			if tt.token != "" {
+				req.Header.Set(QueueTokenHeader, tt.token)
+			}
+			req = mux.SetURLVars(req, map[string]string{"id": "event-123"})
+			w := httptest.NewRecorder()
+
+			handler.QueueStatus(w, req)
+
+			assert.Equal(t, tt.status, w.Code)
+		})
+	}
+}
+
+func TestOpenWaitingRoom_Success(t *testing.T) {
+	mockRooms := new(MockWaitingRoomUsecases)
+	handler := NewWaitingRoomHandler(mockRooms)
+
+	body, _ := json.Marshal(WaitingRoomRequest{RatePerMinute: 300})
+	req := httptest.NewRequest(http.MethodPut, "/api/admin/events/event-123/waiting-room", bytes.NewBuffer(body))
+	req = mux.SetURLVars(req, map[string]string{"id": "event-123"})
+	w := httptest.NewRecorder()
+
+	room := &domain.WaitingRoom{EventId: "event-123", RatePerMinute: 300, OpenedAt: time.Now()}
+	mockRooms.On("OpenWaitingRoom", mock.Anything, &domain.WaitingRoom{EventId: "event-123", RatePerMinute: 300}).Return(room, nil)
+
+	handler.OpenWaitingRoom(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response WaitingRoomResponse
+	json.Unmarshal(w.Body.Bytes(), &response)
+	assert.Equal(t, "event-123", response.EventId)
+	assert.Equal(t, 300, response.RatePerMinute)
+	mockRooms.AssertExpectations(t)
+}
+
+func TestOpenWaitingRoom_InvalidRate(t *testing.T) {
+	mockRooms := new(MockWaitingRoomUsecases)
+	handler := NewWaitingRoomHandler(mockRooms)
+
+	req := httptest.NewRequest(http.MethodPut, "/api/admin/events/event-123/waiting-room", bytes.NewBufferString(`{"rate_per_minute":0}`))
+	req = mux.SetURLVars(req, map[string]string{"id": "event-123"})
+	w := httptest.NewRecorder()
+
+	mockRooms.On("OpenWaitingRoom", mock.Anything, mock.Anything).Return(nil, domain.ErrInvalidWaitingRoom)
+
+	handler.OpenWaitingRoom(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestCloseWaitingRoom_NotFound(t *testing.T) {
+	mockRooms := new(MockWaitingRoomUsecases)
+	handler := NewWaitingRoomHandler(mockRooms)
+
+	req := httptest.NewRequest(http.MethodDelete, "/api/admin/events/event-123/waiting-room", nil)
+	req = mux.SetURLVars(req, map[string]string{"id": "event-123"})
+	w := httptest.NewRecorder()
+
+	mockRooms.On("CloseWaitingRoom", mock.Anything, "event-123").
+		Return(fmt.Errorf("failed to close waiting room: %w", domain.ErrWaitingRoomNotFound))
+
+	handler.CloseWaitingRoom(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	mockRooms.AssertExpectations(t)
+}
+
+func TestBookEvent_AdmissionToken(t *testing.T) {
+	mockUsecases := new(MockUsecases)
+	handler := NewHandler(mockUsecases)
+
+	body, _ := json.Marshal(BookEventRequest{UserId: "user-123"})
+	req := httptest.NewRequest(http.MethodPost, "/api/events/event-123/book", bytes.NewBuffer(body))
+	req.Header.Set(AdmissionTokenHeader, "admission-token")
+	req = mux.SetURLVars(req, map[string]string{"id": "event-123"})
+	w := httptest.NewRecorder()
+
+	mockUsecases.On("BookEvent", mock.MatchedBy(func(ctx context.Context) bool {
+		return port.AdmissionToken(ctx) == "admission-token"
+	}), mock.AnythingOfType("*domain.Booking")).Return("", fmt.Errorf("failed to book event: %w", domain.ErrInvalidAdmissionToken))
+
+	handler.BookEvent(w, req)
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	mockUsecases.AssertExpectations(t)
+
+	// Допуск уже израсходован другой бронью - тоже 403: клиент встает в очередь заново
+	assert.Equal(t, http.StatusForbidden, bookingErrorStatus(fmt.Errorf("failed to use admission: %w", domain.ErrAdmissionTokenUsed)))
+}
@@ -0,0 +1,68 @@
+package http
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// WebSocket поверх gorilla/websocket: сервер шлет текстовые сообщения с JSON, от клиента
+// ожидаются только служебные кадры - ping и close. Библиотека сама проверяет кадры клиента
+// (маска, RSV-биты, фрагментированные и длинные служебные кадры), отвечает на ping и close
+// и закрывает соединение с кодом 1002 или 1009 при нарушении протокола.
+
+const (
+	// wsWriteTimeout - сколько ждать записи сообщения; зависший клиент отключается
+	wsWriteTimeout = 10 * time.Second
+	// wsMaxMessageSize - предел сообщения клиента: данных от него не ждем
+	wsMaxMessageSize = 4096
+)
+
+// wsUpgrader принимает рукопожатие, только если Origin совпадает с Host (проверка
+// gorilla/websocket по умолчанию): браузер не применяет к WebSocket CORS, и без проверки
+// чужая страница могла бы открыть сокет от имени посетителя. Клиенты без Origin - не
+// браузеры - допускаются.
+var wsUpgrader = websocket.Upgrader{
+	HandshakeTimeout: wsWriteTimeout,
+}
+
+type wsConn struct {
+	conn *websocket.Conn
+}
+
+// upgradeWebSocket переключает запрос на WebSocket. При ошибке ответ клиенту уже записан.
+func upgradeWebSocket(w http.ResponseWriter, r *http.Request) (*wsConn, error) {
+	conn, err := wsUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return nil, err
+	}
+	conn.SetReadLimit(wsMaxMessageSize)
+	return &wsConn{conn: conn}, nil
+}
+
+// WriteJSON отправляет значение текстовым сообщением
+func (c *wsConn) WriteJSON(v any) error {
+	if err := c.conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout)); err != nil {
+		return err
+	}
+	return c.conn.WriteJSON(v)
+}
+
+// Close отправляет кадр закрытия с кодом и закрывает соединение. Если кадр закрытия уже
+// отправлен (ответ на close клиента или на нарушение протокола), второй не отправляется.
+func (c *wsConn) Close(code int, reason string) error {
+	c.conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason),
+		time.Now().Add(wsWriteTimeout))
+	return c.conn.Close()
+}
+
+// readLoop читает кадры клиента, чтобы библиотека обработала служебные; данные
+// пропускаются. Возвращается, когда клиент закрыл соединение или нарушил протокол.
+func (c *wsConn) readLoop() error {
+	for {
+		if _, _, err := c.conn.NextReader(); err != nil {
+			return err
+		}
+	}
+}
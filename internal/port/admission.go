@@ -0,0 +1,16 @@
+package port
+
+import "context"
+
+type admissionTokenKey struct{}
+
+// WithAdmissionToken передает в ctx токен допуска из очереди на бронирование
+func WithAdmissionToken(ctx context.Context, token string) context.Context {
+	return context.WithValue(ctx, admissionTokenKey{}, token)
+}
+
+// AdmissionToken возвращает токен допуска из ctx или пустую строку
+func AdmissionToken(ctx context.Context) string {
+	token, _ := ctx.Value(admissionTokenKey{}).(string)
+	return token
+}
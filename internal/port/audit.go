@@ -0,0 +1,127 @@
+package port
+
+import (
+	"context"
+	"time"
+
+	"github.com/dontpanicw/EventBooker/internal/domain"
+	"github.com/google/uuid"
+)
+
+type actorKey struct{}
+
+type auditReasonKey struct{}
+
+// WithActor помечает ctx инициатором изменений, который попадет в журнал аудита
+func WithActor(ctx context.Context, actor domain.Actor) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorFrom возвращает инициатора из ctx; без него изменение считается системным
+func ActorFrom(ctx context.Context) domain.Actor {
+	if actor, ok := ctx.Value(actorKey{}).(domain.Actor); ok {
+		return actor
+	}
+	return domain.Actor{Type: domain.ActorSystem}
+}
+
+// WithAuditReason добавляет в ctx причину изменений для журнала аудита
+func WithAuditReason(ctx context.Context, reason string) context.Context {
+	return context.WithValue(ctx, auditReasonKey{}, reason)
+}
+
+// AuditReason возвращает причину изменений из ctx
+func AuditReason(ctx context.Context) string {
+	reason, _ := ctx.Value(auditReasonKey{}).(string)
+	return reason
+}
+
+// NewAuditEntry создает запись журнала с инициатором и причиной из ctx;
+// хранилища дополняют ее значениями и пишут в той же транзакции, что и изменение
+func NewAuditEntry(ctx context.Context, entityType, entityID, action string) *domain.AuditEntry {
+	actor := ActorFrom(ctx)
+	return &domain.AuditEntry{
+		Id:         uuid.New().String(),
+		EntityType: entityType,
+		EntityId:   entityID,
+		Action:     action,
+		ActorType:  actor.Type,
+		ActorId:    actor.Id,
+		Reason:     AuditReason(ctx),
+		CreatedAt:  time.Now().UTC(),
+	}
+}
+
+// NewEventCreatedAuditEntry - запись о создании события со всеми его полями
+func NewEventCreatedAuditEntry(ctx context.Context, event *domain.Event) *domain.AuditEntry {
+	entry := NewAuditEntry(ctx, domain.AuditEntityEvent, event.Id, domain.AuditEventCreated)
+	entry.EventId = event.Id
+	entry.NewValue = domain.AuditValue(event)
+	return entry
+}
+
+// NewEventUpdatedAuditEntry - запись о правке события: редактируемые поля до и после
+func NewEventUpdatedAuditEntry(ctx context.Context, old, updated *domain.Event) *domain.AuditEntry {
+	entry := NewAuditEntry(ctx, domain.AuditEntityEvent, updated.Id, domain.AuditEventUpdated)
+	entry.EventId = updated.Id
+	entry.OldValue = domain.AuditValue(editableFields(old))
+	entry.NewValue = domain.AuditValue(editableFields(updated))
+	return entry
+}
+
+func editableFields(event *domain.Event) map[string]any {
+	return map[string]any{
+		"name":        event.Name,
+		"description": event.Description,
+		"is_free":     event.IsFree,
+		"price":       event.Price,
+	}
+}
+
+// NewPricePhasesAuditEntry - запись о замене расписания цен события; цены ступеней - в
+// минимальных единицах валюты события
+func NewPricePhasesAuditEntry(ctx context.Context, eventID string, old, updated []domain.PricePhase) *domain.AuditEntry {
+	entry := NewAuditEntry(ctx, domain.AuditEntityEvent, eventID, domain.AuditEventUpdated)
+	entry.EventId = eventID
+	entry.OldValue = domain.AuditValue(map[string]any{"price_phases": pricePhaseFields(old)})
+	entry.NewValue = domain.AuditValue(map[string]any{"price_phases": pricePhaseFields(updated)})
+	return entry
+}
+
+func pricePhaseFields(phases []domain.PricePhase) []map[string]any {
+	fields := make([]map[string]any, 0, len(phases))
+	for _, phase := range phases {
+		field := map[string]any{
+			"name":     phase.Name,
+			"price":    phase.Price,
+			"max_sold": phase.MaxSold,
+		}
+		if !phase.Until.IsZero() {
+			field["until"] = phase.Until.UTC()
+		}
+		fields = append(fields, field)
+	}
+	return fields
+}
+
+// NewBookingAuditEntry - запись о смене статуса брони с oldStatus на booking.Status;
+// пустой oldStatus - бронь только что создана
+func NewBookingAuditEntry(ctx context.Context, booking *domain.Booking, action, oldStatus string) *domain.AuditEntry {
+	entry := NewAuditEntry(ctx, domain.AuditEntityBooking, booking.Id, action)
+	entry.EventId = booking.EventId
+	entry.UserId = booking.UserId
+	if oldStatus != "" {
+		entry.OldValue = domain.AuditValue(map[string]string{"status": oldStatus})
+	}
+	entry.NewValue = domain.AuditValue(map[string]string{"status": booking.Status})
+	return entry
+}
+
+// NewTicketsAuditEntry - запись об изменении счетчика свободных мест события
+func NewTicketsAuditEntry(ctx context.Context, eventID string, oldAvailable, newAvailable int) *domain.AuditEntry {
+	entry := NewAuditEntry(ctx, domain.AuditEntityEvent, eventID, domain.AuditTicketsChanged)
+	entry.EventId = eventID
+	entry.OldValue = domain.AuditValue(map[string]int{"available_tickets": oldAvailable})
+	entry.NewValue = domain.AuditValue(map[string]int{"available_tickets": newAvailable})
+	return entry
+}
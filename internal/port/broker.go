@@ -8,3 +8,16 @@ import (
 type Broker interface {
 	PublishDelayedCancellation(ctx context.Context, booking *domain.Booking) error
 }
+
+// DeadLetterQueue - сообщения, которые consumer не смог обработать за все попытки
+type DeadLetterQueue interface {
+	ListDeadLetters(ctx context.Context, limit int) ([]*domain.DeadLetter, error)
+	GetDeadLetter(ctx context.Context, id string) (*domain.DeadLetter, error)
+	ReplayDeadLetter(ctx context.Context, id string) error
+	DiscardDeadLetter(ctx context.Context, id string) error
+}
+
+// EventPublisher - публикация доменных событий для внешних подписчиков
+type EventPublisher interface {
+	PublishLifecycleEvent(ctx context.Context, event *domain.LifecycleEvent) error
+}
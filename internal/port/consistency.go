@@ -0,0 +1,17 @@
+package port
+
+import "context"
+
+type consistencyKey struct{}
+
+// WithStrongConsistency помечает ctx: чтения в нем должны идти в master,
+// например чтобы увидеть только что записанные данные (read-after-write)
+func WithStrongConsistency(ctx context.Context) context.Context {
+	return context.WithValue(ctx, consistencyKey{}, true)
+}
+
+// StrongConsistency сообщает, требует ли ctx чтения из master
+func StrongConsistency(ctx context.Context) bool {
+	strong, _ := ctx.Value(consistencyKey{}).(bool)
+	return strong
+}
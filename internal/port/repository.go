@@ -3,22 +3,148 @@ package port
 import (
 	"context"
 	"github.com/dontpanicw/EventBooker/internal/domain"
+	"time"
 )
 
 type Repository interface {
 	CreateEvent(ctx context.Context, event *domain.Event) (string, error)
-	BookEvent(ctx context.Context, booking *domain.Booking) (string, error)
-	ConfirmBooking(ctx context.Context, bookingID string) error
+	// BookEvent списывает место и создает бронь по цене билета на момент брони
+	// (Event.TicketPrice с расписанием цены) со сбором и НДС (Booking.Charge) в booking.Price.
+	// Если задан booking.PromoCode, в той же транзакции применяет промокод (PromoCode.Redeem)
+	// и записывает его использование. Если задан booking.Admission, в ней же расходует допуск
+	// из очереди; израсходованный или замененный новым местом - ErrAdmissionTokenUsed.
+	// Возвращает мероприятие с числом мест, оставшихся сразу после брони в той же транзакции.
+	BookEvent(ctx context.Context, booking *domain.Booking) (*domain.Event, error)
+	// ConfirmBooking оплачивает pending-бронь; ненулевой version должен совпадать
+	// с текущей версией брони, иначе ErrVersionMismatch
+	ConfirmBooking(ctx context.Context, bookingID string, version int64) error
+	// UpdateEvent применяет правку к событию и возвращает его новое состояние;
+	// ненулевой version должен совпадать с текущей версией события, иначе ErrVersionMismatch
+	UpdateEvent(ctx context.Context, eventID string, version int64, update domain.EventUpdate) (*domain.Event, error)
 	GetEvent(ctx context.Context, eventID string) (*domain.Event, error)
-	GetAllEvents(ctx context.Context) ([]*domain.Event, error)
+	// ListEvents возвращает страницу событий по фильтру, разрешенному EventFilter.Resolve
+	ListEvents(ctx context.Context, filter domain.EventFilter) (*domain.EventPage, error)
+	// SearchEvents ищет события по названию и описанию и возвращает не больше limit самых релевантных
+	SearchEvents(ctx context.Context, query string, limit int) ([]*domain.EventSearchResult, error)
 	GetBooking(ctx context.Context, bookingID string) (*domain.Booking, error)
+	// ListBookings возвращает страницу броней с мероприятиями по фильтру,
+	// разрешенному BookingFilter.Resolve
+	ListBookings(ctx context.Context, filter domain.BookingFilter) (*domain.BookingPage, error)
+	// CancelBooking отменяет pending-бронь и в той же транзакции возвращает место в продажу
 	CancelBooking(ctx context.Context, bookingID string) error
 	IncrementAvailableTickets(ctx context.Context, eventID string) error
 	AddAvailableTickets(ctx context.Context, eventID string) error
 }
 
+// InventoryRepository - сверка денормализованного счетчика available_tickets с бронями
+type InventoryRepository interface {
+	FindInventoryDiscrepancies(ctx context.Context) ([]*domain.InventoryDiscrepancy, error)
+	// RepairInventory пересчитывает счетчик события под блокировкой и сохраняет запись
+	// об исправлении; возвращает nil, если счетчик уже верный
+	RepairInventory(ctx context.Context, eventID string, reason string) (*domain.InventoryAdjustment, error)
+	// ListUnverifiedCapacities возвращает события с вместимостью из миграции 002
+	ListUnverifiedCapacities(ctx context.Context) ([]*domain.UnverifiedCapacity, error)
+	// VerifyCapacity снимает пометку после ручной проверки вместимости;
+	// ErrUnverifiedCapacityNotFound, если пометки нет
+	VerifyCapacity(ctx context.Context, eventID string) error
+}
+
 //встроенные HTTP-методы:
 //– POST /events — создание мероприятия;
 //– POST /events/{id}/book — бронирование места;
 //– POST /events/{id}/confirm — оплата брони (если мероприятие требует этого);
 //– GET /events/{id} — получение информации о мероприятии и свободных местах.
+
+// AuditRepository - чтение журнала аудита. Записи добавляются самими методами
+// Repository и InventoryRepository в транзакции изменения и не меняются.
+type AuditRepository interface {
+	// ListAuditLog возвращает историю по фильтру в порядке изменений
+	ListAuditLog(ctx context.Context, filter domain.AuditFilter) ([]*domain.AuditEntry, error)
+}
+
+// IdempotencyRepository хранит ключи идемпотентности вместе с ответами
+type IdempotencyRepository interface {
+	// ReserveIdempotencyKey атомарно сохраняет запись без ответа. Если ключ уже занят,
+	// ничего не меняет и возвращает существующую запись; при успехе возвращает nil.
+	ReserveIdempotencyKey(ctx context.Context, record *domain.IdempotencyRecord) (*domain.IdempotencyRecord, error)
+	// CompleteIdempotencyKey сохраняет ответ в записи record.Key
+	CompleteIdempotencyKey(ctx context.Context, record *domain.IdempotencyRecord) error
+	// ReleaseIdempotencyKey удаляет запись, чтобы запрос с этим ключом можно было повторить
+	ReleaseIdempotencyKey(ctx context.Context, key string) error
+	// ReleaseExpiredIdempotencyKey удаляет запись key, только если она создана раньше before:
+	// занятый параллельным запросом свежий ключ остается на месте
+	ReleaseExpiredIdempotencyKey(ctx context.Context, key string, before time.Time) error
+	// PurgeIdempotencyKeys удаляет записи, созданные раньше before, и возвращает их число
+	PurgeIdempotencyKeys(ctx context.Context, before time.Time) (int64, error)
+}
+
+// WaitingRoomRepository хранит очереди на бронирование мероприятий
+type WaitingRoomRepository interface {
+	// SaveWaitingRoom открывает очередь или меняет скорость допуска уже открытой;
+	// позиции и время допуска вставших в нее сохраняются
+	SaveWaitingRoom(ctx context.Context, room *domain.WaitingRoom) (*domain.WaitingRoom, error)
+	// GetWaitingRoom возвращает ErrWaitingRoomNotFound, если очередь не открыта
+	GetWaitingRoom(ctx context.Context, eventID string) (*domain.WaitingRoom, error)
+	// JoinWaitingRoom атомарно ставит пользователя в очередь (WaitingRoom.Enqueue) и возвращает
+	// его место. Повторный вход с действующим местом (QueueTicket.Active) возвращает его же,
+	// а не занимает новое.
+	JoinWaitingRoom(ctx context.Context, eventID, userID string, now time.Time) (*domain.QueueTicket, error)
+	// DeleteWaitingRoom закрывает очередь
+	DeleteWaitingRoom(ctx context.Context, eventID string) error
+}
+
+// BallotRepository хранит розыгрыши мест и заявки на них
+type BallotRepository interface {
+	// SaveBallot создает или меняет розыгрыш, пока он не проведен; после - ErrBallotAlreadyDrawn
+	SaveBallot(ctx context.Context, ballot *domain.Ballot) (*domain.Ballot, error)
+	// GetBallot возвращает ErrBallotNotFound, если розыгрыш не настроен
+	GetBallot(ctx context.Context, eventID string) (*domain.Ballot, error)
+	// ListActiveBallots возвращает непроведенные розыгрыши, прием заявок в которых
+	// закончился до now, и проведенные, в листе ожидания которых еще есть участники
+	ListActiveBallots(ctx context.Context, now time.Time) ([]*domain.Ballot, error)
+	// EnterBallot принимает заявку, пока розыгрыш не проведен (иначе ErrBallotEntryClosed);
+	// повторная заявка пользователя - ErrAlreadyEntered
+	EnterBallot(ctx context.Context, entry *domain.BallotEntry) error
+	GetBallotEntry(ctx context.Context, eventID, userID string) (*domain.BallotEntry, error)
+	// ListBallotEntries возвращает заявки в порядке розыгрыша (до него - по времени подачи);
+	// непустой status оставляет заявки только с этим статусом
+	ListBallotEntries(ctx context.Context, eventID, status string) ([]*domain.BallotEntry, error)
+	// DrawBallot атомарно записывает seed, упорядочивает заявки по domain.DrawOrder
+	// и ставит всех участников в лист ожидания; повторный розыгрыш - ErrBallotAlreadyDrawn
+	DrawBallot(ctx context.Context, eventID string, seed int64, drawnAt time.Time) (*domain.Ballot, error)
+	// AllocateBallotTicket выделяет место первому в листе ожидания: в одной транзакции
+	// списывает билет, создает pending-бронь booking (UserId берется из заявки) и отмечает
+	// заявку выигравшей. Мест нет - ErrNoTicketsAvailable, ожидающих нет - ErrBallotWaitlistEmpty.
+	AllocateBallotTicket(ctx context.Context, booking *domain.Booking) error
+	// CloseBallotWaitlist переводит оставшихся в листе ожидания в lost и возвращает их заявки
+	CloseBallotWaitlist(ctx context.Context, eventID string) ([]*domain.BallotEntry, error)
+}
+
+// PromoRepository хранит промокоды; применяются они в BookEvent
+type PromoRepository interface {
+	// CreatePromoCode сохраняет новый промокод; существующий код - ErrPromoCodeExists
+	CreatePromoCode(ctx context.Context, promo *domain.PromoCode) error
+	GetPromoCode(ctx context.Context, code string) (*domain.PromoCode, error)
+	ListPromoCodes(ctx context.Context) ([]*domain.PromoCode, error)
+	// ListPromoRedemptions - использования кода в порядке их времени, включая отмененные брони
+	ListPromoRedemptions(ctx context.Context, code string) ([]*domain.PromoRedemption, error)
+}
+
+// PricingRepository хранит расписания цен: GetEvent загружает их в Event.PricePhases,
+// а BookEvent и AllocateBallotTicket считают по ним цену брони
+type PricingRepository interface {
+	// SetPricePhases заменяет расписание цены мероприятия и поднимает его версию;
+	// пустое расписание удаляет ступени
+	SetPricePhases(ctx context.Context, eventID string, phases []domain.PricePhase) error
+}
+
+// FeeRepository хранит правила сервисного сбора и НДС. BookEvent и AllocateBallotTicket
+// выбирают правило мероприятия (domain.MatchFeeRule) в транзакции брони и сохраняют
+// состав суммы брони, который GetBooking загружает в Booking.LineItems.
+type FeeRepository interface {
+	// SaveFeeRule создает или заменяет правило области rule.Scope, rule.ScopeId
+	SaveFeeRule(ctx context.Context, rule *domain.FeeRule) error
+	// DeleteFeeRule удаляет правило; отсутствующее - ErrFeeRuleNotFound
+	DeleteFeeRule(ctx context.Context, scope, scopeID string) error
+	ListFeeRules(ctx context.Context) ([]*domain.FeeRule, error)
+}
@@ -0,0 +1,10 @@
+package port
+
+import "github.com/dontpanicw/EventBooker/internal/domain"
+
+// UpdatesStream - подписка на изменения мест и статусов броней
+type UpdatesStream interface {
+	// Subscribe возвращает канал обновлений по фильтру. Канал закрывается после
+	// unsubscribe или если подписчик не успевает читать обновления.
+	Subscribe(filter domain.UpdateFilter) (updates <-chan *domain.Update, unsubscribe func())
+}
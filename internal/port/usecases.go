@@ -8,8 +8,98 @@ import (
 type Usecases interface {
 	CreateEvent(ctx context.Context, event *domain.Event) (string, error)
 	BookEvent(ctx context.Context, booking *domain.Booking) (string, error)
-	ConfirmBooking(ctx context.Context, bookingID string) error
+	// ConfirmBooking оплачивает бронь; version 0 - без проверки версии
+	ConfirmBooking(ctx context.Context, bookingID string, version int64) error
+	// UpdateEvent правит событие, если его версия все еще равна version (0 - без проверки)
+	UpdateEvent(ctx context.Context, eventID string, version int64, update domain.EventUpdate) (*domain.Event, error)
 	GetEvent(ctx context.Context, eventID string) (*domain.Event, error)
-	GetAllEvents(ctx context.Context) ([]*domain.Event, error)
+	ListEvents(ctx context.Context, filter domain.EventFilter) (*domain.EventPage, error)
+	SearchEvents(ctx context.Context, query string, limit int) ([]*domain.EventSearchResult, error)
 	GetBooking(ctx context.Context, bookingID string) (*domain.Booking, error)
+	ListUserBookings(ctx context.Context, filter domain.BookingFilter) (*domain.BookingPage, error)
+	ListEventBookings(ctx context.Context, filter domain.BookingFilter) (*domain.BookingPage, error)
+	// ExportEventBookings передает в fn все брони мероприятия по фильтру, не держа их в памяти
+	// целиком; Limit и After фильтра не учитываются
+	ExportEventBookings(ctx context.Context, filter domain.BookingFilter, fn func(*domain.Booking) error) error
+}
+
+type AdminUsecases interface {
+	ListDeadLetters(ctx context.Context, limit int) ([]*domain.DeadLetter, error)
+	GetDeadLetter(ctx context.Context, id string) (*domain.DeadLetter, error)
+	ReplayDeadLetter(ctx context.Context, id string) error
+	DiscardDeadLetter(ctx context.Context, id string) error
+	ReconcileInventory(ctx context.Context, repair bool) (*domain.InventoryReport, error)
+	VerifyCapacity(ctx context.Context, eventID string) error
+	AuditLog(ctx context.Context, filter domain.AuditFilter) ([]*domain.AuditEntry, error)
+}
+
+// IdempotencyUsecases - повтор ответов на запросы с одинаковым Idempotency-Key
+type IdempotencyUsecases interface {
+	// Begin занимает ключ для нового запроса и возвращает nil или возвращает сохраненный
+	// ответ на повтор. Ключ чужого запроса - ErrIdempotencyKeyReused, незавершенного - ErrIdempotencyInProgress.
+	Begin(ctx context.Context, key, requestHash string) (*domain.IdempotencyRecord, error)
+	Complete(ctx context.Context, record *domain.IdempotencyRecord) error
+	// Release освобождает ключ запроса, который не удалось выполнить
+	Release(ctx context.Context, key string) error
+	// PurgeExpired удаляет ключи старше срока хранения
+	PurgeExpired(ctx context.Context) (int64, error)
+}
+
+// AdmissionChecker решает, можно ли бронировать: пока у мероприятия открыта очередь,
+// нужен токен допуска из ctx (WithAdmissionToken)
+type AdmissionChecker interface {
+	CheckAdmission(ctx context.Context, booking *domain.Booking) error
+}
+
+// WaitingRoomUsecases - очереди на бронирование мероприятий с высоким спросом
+type WaitingRoomUsecases interface {
+	AdmissionChecker
+	OpenWaitingRoom(ctx context.Context, room *domain.WaitingRoom) (*domain.WaitingRoom, error)
+	GetWaitingRoom(ctx context.Context, eventID string) (*domain.WaitingRoom, error)
+	CloseWaitingRoom(ctx context.Context, eventID string) error
+	// JoinQueue выдает пользователю место в очереди и подписанный токен очереди
+	JoinQueue(ctx context.Context, eventID, userID string) (*domain.QueueStatus, error)
+	// QueueStatus проверяет токен очереди; когда очередь подошла, выдает токен допуска
+	QueueStatus(ctx context.Context, eventID, queueToken string) (*domain.QueueStatus, error)
+}
+
+// BallotUsecases - розыгрыш мест мероприятий с SaleMode ballot
+type BallotUsecases interface {
+	// ConfigureBallot задает окно приема заявок и лист ожидания, пока розыгрыш не проведен
+	ConfigureBallot(ctx context.Context, ballot *domain.Ballot) (*domain.Ballot, error)
+	GetBallot(ctx context.Context, eventID string) (*domain.Ballot, error)
+	EnterBallot(ctx context.Context, eventID, userID string) (*domain.BallotEntry, error)
+	GetBallotEntry(ctx context.Context, eventID, userID string) (*domain.BallotEntry, error)
+	// ListBallotEntries - все заявки в порядке розыгрыша, для проверки результата по seed
+	ListBallotEntries(ctx context.Context, eventID string) ([]*domain.BallotEntry, error)
+	// DrawBallot проводит розыгрыш, прием заявок в котором закончился, не дожидаясь RunBallots
+	DrawBallot(ctx context.Context, eventID string) (*domain.Ballot, error)
+	// CloseWaitlist досрочно закрывает лист ожидания; иначе он закрывается в дату мероприятия
+	CloseWaitlist(ctx context.Context, eventID string) ([]*domain.BallotEntry, error)
+	// RunBallots проводит наступившие розыгрыши и раздает освободившиеся места листам ожидания
+	RunBallots(ctx context.Context) error
+}
+
+// PromoUsecases - промокоды для администраторов; применяет их EventsUsecases.BookEvent
+type PromoUsecases interface {
+	CreatePromoCode(ctx context.Context, promo *domain.PromoCode) (*domain.PromoCode, error)
+	GetPromoCode(ctx context.Context, code string) (*domain.PromoCode, error)
+	ListPromoCodes(ctx context.Context) ([]*domain.PromoCode, error)
+	// ListPromoRedemptions - кто и когда использовал код, со статусами броней
+	ListPromoRedemptions(ctx context.Context, code string) ([]*domain.PromoRedemption, error)
+}
+
+// PricingUsecases - расписания цен мероприятий для администраторов
+type PricingUsecases interface {
+	// SetPricePhases заменяет расписание цены и возвращает мероприятие с текущей ценой;
+	// пустое расписание возвращает мероприятию постоянную цену
+	SetPricePhases(ctx context.Context, eventID string, phases []domain.PricePhase) (*domain.Event, error)
+}
+
+// FeeUsecases - правила сервисного сбора и НДС для администраторов. Правило действует
+// для броней, созданных после его изменения; суммы существующих броней не меняются.
+type FeeUsecases interface {
+	SetFeeRule(ctx context.Context, rule *domain.FeeRule) (*domain.FeeRule, error)
+	DeleteFeeRule(ctx context.Context, scope, scopeID string) error
+	ListFeeRules(ctx context.Context) ([]*domain.FeeRule, error)
 }
@@ -0,0 +1,129 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+	"github.com/dontpanicw/EventBooker/internal/domain"
+	"github.com/dontpanicw/EventBooker/internal/port"
+	"log"
+	"time"
+)
+
+const defaultDeadLettersLimit = 100
+
+const defaultAuditLimit = 500
+
+// inventoryRepairReason - причина в записи об исправлении счетчика мест
+const inventoryRepairReason = "inventory reconciliation"
+
+type AdminUsecases struct {
+	deadLetters port.DeadLetterQueue
+	inventory   port.InventoryRepository
+	auditLog    port.AuditRepository
+}
+
+func NewAdminUsecases(deadLetters port.DeadLetterQueue, inventory port.InventoryRepository, auditLog port.AuditRepository) port.AdminUsecases {
+	return &AdminUsecases{
+		deadLetters: deadLetters,
+		inventory:   inventory,
+		auditLog:    auditLog,
+	}
+}
+
+func (a *AdminUsecases) ListDeadLetters(ctx context.Context, limit int) ([]*domain.DeadLetter, error) {
+	if limit <= 0 {
+		limit = defaultDeadLettersLimit
+	}
+	deadLetters, err := a.deadLetters.ListDeadLetters(ctx, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list dead letters: %w", err)
+	}
+	return deadLetters, nil
+}
+
+func (a *AdminUsecases) GetDeadLetter(ctx context.Context, id string) (*domain.DeadLetter, error) {
+	deadLetter, err := a.deadLetters.GetDeadLetter(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get dead letter: %w", err)
+	}
+	return deadLetter, nil
+}
+
+func (a *AdminUsecases) ReplayDeadLetter(ctx context.Context, id string) error {
+	if err := a.deadLetters.ReplayDeadLetter(ctx, id); err != nil {
+		return fmt.Errorf("failed to replay dead letter: %w", err)
+	}
+	return nil
+}
+
+func (a *AdminUsecases) DiscardDeadLetter(ctx context.Context, id string) error {
+	if err := a.deadLetters.DiscardDeadLetter(ctx, id); err != nil {
+		return fmt.Errorf("failed to discard dead letter: %w", err)
+	}
+	return nil
+}
+
+// ReconcileInventory сверяет available_tickets с вместимостью и активными бронями.
+// С repair=true счетчики пересчитываются под блокировкой события, и каждое
+// исправление сохраняется; без repair расхождения только попадают в отчет.
+// События с непроверенной вместимостью попадают в отчет в любом случае.
+func (a *AdminUsecases) ReconcileInventory(ctx context.Context, repair bool) (*domain.InventoryReport, error) {
+	report := &domain.InventoryReport{CheckedAt: time.Now().UTC()}
+
+	discrepancies, err := a.inventory.FindInventoryDiscrepancies(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find inventory discrepancies: %w", err)
+	}
+	report.Discrepancies = discrepancies
+
+	for _, d := range discrepancies {
+		log.Printf("Inventory drift for event %s: available %d, expected %d (capacity %d, active bookings %d)",
+			d.EventId, d.AvailableTickets, d.ExpectedAvailable, d.Capacity, d.ActiveBookings)
+		if !repair {
+			continue
+		}
+
+		adjustment, err := a.inventory.RepairInventory(ctx, d.EventId, inventoryRepairReason)
+		if err != nil {
+			return nil, fmt.Errorf("failed to repair inventory of event %s: %w", d.EventId, err)
+		}
+		// nil - счетчик успел выровняться между отчетом и ремонтом
+		if adjustment != nil {
+			report.Adjustments = append(report.Adjustments, adjustment)
+		}
+	}
+
+	unverified, err := a.inventory.ListUnverifiedCapacities(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list unverified capacities: %w", err)
+	}
+	report.Unverified = unverified
+	if len(unverified) > 0 {
+		log.Printf("Capacity of %d events is not verified since migration 002", len(unverified))
+	}
+
+	return report, nil
+}
+
+// VerifyCapacity снимает пометку непроверенной вместимости после ручной проверки
+func (a *AdminUsecases) VerifyCapacity(ctx context.Context, eventID string) error {
+	if err := a.inventory.VerifyCapacity(ctx, eventID); err != nil {
+		return fmt.Errorf("failed to verify capacity: %w", err)
+	}
+	return nil
+}
+
+// AuditLog возвращает историю изменений одной брони, события или пользователя
+func (a *AdminUsecases) AuditLog(ctx context.Context, filter domain.AuditFilter) ([]*domain.AuditEntry, error) {
+	if filter.BookingId == "" && filter.EventId == "" && filter.UserId == "" {
+		return nil, domain.ErrInvalidAuditFilter
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = defaultAuditLimit
+	}
+	entries, err := a.auditLog.ListAuditLog(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list audit log: %w", err)
+	}
+	return entries, nil
+}
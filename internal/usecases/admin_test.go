@@ -0,0 +1,215 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/dontpanicw/EventBooker/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockDeadLetterQueue - мок dead-letter очереди
+type MockDeadLetterQueue struct {
+	mock.Mock
+}
+
+func (m *MockDeadLetterQueue) ListDeadLetters(ctx context.Context, limit int) ([]*domain.DeadLetter, error) {
+	args := m.Called(ctx, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.DeadLetter), args.Error(1)
+}
+
+func (m *MockDeadLetterQueue) GetDeadLetter(ctx context.Context, id string) (*domain.DeadLetter, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.DeadLetter), args.Error(1)
+}
+
+func (m *MockDeadLetterQueue) ReplayDeadLetter(ctx context.Context, id string) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockDeadLetterQueue) DiscardDeadLetter(ctx context.Context, id string) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+// MockAuditRepository - мок журнала аудита
+type MockAuditRepository struct {
+	mock.Mock
+}
+
+func (m *MockAuditRepository) ListAuditLog(ctx context.Context, filter domain.AuditFilter) ([]*domain.AuditEntry, error) {
+	args := m.Called(ctx, filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.AuditEntry), args.Error(1)
+}
+
+// MockInventoryRepository - мок сверки счетчиков мест
+type MockInventoryRepository struct {
+	mock.Mock
+}
+
+func (m *MockInventoryRepository) FindInventoryDiscrepancies(ctx context.Context) ([]*domain.InventoryDiscrepancy, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.InventoryDiscrepancy), args.Error(1)
+}
+
+func (m *MockInventoryRepository) RepairInventory(ctx context.Context, eventID string, reason string) (*domain.InventoryAdjustment, error) {
+	args := m.Called(ctx, eventID, reason)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.InventoryAdjustment), args.Error(1)
+}
+
+func (m *MockInventoryRepository) ListUnverifiedCapacities(ctx context.Context) ([]*domain.UnverifiedCapacity, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.UnverifiedCapacity), args.Error(1)
+}
+
+func (m *MockInventoryRepository) VerifyCapacity(ctx context.Context, eventID string) error {
+	args := m.Called(ctx, eventID)
+	return args.Error(0)
+}
+
+func TestListDeadLetters_DefaultLimit(t *testing.T) {
+	mockDLQ := new(MockDeadLetterQueue)
+	usecase := &AdminUsecases{deadLetters: mockDLQ}
+
+	ctx := context.Background()
+	mockDLQ.On("ListDeadLetters", ctx, defaultDeadLettersLimit).Return([]*domain.DeadLetter{{Id: "msg-1"}}, nil)
+
+	deadLetters, err := usecase.ListDeadLetters(ctx, 0)
+
+	assert.NoError(t, err)
+	assert.Len(t, deadLetters, 1)
+	mockDLQ.AssertExpectations(t)
+}
+
+func TestReplayDeadLetter_NotFound(t *testing.T) {
+	mockDLQ := new(MockDeadLetterQueue)
+	usecase := &AdminUsecases{deadLetters: mockDLQ}
+
+	ctx := context.Background()
+	mockDLQ.On("ReplayDeadLetter", ctx, "msg-1").Return(domain.ErrDeadLetterNotFound)
+
+	err := usecase.ReplayDeadLetter(ctx, "msg-1")
+
+	assert.ErrorIs(t, err, domain.ErrDeadLetterNotFound)
+	mockDLQ.AssertExpectations(t)
+}
+
+func TestDiscardDeadLetter_Error(t *testing.T) {
+	mockDLQ := new(MockDeadLetterQueue)
+	usecase := &AdminUsecases{deadLetters: mockDLQ}
+
+	ctx := context.Background()
+	mockDLQ.On("DiscardDeadLetter", ctx, "msg-1").Return(errors.New("channel closed"))
+
+	err := usecase.DiscardDeadLetter(ctx, "msg-1")
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to discard dead letter")
+	mockDLQ.AssertExpectations(t)
+}
+
+func TestReconcileInventory_ReportOnly(t *testing.T) {
+	mockInventory := new(MockInventoryRepository)
+	usecase := &AdminUsecases{inventory: mockInventory}
+
+	ctx := context.Background()
+	discrepancies := []*domain.InventoryDiscrepancy{
+		{EventId: "event-1", Capacity: 10, ActiveBookings: 4, AvailableTickets: 7, ExpectedAvailable: 6},
+	}
+	mockInventory.On("FindInventoryDiscrepancies", ctx).Return(discrepancies, nil)
+	unverified := []*domain.UnverifiedCapacity{{EventId: "event-0", Capacity: 100, AvailableTickets: 40}}
+	mockInventory.On("ListUnverifiedCapacities", ctx).Return(unverified, nil)
+
+	report, err := usecase.ReconcileInventory(ctx, false)
+
+	assert.NoError(t, err)
+	assert.Equal(t, discrepancies, report.Discrepancies)
+	assert.Empty(t, report.Adjustments)
+	assert.Equal(t, unverified, report.Unverified)
+	mockInventory.AssertExpectations(t)
+	mockInventory.AssertNotCalled(t, "RepairInventory", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestReconcileInventory_Repair(t *testing.T) {
+	mockInventory := new(MockInventoryRepository)
+	usecase := &AdminUsecases{inventory: mockInventory}
+
+	ctx := context.Background()
+	mockInventory.On("FindInventoryDiscrepancies", ctx).Return([]*domain.InventoryDiscrepancy{
+		{EventId: "event-1", Capacity: 10, ActiveBookings: 4, AvailableTickets: 7, ExpectedAvailable: 6},
+		{EventId: "event-2", Capacity: 5, ActiveBookings: 5, AvailableTickets: 1, ExpectedAvailable: 0},
+	}, nil)
+	adjustment := &domain.InventoryAdjustment{Id: "adj-1", EventId: "event-1", OldAvailable: 7, NewAvailable: 6}
+	mockInventory.On("RepairInventory", ctx, "event-1", inventoryRepairReason).Return(adjustment, nil)
+	// Счетчик event-2 выровнялся до ремонта
+	mockInventory.On("RepairInventory", ctx, "event-2", inventoryRepairReason).Return(nil, nil)
+	mockInventory.On("ListUnverifiedCapacities", ctx).Return(nil, nil)
+
+	report, err := usecase.ReconcileInventory(ctx, true)
+
+	assert.NoError(t, err)
+	assert.Len(t, report.Discrepancies, 2)
+	assert.Equal(t, []*domain.InventoryAdjustment{adjustment}, report.Adjustments)
+	mockInventory.AssertExpectations(t)
+}
+
+func TestVerifyCapacity_NotFlagged(t *testing.T) {
+	mockInventory := new(MockInventoryRepository)
+	usecase := &AdminUsecases{inventory: mockInventory}
+
+	ctx := context.Background()
+	mockInventory.On("VerifyCapacity", ctx, "event-1").Return(fmt.Errorf("error verify capacity: %w", domain.ErrUnverifiedCapacityNotFound))
+
+	err := usecase.VerifyCapacity(ctx, "event-1")
+
+	assert.ErrorIs(t, err, domain.ErrUnverifiedCapacityNotFound)
+	assert.Contains(t, err.Error(), "failed to verify capacity")
+	mockInventory.AssertExpectations(t)
+}
+
+func TestAuditLog_DefaultLimit(t *testing.T) {
+	mockAudit := new(MockAuditRepository)
+	usecase := &AdminUsecases{auditLog: mockAudit}
+
+	ctx := context.Background()
+	expected := []*domain.AuditEntry{{Id: "audit-1", EventId: "event-1", Action: domain.AuditEventCreated}}
+	mockAudit.On("ListAuditLog", ctx, domain.AuditFilter{EventId: "event-1", Limit: defaultAuditLimit}).Return(expected, nil)
+
+	entries, err := usecase.AuditLog(ctx, domain.AuditFilter{EventId: "event-1"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, expected, entries)
+	mockAudit.AssertExpectations(t)
+}
+
+func TestAuditLog_EmptyFilter(t *testing.T) {
+	mockAudit := new(MockAuditRepository)
+	usecase := &AdminUsecases{auditLog: mockAudit}
+
+	_, err := usecase.AuditLog(context.Background(), domain.AuditFilter{Limit: 10})
+
+	assert.ErrorIs(t, err, domain.ErrInvalidAuditFilter)
+	mockAudit.AssertNotCalled(t, "ListAuditLog", mock.Anything, mock.Anything)
+}
@@ -0,0 +1,266 @@
+package usecases
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/dontpanicw/EventBooker/internal/domain"
+	"github.com/dontpanicw/EventBooker/internal/port"
+	"github.com/google/uuid"
+)
+
+type BallotUsecases struct {
+	repo    port.Repository
+	ballots port.BallotRepository
+	broker  port.Broker
+	events  port.EventPublisher
+}
+
+func NewBallotUsecases(repo port.Repository, ballots port.BallotRepository, broker port.Broker, events port.EventPublisher) port.BallotUsecases {
+	return &BallotUsecases{
+		repo:    repo,
+		ballots: ballots,
+		broker:  broker,
+		events:  events,
+	}
+}
+
+func (b *BallotUsecases) ConfigureBallot(ctx context.Context, ballot *domain.Ballot) (*domain.Ballot, error) {
+	if err := ballot.Validate(); err != nil {
+		return nil, err
+	}
+	event, err := b.repo.GetEvent(ctx, ballot.EventId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure ballot: %w", err)
+	}
+	if event.SaleMode != domain.SaleModeBallot {
+		return nil, domain.ErrNotBallotEvent
+	}
+
+	saved, err := b.ballots.SaveBallot(ctx, ballot)
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure ballot: %w", err)
+	}
+	return saved, nil
+}
+
+func (b *BallotUsecases) GetBallot(ctx context.Context, eventID string) (*domain.Ballot, error) {
+	ballot, err := b.ballots.GetBallot(ctx, eventID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ballot: %w", err)
+	}
+	return ballot, nil
+}
+
+func (b *BallotUsecases) EnterBallot(ctx context.Context, eventID, userID string) (*domain.BallotEntry, error) {
+	ballot, err := b.ballots.GetBallot(ctx, eventID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to enter ballot: %w", err)
+	}
+	now := time.Now().UTC()
+	if !ballot.AcceptsEntries(now) {
+		return nil, domain.ErrBallotEntryClosed
+	}
+
+	// Окно проверено по возможно устаревшему розыгрышу; заявку после розыгрыша отклонит хранилище
+	entry := &domain.BallotEntry{EventId: eventID, UserId: userID, Status: domain.BallotEntered, EnteredAt: now}
+	if err := b.ballots.EnterBallot(ctx, entry); err != nil {
+		return nil, fmt.Errorf("failed to enter ballot: %w", err)
+	}
+	return entry, nil
+}
+
+func (b *BallotUsecases) GetBallotEntry(ctx context.Context, eventID, userID string) (*domain.BallotEntry, error) {
+	entry, err := b.ballots.GetBallotEntry(ctx, eventID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ballot entry: %w", err)
+	}
+	return entry, nil
+}
+
+func (b *BallotUsecases) ListBallotEntries(ctx context.Context, eventID string) ([]*domain.BallotEntry, error) {
+	if _, err := b.ballots.GetBallot(ctx, eventID); err != nil {
+		return nil, fmt.Errorf("failed to list ballot entries: %w", err)
+	}
+	entries, err := b.ballots.ListBallotEntries(ctx, eventID, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list ballot entries: %w", err)
+	}
+	return entries, nil
+}
+
+func (b *BallotUsecases) DrawBallot(ctx context.Context, eventID string) (*domain.Ballot, error) {
+	ballot, err := b.ballots.GetBallot(port.WithStrongConsistency(ctx), eventID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to draw ballot: %w", err)
+	}
+	if ballot.Drawn() {
+		return nil, domain.ErrBallotAlreadyDrawn
+	}
+	if !ballot.Due(time.Now().UTC()) {
+		return nil, domain.ErrBallotEntryOpen
+	}
+	return b.draw(ctx, ballot)
+}
+
+// CloseWaitlist досрочно закрывает лист ожидания проведенного розыгрыша: оставшиеся
+// участники выбывают и получают уведомление
+func (b *BallotUsecases) CloseWaitlist(ctx context.Context, eventID string) ([]*domain.BallotEntry, error) {
+	ballot, err := b.ballots.GetBallot(port.WithStrongConsistency(ctx), eventID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to close ballot waitlist: %w", err)
+	}
+	if !ballot.Drawn() {
+		return nil, domain.ErrBallotNotDrawn
+	}
+	return b.closeWaitlist(ctx, eventID)
+}
+
+// RunBallots проводит розыгрыши, прием заявок в которых закончился, и отдает места,
+// освободившиеся после отмены неоплаченных броней, следующим в листе ожидания.
+// С наступлением даты мероприятия лист ожидания закрывается.
+// Ошибка одного розыгрыша не мешает остальным.
+func (b *BallotUsecases) RunBallots(ctx context.Context) error {
+	now := time.Now().UTC()
+	ballots, err := b.ballots.ListActiveBallots(ctx, now)
+	if err != nil {
+		return fmt.Errorf("failed to list active ballots: %w", err)
+	}
+
+	var errs []error
+	for _, ballot := range ballots {
+		if ballot.Drawn() {
+			err = b.refill(ctx, ballot, now)
+		} else {
+			_, err = b.draw(ctx, ballot)
+		}
+		// Розыгрыш уже провел другой экземпляр приложения
+		if err != nil && !errors.Is(err, domain.ErrBallotAlreadyDrawn) {
+			errs = append(errs, fmt.Errorf("ballot of event %s: %w", ballot.EventId, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// draw перемешивает участников по новому seed и раздает места
+func (b *BallotUsecases) draw(ctx context.Context, ballot *domain.Ballot) (*domain.Ballot, error) {
+	seed, err := newBallotSeed()
+	if err != nil {
+		return nil, fmt.Errorf("failed to draw ballot: %w", err)
+	}
+	drawn, err := b.ballots.DrawBallot(ctx, ballot.EventId, seed, time.Now().UTC())
+	if err != nil {
+		return nil, fmt.Errorf("failed to draw ballot: %w", err)
+	}
+	log.Printf("Ballot of event %s drawn with seed %d: %d entries", drawn.EventId, drawn.Seed, drawn.Entries)
+
+	if err := b.allocate(ctx, drawn, true); err != nil {
+		return nil, err
+	}
+	return drawn, nil
+}
+
+// refill раздает освободившиеся места листу ожидания или закрывает его в дату мероприятия.
+// Пока свободных мест нет, транзакция выделения места не открывается.
+func (b *BallotUsecases) refill(ctx context.Context, ballot *domain.Ballot, now time.Time) error {
+	event, err := b.repo.GetEvent(port.WithStrongConsistency(ctx), ballot.EventId)
+	if err != nil {
+		return fmt.Errorf("failed to get event: %w", err)
+	}
+	if domain.WaitlistClosed(event, now) {
+		_, err := b.closeWaitlist(ctx, ballot.EventId)
+		return err
+	}
+	if event.AvailableTickets == 0 {
+		return nil
+	}
+	return b.allocate(ctx, ballot, false)
+}
+
+// allocate создает брони следующим в порядке розыгрыша, пока есть места. Бронь ждет оплаты
+// столько же, сколько обычная. Когда места кончились, не получившие их уходят в лист
+// ожидания или, если он выключен, выбывают; уведомление об этом отправляется один раз,
+// сразу после розыгрыша (justDrawn).
+func (b *BallotUsecases) allocate(ctx context.Context, ballot *domain.Ballot, justDrawn bool) error {
+	for {
+		booking := &domain.Booking{
+			Id:      uuid.New().String(),
+			EventId: ballot.EventId,
+			Status:  domain.PendingStatus,
+			Date:    time.Now(),
+		}
+		err := b.ballots.AllocateBallotTicket(ctx, booking)
+		if errors.Is(err, domain.ErrBallotWaitlistEmpty) {
+			return nil
+		}
+		if errors.Is(err, domain.ErrNoTicketsAvailable) {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("failed to allocate ballot ticket: %w", err)
+		}
+
+		if err := b.broker.PublishDelayedCancellation(ctx, booking); err != nil {
+			return fmt.Errorf("failed to publish delayed cancellation: %w", err)
+		}
+		b.publish(ctx, domain.NewBookingLifecycleEvent(domain.BookingCreatedEvent, booking))
+		if entry, err := b.ballots.GetBallotEntry(port.WithStrongConsistency(ctx), booking.EventId, booking.UserId); err != nil {
+			log.Printf("Failed to get ballot entry of user %s: %v", booking.UserId, err)
+		} else {
+			b.publish(ctx, domain.NewBallotLifecycleEvent(domain.BallotWonEvent, entry))
+		}
+	}
+
+	if !ballot.Waitlist {
+		_, err := b.closeWaitlist(ctx, ballot.EventId)
+		return err
+	}
+	if !justDrawn {
+		return nil
+	}
+
+	waitlisted, err := b.ballots.ListBallotEntries(port.WithStrongConsistency(ctx), ballot.EventId, domain.BallotWaitlisted)
+	if err != nil {
+		return fmt.Errorf("failed to list ballot waitlist: %w", err)
+	}
+	for _, entry := range waitlisted {
+		b.publish(ctx, domain.NewBallotLifecycleEvent(domain.BallotWaitlistedEvent, entry))
+	}
+	return nil
+}
+
+// closeWaitlist переводит оставшихся в листе ожидания в выбывшие и уведомляет их
+func (b *BallotUsecases) closeWaitlist(ctx context.Context, eventID string) ([]*domain.BallotEntry, error) {
+	lost, err := b.ballots.CloseBallotWaitlist(ctx, eventID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to close ballot waitlist: %w", err)
+	}
+	for _, entry := range lost {
+		b.publish(ctx, domain.NewBallotLifecycleEvent(domain.BallotLostEvent, entry))
+	}
+	if len(lost) > 0 {
+		log.Printf("Ballot waitlist of event %s closed: %d entries lost", eventID, len(lost))
+	}
+	return lost, nil
+}
+
+// publish отправляет доменное событие подписчикам; ошибка только логируется, как в EventsUsecases
+func (b *BallotUsecases) publish(ctx context.Context, event *domain.LifecycleEvent) {
+	if err := b.events.PublishLifecycleEvent(ctx, event); err != nil {
+		log.Printf("Failed to publish %s event: %v", event.Type, err)
+	}
+}
+
+// newBallotSeed - неотрицательный seed из crypto/rand: исход розыгрыша нельзя предсказать заранее
+func newBallotSeed() (int64, error) {
+	var buf [8]byte
+	if _, err := rand.Read(buf[:]); err != nil {
+		return 0, err
+	}
+	return int64(binary.BigEndian.Uint64(buf[:]) >> 1), nil
+}
@@ -0,0 +1,300 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/dontpanicw/EventBooker/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockBallotRepository - мок хранилища розыгрышей
+type MockBallotRepository struct {
+	mock.Mock
+}
+
+func (m *MockBallotRepository) SaveBallot(ctx context.Context, ballot *domain.Ballot) (*domain.Ballot, error) {
+	args := m.Called(ctx, ballot)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Ballot), args.Error(1)
+}
+
+func (m *MockBallotRepository) GetBallot(ctx context.Context, eventID string) (*domain.Ballot, error) {
+	args := m.Called(ctx, eventID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Ballot), args.Error(1)
+}
+
+func (m *MockBallotRepository) ListActiveBallots(ctx context.Context, now time.Time) ([]*domain.Ballot, error) {
+	args := m.Called(ctx, now)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.Ballot), args.Error(1)
+}
+
+func (m *MockBallotRepository) EnterBallot(ctx context.Context, entry *domain.BallotEntry) error {
+	args := m.Called(ctx, entry)
+	return args.Error(0)
+}
+
+func (m *MockBallotRepository) GetBallotEntry(ctx context.Context, eventID, userID string) (*domain.BallotEntry, error) {
+	args := m.Called(ctx, eventID, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.BallotEntry), args.Error(1)
+}
+
+func (m *MockBallotRepository) ListBallotEntries(ctx context.Context, eventID, status string) ([]*domain.BallotEntry, error) {
+	args := m.Called(ctx, eventID, status)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.BallotEntry), args.Error(1)
+}
+
+func (m *MockBallotRepository) DrawBallot(ctx context.Context, eventID string, seed int64, drawnAt time.Time) (*domain.Ballot, error) {
+	args := m.Called(ctx, eventID, seed, drawnAt)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Ballot), args.Error(1)
+}
+
+func (m *MockBallotRepository) AllocateBallotTicket(ctx context.Context, booking *domain.Booking) error {
+	args := m.Called(ctx, booking)
+	return args.Error(0)
+}
+
+func (m *MockBallotRepository) CloseBallotWaitlist(ctx context.Context, eventID string) ([]*domain.BallotEntry, error) {
+	args := m.Called(ctx, eventID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.BallotEntry), args.Error(1)
+}
+
+func newBallot(opensAt, closesAt time.Time, waitlist bool) *domain.Ballot {
+	return &domain.Ballot{EventId: "event-123", EntryOpensAt: opensAt, EntryClosesAt: closesAt, Waitlist: waitlist}
+}
+
+func TestConfigureBallot(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockBallots := new(MockBallotRepository)
+	usecase := NewBallotUsecases(mockRepo, mockBallots, nil, nil)
+	ctx := context.Background()
+	now := time.Now().UTC()
+
+	_, err := usecase.ConfigureBallot(ctx, newBallot(now, now, true))
+	assert.ErrorIs(t, err, domain.ErrInvalidBallot)
+
+	mockRepo.On("GetEvent", ctx, "event-123").Return(&domain.Event{Id: "event-123", SaleMode: domain.SaleModeFirstCome}, nil).Once()
+	_, err = usecase.ConfigureBallot(ctx, newBallot(now, now.Add(time.Hour), true))
+	assert.ErrorIs(t, err, domain.ErrNotBallotEvent)
+
+	ballot := newBallot(now, now.Add(time.Hour), true)
+	mockRepo.On("GetEvent", ctx, "event-123").Return(&domain.Event{Id: "event-123", SaleMode: domain.SaleModeBallot}, nil)
+	mockBallots.On("SaveBallot", ctx, ballot).Return(ballot, nil)
+	saved, err := usecase.ConfigureBallot(ctx, ballot)
+	require.NoError(t, err)
+	assert.Equal(t, ballot, saved)
+	mockBallots.AssertExpectations(t)
+}
+
+func TestEnterBallot(t *testing.T) {
+	mockBallots := new(MockBallotRepository)
+	usecase := NewBallotUsecases(new(MockRepository), mockBallots, nil, nil)
+	ctx := context.Background()
+	now := time.Now().UTC()
+
+	mockBallots.On("GetBallot", ctx, "closed").Return(newBallot(now.Add(-2*time.Hour), now.Add(-time.Hour), true), nil)
+	_, err := usecase.EnterBallot(ctx, "closed", "user-1")
+	assert.ErrorIs(t, err, domain.ErrBallotEntryClosed)
+
+	mockBallots.On("GetBallot", ctx, "event-123").Return(newBallot(now.Add(-time.Hour), now.Add(time.Hour), true), nil)
+	mockBallots.On("EnterBallot", ctx, mock.MatchedBy(func(entry *domain.BallotEntry) bool {
+		return entry.EventId == "event-123" && entry.UserId == "user-1" && entry.Status == domain.BallotEntered
+	})).Return(nil).Once()
+	entry, err := usecase.EnterBallot(ctx, "event-123", "user-1")
+	require.NoError(t, err)
+	assert.Equal(t, domain.BallotEntered, entry.Status)
+
+	mockBallots.On("EnterBallot", ctx, mock.Anything).Return(fmt.Errorf("error enter ballot: %w", domain.ErrAlreadyEntered))
+	_, err = usecase.EnterBallot(ctx, "event-123", "user-1")
+	assert.ErrorIs(t, err, domain.ErrAlreadyEntered)
+	mockBallots.AssertExpectations(t)
+}
+
+func TestDrawBallot_NotDue(t *testing.T) {
+	mockBallots := new(MockBallotRepository)
+	usecase := NewBallotUsecases(new(MockRepository), mockBallots, nil, nil)
+	ctx := context.Background()
+	now := time.Now().UTC()
+
+	mockBallots.On("GetBallot", mock.Anything, "open").Return(newBallot(now.Add(-time.Hour), now.Add(time.Hour), true), nil)
+	_, err := usecase.DrawBallot(ctx, "open")
+	assert.ErrorIs(t, err, domain.ErrBallotEntryOpen)
+
+	drawn := newBallot(now.Add(-2*time.Hour), now.Add(-time.Hour), true)
+	drawn.DrawnAt = &now
+	mockBallots.On("GetBallot", mock.Anything, "drawn").Return(drawn, nil)
+	_, err = usecase.DrawBallot(ctx, "drawn")
+	assert.ErrorIs(t, err, domain.ErrBallotAlreadyDrawn)
+	mockBallots.AssertNotCalled(t, "DrawBallot", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestDrawBallot_AllocatesAndNotifies(t *testing.T) {
+	mockBallots := new(MockBallotRepository)
+	mockBroker := new(MockRabbitMQBroker)
+	mockEvents := new(MockEventPublisher)
+	usecase := NewBallotUsecases(new(MockRepository), mockBallots, mockBroker, mockEvents)
+	ctx := context.Background()
+	now := time.Now().UTC()
+
+	ballot := newBallot(now.Add(-2*time.Hour), now.Add(-time.Hour), true)
+	drawn := *ballot
+	drawn.Seed = 42
+	drawn.DrawnAt = &now
+	mockBallots.On("GetBallot", mock.Anything, "event-123").Return(ballot, nil)
+	mockBallots.On("DrawBallot", ctx, "event-123", mock.AnythingOfType("int64"), mock.Anything).Return(&drawn, nil)
+
+	// Одно место: первому в порядке розыгрыша
+	mockBallots.On("AllocateBallotTicket", ctx, mock.AnythingOfType("*domain.Booking")).Run(func(args mock.Arguments) {
+		args.Get(1).(*domain.Booking).UserId = "user-2"
+	}).Return(nil).Once()
+	mockBallots.On("AllocateBallotTicket", ctx, mock.Anything).Return(fmt.Errorf("error allocate ballot ticket: %w", domain.ErrNoTicketsAvailable)).Once()
+	mockBallots.On("GetBallotEntry", mock.Anything, "event-123", "user-2").Return(&domain.BallotEntry{
+		EventId: "event-123", UserId: "user-2", Status: domain.BallotWon, Rank: 1, BookingId: "booking-1",
+	}, nil)
+	mockBallots.On("ListBallotEntries", mock.Anything, "event-123", domain.BallotWaitlisted).Return([]*domain.BallotEntry{
+		{EventId: "event-123", UserId: "user-1", Status: domain.BallotWaitlisted, Rank: 2},
+	}, nil)
+
+	mockBroker.On("PublishDelayedCancellation", ctx, mock.MatchedBy(func(booking *domain.Booking) bool {
+		return booking.UserId == "user-2" && booking.Status == domain.PendingStatus
+	})).Return(nil).Once()
+	mockEvents.On("PublishLifecycleEvent", ctx, lifecycleEventOfType(domain.BookingCreatedEvent)).Return(nil).Once()
+	mockEvents.On("PublishLifecycleEvent", ctx, lifecycleEventOfType(domain.BallotWonEvent)).Return(nil).Once()
+	mockEvents.On("PublishLifecycleEvent", ctx, lifecycleEventOfType(domain.BallotWaitlistedEvent)).Return(nil).Once()
+
+	result, err := usecase.DrawBallot(ctx, "event-123")
+	require.NoError(t, err)
+	assert.Equal(t, int64(42), result.Seed)
+	mockBallots.AssertExpectations(t)
+	mockBroker.AssertExpectations(t)
+	mockEvents.AssertExpectations(t)
+}
+
+func TestRunBallots_WithoutWaitlistLosersNotified(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockBallots := new(MockBallotRepository)
+	mockEvents := new(MockEventPublisher)
+	usecase := NewBallotUsecases(mockRepo, mockBallots, new(MockRabbitMQBroker), mockEvents)
+	ctx := context.Background()
+	now := time.Now().UTC()
+
+	drawn := newBallot(now.Add(-2*time.Hour), now.Add(-time.Hour), false)
+	drawn.DrawnAt = &now
+	mockBallots.On("ListActiveBallots", ctx, mock.Anything).Return([]*domain.Ballot{drawn}, nil)
+	mockRepo.On("GetEvent", mock.Anything, "event-123").Return(&domain.Event{Id: "event-123", AvailableTickets: 1, Date: now.Add(24 * time.Hour)}, nil)
+	mockBallots.On("AllocateBallotTicket", ctx, mock.Anything).Return(fmt.Errorf("error allocate ballot ticket: %w", domain.ErrNoTicketsAvailable))
+	mockBallots.On("CloseBallotWaitlist", ctx, "event-123").Return([]*domain.BallotEntry{
+		{EventId: "event-123", UserId: "user-1", Status: domain.BallotLost, Rank: 2},
+	}, nil)
+	mockEvents.On("PublishLifecycleEvent", ctx, lifecycleEventOfType(domain.BallotLostEvent)).Return(nil).Once()
+
+	require.NoError(t, usecase.RunBallots(ctx))
+	mockBallots.AssertExpectations(t)
+	mockEvents.AssertExpectations(t)
+}
+
+func TestRunBallots_NoTicketsSkipsAllocation(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockBallots := new(MockBallotRepository)
+	usecase := NewBallotUsecases(mockRepo, mockBallots, nil, nil)
+	ctx := context.Background()
+	now := time.Now().UTC()
+
+	drawn := newBallot(now.Add(-2*time.Hour), now.Add(-time.Hour), true)
+	drawn.DrawnAt = &now
+	mockBallots.On("ListActiveBallots", ctx, mock.Anything).Return([]*domain.Ballot{drawn}, nil)
+	mockRepo.On("GetEvent", mock.Anything, "event-123").Return(&domain.Event{Id: "event-123", AvailableTickets: 0, Date: now.Add(24 * time.Hour)}, nil)
+
+	require.NoError(t, usecase.RunBallots(ctx))
+	mockBallots.AssertNotCalled(t, "AllocateBallotTicket", mock.Anything, mock.Anything)
+	mockBallots.AssertNotCalled(t, "CloseBallotWaitlist", mock.Anything, mock.Anything)
+}
+
+func TestRunBallots_WaitlistClosesOnEventDate(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockBallots := new(MockBallotRepository)
+	mockEvents := new(MockEventPublisher)
+	usecase := NewBallotUsecases(mockRepo, mockBallots, nil, mockEvents)
+	ctx := context.Background()
+	now := time.Now().UTC()
+
+	drawn := newBallot(now.Add(-48*time.Hour), now.Add(-47*time.Hour), true)
+	drawn.DrawnAt = &now
+	mockBallots.On("ListActiveBallots", ctx, mock.Anything).Return([]*domain.Ballot{drawn}, nil)
+	// Место освободилось, но мероприятие уже началось
+	mockRepo.On("GetEvent", mock.Anything, "event-123").Return(&domain.Event{Id: "event-123", AvailableTickets: 1, Date: now.Add(-time.Minute)}, nil)
+	mockBallots.On("CloseBallotWaitlist", ctx, "event-123").Return([]*domain.BallotEntry{
+		{EventId: "event-123", UserId: "user-1", Status: domain.BallotLost, Rank: 2},
+		{EventId: "event-123", UserId: "user-3", Status: domain.BallotLost, Rank: 3},
+	}, nil)
+	mockEvents.On("PublishLifecycleEvent", ctx, lifecycleEventOfType(domain.BallotLostEvent)).Return(nil).Twice()
+
+	require.NoError(t, usecase.RunBallots(ctx))
+	mockBallots.AssertNotCalled(t, "AllocateBallotTicket", mock.Anything, mock.Anything)
+	mockBallots.AssertExpectations(t)
+	mockEvents.AssertExpectations(t)
+}
+
+func TestCloseWaitlist(t *testing.T) {
+	mockBallots := new(MockBallotRepository)
+	mockEvents := new(MockEventPublisher)
+	usecase := NewBallotUsecases(new(MockRepository), mockBallots, nil, mockEvents)
+	ctx := context.Background()
+	now := time.Now().UTC()
+
+	mockBallots.On("GetBallot", mock.Anything, "open").Return(newBallot(now.Add(-time.Hour), now.Add(time.Hour), true), nil)
+	_, err := usecase.CloseWaitlist(ctx, "open")
+	assert.ErrorIs(t, err, domain.ErrBallotNotDrawn)
+
+	drawn := newBallot(now.Add(-2*time.Hour), now.Add(-time.Hour), true)
+	drawn.DrawnAt = &now
+	mockBallots.On("GetBallot", mock.Anything, "event-123").Return(drawn, nil)
+	mockBallots.On("CloseBallotWaitlist", ctx, "event-123").Return([]*domain.BallotEntry{
+		{EventId: "event-123", UserId: "user-1", Status: domain.BallotLost, Rank: 2},
+	}, nil)
+	mockEvents.On("PublishLifecycleEvent", ctx, lifecycleEventOfType(domain.BallotLostEvent)).Return(nil).Once()
+
+	lost, err := usecase.CloseWaitlist(ctx, "event-123")
+	require.NoError(t, err)
+	assert.Len(t, lost, 1)
+	mockEvents.AssertExpectations(t)
+}
+
+func TestRunBallots_DrawnElsewhere(t *testing.T) {
+	mockBallots := new(MockBallotRepository)
+	usecase := NewBallotUsecases(new(MockRepository), mockBallots, nil, nil)
+	ctx := context.Background()
+	now := time.Now().UTC()
+
+	due := newBallot(now.Add(-2*time.Hour), now.Add(-time.Hour), true)
+	mockBallots.On("ListActiveBallots", ctx, mock.Anything).Return([]*domain.Ballot{due}, nil)
+	mockBallots.On("DrawBallot", ctx, "event-123", mock.Anything, mock.Anything).
+		Return(nil, fmt.Errorf("error draw ballot: %w", domain.ErrBallotAlreadyDrawn))
+
+	assert.NoError(t, usecase.RunBallots(ctx))
+	mockBallots.AssertExpectations(t)
+}
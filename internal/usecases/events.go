@@ -6,31 +6,61 @@ import (
 	"github.com/dontpanicw/EventBooker/internal/domain"
 	"github.com/dontpanicw/EventBooker/internal/port"
 	"github.com/google/uuid"
+	"log"
 	"time"
 )
 
 type EventsUsecases struct {
-	repo   port.Repository
-	broker port.Broker
+	repo      port.Repository
+	broker    port.Broker
+	events    port.EventPublisher
+	admission port.AdmissionChecker
 }
 
-func NewEventsUsecases(repo port.Repository, rabbitBroker port.Broker) port.Usecases {
+func NewEventsUsecases(repo port.Repository, rabbitBroker port.Broker, events port.EventPublisher, admission port.AdmissionChecker) port.Usecases {
 	return &EventsUsecases{
-		repo:   repo,
-		broker: rabbitBroker,
+		repo:      repo,
+		broker:    rabbitBroker,
+		events:    events,
+		admission: admission,
 	}
 }
 
 func (e *EventsUsecases) CreateEvent(ctx context.Context, event *domain.Event) (string, error) {
+	if event.Date.IsZero() {
+		return "", domain.ErrInvalidEventDate
+	}
+	switch event.SaleMode {
+	case "":
+		event.SaleMode = domain.SaleModeFirstCome
+	case domain.SaleModeFirstCome, domain.SaleModeBallot:
+	default:
+		return "", domain.ErrInvalidSaleMode
+	}
+	currency, err := domain.ParseCurrency(event.Price.Currency)
+	if err != nil {
+		return "", err
+	}
+	event.Price.Currency = currency
+	event.NormalizePrice()
+
 	id := uuid.New().String()
 	event.Id = id
-	date := time.Now()
-	event.Date = date
+	// Дата проведения задается администратором: по ней фильтруются upcoming и past
+	event.Date = event.Date.UTC()
+
+	// Новое событие продает все места; старые клиенты передают только available_tickets
+	if event.Capacity == 0 {
+		event.Capacity = event.AvailableTickets
+	}
+	event.AvailableTickets = event.Capacity
 
-	id, err := e.repo.CreateEvent(ctx, event)
+	id, err = e.repo.CreateEvent(ctx, event)
 	if err != nil {
 		return "", fmt.Errorf("failed to create event: %w", err)
 	}
+
+	e.publish(ctx, domain.NewEventLifecycleEvent(domain.EventCreatedEvent, event))
 	return id, nil
 }
 
@@ -39,8 +69,14 @@ func (e *EventsUsecases) BookEvent(ctx context.Context, booking *domain.Booking)
 	booking.Id = id
 	booking.Date = time.Now()
 	booking.Status = domain.PendingStatus
+	booking.PromoCode = domain.NormalizePromoCode(booking.PromoCode)
 
-	id, err := e.repo.BookEvent(ctx, booking)
+	// Пока у мероприятия открыта очередь, бронирует только допущенный из нее
+	if err := e.admission.CheckAdmission(ctx, booking); err != nil {
+		return "", fmt.Errorf("failed to book event: %w", err)
+	}
+
+	event, err := e.repo.BookEvent(ctx, booking)
 	if err != nil {
 		return "", fmt.Errorf("failed to book event: %w", err)
 	}
@@ -50,21 +86,24 @@ func (e *EventsUsecases) BookEvent(ctx context.Context, booking *domain.Booking)
 		return "", fmt.Errorf("failed to publish delayed cancellation: %w", err)
 	}
 
+	e.publish(ctx, domain.NewBookingLifecycleEvent(domain.BookingCreatedEvent, booking))
+	e.publishIfSoldOut(ctx, event)
+
 	return id, nil
 }
 
-func (e *EventsUsecases) ConfirmEvent(ctx context.Context, eventID string) error {
-	err := e.repo.ConfirmBooking(ctx, eventID)
+// UpdateEvent правит событие; правка, основанная на устаревшей версии, отклоняется с ErrVersionMismatch
+func (e *EventsUsecases) UpdateEvent(ctx context.Context, eventID string, version int64, update domain.EventUpdate) (*domain.Event, error) {
+	if err := update.Validate(); err != nil {
+		return nil, err
+	}
+	event, err := e.repo.UpdateEvent(ctx, eventID, version, update)
 	if err != nil {
-		return fmt.Errorf("failed to confirm event: %w", err)
+		return nil, fmt.Errorf("failed to update event: %w", err)
 	}
 
-	// Публикуем сообщение о подтверждении
-	//if err := e.broker.PublishConfirmation(ctx, eventID, eventID); err != nil {
-	//	return fmt.Errorf("failed to publish confirmation: %w", err)
-	//}
-
-	return nil
+	e.publish(ctx, domain.NewEventLifecycleEvent(domain.EventUpdatedEvent, event))
+	return event, nil
 }
 
 func (e *EventsUsecases) GetEvent(ctx context.Context, eventID string) (*domain.Event, error) {
@@ -72,15 +111,47 @@ func (e *EventsUsecases) GetEvent(ctx context.Context, eventID string) (*domain.
 	if err != nil {
 		return nil, fmt.Errorf("failed to confirm event: %w", err)
 	}
+	quotePrice(event, time.Now().UTC())
 	return event, nil
 }
 
-func (e *EventsUsecases) GetAllEvents(ctx context.Context) ([]*domain.Event, error) {
-	events, err := e.repo.GetAllEvents(ctx)
+// quotePrice показывает текущую и следующую цену мероприятия с расписанием цены
+func quotePrice(event *domain.Event, now time.Time) {
+	if len(event.PricePhases) > 0 {
+		quote := event.Quote(now)
+		event.Pricing = &quote
+	}
+}
+
+// ListEvents возвращает страницу событий; upcoming и past считаются от текущего момента
+func (e *EventsUsecases) ListEvents(ctx context.Context, filter domain.EventFilter) (*domain.EventPage, error) {
+	filter, err := filter.Resolve(time.Now().UTC())
+	if err != nil {
+		return nil, err
+	}
+	page, err := e.repo.ListEvents(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list events: %w", err)
+	}
+	return page, nil
+}
+
+// SearchEvents ищет события по словам запроса; limit по умолчанию и его предел те же, что у списка
+func (e *EventsUsecases) SearchEvents(ctx context.Context, query string, limit int) ([]*domain.EventSearchResult, error) {
+	if len(domain.SearchTerms(query)) == 0 {
+		return nil, domain.ErrInvalidSearchQuery
+	}
+	switch {
+	case limit <= 0:
+		limit = domain.DefaultEventsLimit
+	case limit > domain.MaxEventsLimit:
+		limit = domain.MaxEventsLimit
+	}
+	results, err := e.repo.SearchEvents(ctx, query, limit)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get all events: %w", err)
+		return nil, fmt.Errorf("failed to search events: %w", err)
 	}
-	return events, nil
+	return results, nil
 }
 
 func (e *EventsUsecases) GetBooking(ctx context.Context, bookingID string) (*domain.Booking, error) {
@@ -91,17 +162,108 @@ func (e *EventsUsecases) GetBooking(ctx context.Context, bookingID string) (*dom
 	return booking, nil
 }
 
-func (e *EventsUsecases) ConfirmBooking(ctx context.Context, bookingID string) error {
+// ListUserBookings возвращает страницу броней пользователя вместе с мероприятиями
+func (e *EventsUsecases) ListUserBookings(ctx context.Context, filter domain.BookingFilter) (*domain.BookingPage, error) {
+	filter, err := filter.Resolve()
+	if err != nil {
+		return nil, err
+	}
+	page, err := e.repo.ListBookings(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list user bookings: %w", err)
+	}
+	return page, nil
+}
+
+// ListEventBookings возвращает страницу броней мероприятия; несуществующее мероприятие - ErrEventNotFound
+func (e *EventsUsecases) ListEventBookings(ctx context.Context, filter domain.BookingFilter) (*domain.BookingPage, error) {
+	filter, err := filter.Resolve()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := e.repo.GetEvent(ctx, filter.EventId); err != nil {
+		return nil, fmt.Errorf("failed to list event bookings: %w", err)
+	}
+	page, err := e.repo.ListBookings(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list event bookings: %w", err)
+	}
+	return page, nil
+}
+
+// exportBatchSize - сколько броней экспорт читает из хранилища за один запрос
+const exportBatchSize = 500
+
+// ExportEventBookings читает брони мероприятия страницами по exportBatchSize и отдает их fn
+// по одной. Соединение с БД не держится, пока клиент читает файл, зато выгрузка - не снимок:
+// брони, созданные во время экспорта, могут в нее не попасть.
+func (e *EventsUsecases) ExportEventBookings(ctx context.Context, filter domain.BookingFilter, fn func(*domain.Booking) error) error {
+	filter.After = nil
+	filter, err := filter.Resolve()
+	if err != nil {
+		return err
+	}
+	filter.Limit = exportBatchSize
+	filter.LineItems = true
+	if _, err := e.repo.GetEvent(ctx, filter.EventId); err != nil {
+		return fmt.Errorf("failed to export event bookings: %w", err)
+	}
+
+	for {
+		page, err := e.repo.ListBookings(ctx, filter)
+		if err != nil {
+			return fmt.Errorf("failed to export event bookings: %w", err)
+		}
+		for _, b := range page.Bookings {
+			if err := fn(b.Booking); err != nil {
+				return err
+			}
+		}
+		if page.NextCursor == "" {
+			return nil
+		}
+		last := page.Bookings[len(page.Bookings)-1].Booking
+		filter.After = &domain.BookingCursor{Date: last.Date, Id: last.Id}
+	}
+}
+
+func (e *EventsUsecases) ConfirmBooking(ctx context.Context, bookingID string, version int64) error {
 	// Сначала получаем бронь, чтобы узнать eventID
-	_, err := e.repo.GetBooking(ctx, bookingID)
+	booking, err := e.repo.GetBooking(ctx, bookingID)
 	if err != nil {
 		return fmt.Errorf("failed to get booking: %w", err)
 	}
 
+	// Оплату подтверждает владелец брони: запрос не несет user_id, поэтому в журнале
+	// инициатор - пользователь брони
+	if actor := port.ActorFrom(ctx); actor.Type == domain.ActorUser && actor.Id == "" {
+		ctx = port.WithActor(ctx, domain.Actor{Type: domain.ActorUser, Id: booking.UserId})
+	}
+
 	// Обновляем статус брони
-	err = e.repo.ConfirmBooking(ctx, bookingID)
+	err = e.repo.ConfirmBooking(ctx, bookingID, version)
 	if err != nil {
 		return fmt.Errorf("failed to confirm booking: %w", err)
 	}
+
+	booking.Status = domain.ConfirmedStatus
+	e.publish(ctx, domain.NewBookingLifecycleEvent(domain.BookingConfirmedEvent, booking))
 	return nil
 }
+
+// publishIfSoldOut публикует event.sold_out, если бронь забрала последнее место. Остаток
+// берется из транзакции брони: повторное чтение после нее могло бы увидеть место,
+// освобожденное отменой, или пропустить распродажу. Так событие публикует ровно одна бронь.
+func (e *EventsUsecases) publishIfSoldOut(ctx context.Context, event *domain.Event) {
+	if event.AvailableTickets == 0 {
+		e.publish(ctx, domain.NewEventLifecycleEvent(domain.EventSoldOutEvent, event))
+	}
+}
+
+// publish отправляет доменное событие подписчикам. Изменение состояния уже сохранено,
+// поэтому ошибка публикации только логируется и не откатывает операцию.
+func (e *EventsUsecases) publish(ctx context.Context, event *domain.LifecycleEvent) {
+	if err := e.events.PublishLifecycleEvent(ctx, event); err != nil {
+		log.Printf("Failed to publish %s event: %v", event.Type, err)
+	}
+}
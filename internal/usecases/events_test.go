@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/dontpanicw/EventBooker/internal/domain"
+	"github.com/dontpanicw/EventBooker/internal/port"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
@@ -21,16 +22,27 @@ func (m *MockRepository) CreateEvent(ctx context.Context, event *domain.Event) (
 	return args.String(0), args.Error(1)
 }
 
-func (m *MockRepository) BookEvent(ctx context.Context, booking *domain.Booking) (string, error) {
+func (m *MockRepository) BookEvent(ctx context.Context, booking *domain.Booking) (*domain.Event, error) {
 	args := m.Called(ctx, booking)
-	return args.String(0), args.Error(1)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Event), args.Error(1)
 }
 
-func (m *MockRepository) ConfirmBooking(ctx context.Context, bookingID string) error {
-	args := m.Called(ctx, bookingID)
+func (m *MockRepository) ConfirmBooking(ctx context.Context, bookingID string, version int64) error {
+	args := m.Called(ctx, bookingID, version)
 	return args.Error(0)
 }
 
+func (m *MockRepository) UpdateEvent(ctx context.Context, eventID string, version int64, update domain.EventUpdate) (*domain.Event, error) {
+	args := m.Called(ctx, eventID, version, update)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Event), args.Error(1)
+}
+
 func (m *MockRepository) GetEvent(ctx context.Context, eventID string) (*domain.Event, error) {
 	args := m.Called(ctx, eventID)
 	if args.Get(0) == nil {
@@ -39,12 +51,28 @@ func (m *MockRepository) GetEvent(ctx context.Context, eventID string) (*domain.
 	return args.Get(0).(*domain.Event), args.Error(1)
 }
 
-func (m *MockRepository) GetAllEvents(ctx context.Context) ([]*domain.Event, error) {
-	args := m.Called(ctx)
+func (m *MockRepository) SearchEvents(ctx context.Context, query string, limit int) ([]*domain.EventSearchResult, error) {
+	args := m.Called(ctx, query, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.EventSearchResult), args.Error(1)
+}
+
+func (m *MockRepository) ListBookings(ctx context.Context, filter domain.BookingFilter) (*domain.BookingPage, error) {
+	args := m.Called(ctx, filter)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
-	return args.Get(0).([]*domain.Event), args.Error(1)
+	return args.Get(0).(*domain.BookingPage), args.Error(1)
+}
+
+func (m *MockRepository) ListEvents(ctx context.Context, filter domain.EventFilter) (*domain.EventPage, error) {
+	args := m.Called(ctx, filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.EventPage), args.Error(1)
 }
 
 func (m *MockRepository) GetBooking(ctx context.Context, bookingID string) (*domain.Booking, error) {
@@ -80,23 +108,36 @@ func (m *MockRabbitMQBroker) PublishDelayedCancellation(ctx context.Context, boo
 	return args.Error(0)
 }
 
-//func (m *MockRabbitMQBroker) PublishConfirmation(ctx context.Context, bookingID, eventID string) error {
-//	args := m.Called(ctx, bookingID, eventID)
-//	return args.Error(0)
-//}
+// MockEventPublisher - мок публикации доменных событий
+type MockEventPublisher struct {
+	mock.Mock
+}
+
+func (m *MockEventPublisher) PublishLifecycleEvent(ctx context.Context, event *domain.LifecycleEvent) error {
+	args := m.Called(ctx, event)
+	return args.Error(0)
+}
 
 func (m *MockRabbitMQBroker) Close() error {
 	args := m.Called()
 	return args.Error(0)
 }
 
+func lifecycleEventOfType(eventType string) interface{} {
+	return mock.MatchedBy(func(event *domain.LifecycleEvent) bool {
+		return event.Type == eventType
+	})
+}
+
 func TestCreateEvent_Success(t *testing.T) {
 	mockRepo := new(MockRepository)
 	mockBroker := new(MockRabbitMQBroker)
+	mockEvents := new(MockEventPublisher)
 
 	usecase := &EventsUsecases{
 		repo:   mockRepo,
 		broker: mockBroker,
+		events: mockEvents,
 	}
 
 	ctx := context.Background()
@@ -104,27 +145,99 @@ func TestCreateEvent_Success(t *testing.T) {
 		Name:             "Test Event",
 		Description:      "Test Description",
 		IsFree:           false,
-		Price:            100.0,
+		Price:            domain.Money{Amount: 10000, Currency: "eur"},
 		AvailableTickets: 50,
+		Date:             time.Date(2026, time.June, 1, 19, 0, 0, 0, time.FixedZone("MSK", 3*60*60)),
 	}
 
 	mockRepo.On("CreateEvent", ctx, mock.AnythingOfType("*domain.Event")).Return("event-123", nil)
+	mockEvents.On("PublishLifecycleEvent", ctx, lifecycleEventOfType(domain.EventCreatedEvent)).Return(nil)
 
 	eventID, err := usecase.CreateEvent(ctx, event)
 
 	assert.NoError(t, err)
 	assert.NotEmpty(t, eventID)
 	assert.NotEmpty(t, event.Id)
+	assert.Equal(t, "EUR", event.Price.Currency)
+	assert.Equal(t, time.Date(2026, time.June, 1, 16, 0, 0, 0, time.UTC), event.Date)
 	mockRepo.AssertExpectations(t)
+	mockEvents.AssertExpectations(t)
+}
+
+func TestCreateEvent_Currency(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockEvents := new(MockEventPublisher)
+	usecase := &EventsUsecases{repo: mockRepo, events: mockEvents}
+
+	ctx := context.Background()
+	date := time.Date(2026, time.June, 1, 19, 0, 0, 0, time.UTC)
+	mockRepo.On("CreateEvent", ctx, mock.AnythingOfType("*domain.Event")).Return("event-123", nil)
+	mockEvents.On("PublishLifecycleEvent", ctx, lifecycleEventOfType(domain.EventCreatedEvent)).Return(nil)
+
+	event := &domain.Event{Name: "No currency", Price: domain.Money{Amount: 150000}, AvailableTickets: 10, Date: date}
+	_, err := usecase.CreateEvent(ctx, event)
+	assert.NoError(t, err)
+	assert.Equal(t, domain.DefaultCurrency, event.Price.Currency)
+
+	// Цена бесплатного события не сохраняется: сортировка по цене считает его нулевым
+	free := &domain.Event{Name: "Free", IsFree: true, Price: domain.Money{Amount: 150000}, AvailableTickets: 10, Date: date}
+	_, err = usecase.CreateEvent(ctx, free)
+	assert.NoError(t, err)
+	assert.Equal(t, domain.Money{Currency: domain.DefaultCurrency}, free.Price)
+
+	_, err = usecase.CreateEvent(ctx, &domain.Event{Name: "Bad", Price: domain.Money{Amount: 100, Currency: "XXX"}, Date: date})
+	assert.ErrorIs(t, err, domain.ErrInvalidCurrency)
+	mockRepo.AssertNumberOfCalls(t, "CreateEvent", 2)
+}
+
+func TestCreateEvent_CapacityDefaultsToAvailableTickets(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockEvents := new(MockEventPublisher)
+
+	usecase := &EventsUsecases{
+		repo:   mockRepo,
+		events: mockEvents,
+	}
+
+	ctx := context.Background()
+	date := time.Date(2026, time.June, 1, 19, 0, 0, 0, time.UTC)
+	legacy := &domain.Event{Name: "Legacy", AvailableTickets: 50, Date: date}
+	withCapacity := &domain.Event{Name: "New", AvailableTickets: 10, Capacity: 30, Date: date}
+
+	mockRepo.On("CreateEvent", ctx, mock.AnythingOfType("*domain.Event")).Return("event-123", nil)
+	mockEvents.On("PublishLifecycleEvent", ctx, lifecycleEventOfType(domain.EventCreatedEvent)).Return(nil)
+
+	_, err := usecase.CreateEvent(ctx, legacy)
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(50), legacy.Capacity)
+	assert.Equal(t, uint32(50), legacy.AvailableTickets)
+
+	// Новое событие открывает в продажу всю вместимость
+	_, err = usecase.CreateEvent(ctx, withCapacity)
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(30), withCapacity.Capacity)
+	assert.Equal(t, uint32(30), withCapacity.AvailableTickets)
+}
+
+func TestCreateEvent_DateRequired(t *testing.T) {
+	mockRepo := new(MockRepository)
+	usecase := &EventsUsecases{repo: mockRepo}
+
+	_, err := usecase.CreateEvent(context.Background(), &domain.Event{Name: "No date", AvailableTickets: 10})
+
+	assert.ErrorIs(t, err, domain.ErrInvalidEventDate)
+	mockRepo.AssertNotCalled(t, "CreateEvent", mock.Anything, mock.Anything)
 }
 
 func TestCreateEvent_RepositoryError(t *testing.T) {
 	mockRepo := new(MockRepository)
 	mockBroker := new(MockRabbitMQBroker)
+	mockEvents := new(MockEventPublisher)
 
 	usecase := &EventsUsecases{
 		repo:   mockRepo,
 		broker: mockBroker,
+		events: mockEvents,
 	}
 
 	ctx := context.Background()
@@ -132,6 +245,7 @@ func TestCreateEvent_RepositoryError(t *testing.T) {
 		Name:             "Test Event",
 		Description:      "Test Description",
 		AvailableTickets: 50,
+		Date:             time.Now(),
 	}
 
 	mockRepo.On("CreateEvent", ctx, mock.AnythingOfType("*domain.Event")).Return("", errors.New("database error"))
@@ -144,13 +258,25 @@ func TestCreateEvent_RepositoryError(t *testing.T) {
 	mockRepo.AssertExpectations(t)
 }
 
+// stubAdmission пропускает бронь или отклоняет ее с err
+type stubAdmission struct {
+	err error
+}
+
+func (s stubAdmission) CheckAdmission(ctx context.Context, booking *domain.Booking) error {
+	return s.err
+}
+
 func TestBookEvent_Success(t *testing.T) {
 	mockRepo := new(MockRepository)
 	mockBroker := new(MockRabbitMQBroker)
+	mockEvents := new(MockEventPublisher)
 
 	usecase := &EventsUsecases{
-		repo:   mockRepo,
-		broker: mockBroker,
+		repo:      mockRepo,
+		broker:    mockBroker,
+		events:    mockEvents,
+		admission: stubAdmission{},
 	}
 
 	ctx := context.Background()
@@ -159,8 +285,9 @@ func TestBookEvent_Success(t *testing.T) {
 		EventId: "event-123",
 	}
 
-	mockRepo.On("BookEvent", ctx, mock.AnythingOfType("*domain.Booking")).Return("booking-123", nil)
+	mockRepo.On("BookEvent", ctx, mock.AnythingOfType("*domain.Booking")).Return(&domain.Event{Id: "event-123", AvailableTickets: 10}, nil)
 	mockBroker.On("PublishDelayedCancellation", ctx, mock.AnythingOfType("*domain.Booking")).Return(nil)
+	mockEvents.On("PublishLifecycleEvent", ctx, lifecycleEventOfType(domain.BookingCreatedEvent)).Return(nil)
 
 	bookingID, err := usecase.BookEvent(ctx, booking)
 
@@ -170,15 +297,79 @@ func TestBookEvent_Success(t *testing.T) {
 	assert.NotEmpty(t, booking.Id)
 	mockRepo.AssertExpectations(t)
 	mockBroker.AssertExpectations(t)
+	mockEvents.AssertExpectations(t)
+	mockEvents.AssertNotCalled(t, "PublishLifecycleEvent", ctx, lifecycleEventOfType(domain.EventSoldOutEvent))
+}
+
+func TestBookEvent_LastTicketPublishesSoldOut(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockBroker := new(MockRabbitMQBroker)
+	mockEvents := new(MockEventPublisher)
+
+	usecase := &EventsUsecases{
+		repo:      mockRepo,
+		broker:    mockBroker,
+		events:    mockEvents,
+		admission: stubAdmission{},
+	}
+
+	ctx := context.Background()
+	booking := &domain.Booking{
+		UserId:  "user-123",
+		EventId: "event-123",
+	}
+
+	// Остаток мест берется из транзакции брони, а не повторным чтением
+	mockRepo.On("BookEvent", ctx, mock.AnythingOfType("*domain.Booking")).Return(&domain.Event{Id: "event-123", AvailableTickets: 0}, nil)
+	mockBroker.On("PublishDelayedCancellation", ctx, mock.AnythingOfType("*domain.Booking")).Return(nil)
+	mockEvents.On("PublishLifecycleEvent", ctx, lifecycleEventOfType(domain.BookingCreatedEvent)).Return(nil)
+	mockEvents.On("PublishLifecycleEvent", ctx, lifecycleEventOfType(domain.EventSoldOutEvent)).Return(nil)
+
+	_, err := usecase.BookEvent(ctx, booking)
+
+	assert.NoError(t, err)
+	mockEvents.AssertExpectations(t)
+	mockRepo.AssertNotCalled(t, "GetEvent", mock.Anything, mock.Anything)
+}
+
+func TestBookEvent_EventPublishErrorDoesNotFailBooking(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockBroker := new(MockRabbitMQBroker)
+	mockEvents := new(MockEventPublisher)
+
+	usecase := &EventsUsecases{
+		repo:      mockRepo,
+		broker:    mockBroker,
+		events:    mockEvents,
+		admission: stubAdmission{},
+	}
+
+	ctx := context.Background()
+	booking := &domain.Booking{
+		UserId:  "user-123",
+		EventId: "event-123",
+	}
+
+	mockRepo.On("BookEvent", ctx, mock.AnythingOfType("*domain.Booking")).Return(&domain.Event{Id: "event-123", AvailableTickets: 5}, nil)
+	mockBroker.On("PublishDelayedCancellation", ctx, mock.AnythingOfType("*domain.Booking")).Return(nil)
+	mockEvents.On("PublishLifecycleEvent", ctx, mock.Anything).Return(errors.New("rabbitmq error"))
+
+	bookingID, err := usecase.BookEvent(ctx, booking)
+
+	assert.NoError(t, err)
+	assert.Equal(t, booking.Id, bookingID)
 }
 
 func TestBookEvent_RepositoryError(t *testing.T) {
 	mockRepo := new(MockRepository)
 	mockBroker := new(MockRabbitMQBroker)
+	mockEvents := new(MockEventPublisher)
 
 	usecase := &EventsUsecases{
-		repo:   mockRepo,
-		broker: mockBroker,
+		repo:      mockRepo,
+		broker:    mockBroker,
+		events:    mockEvents,
+		admission: stubAdmission{},
 	}
 
 	ctx := context.Background()
@@ -187,7 +378,7 @@ func TestBookEvent_RepositoryError(t *testing.T) {
 		EventId: "event-123",
 	}
 
-	mockRepo.On("BookEvent", ctx, mock.AnythingOfType("*domain.Booking")).Return("", errors.New("no tickets available"))
+	mockRepo.On("BookEvent", ctx, mock.AnythingOfType("*domain.Booking")).Return(nil, errors.New("no tickets available"))
 
 	bookingID, err := usecase.BookEvent(ctx, booking)
 
@@ -200,10 +391,13 @@ func TestBookEvent_RepositoryError(t *testing.T) {
 func TestBookEvent_BrokerError(t *testing.T) {
 	mockRepo := new(MockRepository)
 	mockBroker := new(MockRabbitMQBroker)
+	mockEvents := new(MockEventPublisher)
 
 	usecase := &EventsUsecases{
-		repo:   mockRepo,
-		broker: mockBroker,
+		repo:      mockRepo,
+		broker:    mockBroker,
+		events:    mockEvents,
+		admission: stubAdmission{},
 	}
 
 	ctx := context.Background()
@@ -212,7 +406,7 @@ func TestBookEvent_BrokerError(t *testing.T) {
 		EventId: "event-123",
 	}
 
-	mockRepo.On("BookEvent", ctx, mock.AnythingOfType("*domain.Booking")).Return("booking-123", nil)
+	mockRepo.On("BookEvent", ctx, mock.AnythingOfType("*domain.Booking")).Return(&domain.Event{Id: "event-123", AvailableTickets: 5}, nil)
 	mockBroker.On("PublishDelayedCancellation", ctx, mock.AnythingOfType("*domain.Booking")).Return(errors.New("rabbitmq error"))
 
 	bookingID, err := usecase.BookEvent(ctx, booking)
@@ -224,13 +418,81 @@ func TestBookEvent_BrokerError(t *testing.T) {
 	mockBroker.AssertExpectations(t)
 }
 
+func TestBookEvent_AdmissionRequired(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockBroker := new(MockRabbitMQBroker)
+
+	usecase := &EventsUsecases{
+		repo:      mockRepo,
+		broker:    mockBroker,
+		admission: stubAdmission{err: domain.ErrAdmissionRequired},
+	}
+
+	bookingID, err := usecase.BookEvent(context.Background(), &domain.Booking{UserId: "user-123", EventId: "event-123"})
+
+	assert.ErrorIs(t, err, domain.ErrAdmissionRequired)
+	assert.Empty(t, bookingID)
+	mockRepo.AssertNotCalled(t, "BookEvent", mock.Anything, mock.Anything)
+	mockBroker.AssertNotCalled(t, "PublishDelayedCancellation", mock.Anything, mock.Anything)
+}
+
+func TestUpdateEvent_Success(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockEvents := new(MockEventPublisher)
+	usecase := &EventsUsecases{repo: mockRepo, events: mockEvents}
+
+	ctx := context.Background()
+	name := "New name"
+	update := domain.EventUpdate{Name: &name}
+	updated := &domain.Event{Id: "event-123", Name: name, Version: 4}
+
+	mockRepo.On("UpdateEvent", ctx, "event-123", int64(3), update).Return(updated, nil)
+	mockEvents.On("PublishLifecycleEvent", ctx, lifecycleEventOfType(domain.EventUpdatedEvent)).Return(nil)
+
+	event, err := usecase.UpdateEvent(ctx, "event-123", 3, update)
+
+	assert.NoError(t, err)
+	assert.Equal(t, updated, event)
+	mockRepo.AssertExpectations(t)
+	mockEvents.AssertExpectations(t)
+}
+
+func TestUpdateEvent_Invalid(t *testing.T) {
+	mockRepo := new(MockRepository)
+	usecase := &EventsUsecases{repo: mockRepo}
+
+	empty := " "
+	_, err := usecase.UpdateEvent(context.Background(), "event-123", 3, domain.EventUpdate{Name: &empty})
+
+	assert.ErrorIs(t, err, domain.ErrInvalidEventUpdate)
+	mockRepo.AssertNotCalled(t, "UpdateEvent", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestUpdateEvent_VersionMismatch(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockEvents := new(MockEventPublisher)
+	usecase := &EventsUsecases{repo: mockRepo, events: mockEvents}
+
+	ctx := context.Background()
+	price := int64(10000)
+	update := domain.EventUpdate{Price: &price}
+	mockRepo.On("UpdateEvent", ctx, "event-123", int64(3), update).Return(nil, domain.ErrVersionMismatch)
+
+	_, err := usecase.UpdateEvent(ctx, "event-123", 3, update)
+
+	assert.ErrorIs(t, err, domain.ErrVersionMismatch)
+	mockEvents.AssertNotCalled(t, "PublishLifecycleEvent", mock.Anything, mock.Anything)
+}
+
 func TestConfirmBooking_Success(t *testing.T) {
 	mockRepo := new(MockRepository)
 	mockBroker := new(MockRabbitMQBroker)
+	mockEvents := new(MockEventPublisher)
 
 	usecase := &EventsUsecases{
 		repo:   mockRepo,
 		broker: mockBroker,
+		events: mockEvents,
 	}
 
 	ctx := context.Background()
@@ -244,22 +506,42 @@ func TestConfirmBooking_Success(t *testing.T) {
 	}
 
 	mockRepo.On("GetBooking", ctx, bookingID).Return(booking, nil)
-	mockRepo.On("ConfirmBooking", ctx, bookingID).Return(nil)
+	mockRepo.On("ConfirmBooking", ctx, bookingID, int64(0)).Return(nil)
+	mockEvents.On("PublishLifecycleEvent", ctx, lifecycleEventOfType(domain.BookingConfirmedEvent)).Return(nil)
 
-	err := usecase.ConfirmBooking(ctx, bookingID)
+	err := usecase.ConfirmBooking(ctx, bookingID, 0)
 
 	assert.NoError(t, err)
 	mockRepo.AssertExpectations(t)
 	mockBroker.AssertExpectations(t)
+	mockEvents.AssertExpectations(t)
+}
+
+func TestConfirmBooking_AuditActorIsOwner(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockEvents := new(MockEventPublisher)
+	usecase := NewEventsUsecases(mockRepo, nil, mockEvents, nil)
+	ctx := port.WithActor(context.Background(), domain.Actor{Type: domain.ActorUser})
+
+	mockRepo.On("GetBooking", ctx, "booking-1").Return(&domain.Booking{Id: "booking-1", UserId: "user-1", Status: domain.PendingStatus}, nil)
+	mockRepo.On("ConfirmBooking", mock.MatchedBy(func(ctx context.Context) bool {
+		return port.ActorFrom(ctx) == domain.Actor{Type: domain.ActorUser, Id: "user-1"}
+	}), "booking-1", int64(0)).Return(nil)
+	mockEvents.On("PublishLifecycleEvent", mock.Anything, mock.Anything).Return(nil)
+
+	assert.NoError(t, usecase.ConfirmBooking(ctx, "booking-1", 0))
+	mockRepo.AssertExpectations(t)
 }
 
 func TestConfirmBooking_GetBookingError(t *testing.T) {
 	mockRepo := new(MockRepository)
 	mockBroker := new(MockRabbitMQBroker)
+	mockEvents := new(MockEventPublisher)
 
 	usecase := &EventsUsecases{
 		repo:   mockRepo,
 		broker: mockBroker,
+		events: mockEvents,
 	}
 
 	ctx := context.Background()
@@ -267,7 +549,7 @@ func TestConfirmBooking_GetBookingError(t *testing.T) {
 
 	mockRepo.On("GetBooking", ctx, bookingID).Return(nil, errors.New("booking not found"))
 
-	err := usecase.ConfirmBooking(ctx, bookingID)
+	err := usecase.ConfirmBooking(ctx, bookingID, 0)
 
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "failed to get booking")
@@ -277,10 +559,12 @@ func TestConfirmBooking_GetBookingError(t *testing.T) {
 func TestGetEvent_Success(t *testing.T) {
 	mockRepo := new(MockRepository)
 	mockBroker := new(MockRabbitMQBroker)
+	mockEvents := new(MockEventPublisher)
 
 	usecase := &EventsUsecases{
 		repo:   mockRepo,
 		broker: mockBroker,
+		events: mockEvents,
 	}
 
 	ctx := context.Background()
@@ -300,38 +584,169 @@ func TestGetEvent_Success(t *testing.T) {
 	mockRepo.AssertExpectations(t)
 }
 
-func TestGetAllEvents_Success(t *testing.T) {
+func TestListEvents_Success(t *testing.T) {
 	mockRepo := new(MockRepository)
 	mockBroker := new(MockRabbitMQBroker)
+	mockEvents := new(MockEventPublisher)
 
 	usecase := &EventsUsecases{
 		repo:   mockRepo,
 		broker: mockBroker,
+		events: mockEvents,
 	}
 
 	ctx := context.Background()
-	expectedEvents := []*domain.Event{
-		{Id: "event-1", Name: "Event 1"},
-		{Id: "event-2", Name: "Event 2"},
+	expectedPage := &domain.EventPage{
+		Events: []*domain.Event{
+			{Id: "event-1", Name: "Event 1"},
+			{Id: "event-2", Name: "Event 2"},
+		},
+		NextCursor: "cursor",
 	}
 
-	mockRepo.On("GetAllEvents", ctx).Return(expectedEvents, nil)
+	// В хранилище уходит фильтр со значениями по умолчанию, а period становится границей дат
+	resolved := mock.MatchedBy(func(filter domain.EventFilter) bool {
+		return filter.Sort == domain.SortDateAsc && filter.Limit == 5 && filter.Period == "" &&
+			!filter.DateFrom.IsZero() && time.Since(filter.DateFrom) < time.Minute
+	})
+	mockRepo.On("ListEvents", ctx, resolved).Return(expectedPage, nil)
+
+	page, err := usecase.ListEvents(ctx, domain.EventFilter{Limit: 5, Period: domain.PeriodUpcoming})
+
+	assert.NoError(t, err)
+	assert.Equal(t, expectedPage, page)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestListEvents_InvalidSort(t *testing.T) {
+	mockRepo := new(MockRepository)
+	usecase := &EventsUsecases{repo: mockRepo}
+
+	_, err := usecase.ListEvents(context.Background(), domain.EventFilter{Sort: "popularity"})
+
+	assert.ErrorIs(t, err, domain.ErrInvalidEventFilter)
+	mockRepo.AssertNotCalled(t, "ListEvents", mock.Anything, mock.Anything)
+}
+
+func TestSearchEvents_DefaultLimit(t *testing.T) {
+	mockRepo := new(MockRepository)
+	usecase := &EventsUsecases{repo: mockRepo}
+
+	ctx := context.Background()
+	expected := []*domain.EventSearchResult{{Event: &domain.Event{Id: "event-1"}, Rank: 0.5}}
+	mockRepo.On("SearchEvents", ctx, "концерт", domain.DefaultEventsLimit).Return(expected, nil)
+
+	results, err := usecase.SearchEvents(ctx, "концерт", 0)
+
+	assert.NoError(t, err)
+	assert.Equal(t, expected, results)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestSearchEvents_LimitCapped(t *testing.T) {
+	mockRepo := new(MockRepository)
+	usecase := &EventsUsecases{repo: mockRepo}
+
+	ctx := context.Background()
+	mockRepo.On("SearchEvents", ctx, "jazz", domain.MaxEventsLimit).Return([]*domain.EventSearchResult{}, nil)
 
-	events, err := usecase.GetAllEvents(ctx)
+	_, err := usecase.SearchEvents(ctx, "jazz", 1000)
 
 	assert.NoError(t, err)
-	assert.Len(t, events, 2)
-	assert.Equal(t, expectedEvents, events)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestSearchEvents_EmptyQuery(t *testing.T) {
+	mockRepo := new(MockRepository)
+	usecase := &EventsUsecases{repo: mockRepo}
+
+	_, err := usecase.SearchEvents(context.Background(), "  -- !", 10)
+
+	assert.ErrorIs(t, err, domain.ErrInvalidSearchQuery)
+	mockRepo.AssertNotCalled(t, "SearchEvents", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestListUserBookings_Success(t *testing.T) {
+	mockRepo := new(MockRepository)
+	usecase := &EventsUsecases{repo: mockRepo}
+
+	ctx := context.Background()
+	expected := &domain.BookingPage{Bookings: []*domain.BookingDetails{{
+		Booking: &domain.Booking{Id: "booking-1", UserId: "user-1"},
+		Event:   &domain.Event{Id: "event-1"},
+	}}}
+	resolved := domain.BookingFilter{UserId: "user-1", Status: domain.PendingStatus, Limit: domain.DefaultBookingsLimit}
+	mockRepo.On("ListBookings", ctx, resolved).Return(expected, nil)
+
+	page, err := usecase.ListUserBookings(ctx, domain.BookingFilter{UserId: "user-1", Status: domain.PendingStatus})
+
+	assert.NoError(t, err)
+	assert.Equal(t, expected, page)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestListUserBookings_InvalidStatus(t *testing.T) {
+	mockRepo := new(MockRepository)
+	usecase := &EventsUsecases{repo: mockRepo}
+
+	_, err := usecase.ListUserBookings(context.Background(), domain.BookingFilter{UserId: "user-1", Status: "paid"})
+
+	assert.ErrorIs(t, err, domain.ErrInvalidBookingFilter)
+	mockRepo.AssertNotCalled(t, "ListBookings", mock.Anything, mock.Anything)
+}
+
+func TestListEventBookings_EventNotFound(t *testing.T) {
+	mockRepo := new(MockRepository)
+	usecase := &EventsUsecases{repo: mockRepo}
+
+	ctx := context.Background()
+	mockRepo.On("GetEvent", ctx, "event-1").Return(nil, domain.ErrEventNotFound)
+
+	_, err := usecase.ListEventBookings(ctx, domain.BookingFilter{EventId: "event-1"})
+
+	assert.ErrorIs(t, err, domain.ErrEventNotFound)
+	mockRepo.AssertNotCalled(t, "ListBookings", mock.Anything, mock.Anything)
+}
+
+func TestExportEventBookings_ReadsInBatches(t *testing.T) {
+	mockRepo := new(MockRepository)
+	usecase := &EventsUsecases{repo: mockRepo}
+
+	ctx := context.Background()
+	date := time.Date(2026, time.March, 1, 19, 0, 0, 0, time.UTC)
+	first := &domain.Booking{Id: "booking-2", EventId: "event-1", Date: date}
+	second := &domain.Booking{Id: "booking-1", EventId: "event-1", Date: date}
+
+	mockRepo.On("GetEvent", ctx, "event-1").Return(&domain.Event{Id: "event-1"}, nil)
+	mockRepo.On("ListBookings", ctx, mock.MatchedBy(func(filter domain.BookingFilter) bool {
+		return filter.After == nil && filter.Limit == exportBatchSize && filter.Status == domain.ConfirmedStatus && filter.LineItems
+	})).Return(&domain.BookingPage{Bookings: []*domain.BookingDetails{{Booking: first}}, NextCursor: "next"}, nil)
+	// Следующая пачка начинается после последней брони предыдущей, курсор клиента не используется
+	mockRepo.On("ListBookings", ctx, mock.MatchedBy(func(filter domain.BookingFilter) bool {
+		return filter.After != nil && filter.After.Id == first.Id && filter.After.Date.Equal(date)
+	})).Return(&domain.BookingPage{Bookings: []*domain.BookingDetails{{Booking: second}}}, nil)
+
+	var exported []string
+	filter := domain.BookingFilter{EventId: "event-1", Status: domain.ConfirmedStatus, After: &domain.BookingCursor{Id: "ignored"}}
+	err := usecase.ExportEventBookings(ctx, filter, func(booking *domain.Booking) error {
+		exported = append(exported, booking.Id)
+		return nil
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"booking-2", "booking-1"}, exported)
 	mockRepo.AssertExpectations(t)
 }
 
 func TestGetBooking_Success(t *testing.T) {
 	mockRepo := new(MockRepository)
 	mockBroker := new(MockRabbitMQBroker)
+	mockEvents := new(MockEventPublisher)
 
 	usecase := &EventsUsecases{
 		repo:   mockRepo,
 		broker: mockBroker,
+		events: mockEvents,
 	}
 
 	ctx := context.Background()
@@ -352,3 +767,8 @@ func TestGetBooking_Success(t *testing.T) {
 	assert.Equal(t, expectedBooking, booking)
 	mockRepo.AssertExpectations(t)
 }
+
+// strongConsistencyCtx совпадает с контекстом, требующим чтения из master
+var strongConsistencyCtx = mock.MatchedBy(func(ctx context.Context) bool {
+	return port.StrongConsistency(ctx)
+})
@@ -0,0 +1,67 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/dontpanicw/EventBooker/internal/domain"
+	"github.com/dontpanicw/EventBooker/internal/port"
+)
+
+type FeeUsecases struct {
+	events port.Repository
+	fees   port.FeeRepository
+}
+
+func NewFeeUsecases(events port.Repository, fees port.FeeRepository) port.FeeUsecases {
+	return &FeeUsecases{
+		events: events,
+		fees:   fees,
+	}
+}
+
+// SetFeeRule проверяет правило и сохраняет его. Правило мероприятия проверяет, что оно
+// существует и фиксированный сбор задан в его валюте.
+func (f *FeeUsecases) SetFeeRule(ctx context.Context, rule *domain.FeeRule) (*domain.FeeRule, error) {
+	if rule.FixedFee.Amount != 0 {
+		currency, err := domain.ParseCurrency(rule.FixedFee.Currency)
+		if err != nil {
+			return nil, domain.ErrInvalidFeeRule
+		}
+		rule.FixedFee.Currency = currency
+	}
+	if err := rule.Validate(); err != nil {
+		return nil, err
+	}
+	if rule.Scope == domain.FeeScopeEvent {
+		event, err := f.events.GetEvent(ctx, rule.ScopeId)
+		if err != nil {
+			return nil, fmt.Errorf("failed to set fee rule: %w", err)
+		}
+		if rule.FixedFee.Amount != 0 && rule.FixedFee.Currency != event.Price.Currency {
+			return nil, domain.ErrInvalidFeeRule
+		}
+	}
+	rule.UpdatedAt = time.Now().UTC()
+
+	if err := f.fees.SaveFeeRule(ctx, rule); err != nil {
+		return nil, fmt.Errorf("failed to set fee rule: %w", err)
+	}
+	return rule, nil
+}
+
+func (f *FeeUsecases) DeleteFeeRule(ctx context.Context, scope, scopeID string) error {
+	if err := f.fees.DeleteFeeRule(ctx, scope, scopeID); err != nil {
+		return fmt.Errorf("failed to delete fee rule: %w", err)
+	}
+	return nil
+}
+
+func (f *FeeUsecases) ListFeeRules(ctx context.Context) ([]*domain.FeeRule, error) {
+	rules, err := f.fees.ListFeeRules(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list fee rules: %w", err)
+	}
+	return rules, nil
+}
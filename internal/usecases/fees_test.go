@@ -0,0 +1,81 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/dontpanicw/EventBooker/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockFeeRepository - мок хранилища правил сбора
+type MockFeeRepository struct {
+	mock.Mock
+}
+
+func (m *MockFeeRepository) SaveFeeRule(ctx context.Context, rule *domain.FeeRule) error {
+	args := m.Called(ctx, rule)
+	return args.Error(0)
+}
+
+func (m *MockFeeRepository) DeleteFeeRule(ctx context.Context, scope, scopeID string) error {
+	args := m.Called(ctx, scope, scopeID)
+	return args.Error(0)
+}
+
+func (m *MockFeeRepository) ListFeeRules(ctx context.Context) ([]*domain.FeeRule, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.FeeRule), args.Error(1)
+}
+
+func TestSetFeeRule(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockFees := new(MockFeeRepository)
+	usecase := NewFeeUsecases(mockRepo, mockFees)
+	ctx := context.Background()
+
+	_, err := usecase.SetFeeRule(ctx, &domain.FeeRule{Scope: domain.FeeScopeDefault, FeePercent: 150})
+	assert.ErrorIs(t, err, domain.ErrInvalidFeeRule)
+	_, err = usecase.SetFeeRule(ctx, &domain.FeeRule{Scope: domain.FeeScopeDefault, FixedFee: domain.Money{Amount: 100, Currency: "XXX"}})
+	assert.ErrorIs(t, err, domain.ErrInvalidFeeRule)
+
+	mockRepo.On("GetEvent", ctx, "missing").Return(nil, fmt.Errorf("error get event: %w", domain.ErrEventNotFound))
+	_, err = usecase.SetFeeRule(ctx, &domain.FeeRule{Scope: domain.FeeScopeEvent, ScopeId: "missing", FeePercent: 5})
+	assert.ErrorIs(t, err, domain.ErrEventNotFound)
+
+	// Фиксированный сбор правила мероприятия - только в валюте мероприятия
+	mockRepo.On("GetEvent", ctx, "event-eur").Return(&domain.Event{Id: "event-eur", Price: domain.Money{Amount: 5000, Currency: "EUR"}}, nil)
+	_, err = usecase.SetFeeRule(ctx, &domain.FeeRule{Scope: domain.FeeScopeEvent, ScopeId: "event-eur", FixedFee: domain.Money{Amount: 10000}})
+	assert.ErrorIs(t, err, domain.ErrInvalidFeeRule)
+
+	// Фиксированный сбор без валюты - в DefaultCurrency
+	mockFees.On("SaveFeeRule", ctx, mock.MatchedBy(func(rule *domain.FeeRule) bool {
+		return rule.FixedFee.Currency == domain.DefaultCurrency && !rule.UpdatedAt.IsZero()
+	})).Return(nil).Once()
+	vat := 20.0
+	rule, err := usecase.SetFeeRule(ctx, &domain.FeeRule{Scope: domain.FeeScopeOrganizer, ScopeId: "org-1", FixedFee: domain.Money{Amount: 5000}, VatPercent: &vat})
+	require.NoError(t, err)
+	assert.Equal(t, "org-1", rule.ScopeId)
+
+	mockFees.On("SaveFeeRule", ctx, mock.Anything).Return(nil).Once()
+	_, err = usecase.SetFeeRule(ctx, &domain.FeeRule{Scope: domain.FeeScopeEvent, ScopeId: "event-eur", FixedFee: domain.Money{Amount: 100, Currency: "eur"}})
+	require.NoError(t, err)
+	mockFees.AssertExpectations(t)
+}
+
+func TestDeleteFeeRule_NotFound(t *testing.T) {
+	mockFees := new(MockFeeRepository)
+	usecase := NewFeeUsecases(new(MockRepository), mockFees)
+	ctx := context.Background()
+
+	mockFees.On("DeleteFeeRule", ctx, domain.FeeScopeOrganizer, "org-1").
+		Return(fmt.Errorf("error delete fee rule: %w", domain.ErrFeeRuleNotFound))
+	err := usecase.DeleteFeeRule(ctx, domain.FeeScopeOrganizer, "org-1")
+	assert.ErrorIs(t, err, domain.ErrFeeRuleNotFound)
+}
@@ -0,0 +1,74 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+	"github.com/dontpanicw/EventBooker/internal/domain"
+	"github.com/dontpanicw/EventBooker/internal/port"
+	"time"
+)
+
+type IdempotencyUsecases struct {
+	repo port.IdempotencyRepository
+	// retention - сколько хранится ответ; после этого ключ можно использовать заново
+	retention time.Duration
+}
+
+func NewIdempotencyUsecases(repo port.IdempotencyRepository, retention time.Duration) port.IdempotencyUsecases {
+	return &IdempotencyUsecases{
+		repo:      repo,
+		retention: retention,
+	}
+}
+
+func (i *IdempotencyUsecases) Begin(ctx context.Context, key, requestHash string) (*domain.IdempotencyRecord, error) {
+	now := time.Now().UTC()
+	record := &domain.IdempotencyRecord{Key: key, RequestHash: requestHash, CreatedAt: now}
+
+	existing, err := i.repo.ReserveIdempotencyKey(ctx, record)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reserve idempotency key: %w", err)
+	}
+	cutoff := now.Add(-i.retention)
+	if existing != nil && existing.CreatedAt.Before(cutoff) {
+		// Запись пережила срок хранения, но еще не удалена PurgeExpired. Удаляем только
+		// просроченную: если параллельный запрос уже занял ключ заново, повторная попытка увидит его
+		if err := i.repo.ReleaseExpiredIdempotencyKey(ctx, key, cutoff); err != nil {
+			return nil, fmt.Errorf("failed to release expired idempotency key: %w", err)
+		}
+		if existing, err = i.repo.ReserveIdempotencyKey(ctx, record); err != nil {
+			return nil, fmt.Errorf("failed to reserve idempotency key: %w", err)
+		}
+	}
+	switch {
+	case existing == nil:
+		return nil, nil
+	case existing.RequestHash != requestHash:
+		return nil, domain.ErrIdempotencyKeyReused
+	case !existing.Completed():
+		return nil, domain.ErrIdempotencyInProgress
+	}
+	return existing, nil
+}
+
+func (i *IdempotencyUsecases) Complete(ctx context.Context, record *domain.IdempotencyRecord) error {
+	if err := i.repo.CompleteIdempotencyKey(ctx, record); err != nil {
+		return fmt.Errorf("failed to save idempotent response: %w", err)
+	}
+	return nil
+}
+
+func (i *IdempotencyUsecases) Release(ctx context.Context, key string) error {
+	if err := i.repo.ReleaseIdempotencyKey(ctx, key); err != nil {
+		return fmt.Errorf("failed to release idempotency key: %w", err)
+	}
+	return nil
+}
+
+func (i *IdempotencyUsecases) PurgeExpired(ctx context.Context) (int64, error) {
+	purged, err := i.repo.PurgeIdempotencyKeys(ctx, time.Now().UTC().Add(-i.retention))
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge idempotency keys: %w", err)
+	}
+	return purged, nil
+}
@@ -0,0 +1,152 @@
+package usecases
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dontpanicw/EventBooker/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockIdempotencyRepository - мок хранилища ключей идемпотентности
+type MockIdempotencyRepository struct {
+	mock.Mock
+}
+
+func (m *MockIdempotencyRepository) ReserveIdempotencyKey(ctx context.Context, record *domain.IdempotencyRecord) (*domain.IdempotencyRecord, error) {
+	args := m.Called(ctx, record)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.IdempotencyRecord), args.Error(1)
+}
+
+func (m *MockIdempotencyRepository) CompleteIdempotencyKey(ctx context.Context, record *domain.IdempotencyRecord) error {
+	args := m.Called(ctx, record)
+	return args.Error(0)
+}
+
+func (m *MockIdempotencyRepository) ReleaseIdempotencyKey(ctx context.Context, key string) error {
+	args := m.Called(ctx, key)
+	return args.Error(0)
+}
+
+func (m *MockIdempotencyRepository) ReleaseExpiredIdempotencyKey(ctx context.Context, key string, before time.Time) error {
+	args := m.Called(ctx, key, before)
+	return args.Error(0)
+}
+
+func (m *MockIdempotencyRepository) PurgeIdempotencyKeys(ctx context.Context, before time.Time) (int64, error) {
+	args := m.Called(ctx, before)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func TestIdempotencyBegin_NewKey(t *testing.T) {
+	mockRepo := new(MockIdempotencyRepository)
+	usecase := NewIdempotencyUsecases(mockRepo, time.Hour)
+
+	ctx := context.Background()
+	mockRepo.On("ReserveIdempotencyKey", ctx, mock.MatchedBy(func(record *domain.IdempotencyRecord) bool {
+		return record.Key == "key-1" && record.RequestHash == "hash" && time.Since(record.CreatedAt) < time.Minute
+	})).Return(nil, nil)
+
+	saved, err := usecase.Begin(ctx, "key-1", "hash")
+
+	assert.NoError(t, err)
+	assert.Nil(t, saved)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestIdempotencyBegin_Replay(t *testing.T) {
+	mockRepo := new(MockIdempotencyRepository)
+	usecase := NewIdempotencyUsecases(mockRepo, time.Hour)
+
+	ctx := context.Background()
+	existing := &domain.IdempotencyRecord{Key: "key-1", RequestHash: "hash", StatusCode: 201, CreatedAt: time.Now().UTC()}
+	mockRepo.On("ReserveIdempotencyKey", ctx, mock.Anything).Return(existing, nil)
+
+	saved, err := usecase.Begin(ctx, "key-1", "hash")
+
+	assert.NoError(t, err)
+	assert.Equal(t, existing, saved)
+}
+
+func TestIdempotencyBegin_Conflicts(t *testing.T) {
+	now := time.Now().UTC()
+	tests := []struct {
+		name     string
+		existing *domain.IdempotencyRecord
+		err      error
+	}{
+		{"different request", &domain.IdempotencyRecord{RequestHash: "other", StatusCode: 201, CreatedAt: now}, domain.ErrIdempotencyKeyReused},
+		{"in progress", &domain.IdempotencyRecord{RequestHash: "hash", CreatedAt: now}, domain.ErrIdempotencyInProgress},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockIdempotencyRepository)
+			usecase := NewIdempotencyUsecases(mockRepo, time.Hour)
+
+			mockRepo.On("ReserveIdempotencyKey", mock.Anything, mock.Anything).Return(tt.existing, nil)
+
+			_, err := usecase.Begin(context.Background(), "key-1", "hash")
+
+			assert.ErrorIs(t, err, tt.err)
+		})
+	}
+}
+
+func TestIdempotencyBegin_ExpiredKeyReserved(t *testing.T) {
+	mockRepo := new(MockIdempotencyRepository)
+	usecase := NewIdempotencyUsecases(mockRepo, time.Hour)
+
+	ctx := context.Background()
+	expired := &domain.IdempotencyRecord{Key: "key-1", RequestHash: "other", StatusCode: 201, CreatedAt: time.Now().Add(-2 * time.Hour)}
+	mockRepo.On("ReserveIdempotencyKey", ctx, mock.Anything).Return(expired, nil).Once()
+	mockRepo.On("ReleaseExpiredIdempotencyKey", ctx, "key-1", mock.MatchedBy(func(before time.Time) bool {
+		return before.After(expired.CreatedAt) && time.Since(before) < 2*time.Hour
+	})).Return(nil)
+	mockRepo.On("ReserveIdempotencyKey", ctx, mock.Anything).Return(nil, nil).Once()
+
+	saved, err := usecase.Begin(ctx, "key-1", "hash")
+
+	assert.NoError(t, err)
+	assert.Nil(t, saved)
+	mockRepo.AssertExpectations(t)
+	mockRepo.AssertNotCalled(t, "ReleaseIdempotencyKey", mock.Anything, mock.Anything)
+}
+
+func TestIdempotencyBegin_ExpiredKeyTakenConcurrently(t *testing.T) {
+	mockRepo := new(MockIdempotencyRepository)
+	usecase := NewIdempotencyUsecases(mockRepo, time.Hour)
+
+	ctx := context.Background()
+	expired := &domain.IdempotencyRecord{Key: "key-1", RequestHash: "other", StatusCode: 201, CreatedAt: time.Now().Add(-2 * time.Hour)}
+	fresh := &domain.IdempotencyRecord{Key: "key-1", RequestHash: "hash", CreatedAt: time.Now()}
+	mockRepo.On("ReserveIdempotencyKey", ctx, mock.Anything).Return(expired, nil).Once()
+	mockRepo.On("ReleaseExpiredIdempotencyKey", ctx, "key-1", mock.Anything).Return(nil)
+	// Параллельный запрос успел освободить и занять ключ - его запись не удаляется
+	mockRepo.On("ReserveIdempotencyKey", ctx, mock.Anything).Return(fresh, nil).Once()
+
+	_, err := usecase.Begin(ctx, "key-1", "hash")
+
+	assert.ErrorIs(t, err, domain.ErrIdempotencyInProgress)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestIdempotencyPurgeExpired(t *testing.T) {
+	mockRepo := new(MockIdempotencyRepository)
+	usecase := NewIdempotencyUsecases(mockRepo, 24*time.Hour)
+
+	ctx := context.Background()
+	mockRepo.On("PurgeIdempotencyKeys", ctx, mock.MatchedBy(func(before time.Time) bool {
+		return time.Since(before) > 23*time.Hour && time.Since(before) < 25*time.Hour
+	})).Return(int64(3), nil)
+
+	purged, err := usecase.PurgeExpired(ctx)
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), purged)
+}
@@ -0,0 +1,51 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/dontpanicw/EventBooker/internal/domain"
+	"github.com/dontpanicw/EventBooker/internal/port"
+)
+
+type PricingUsecases struct {
+	repo    port.Repository
+	pricing port.PricingRepository
+	events  port.EventPublisher
+}
+
+func NewPricingUsecases(repo port.Repository, pricing port.PricingRepository, events port.EventPublisher) port.PricingUsecases {
+	return &PricingUsecases{
+		repo:    repo,
+		pricing: pricing,
+		events:  events,
+	}
+}
+
+func (p *PricingUsecases) SetPricePhases(ctx context.Context, eventID string, phases []domain.PricePhase) (*domain.Event, error) {
+	if err := domain.ValidatePricePhases(phases); err != nil {
+		return nil, err
+	}
+	for i := range phases {
+		phases[i].Until = phases[i].Until.UTC()
+	}
+	if err := p.pricing.SetPricePhases(ctx, eventID, phases); err != nil {
+		return nil, fmt.Errorf("failed to set price phases: %w", err)
+	}
+
+	// Администратор сразу видит новое расписание, даже если реплики отстают
+	event, err := p.repo.GetEvent(port.WithStrongConsistency(ctx), eventID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set price phases: %w", err)
+	}
+	quotePrice(event, time.Now().UTC())
+
+	// Новое расписание меняет цену, которую видят клиенты, как и правка мероприятия
+	updated := domain.NewEventLifecycleEvent(domain.EventUpdatedEvent, event)
+	if err := p.events.PublishLifecycleEvent(ctx, updated); err != nil {
+		log.Printf("Failed to publish %s event: %v", updated.Type, err)
+	}
+	return event, nil
+}
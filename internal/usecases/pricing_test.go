@@ -0,0 +1,96 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/dontpanicw/EventBooker/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockPricingRepository - мок хранилища расписаний цен
+type MockPricingRepository struct {
+	mock.Mock
+}
+
+func (m *MockPricingRepository) SetPricePhases(ctx context.Context, eventID string, phases []domain.PricePhase) error {
+	args := m.Called(ctx, eventID, phases)
+	return args.Error(0)
+}
+
+func TestSetPricePhases(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockPricing := new(MockPricingRepository)
+	mockEvents := new(MockEventPublisher)
+	usecase := NewPricingUsecases(mockRepo, mockPricing, mockEvents)
+	ctx := context.Background()
+
+	// Ступень без ограничений перед другой ступенью делает ее недостижимой
+	_, err := usecase.SetPricePhases(ctx, "event-1", []domain.PricePhase{{Price: 1000}, {Price: 1200}})
+	assert.ErrorIs(t, err, domain.ErrInvalidPriceSchedule)
+	_, err = usecase.SetPricePhases(ctx, "event-1", []domain.PricePhase{{Price: -1, MaxSold: 5}})
+	assert.ErrorIs(t, err, domain.ErrInvalidPriceSchedule)
+
+	until := time.Now().Add(time.Hour).In(time.FixedZone("MSK", 3*60*60))
+	phases := []domain.PricePhase{{Name: "Early bird", Price: 1000, Until: until}}
+	mockPricing.On("SetPricePhases", ctx, "event-1", mock.Anything).Return(nil)
+	mockRepo.On("GetEvent", mock.Anything, "event-1").Return(&domain.Event{
+		Id:               "event-1",
+		Price:            domain.Money{Amount: 1500, Currency: "RUB"},
+		Capacity:         10,
+		AvailableTickets: 10,
+		PricePhases:      phases,
+	}, nil)
+	mockEvents.On("PublishLifecycleEvent", ctx, mock.MatchedBy(func(event *domain.LifecycleEvent) bool {
+		return event.Type == domain.EventUpdatedEvent && event.AggregateId() == "event-1"
+	})).Return(nil).Once()
+
+	event, err := usecase.SetPricePhases(ctx, "event-1", phases)
+	require.NoError(t, err)
+	require.NotNil(t, event.Pricing)
+	assert.Equal(t, domain.Money{Amount: 1000, Currency: "RUB"}, event.Pricing.Price)
+	assert.Equal(t, 1, event.Pricing.Phase)
+	require.NotNil(t, event.Pricing.Next)
+	assert.Equal(t, domain.Money{Amount: 1500, Currency: "RUB"}, event.Pricing.Next.Price)
+
+	saved := mockPricing.Calls[0].Arguments.Get(2).([]domain.PricePhase)
+	assert.Equal(t, time.UTC, saved[0].Until.Location())
+
+	mockPricing.On("SetPricePhases", ctx, "missing", mock.Anything).
+		Return(fmt.Errorf("error set price phases: %w", domain.ErrEventNotFound))
+	_, err = usecase.SetPricePhases(ctx, "missing", nil)
+	assert.ErrorIs(t, err, domain.ErrEventNotFound)
+	mockEvents.AssertExpectations(t)
+}
+
+func TestGetEvent_PriceQuote(t *testing.T) {
+	mockRepo := new(MockRepository)
+	usecase := &EventsUsecases{repo: mockRepo}
+	ctx := context.Background()
+
+	mockRepo.On("GetEvent", ctx, "event-1").Return(&domain.Event{
+		Id:               "event-1",
+		Price:            domain.Money{Amount: 1500, Currency: "RUB"},
+		Capacity:         10,
+		AvailableTickets: 7,
+		PricePhases: []domain.PricePhase{
+			{Name: "First five", Price: 1000, MaxSold: 5},
+			{Name: "Next five", Price: 1200, MaxSold: 10},
+		},
+	}, nil)
+
+	event, err := usecase.GetEvent(ctx, "event-1")
+	require.NoError(t, err)
+	require.NotNil(t, event.Pricing)
+	assert.Equal(t, domain.PriceQuote{
+		Price:     domain.Money{Amount: 1000, Currency: "RUB"},
+		Phase:     1,
+		Name:      "First five",
+		Remaining: 2,
+		Next:      &domain.NextPrice{Price: domain.Money{Amount: 1200, Currency: "RUB"}, Phase: 2, Name: "Next five"},
+	}, *event.Pricing)
+}
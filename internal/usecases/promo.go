@@ -0,0 +1,81 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/dontpanicw/EventBooker/internal/domain"
+	"github.com/dontpanicw/EventBooker/internal/port"
+)
+
+type PromoUsecases struct {
+	events port.Repository
+	promos port.PromoRepository
+}
+
+func NewPromoUsecases(events port.Repository, promos port.PromoRepository) port.PromoUsecases {
+	return &PromoUsecases{
+		events: events,
+		promos: promos,
+	}
+}
+
+// CreatePromoCode сохраняет код в верхнем регистре; код мероприятия проверяет, что оно существует.
+// Фиксированная скидка без валюты - в DefaultCurrency, у кода мероприятия - в его валюте.
+func (p *PromoUsecases) CreatePromoCode(ctx context.Context, promo *domain.PromoCode) (*domain.PromoCode, error) {
+	promo.Code = domain.NormalizePromoCode(promo.Code)
+	if promo.DiscountType == domain.DiscountFixed {
+		currency, err := domain.ParseCurrency(promo.Amount.Currency)
+		if err != nil {
+			return nil, domain.ErrInvalidPromoCode
+		}
+		promo.Amount.Currency = currency
+	}
+	if err := promo.Validate(); err != nil {
+		return nil, err
+	}
+	if promo.EventId != "" {
+		event, err := p.events.GetEvent(ctx, promo.EventId)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create promo code: %w", err)
+		}
+		if promo.DiscountType == domain.DiscountFixed && promo.Amount.Currency != event.Price.Currency {
+			return nil, domain.ErrInvalidPromoCode
+		}
+	}
+	promo.CreatedAt = time.Now().UTC()
+
+	if err := p.promos.CreatePromoCode(ctx, promo); err != nil {
+		return nil, fmt.Errorf("failed to create promo code: %w", err)
+	}
+	return promo, nil
+}
+
+func (p *PromoUsecases) GetPromoCode(ctx context.Context, code string) (*domain.PromoCode, error) {
+	promo, err := p.promos.GetPromoCode(ctx, domain.NormalizePromoCode(code))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get promo code: %w", err)
+	}
+	return promo, nil
+}
+
+func (p *PromoUsecases) ListPromoCodes(ctx context.Context) ([]*domain.PromoCode, error) {
+	promos, err := p.promos.ListPromoCodes(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list promo codes: %w", err)
+	}
+	return promos, nil
+}
+
+func (p *PromoUsecases) ListPromoRedemptions(ctx context.Context, code string) ([]*domain.PromoRedemption, error) {
+	code = domain.NormalizePromoCode(code)
+	if _, err := p.promos.GetPromoCode(ctx, code); err != nil {
+		return nil, fmt.Errorf("failed to list promo redemptions: %w", err)
+	}
+	redemptions, err := p.promos.ListPromoRedemptions(ctx, code)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list promo redemptions: %w", err)
+	}
+	return redemptions, nil
+}
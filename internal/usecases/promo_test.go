@@ -0,0 +1,105 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/dontpanicw/EventBooker/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockPromoRepository - мок хранилища промокодов
+type MockPromoRepository struct {
+	mock.Mock
+}
+
+func (m *MockPromoRepository) CreatePromoCode(ctx context.Context, promo *domain.PromoCode) error {
+	args := m.Called(ctx, promo)
+	return args.Error(0)
+}
+
+func (m *MockPromoRepository) GetPromoCode(ctx context.Context, code string) (*domain.PromoCode, error) {
+	args := m.Called(ctx, code)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.PromoCode), args.Error(1)
+}
+
+func (m *MockPromoRepository) ListPromoCodes(ctx context.Context) ([]*domain.PromoCode, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.PromoCode), args.Error(1)
+}
+
+func (m *MockPromoRepository) ListPromoRedemptions(ctx context.Context, code string) ([]*domain.PromoRedemption, error) {
+	args := m.Called(ctx, code)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.PromoRedemption), args.Error(1)
+}
+
+func TestCreatePromoCode(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockPromos := new(MockPromoRepository)
+	usecase := NewPromoUsecases(mockRepo, mockPromos)
+	ctx := context.Background()
+
+	_, err := usecase.CreatePromoCode(ctx, &domain.PromoCode{Code: "spring", DiscountType: domain.DiscountPercent, Percent: 150})
+	assert.ErrorIs(t, err, domain.ErrInvalidPromoCode)
+
+	mockRepo.On("GetEvent", ctx, "missing").Return(nil, fmt.Errorf("error get event: %w", domain.ErrEventNotFound))
+	_, err = usecase.CreatePromoCode(ctx, &domain.PromoCode{Code: "VIP", EventId: "missing", DiscountType: domain.DiscountFixed, Amount: domain.Money{Amount: 10000}})
+	assert.ErrorIs(t, err, domain.ErrEventNotFound)
+
+	// Фиксированная скидка кода мероприятия - только в валюте мероприятия
+	mockRepo.On("GetEvent", ctx, "event-eur").Return(&domain.Event{Id: "event-eur", Price: domain.Money{Amount: 5000, Currency: "EUR"}}, nil)
+	_, err = usecase.CreatePromoCode(ctx, &domain.PromoCode{Code: "VIP", EventId: "event-eur", DiscountType: domain.DiscountFixed, Amount: domain.Money{Amount: 10000}})
+	assert.ErrorIs(t, err, domain.ErrInvalidPromoCode)
+	_, err = usecase.CreatePromoCode(ctx, &domain.PromoCode{Code: "VIP", DiscountType: domain.DiscountFixed, Amount: domain.Money{Amount: 100, Currency: "XXX"}})
+	assert.ErrorIs(t, err, domain.ErrInvalidPromoCode)
+
+	mockPromos.On("CreatePromoCode", ctx, mock.MatchedBy(func(promo *domain.PromoCode) bool {
+		return promo.Code == "SPRING" && !promo.CreatedAt.IsZero()
+	})).Return(nil).Once()
+	promo, err := usecase.CreatePromoCode(ctx, &domain.PromoCode{Code: " spring ", DiscountType: domain.DiscountPercent, Percent: 10})
+	require.NoError(t, err)
+	assert.Equal(t, "SPRING", promo.Code)
+
+	mockPromos.On("CreatePromoCode", ctx, mock.Anything).Return(fmt.Errorf("error create promo code: %w", domain.ErrPromoCodeExists))
+	_, err = usecase.CreatePromoCode(ctx, &domain.PromoCode{Code: "SPRING", DiscountType: domain.DiscountFixed, Amount: domain.Money{Amount: 10000, Currency: "eur"}})
+	assert.ErrorIs(t, err, domain.ErrPromoCodeExists)
+	mockPromos.AssertExpectations(t)
+}
+
+func TestListPromoRedemptions_NotFound(t *testing.T) {
+	mockPromos := new(MockPromoRepository)
+	usecase := NewPromoUsecases(new(MockRepository), mockPromos)
+	ctx := context.Background()
+
+	mockPromos.On("GetPromoCode", ctx, "MISSING").Return(nil, fmt.Errorf("error get promo code: %w", domain.ErrPromoCodeNotFound))
+
+	_, err := usecase.ListPromoRedemptions(ctx, "missing")
+	assert.ErrorIs(t, err, domain.ErrPromoCodeNotFound)
+	mockPromos.AssertNotCalled(t, "ListPromoRedemptions", mock.Anything, mock.Anything)
+}
+
+func TestBookEvent_NormalizesPromoCode(t *testing.T) {
+	mockRepo := new(MockRepository)
+	usecase := &EventsUsecases{repo: mockRepo, admission: stubAdmission{}}
+	ctx := context.Background()
+
+	mockRepo.On("BookEvent", ctx, mock.MatchedBy(func(booking *domain.Booking) bool {
+		return booking.PromoCode == "SPRING"
+	})).Return(nil, fmt.Errorf("failed to book event: %w", domain.ErrPromoCodeUsed))
+
+	_, err := usecase.BookEvent(ctx, &domain.Booking{UserId: "user-123", EventId: "event-123", PromoCode: " spring"})
+	assert.ErrorIs(t, err, domain.ErrPromoCodeUsed)
+	mockRepo.AssertExpectations(t)
+}
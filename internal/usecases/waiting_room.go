@@ -0,0 +1,216 @@
+package usecases
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/dontpanicw/EventBooker/internal/domain"
+	"github.com/dontpanicw/EventBooker/internal/port"
+)
+
+// Виды токенов очереди: токен места нельзя предъявить вместо допуска
+const (
+	queueTokenKind     = "queue"
+	admissionTokenKind = "admission"
+)
+
+type WaitingRoomUsecases struct {
+	events port.Repository
+	rooms  port.WaitingRoomRepository
+	// secret - ключ подписи токенов; у всех экземпляров приложения он должен совпадать
+	secret []byte
+}
+
+func NewWaitingRoomUsecases(events port.Repository, rooms port.WaitingRoomRepository, secret []byte) port.WaitingRoomUsecases {
+	return &WaitingRoomUsecases{
+		events: events,
+		rooms:  rooms,
+		secret: secret,
+	}
+}
+
+// OpenWaitingRoom открывает очередь мероприятия или меняет скорость допуска открытой
+func (w *WaitingRoomUsecases) OpenWaitingRoom(ctx context.Context, room *domain.WaitingRoom) (*domain.WaitingRoom, error) {
+	if err := room.Validate(); err != nil {
+		return nil, err
+	}
+	if _, err := w.events.GetEvent(ctx, room.EventId); err != nil {
+		return nil, fmt.Errorf("failed to open waiting room: %w", err)
+	}
+	room.OpenedAt = time.Now().UTC()
+
+	saved, err := w.rooms.SaveWaitingRoom(ctx, room)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open waiting room: %w", err)
+	}
+	return saved, nil
+}
+
+func (w *WaitingRoomUsecases) GetWaitingRoom(ctx context.Context, eventID string) (*domain.WaitingRoom, error) {
+	room, err := w.rooms.GetWaitingRoom(ctx, eventID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get waiting room: %w", err)
+	}
+	return room, nil
+}
+
+// CloseWaitingRoom закрывает очередь: бронировать снова можно без допуска
+func (w *WaitingRoomUsecases) CloseWaitingRoom(ctx context.Context, eventID string) error {
+	if err := w.rooms.DeleteWaitingRoom(ctx, eventID); err != nil {
+		return fmt.Errorf("failed to close waiting room: %w", err)
+	}
+	return nil
+}
+
+// JoinQueue ставит пользователя в очередь; повторный вход возвращает его действующее место
+func (w *WaitingRoomUsecases) JoinQueue(ctx context.Context, eventID, userID string) (*domain.QueueStatus, error) {
+	ticket, err := w.rooms.JoinWaitingRoom(ctx, eventID, userID, time.Now().UTC())
+	if err != nil {
+		return nil, fmt.Errorf("failed to join queue: %w", err)
+	}
+
+	token, err := w.sign(queueTokenKind, *ticket)
+	if err != nil {
+		return nil, err
+	}
+	return w.admit(*ticket, token, time.Now())
+}
+
+func (w *WaitingRoomUsecases) QueueStatus(ctx context.Context, eventID, queueToken string) (*domain.QueueStatus, error) {
+	ticket, err := w.verify(queueTokenKind, queueToken)
+	if err != nil || ticket.EventId != eventID {
+		return nil, domain.ErrInvalidQueueToken
+	}
+	return w.status(ctx, ticket, queueToken)
+}
+
+// status проверяет, что очередь еще открыта: если ее закрыли, пока пользователь ждал,
+// он допущен без токена. Место в очереди, закрытой и открытой заново, недействительно.
+func (w *WaitingRoomUsecases) status(ctx context.Context, ticket domain.QueueTicket, queueToken string) (*domain.QueueStatus, error) {
+	room, err := w.rooms.GetWaitingRoom(ctx, ticket.EventId)
+	if err != nil {
+		if errors.Is(err, domain.ErrWaitingRoomNotFound) {
+			return &domain.QueueStatus{Ticket: ticket, QueueToken: queueToken, Admitted: true}, nil
+		}
+		return nil, fmt.Errorf("failed to get queue status: %w", err)
+	}
+	if !room.OpenedAt.Equal(ticket.OpenedAt) {
+		return nil, domain.ErrQueueTokenExpired
+	}
+	return w.admit(ticket, queueToken, time.Now())
+}
+
+// admit выдает токен допуска, если очередь подошла
+func (w *WaitingRoomUsecases) admit(ticket domain.QueueTicket, queueToken string, now time.Time) (*domain.QueueStatus, error) {
+	if !now.Before(ticket.ExpiresAt()) {
+		return nil, domain.ErrQueueTokenExpired
+	}
+	status := &domain.QueueStatus{Ticket: ticket, QueueToken: queueToken}
+	if now.Before(ticket.AdmitAt) {
+		return status, nil
+	}
+
+	token, err := w.sign(admissionTokenKind, ticket)
+	if err != nil {
+		return nil, err
+	}
+	status.Admitted = true
+	status.AdmissionToken = token
+	return status, nil
+}
+
+// CheckAdmission пропускает бронь, если очереди нет или токен допуска выдан этому
+// пользователю на это мероприятие в текущей очереди и еще действует. Место из токена
+// записывается в booking.Admission: хранилище расходует допуск вместе с бронью,
+// поэтому по одному допуску проходит одна бронь.
+func (w *WaitingRoomUsecases) CheckAdmission(ctx context.Context, booking *domain.Booking) error {
+	room, err := w.rooms.GetWaitingRoom(ctx, booking.EventId)
+	if err != nil {
+		if errors.Is(err, domain.ErrWaitingRoomNotFound) {
+			return nil
+		}
+		return fmt.Errorf("failed to check admission: %w", err)
+	}
+
+	token := port.AdmissionToken(ctx)
+	if token == "" {
+		return domain.ErrAdmissionRequired
+	}
+	ticket, err := w.verify(admissionTokenKind, token)
+	if err != nil ||
+		ticket.EventId != booking.EventId ||
+		ticket.UserId != booking.UserId ||
+		!ticket.OpenedAt.Equal(room.OpenedAt) ||
+		!time.Now().Before(ticket.ExpiresAt()) {
+		return domain.ErrInvalidAdmissionToken
+	}
+	booking.Admission = &ticket
+	return nil
+}
+
+// queueClaims - содержимое токена очереди или допуска
+type queueClaims struct {
+	Kind     string    `json:"kind"`
+	EventId  string    `json:"event_id"`
+	UserId   string    `json:"user_id"`
+	Position int64     `json:"position"`
+	AdmitAt  time.Time `json:"admit_at"`
+	OpenedAt time.Time `json:"opened_at"`
+}
+
+// sign возвращает токен вида base64url(JSON).base64url(HMAC-SHA256)
+func (w *WaitingRoomUsecases) sign(kind string, ticket domain.QueueTicket) (string, error) {
+	payload, err := json.Marshal(queueClaims{
+		Kind:     kind,
+		EventId:  ticket.EventId,
+		UserId:   ticket.UserId,
+		Position: ticket.Position,
+		AdmitAt:  ticket.AdmitAt,
+		OpenedAt: ticket.OpenedAt,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to sign %s token: %w", kind, err)
+	}
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return encoded + "." + base64.RawURLEncoding.EncodeToString(w.mac(encoded)), nil
+}
+
+func (w *WaitingRoomUsecases) verify(kind, token string) (domain.QueueTicket, error) {
+	encoded, signature, ok := strings.Cut(token, ".")
+	if !ok {
+		return domain.QueueTicket{}, domain.ErrInvalidQueueToken
+	}
+	sum, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil || !hmac.Equal(sum, w.mac(encoded)) {
+		return domain.QueueTicket{}, domain.ErrInvalidQueueToken
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return domain.QueueTicket{}, domain.ErrInvalidQueueToken
+	}
+
+	var claims queueClaims
+	if err := json.Unmarshal(payload, &claims); err != nil || claims.Kind != kind {
+		return domain.QueueTicket{}, domain.ErrInvalidQueueToken
+	}
+	return domain.QueueTicket{
+		EventId:  claims.EventId,
+		UserId:   claims.UserId,
+		Position: claims.Position,
+		AdmitAt:  claims.AdmitAt,
+		OpenedAt: claims.OpenedAt,
+	}, nil
+}
+
+func (w *WaitingRoomUsecases) mac(encoded string) []byte {
+	h := hmac.New(sha256.New, w.secret)
+	h.Write([]byte(encoded))
+	return h.Sum(nil)
+}
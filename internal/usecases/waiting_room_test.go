@@ -0,0 +1,195 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/dontpanicw/EventBooker/internal/domain"
+	"github.com/dontpanicw/EventBooker/internal/port"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockWaitingRoomRepository - мок хранилища очередей
+type MockWaitingRoomRepository struct {
+	mock.Mock
+}
+
+func (m *MockWaitingRoomRepository) SaveWaitingRoom(ctx context.Context, room *domain.WaitingRoom) (*domain.WaitingRoom, error) {
+	args := m.Called(ctx, room)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.WaitingRoom), args.Error(1)
+}
+
+func (m *MockWaitingRoomRepository) GetWaitingRoom(ctx context.Context, eventID string) (*domain.WaitingRoom, error) {
+	args := m.Called(ctx, eventID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.WaitingRoom), args.Error(1)
+}
+
+func (m *MockWaitingRoomRepository) JoinWaitingRoom(ctx context.Context, eventID, userID string, now time.Time) (*domain.QueueTicket, error) {
+	args := m.Called(ctx, eventID, userID, now)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.QueueTicket), args.Error(1)
+}
+
+func (m *MockWaitingRoomRepository) DeleteWaitingRoom(ctx context.Context, eventID string) error {
+	args := m.Called(ctx, eventID)
+	return args.Error(0)
+}
+
+var errRoomNotFound = fmt.Errorf("error get waiting room: %w", domain.ErrWaitingRoomNotFound)
+
+var roomOpenedAt = time.Date(2026, time.March, 1, 12, 0, 0, 0, time.UTC)
+
+func openRoom() *domain.WaitingRoom {
+	return &domain.WaitingRoom{EventId: "event-123", RatePerMinute: 60, OpenedAt: roomOpenedAt}
+}
+
+func queueTicket(position int64, admitAt time.Time) *domain.QueueTicket {
+	return &domain.QueueTicket{EventId: "event-123", UserId: "user-1", Position: position, AdmitAt: admitAt, OpenedAt: roomOpenedAt}
+}
+
+func TestOpenWaitingRoom(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockRooms := new(MockWaitingRoomRepository)
+	usecase := NewWaitingRoomUsecases(mockRepo, mockRooms, []byte("secret"))
+	ctx := context.Background()
+
+	_, err := usecase.OpenWaitingRoom(ctx, &domain.WaitingRoom{EventId: "event-123", RatePerMinute: 0})
+	assert.ErrorIs(t, err, domain.ErrInvalidWaitingRoom)
+
+	mockRepo.On("GetEvent", ctx, "missing").Return(nil, domain.ErrEventNotFound)
+	_, err = usecase.OpenWaitingRoom(ctx, &domain.WaitingRoom{EventId: "missing", RatePerMinute: 60})
+	assert.ErrorIs(t, err, domain.ErrEventNotFound)
+
+	mockRepo.On("GetEvent", ctx, "event-123").Return(&domain.Event{Id: "event-123"}, nil)
+	mockRooms.On("SaveWaitingRoom", ctx, mock.MatchedBy(func(room *domain.WaitingRoom) bool {
+		return room.RatePerMinute == 60 && !room.OpenedAt.IsZero()
+	})).Return(openRoom(), nil)
+	room, err := usecase.OpenWaitingRoom(ctx, openRoom())
+	require.NoError(t, err)
+	assert.Equal(t, 60, room.RatePerMinute)
+	mockRooms.AssertExpectations(t)
+}
+
+func TestJoinQueue_WaitThenAdmitted(t *testing.T) {
+	mockRooms := new(MockWaitingRoomRepository)
+	usecase := NewWaitingRoomUsecases(new(MockRepository), mockRooms, []byte("secret"))
+	ctx := context.Background()
+
+	mockRooms.On("JoinWaitingRoom", ctx, "event-123", "user-1", mock.Anything).Return(queueTicket(7, time.Now().Add(time.Minute)), nil).Once()
+
+	status, err := usecase.JoinQueue(ctx, "event-123", "user-1")
+	require.NoError(t, err)
+	assert.Equal(t, int64(7), status.Ticket.Position)
+	assert.False(t, status.Admitted)
+	assert.Empty(t, status.AdmissionToken)
+	require.NotEmpty(t, status.QueueToken)
+
+	// Очередь еще не подошла: токен места проверяется, допуска нет
+	mockRooms.On("GetWaitingRoom", mock.Anything, "event-123").Return(openRoom(), nil)
+	again, err := usecase.QueueStatus(ctx, "event-123", status.QueueToken)
+	require.NoError(t, err)
+	assert.Equal(t, int64(7), again.Ticket.Position)
+	assert.False(t, again.Admitted)
+
+	// Токен места не подходит вместо допуска
+	booking := &domain.Booking{EventId: "event-123", UserId: "user-1"}
+	err = usecase.CheckAdmission(port.WithAdmissionToken(ctx, status.QueueToken), booking)
+	assert.ErrorIs(t, err, domain.ErrInvalidAdmissionToken)
+
+	mockRooms.On("JoinWaitingRoom", ctx, "event-123", "user-1", mock.Anything).Return(queueTicket(7, time.Now().Add(-time.Second)), nil).Once()
+	admitted, err := usecase.JoinQueue(ctx, "event-123", "user-1")
+	require.NoError(t, err)
+	assert.True(t, admitted.Admitted)
+	require.NotEmpty(t, admitted.AdmissionToken)
+
+	// Место из токена передается хранилищу, чтобы оно израсходовало допуск вместе с бронью
+	assert.NoError(t, usecase.CheckAdmission(port.WithAdmissionToken(ctx, admitted.AdmissionToken), booking))
+	require.NotNil(t, booking.Admission)
+	assert.Equal(t, int64(7), booking.Admission.Position)
+	assert.Equal(t, "user-1", booking.Admission.UserId)
+	err = usecase.CheckAdmission(port.WithAdmissionToken(ctx, admitted.AdmissionToken), &domain.Booking{EventId: "event-123", UserId: "user-2"})
+	assert.ErrorIs(t, err, domain.ErrInvalidAdmissionToken)
+	assert.ErrorIs(t, usecase.CheckAdmission(ctx, booking), domain.ErrAdmissionRequired)
+}
+
+func TestQueueStatus_InvalidTokens(t *testing.T) {
+	mockRooms := new(MockWaitingRoomRepository)
+	usecase := NewWaitingRoomUsecases(new(MockRepository), mockRooms, []byte("secret"))
+	ctx := context.Background()
+
+	mockRooms.On("JoinWaitingRoom", ctx, "event-123", "user-1", mock.Anything).
+		Return(queueTicket(1, time.Now().Add(-domain.AdmissionWindow-time.Second)), nil).Once()
+
+	_, err := usecase.JoinQueue(ctx, "event-123", "user-1")
+	assert.ErrorIs(t, err, domain.ErrQueueTokenExpired)
+
+	mockRooms.On("JoinWaitingRoom", ctx, "event-123", "user-1", mock.Anything).Return(queueTicket(1, time.Now().Add(time.Minute)), nil)
+	status, err := usecase.JoinQueue(ctx, "event-123", "user-1")
+	require.NoError(t, err)
+
+	_, err = usecase.QueueStatus(ctx, "event-456", status.QueueToken)
+	assert.ErrorIs(t, err, domain.ErrInvalidQueueToken)
+	_, err = usecase.QueueStatus(ctx, "event-123", status.QueueToken+"x")
+	assert.ErrorIs(t, err, domain.ErrInvalidQueueToken)
+	_, err = usecase.QueueStatus(ctx, "event-123", "garbage")
+	assert.ErrorIs(t, err, domain.ErrInvalidQueueToken)
+
+	// Токен другого ключа подписи - например, экземпляра с другим WAITING_ROOM_SECRET
+	other := NewWaitingRoomUsecases(new(MockRepository), mockRooms, []byte("other"))
+	_, err = other.QueueStatus(ctx, "event-123", status.QueueToken)
+	assert.ErrorIs(t, err, domain.ErrInvalidQueueToken)
+}
+
+func TestQueueStatus_RoomClosed(t *testing.T) {
+	mockRooms := new(MockWaitingRoomRepository)
+	usecase := NewWaitingRoomUsecases(new(MockRepository), mockRooms, []byte("secret"))
+	ctx := context.Background()
+
+	mockRooms.On("JoinWaitingRoom", ctx, "event-123", "user-1", mock.Anything).Return(queueTicket(3, time.Now().Add(time.Minute)), nil)
+	mockRooms.On("GetWaitingRoom", mock.Anything, "event-123").Return(nil, errRoomNotFound)
+
+	status, err := usecase.JoinQueue(ctx, "event-123", "user-1")
+	require.NoError(t, err)
+
+	// Очередь закрыли, пока пользователь ждал: допущен без токена, бронь без допуска проходит
+	closed, err := usecase.QueueStatus(ctx, "event-123", status.QueueToken)
+	require.NoError(t, err)
+	assert.True(t, closed.Admitted)
+	assert.Empty(t, closed.AdmissionToken)
+	assert.NoError(t, usecase.CheckAdmission(ctx, &domain.Booking{EventId: "event-123", UserId: "user-1"}))
+}
+
+func TestCheckAdmission_RoomReopened(t *testing.T) {
+	mockRooms := new(MockWaitingRoomRepository)
+	usecase := NewWaitingRoomUsecases(new(MockRepository), mockRooms, []byte("secret"))
+	ctx := context.Background()
+
+	mockRooms.On("JoinWaitingRoom", ctx, "event-123", "user-1", mock.Anything).Return(queueTicket(1, time.Now().Add(-time.Second)), nil)
+	status, err := usecase.JoinQueue(ctx, "event-123", "user-1")
+	require.NoError(t, err)
+	require.NotEmpty(t, status.AdmissionToken)
+
+	// Очередь закрыли и открыли снова: токены прежней очереди не действуют
+	reopened := openRoom()
+	reopened.OpenedAt = roomOpenedAt.Add(time.Hour)
+	mockRooms.On("GetWaitingRoom", mock.Anything, "event-123").Return(reopened, nil)
+
+	booking := &domain.Booking{EventId: "event-123", UserId: "user-1"}
+	err = usecase.CheckAdmission(port.WithAdmissionToken(ctx, status.AdmissionToken), booking)
+	assert.ErrorIs(t, err, domain.ErrInvalidAdmissionToken)
+	assert.Nil(t, booking.Admission)
+	_, err = usecase.QueueStatus(ctx, "event-123", status.QueueToken)
+	assert.ErrorIs(t, err, domain.ErrQueueTokenExpired)
+}
@@ -0,0 +1,13 @@
+-- +goose Up
+-- Счетчик вне диапазона [0, capacity] приводим к границам до включения ограничения;
+-- точное значение потом восстановит сверка
+UPDATE events SET available_tickets = 0 WHERE available_tickets IS NULL OR available_tickets < 0;
+UPDATE events SET available_tickets = capacity WHERE available_tickets > capacity;
+
+ALTER TABLE events ALTER COLUMN available_tickets SET NOT NULL;
+ALTER TABLE events ADD CONSTRAINT check_available_tickets_range
+    CHECK (available_tickets >= 0 AND available_tickets <= capacity);
+
+-- +goose Down
+ALTER TABLE events DROP CONSTRAINT IF EXISTS check_available_tickets_range;
+ALTER TABLE events ALTER COLUMN available_tickets DROP NOT NULL;
@@ -0,0 +1,40 @@
+-- +goose Up
+-- Журнал изменений броней и событий. Внешних ключей нет: история переживает удаление сущностей
+CREATE TABLE IF NOT EXISTS audit_log (
+    id VARCHAR(36) PRIMARY KEY,
+    -- порядок вставки для записей одной транзакции с одинаковым created_at
+    seq BIGINT GENERATED ALWAYS AS IDENTITY,
+    entity_type VARCHAR(20) NOT NULL,
+    entity_id VARCHAR(36) NOT NULL,
+    event_id VARCHAR(36) NOT NULL,
+    user_id VARCHAR(36),
+    action VARCHAR(50) NOT NULL,
+    actor_type VARCHAR(20) NOT NULL,
+    actor_id VARCHAR(255),
+    old_value JSONB,
+    new_value JSONB,
+    reason TEXT,
+    created_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_log_entity ON audit_log(entity_type, entity_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_audit_log_event ON audit_log(event_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_audit_log_user ON audit_log(user_id, created_at);
+
+-- Журнал только дополняется
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION audit_log_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_log is append-only';
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER audit_log_append_only
+    BEFORE UPDATE OR DELETE ON audit_log
+    FOR EACH ROW EXECUTE FUNCTION audit_log_append_only();
+
+-- +goose Down
+DROP TRIGGER IF EXISTS audit_log_append_only ON audit_log;
+DROP FUNCTION IF EXISTS audit_log_append_only();
+DROP TABLE IF EXISTS audit_log;
@@ -0,0 +1,6 @@
+-- +goose Up
+-- Брони пользователя от новых к старым: GET /api/users/{id}/bookings
+CREATE INDEX IF NOT EXISTS idx_bookings_user_date_id ON bookings(user_id, date DESC, id DESC);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_bookings_user_date_id;
@@ -0,0 +1,6 @@
+-- +goose Up
+-- Брони мероприятия от новых к старым: GET /api/events/{id}/bookings и выгрузка участников
+CREATE INDEX IF NOT EXISTS idx_bookings_event_date_id ON bookings(event_id, date DESC, id DESC);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_bookings_event_date_id;
@@ -0,0 +1,17 @@
+-- +goose Up
+-- Ключи Idempotency-Key и сохраненные ответы. Пока запрос выполняется, status_code пустой.
+-- request_hash - SHA-256 метода, пути и тела: повтор ключа с другим запросом отклоняется.
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+    idempotency_key VARCHAR(255) PRIMARY KEY,
+    request_hash VARCHAR(64) NOT NULL,
+    status_code INTEGER,
+    content_type VARCHAR(255),
+    response_body BYTEA,
+    created_at TIMESTAMP NOT NULL
+);
+
+-- Удаление ключей старше срока хранения
+CREATE INDEX IF NOT EXISTS idx_idempotency_keys_created_at ON idempotency_keys(created_at);
+
+-- +goose Down
+DROP TABLE IF EXISTS idempotency_keys;
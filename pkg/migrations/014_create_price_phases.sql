@@ -0,0 +1,17 @@
+-- +goose Up
+-- Расписание цены мероприятия: ступени в порядке position, действует первая, у которой
+-- не наступил until и продано меньше max_sold билетов (domain.Event.Quote).
+CREATE TABLE IF NOT EXISTS price_phases (
+    event_id VARCHAR(36) NOT NULL REFERENCES events(id) ON DELETE CASCADE,
+    position INTEGER NOT NULL CHECK (position >= 0),
+    name VARCHAR(100) NOT NULL DEFAULT '',
+    price DECIMAL(10, 2) NOT NULL CHECK (price >= 0),
+    -- NULL - ступень не ограничена по времени
+    until TIMESTAMP,
+    -- 0 - ступень не ограничена продажами
+    max_sold INTEGER NOT NULL DEFAULT 0 CHECK (max_sold >= 0),
+    PRIMARY KEY (event_id, position)
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS price_phases;
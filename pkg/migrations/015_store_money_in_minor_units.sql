@@ -0,0 +1,30 @@
+-- +goose Up
+-- Суммы хранятся целыми числами в минимальных единицах валюты (копейках, центах),
+-- у мероприятия и брони появляется валюта. Суммы до миграции - в рублях.
+ALTER TABLE events ALTER COLUMN price TYPE BIGINT USING ROUND(price * 100);
+ALTER TABLE events ADD COLUMN IF NOT EXISTS currency CHAR(3) NOT NULL DEFAULT 'RUB';
+
+-- Валюта брони - валюта мероприятия на момент бронирования
+ALTER TABLE bookings ALTER COLUMN price TYPE BIGINT USING ROUND(price * 100);
+ALTER TABLE bookings ADD COLUMN IF NOT EXISTS currency CHAR(3) NOT NULL DEFAULT 'RUB';
+
+-- Ступени цены и скидки - в валюте мероприятия и брони
+ALTER TABLE price_phases ALTER COLUMN price TYPE BIGINT USING ROUND(price * 100);
+ALTER TABLE promo_redemptions ALTER COLUMN discount TYPE BIGINT USING ROUND(discount * 100);
+
+-- value фиксированной скидки - в минимальных единицах currency; у процентной currency нет
+ALTER TABLE promo_codes ADD COLUMN IF NOT EXISTS currency CHAR(3);
+ALTER TABLE promo_codes ALTER COLUMN value TYPE DECIMAL(14, 2);
+UPDATE promo_codes SET value = value * 100, currency = 'RUB' WHERE discount_type = 'fixed';
+ALTER TABLE promo_codes ADD CONSTRAINT check_promo_currency CHECK ((discount_type = 'fixed') = (currency IS NOT NULL));
+
+-- +goose Down
+ALTER TABLE promo_codes DROP CONSTRAINT IF EXISTS check_promo_currency;
+ALTER TABLE promo_codes ALTER COLUMN value TYPE DECIMAL(10, 2) USING CASE WHEN discount_type = 'fixed' THEN value / 100 ELSE value END;
+ALTER TABLE promo_codes DROP COLUMN IF EXISTS currency;
+ALTER TABLE promo_redemptions ALTER COLUMN discount TYPE DECIMAL(10, 2) USING discount / 100.0;
+ALTER TABLE price_phases ALTER COLUMN price TYPE DECIMAL(10, 2) USING price / 100.0;
+ALTER TABLE bookings DROP COLUMN IF EXISTS currency;
+ALTER TABLE bookings ALTER COLUMN price TYPE DECIMAL(10, 2) USING price / 100.0;
+ALTER TABLE events DROP COLUMN IF EXISTS currency;
+ALTER TABLE events ALTER COLUMN price TYPE DECIMAL(10, 2) USING price / 100.0;
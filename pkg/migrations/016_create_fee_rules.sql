@@ -0,0 +1,34 @@
+-- +goose Up
+-- Организатор мероприятия; пустой - мероприятие без организатора
+ALTER TABLE events ADD COLUMN IF NOT EXISTS organizer_id VARCHAR(36) NOT NULL DEFAULT '';
+
+-- Правила сервисного сбора и НДС. Бронь берет правило мероприятия, затем его организатора,
+-- затем общее (scope = 'default', scope_id пустой) - domain.MatchFeeRule.
+CREATE TABLE IF NOT EXISTS fee_rules (
+    scope VARCHAR(16) NOT NULL CHECK (scope IN ('default', 'organizer', 'event')),
+    scope_id VARCHAR(36) NOT NULL DEFAULT '',
+    -- Фиксированная часть сбора в минимальных единицах currency; NULL currency - без нее
+    fixed_fee BIGINT NOT NULL DEFAULT 0 CHECK (fixed_fee >= 0),
+    currency CHAR(3),
+    fee_percent DECIMAL(5, 2) NOT NULL DEFAULT 0 CHECK (fee_percent >= 0 AND fee_percent <= 100),
+    vat_percent DECIMAL(5, 2) NOT NULL DEFAULT 0 CHECK (vat_percent >= 0 AND vat_percent <= 100),
+    updated_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (scope, scope_id),
+    CHECK ((scope = 'default') = (scope_id = '')),
+    CHECK ((fixed_fee > 0) = (currency IS NOT NULL))
+);
+
+-- Состав суммы брони, рассчитанный при бронировании: цена билета, скидка, сбор, НДС.
+-- Суммы - в минимальных единицах валюты брони; их сумма равна bookings.price.
+CREATE TABLE IF NOT EXISTS booking_line_items (
+    booking_id VARCHAR(36) NOT NULL REFERENCES bookings(id) ON DELETE CASCADE,
+    position INTEGER NOT NULL CHECK (position >= 0),
+    kind VARCHAR(16) NOT NULL CHECK (kind IN ('face_value', 'discount', 'service_fee', 'vat')),
+    amount BIGINT NOT NULL,
+    PRIMARY KEY (booking_id, position)
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS booking_line_items;
+DROP TABLE IF EXISTS fee_rules;
+ALTER TABLE events DROP COLUMN IF EXISTS organizer_id;
@@ -0,0 +1,14 @@
+-- +goose Up
+-- Места в очередях: по одному на пользователя, чтобы повторный вход не занимал новую
+-- позицию, а допуск расходовался одной бронью (booking_id). Закрытие очереди удаляет места.
+CREATE TABLE IF NOT EXISTS waiting_room_tickets (
+    event_id VARCHAR(36) NOT NULL REFERENCES waiting_rooms(event_id) ON DELETE CASCADE,
+    user_id VARCHAR(36) NOT NULL,
+    position BIGINT NOT NULL,
+    admit_at TIMESTAMP NOT NULL,
+    booking_id VARCHAR(36),
+    PRIMARY KEY (event_id, user_id)
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS waiting_room_tickets;
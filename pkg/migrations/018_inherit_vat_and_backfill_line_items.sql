@@ -0,0 +1,26 @@
+-- +goose Up
+-- Ставка НДС правила необязательна: правило без нее берет ставку правила организатора,
+-- затем общего (domain.MatchFeeRule). До миграции ставку нельзя было не указать, поэтому
+-- нулевая ставка правил организаторов и мероприятий считается не заданной.
+ALTER TABLE fee_rules ALTER COLUMN vat_percent DROP NOT NULL;
+ALTER TABLE fee_rules ALTER COLUMN vat_percent DROP DEFAULT;
+UPDATE fee_rules SET vat_percent = NULL WHERE scope <> 'default' AND vat_percent = 0;
+
+-- Брони до миграции 016 не имеют состава суммы. Сбора и НДС у них не было, поэтому сумма
+-- брони - цена билета за вычетом скидки промокода.
+INSERT INTO booking_line_items (booking_id, position, kind, amount)
+SELECT b.id, 0, 'face_value', b.price + COALESCE(r.discount, 0)
+FROM bookings b LEFT JOIN promo_redemptions r ON r.booking_id = b.id
+WHERE NOT EXISTS (SELECT 1 FROM booking_line_items i WHERE i.booking_id = b.id);
+
+INSERT INTO booking_line_items (booking_id, position, kind, amount)
+SELECT r.booking_id, 1, 'discount', -r.discount
+FROM promo_redemptions r
+WHERE r.discount > 0
+  AND NOT EXISTS (SELECT 1 FROM booking_line_items i WHERE i.booking_id = r.booking_id AND i.position = 1);
+
+-- +goose Down
+-- Восстановленный состав сумм не удаляется: его не отличить от рассчитанного при бронировании
+UPDATE fee_rules SET vat_percent = 0 WHERE vat_percent IS NULL;
+ALTER TABLE fee_rules ALTER COLUMN vat_percent SET DEFAULT 0;
+ALTER TABLE fee_rules ALTER COLUMN vat_percent SET NOT NULL;
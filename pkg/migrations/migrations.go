@@ -10,6 +10,9 @@ import (
 //go:embed *.sql
 var embedMigrations embed.FS
 
+//go:embed sqlite/*.sql
+var embedSQLiteMigrations embed.FS
+
 func Migrate(db *sql.DB) error {
 	goose.SetBaseFS(embedMigrations)
 	err := goose.SetDialect("postgres")
@@ -20,3 +23,14 @@ func Migrate(db *sql.DB) error {
 	// "." означает: использовать файлы .sql из той же директории, что и migrate.go
 	return goose.UpContext(context.Background(), db, ".")
 }
+
+// MigrateSQLite применяет миграции SQLite из поддиректории sqlite
+func MigrateSQLite(db *sql.DB) error {
+	goose.SetBaseFS(embedSQLiteMigrations)
+	err := goose.SetDialect("sqlite3")
+	if err != nil {
+		return err
+	}
+
+	return goose.UpContext(context.Background(), db, "sqlite")
+}
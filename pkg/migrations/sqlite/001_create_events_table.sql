@@ -0,0 +1,36 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS events (
+    id TEXT PRIMARY KEY,
+    name TEXT NOT NULL,
+    description TEXT,
+    is_free BOOLEAN NOT NULL DEFAULT FALSE,
+    price REAL,
+    available_tickets INTEGER NOT NULL DEFAULT 0 CHECK (available_tickets >= 0),
+    date TIMESTAMP NOT NULL,
+
+    CONSTRAINT check_price_for_free CHECK (
+        (is_free = TRUE AND (price IS NULL OR price = 0))
+        OR
+        (is_free = FALSE AND price > 0)
+    )
+);
+
+CREATE TABLE IF NOT EXISTS bookings (
+    id TEXT PRIMARY KEY,
+    user_id TEXT NOT NULL,
+    event_id TEXT NOT NULL,
+    status TEXT NOT NULL,
+    date TIMESTAMP NOT NULL,
+
+    CONSTRAINT fk_bookings_event
+        FOREIGN KEY (event_id)
+            REFERENCES events(id)
+            ON DELETE RESTRICT
+            ON UPDATE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_events_date ON events(date);
+
+-- +goose Down
+DROP TABLE IF EXISTS bookings;
+DROP TABLE IF EXISTS events;
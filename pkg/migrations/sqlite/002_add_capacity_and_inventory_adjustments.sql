@@ -0,0 +1,30 @@
+-- +goose Up
+ALTER TABLE events ADD COLUMN capacity INTEGER NOT NULL DEFAULT 0;
+
+-- Вместимость существующих событий восстанавливаем по счетчику и активным броням.
+-- Ограничение: истинной вместимости до миграции нигде нет, поэтому расхождение счетчика,
+-- накопленное до нее, переходит в capacity, и сверка его уже не найдет - у таких событий
+-- available_tickets по определению совпадает с capacity минус активные брони. Такие события
+-- помечает миграция 019, и сверка выводит их отдельным списком для ручной проверки.
+UPDATE events SET capacity = COALESCE(available_tickets, 0) + (
+    SELECT COUNT(*) FROM bookings
+    WHERE bookings.event_id = events.id AND bookings.status IN ('pending', 'confirmed')
+);
+
+CREATE INDEX IF NOT EXISTS idx_bookings_event_status ON bookings(event_id, status);
+
+-- Исправления счетчика available_tickets, сделанные сверкой
+CREATE TABLE IF NOT EXISTS inventory_adjustments (
+    id TEXT PRIMARY KEY,
+    event_id TEXT NOT NULL REFERENCES events(id) ON DELETE CASCADE,
+    old_available INTEGER NOT NULL,
+    new_available INTEGER NOT NULL,
+    active_bookings INTEGER NOT NULL,
+    reason TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS inventory_adjustments;
+DROP INDEX IF EXISTS idx_bookings_event_status;
+ALTER TABLE events DROP COLUMN capacity;
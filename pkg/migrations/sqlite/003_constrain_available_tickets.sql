@@ -0,0 +1,27 @@
+-- +goose Up
+-- SQLite не умеет добавлять CHECK к существующей таблице, поэтому диапазон [0, capacity]
+-- держат триггеры
+UPDATE events SET available_tickets = 0 WHERE available_tickets IS NULL OR available_tickets < 0;
+UPDATE events SET available_tickets = capacity WHERE available_tickets > capacity;
+
+-- +goose StatementBegin
+CREATE TRIGGER IF NOT EXISTS check_available_tickets_insert
+BEFORE INSERT ON events
+WHEN NEW.available_tickets IS NULL OR NEW.available_tickets < 0 OR NEW.available_tickets > NEW.capacity
+BEGIN
+    SELECT RAISE(ABORT, 'available_tickets out of range');
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER IF NOT EXISTS check_available_tickets_update
+BEFORE UPDATE OF available_tickets, capacity ON events
+WHEN NEW.available_tickets IS NULL OR NEW.available_tickets < 0 OR NEW.available_tickets > NEW.capacity
+BEGIN
+    SELECT RAISE(ABORT, 'available_tickets out of range');
+END;
+-- +goose StatementEnd
+
+-- +goose Down
+DROP TRIGGER IF EXISTS check_available_tickets_update;
+DROP TRIGGER IF EXISTS check_available_tickets_insert;
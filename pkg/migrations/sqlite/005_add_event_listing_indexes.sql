@@ -0,0 +1,13 @@
+-- +goose Up
+-- Индексы под сортировки списка событий: ключ сортировки и id для курсора
+CREATE INDEX IF NOT EXISTS idx_events_date_id ON events(date, id);
+CREATE INDEX IF NOT EXISTS idx_events_price_id ON events((COALESCE(price, 0)), id);
+-- Список без распроданных событий - самый частый запрос пользовательской страницы
+CREATE INDEX IF NOT EXISTS idx_events_available_date_id ON events(date, id) WHERE available_tickets > 0;
+CREATE INDEX IF NOT EXISTS idx_events_is_free_date_id ON events(is_free, date, id);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_events_is_free_date_id;
+DROP INDEX IF EXISTS idx_events_available_date_id;
+DROP INDEX IF EXISTS idx_events_price_id;
+DROP INDEX IF EXISTS idx_events_date_id;
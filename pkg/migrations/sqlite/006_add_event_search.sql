@@ -0,0 +1,50 @@
+-- +goose Up
+-- Полнотекстовый индекс FTS5 по названию и описанию. Текст хранится экранированным для HTML,
+-- чтобы snippet() возвращал безопасную подсветку. Стемминга нет - поиск идет по префиксам слов.
+-- События связаны по id, а не по rowid: у таблицы с TEXT-ключом VACUUM может перенумеровать rowid.
+CREATE VIRTUAL TABLE IF NOT EXISTS events_fts USING fts5(
+    event_id UNINDEXED,
+    name,
+    description,
+    tokenize = 'unicode61 remove_diacritics 2'
+);
+
+INSERT INTO events_fts (event_id, name, description)
+SELECT id,
+       replace(replace(replace(name, '&', '&amp;'), '<', '&lt;'), '>', '&gt;'),
+       replace(replace(replace(COALESCE(description, ''), '&', '&amp;'), '<', '&lt;'), '>', '&gt;')
+FROM events;
+
+-- +goose StatementBegin
+CREATE TRIGGER IF NOT EXISTS events_fts_insert AFTER INSERT ON events
+BEGIN
+    INSERT INTO events_fts (event_id, name, description) VALUES (
+        NEW.id,
+        replace(replace(replace(NEW.name, '&', '&amp;'), '<', '&lt;'), '>', '&gt;'),
+        replace(replace(replace(COALESCE(NEW.description, ''), '&', '&amp;'), '<', '&lt;'), '>', '&gt;')
+    );
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER IF NOT EXISTS events_fts_update AFTER UPDATE OF name, description ON events
+BEGIN
+    UPDATE events_fts SET
+        name = replace(replace(replace(NEW.name, '&', '&amp;'), '<', '&lt;'), '>', '&gt;'),
+        description = replace(replace(replace(COALESCE(NEW.description, ''), '&', '&amp;'), '<', '&lt;'), '>', '&gt;')
+    WHERE event_id = NEW.id;
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER IF NOT EXISTS events_fts_delete AFTER DELETE ON events
+BEGIN
+    DELETE FROM events_fts WHERE event_id = OLD.id;
+END;
+-- +goose StatementEnd
+
+-- +goose Down
+DROP TRIGGER IF EXISTS events_fts_delete;
+DROP TRIGGER IF EXISTS events_fts_update;
+DROP TRIGGER IF EXISTS events_fts_insert;
+DROP TABLE IF EXISTS events_fts;
@@ -0,0 +1,9 @@
+-- +goose Up
+-- Версии для ETag и If-Match: растут при каждом изменении строки. Версия события
+-- растет и при смене статуса его броней, потому что от них зависят Sold и Held.
+ALTER TABLE events ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE bookings ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+
+-- +goose Down
+ALTER TABLE bookings DROP COLUMN version;
+ALTER TABLE events DROP COLUMN version;
@@ -0,0 +1,13 @@
+-- +goose Up
+-- Очереди на бронирование мероприятий. Ожидающие не хранятся: позиция и время допуска
+-- подписаны в их токенах, а здесь - только последняя выданная позиция и ее время допуска.
+CREATE TABLE IF NOT EXISTS waiting_rooms (
+    event_id TEXT PRIMARY KEY REFERENCES events(id) ON DELETE CASCADE,
+    rate_per_minute INTEGER NOT NULL CHECK (rate_per_minute > 0),
+    joined INTEGER NOT NULL DEFAULT 0,
+    last_admit_at TIMESTAMP NOT NULL,
+    opened_at TIMESTAMP NOT NULL
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS waiting_rooms;
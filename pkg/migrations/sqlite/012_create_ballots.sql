@@ -0,0 +1,33 @@
+-- +goose Up
+-- Режим продажи мероприятия и розыгрыши мест. Seed записывается при розыгрыше:
+-- порядок участников (rank) пересчитывается по нему функцией domain.DrawOrder.
+ALTER TABLE events ADD COLUMN sale_mode TEXT NOT NULL DEFAULT 'first_come'
+    CHECK (sale_mode IN ('first_come', 'ballot'));
+
+CREATE TABLE IF NOT EXISTS ballots (
+    event_id TEXT PRIMARY KEY REFERENCES events(id) ON DELETE CASCADE,
+    entry_opens_at TIMESTAMP NOT NULL,
+    entry_closes_at TIMESTAMP NOT NULL,
+    waitlist BOOLEAN NOT NULL DEFAULT FALSE,
+    seed INTEGER,
+    drawn_at TIMESTAMP,
+    CHECK (entry_closes_at > entry_opens_at)
+);
+
+CREATE TABLE IF NOT EXISTS ballot_entries (
+    event_id TEXT NOT NULL REFERENCES ballots(event_id) ON DELETE CASCADE,
+    user_id TEXT NOT NULL,
+    status TEXT NOT NULL CHECK (status IN ('entered', 'won', 'waitlisted', 'lost')),
+    rank INTEGER NOT NULL DEFAULT 0,
+    booking_id TEXT REFERENCES bookings(id),
+    entered_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (event_id, user_id)
+);
+
+-- Лист ожидания читается по порядку розыгрыша
+CREATE INDEX IF NOT EXISTS idx_ballot_entries_event_status_rank ON ballot_entries (event_id, status, rank);
+
+-- +goose Down
+DROP TABLE IF EXISTS ballot_entries;
+DROP TABLE IF EXISTS ballots;
+ALTER TABLE events DROP COLUMN sale_mode;
@@ -0,0 +1,42 @@
+-- +goose Up
+-- Промокоды и их использования. Сумма к оплате фиксируется в брони при бронировании:
+-- последующая смена цены мероприятия или промокода ее не меняет.
+ALTER TABLE bookings ADD COLUMN price REAL NOT NULL DEFAULT 0 CHECK (price >= 0);
+ALTER TABLE bookings ADD COLUMN promo_code TEXT;
+
+-- Брони до миграции оплачиваются по текущей цене мероприятия
+UPDATE bookings SET price = (
+    SELECT CASE WHEN e.is_free THEN 0 ELSE COALESCE(e.price, 0) END FROM events e WHERE e.id = bookings.event_id
+);
+
+CREATE TABLE IF NOT EXISTS promo_codes (
+    code TEXT PRIMARY KEY,
+    -- NULL - промокод действует для всех мероприятий
+    event_id TEXT REFERENCES events(id) ON DELETE CASCADE,
+    discount_type TEXT NOT NULL CHECK (discount_type IN ('percent', 'fixed')),
+    value REAL NOT NULL CHECK (value > 0),
+    max_uses INTEGER NOT NULL DEFAULT 0 CHECK (max_uses >= 0),
+    valid_from TIMESTAMP,
+    valid_until TIMESTAMP,
+    created_at TIMESTAMP NOT NULL,
+    CHECK (discount_type <> 'percent' OR value <= 100),
+    CHECK (valid_until IS NULL OR valid_from IS NULL OR valid_until > valid_from)
+);
+
+-- Использование промокода бронью; использования отмененных броней не считаются
+CREATE TABLE IF NOT EXISTS promo_redemptions (
+    booking_id TEXT PRIMARY KEY REFERENCES bookings(id) ON DELETE CASCADE,
+    code TEXT NOT NULL REFERENCES promo_codes(code) ON DELETE CASCADE,
+    user_id TEXT NOT NULL,
+    event_id TEXT NOT NULL,
+    discount REAL NOT NULL CHECK (discount >= 0),
+    redeemed_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_promo_redemptions_code_user ON promo_redemptions (code, user_id);
+
+-- +goose Down
+DROP TABLE IF EXISTS promo_redemptions;
+DROP TABLE IF EXISTS promo_codes;
+ALTER TABLE bookings DROP COLUMN promo_code;
+ALTER TABLE bookings DROP COLUMN price;
@@ -0,0 +1,28 @@
+-- +goose Up
+-- Суммы хранятся целыми числами в минимальных единицах валюты (копейках, центах),
+-- у мероприятия и брони появляется валюта. Суммы до миграции - в рублях.
+-- SQLite не меняет тип колонки, поэтому суммы остаются в колонках REAL целыми значениями.
+UPDATE events SET price = ROUND(price * 100) WHERE price IS NOT NULL;
+ALTER TABLE events ADD COLUMN currency TEXT NOT NULL DEFAULT 'RUB';
+
+-- Валюта брони - валюта мероприятия на момент бронирования
+UPDATE bookings SET price = ROUND(price * 100);
+ALTER TABLE bookings ADD COLUMN currency TEXT NOT NULL DEFAULT 'RUB';
+
+-- Ступени цены и скидки - в валюте мероприятия и брони
+UPDATE price_phases SET price = ROUND(price * 100);
+UPDATE promo_redemptions SET discount = ROUND(discount * 100);
+
+-- value фиксированной скидки - в минимальных единицах currency; у процентной currency нет
+ALTER TABLE promo_codes ADD COLUMN currency TEXT;
+UPDATE promo_codes SET value = value * 100, currency = 'RUB' WHERE discount_type = 'fixed';
+
+-- +goose Down
+UPDATE promo_codes SET value = value / 100 WHERE discount_type = 'fixed';
+ALTER TABLE promo_codes DROP COLUMN currency;
+UPDATE promo_redemptions SET discount = discount / 100.0;
+UPDATE price_phases SET price = price / 100.0;
+ALTER TABLE bookings DROP COLUMN currency;
+UPDATE bookings SET price = price / 100.0;
+ALTER TABLE events DROP COLUMN currency;
+UPDATE events SET price = price / 100.0 WHERE price IS NOT NULL;
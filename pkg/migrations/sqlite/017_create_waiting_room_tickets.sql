@@ -0,0 +1,14 @@
+-- +goose Up
+-- Места в очередях: по одному на пользователя, чтобы повторный вход не занимал новую
+-- позицию, а допуск расходовался одной бронью (booking_id). Закрытие очереди удаляет места.
+CREATE TABLE IF NOT EXISTS waiting_room_tickets (
+    event_id TEXT NOT NULL REFERENCES waiting_rooms(event_id) ON DELETE CASCADE,
+    user_id TEXT NOT NULL,
+    position INTEGER NOT NULL,
+    admit_at TIMESTAMP NOT NULL,
+    booking_id TEXT,
+    PRIMARY KEY (event_id, user_id)
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS waiting_room_tickets;
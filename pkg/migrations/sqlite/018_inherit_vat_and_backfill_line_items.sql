@@ -0,0 +1,57 @@
+-- +goose Up
+-- Ставка НДС правила необязательна: правило без нее берет ставку правила организатора,
+-- затем общего (domain.MatchFeeRule). До миграции ставку нельзя было не указать, поэтому
+-- нулевая ставка правил организаторов и мероприятий считается не заданной.
+-- SQLite не снимает NOT NULL с колонки, поэтому таблица пересоздается.
+CREATE TABLE fee_rules_new (
+    scope TEXT NOT NULL CHECK (scope IN ('default', 'organizer', 'event')),
+    scope_id TEXT NOT NULL DEFAULT '',
+    -- Фиксированная часть сбора в минимальных единицах currency; NULL currency - без нее
+    fixed_fee INTEGER NOT NULL DEFAULT 0 CHECK (fixed_fee >= 0),
+    currency TEXT,
+    fee_percent REAL NOT NULL DEFAULT 0 CHECK (fee_percent >= 0 AND fee_percent <= 100),
+    vat_percent REAL CHECK (vat_percent >= 0 AND vat_percent <= 100),
+    updated_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (scope, scope_id),
+    CHECK ((scope = 'default') = (scope_id = '')),
+    CHECK ((fixed_fee > 0) = (currency IS NOT NULL))
+);
+INSERT INTO fee_rules_new (scope, scope_id, fixed_fee, currency, fee_percent, vat_percent, updated_at)
+SELECT scope, scope_id, fixed_fee, currency, fee_percent,
+       CASE WHEN scope <> 'default' AND vat_percent = 0 THEN NULL ELSE vat_percent END, updated_at
+FROM fee_rules;
+DROP TABLE fee_rules;
+ALTER TABLE fee_rules_new RENAME TO fee_rules;
+
+-- Брони до миграции 016 не имеют состава суммы. Сбора и НДС у них не было, поэтому сумма
+-- брони - цена билета за вычетом скидки промокода.
+INSERT INTO booking_line_items (booking_id, position, kind, amount)
+SELECT b.id, 0, 'face_value', b.price + COALESCE(r.discount, 0)
+FROM bookings b LEFT JOIN promo_redemptions r ON r.booking_id = b.id
+WHERE NOT EXISTS (SELECT 1 FROM booking_line_items i WHERE i.booking_id = b.id);
+
+INSERT INTO booking_line_items (booking_id, position, kind, amount)
+SELECT r.booking_id, 1, 'discount', -r.discount
+FROM promo_redemptions r
+WHERE r.discount > 0
+  AND NOT EXISTS (SELECT 1 FROM booking_line_items i WHERE i.booking_id = r.booking_id AND i.position = 1);
+
+-- +goose Down
+-- Восстановленный состав сумм не удаляется: его не отличить от рассчитанного при бронировании
+CREATE TABLE fee_rules_old (
+    scope TEXT NOT NULL CHECK (scope IN ('default', 'organizer', 'event')),
+    scope_id TEXT NOT NULL DEFAULT '',
+    fixed_fee INTEGER NOT NULL DEFAULT 0 CHECK (fixed_fee >= 0),
+    currency TEXT,
+    fee_percent REAL NOT NULL DEFAULT 0 CHECK (fee_percent >= 0 AND fee_percent <= 100),
+    vat_percent REAL NOT NULL DEFAULT 0 CHECK (vat_percent >= 0 AND vat_percent <= 100),
+    updated_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (scope, scope_id),
+    CHECK ((scope = 'default') = (scope_id = '')),
+    CHECK ((fixed_fee > 0) = (currency IS NOT NULL))
+);
+INSERT INTO fee_rules_old (scope, scope_id, fixed_fee, currency, fee_percent, vat_percent, updated_at)
+SELECT scope, scope_id, fixed_fee, currency, fee_percent, COALESCE(vat_percent, 0), updated_at
+FROM fee_rules;
+DROP TABLE fee_rules;
+ALTER TABLE fee_rules_old RENAME TO fee_rules;
@@ -0,0 +1,20 @@
+-- +goose Up
+-- События, вместимость которых восстановлена миграцией 002, а не задана при создании:
+-- расхождение счетчика, накопленное до нее, перешло в capacity, и сверка его не найдет.
+-- Создание события пишется в audit_log с миграции 004, поэтому событие без записи
+-- event_created создано до нее; события между 002 и 004 тоже попадают в список - лишняя
+-- проверка безопаснее пропущенной. Пометка снимается после ручной проверки вместимости.
+CREATE TABLE IF NOT EXISTS unverified_capacities (
+    event_id TEXT PRIMARY KEY REFERENCES events(id) ON DELETE CASCADE,
+    flagged_at TIMESTAMP NOT NULL
+);
+
+INSERT INTO unverified_capacities (event_id, flagged_at)
+SELECT e.id, strftime('%Y-%m-%dT%H:%M:%SZ', 'now') FROM events e
+WHERE NOT EXISTS (
+    SELECT 1 FROM audit_log a
+    WHERE a.entity_type = 'event' AND a.entity_id = e.id AND a.action = 'event_created'
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS unverified_capacities;